@@ -8,14 +8,20 @@ The Apache Software Foundation (http://www.apache.org/).
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
 
 	"github.com/score-spec/score-k8s/internal/command"
 )
 
 func main() {
-	if err := command.Execute(); err != nil {
+	// Cancel the context on the first interrupt so that any in-flight provisioners are aborted cleanly.
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := command.Execute(ctx)
+	cancel()
+	if err != nil {
 		_, _ = fmt.Fprintln(os.Stderr, "Error: "+err.Error())
 		os.Exit(1)
 	}
@@ -19,11 +19,119 @@ import (
 )
 
 const (
-	AnnotationPrefix              = "k8s.score.dev/"
-	WorkloadKindAnnotation        = AnnotationPrefix + "kind"
-	WorkloadServiceNameAnnotation = AnnotationPrefix + "service-name"
+	AnnotationPrefix                = "k8s.score.dev/"
+	WorkloadKindAnnotation          = AnnotationPrefix + "kind"
+	WorkloadServiceNameAnnotation   = AnnotationPrefix + "service-name"
+	WaitForAnnotation               = AnnotationPrefix + "wait-for"
+	WaitForImageAnnotation          = AnnotationPrefix + "wait-for-image"
+	WaitForTimeoutAnnotation        = AnnotationPrefix + "wait-for-timeout"
+	CommandShellAnnotation          = AnnotationPrefix + "command-shell"
+	PodMonitorAnnotation            = AnnotationPrefix + "pod-monitor"
+	PodMonitorPathAnnotation        = AnnotationPrefix + "pod-monitor-path"
+	WorkingDirAnnotation            = AnnotationPrefix + "working-dir"
+	ContainerResourcesAnnotation    = AnnotationPrefix + "container-resources"
+	RuntimeClassAnnotation          = AnnotationPrefix + "runtime-class"
+	ResourceAliasesAnnotation       = AnnotationPrefix + "resource-aliases"
+	NetworkPolicyAnnotation         = AnnotationPrefix + "network-policy"
+	TerminationMessageAnnotation    = AnnotationPrefix + "termination-message"
+	ServiceTypeAnnotation           = AnnotationPrefix + "service-type"
+	ProgressDeadlineAnnotation      = AnnotationPrefix + "progress-deadline"
+	RolloutStrategyAnnotation       = AnnotationPrefix + "rollout-strategy"
+	ReadinessGatesAnnotation        = AnnotationPrefix + "readiness-gates"
+	ImmutableConfigAnnotation       = AnnotationPrefix + "immutable-config"
+	FileStorageAnnotation           = AnnotationPrefix + "file-storage"
+	ServiceAnnotationsAnnotation    = AnnotationPrefix + "service-annotations"
+	ProbesAnnotation                = AnnotationPrefix + "probes"
+	PodSpecPatchAnnotation          = AnnotationPrefix + "pod-spec-patch"
+	PublishNotReadyAnnotation       = AnnotationPrefix + "publish-not-ready-addresses"
+	InteractiveAnnotation           = AnnotationPrefix + "interactive"
+	ResourcePriorityAnnotation      = AnnotationPrefix + "priority"
+	EnvFromExistingAnnotation       = AnnotationPrefix + "env-from-existing"
+	CiliumNetworkPolicyAnnotation   = AnnotationPrefix + "cilium-network-policy"
+	ShareProcessNamespaceAnnotation = AnnotationPrefix + "share-process-namespace"
+	InitContainersAnnotation        = AnnotationPrefix + "init-containers"
+	WhenAnnotation                  = AnnotationPrefix + "when"
+	LifecycleAnnotation             = AnnotationPrefix + "lifecycle"
+
+	// GeneratorVersionAnnotation is added to the output manifests with the version of score-k8s that generated them.
+	GeneratorVersionAnnotation = AnnotationPrefix + "generator-version"
+	// WorkloadNameAnnotation is added to the workload manifests with the name of the workload.
+	WorkloadNameAnnotation = AnnotationPrefix + "workload-name"
+)
+
+// Where an annotation is set. Workload and resource annotations are read from the metadata of the Score workload or
+// resource, while output annotations are added by score-k8s to the manifests it generates.
+const (
+	AnnotationOnWorkload = "workload"
+	AnnotationOnResource = "resource"
+	AnnotationOnOutput   = "output"
 )
 
+// AnnotationSpec describes an annotation recognized by score-k8s.
+type AnnotationSpec struct {
+	Key string `json:"key"`
+	// On is one of AnnotationOnWorkload, AnnotationOnResource, or AnnotationOnOutput.
+	On string `json:"on"`
+	// Value is the shape of the annotation value, such as "boolean" or "yaml map".
+	Value string `json:"value"`
+	// Affects are the kinds of the output objects that the annotation changes or emits.
+	Affects     []string `json:"affects"`
+	Description string   `json:"description"`
+}
+
+var (
+	podKinds      = []string{"Deployment", "StatefulSet", "Rollout"}
+	workloadKinds = []string{"Deployment", "StatefulSet", "Rollout", "Service"}
+)
+
+// Annotations is the registry of every annotation recognized by score-k8s. Any new annotation must be added here so
+// that it is listed by "score-k8s schema annotations" and accepted by the annotation validation.
+var Annotations = []AnnotationSpec{
+	{WorkloadKindAnnotation, AnnotationOnWorkload, "Deployment, StatefulSet, or Rollout", podKinds, "The kind of workload to generate, defaults to Deployment."},
+	{ProgressDeadlineAnnotation, AnnotationOnWorkload, "integer", []string{"Deployment"}, "The progressDeadlineSeconds of the Deployment as a positive number of seconds."},
+	{RolloutStrategyAnnotation, AnnotationOnWorkload, "yaml object", []string{"Rollout", "Service"}, "The canary or blueGreen strategy of an Argo Rollout, defaults to a canary strategy without steps."},
+	{WorkloadServiceNameAnnotation, AnnotationOnWorkload, "string", []string{"Service"}, "The name of the Service to generate for the workload, defaults to the workload name."},
+	{ServiceTypeAnnotation, AnnotationOnWorkload, "string or yaml object", []string{"Service"}, "The type of the workload Service, or an object with the type, externalTrafficPolicy, ipFamilyPolicy, ipFamilies, and named groups of ports that each emit an additional Service."},
+	{ServiceAnnotationsAnnotation, AnnotationOnWorkload, "yaml map", []string{"Service"}, "Extra annotations for the workload Service, which may contain resource placeholders."},
+	{InitContainersAnnotation, AnnotationOnWorkload, "comma-separated list", podKinds, "The workload containers to run as init containers, in the listed order."},
+	{WaitForAnnotation, AnnotationOnWorkload, "comma-separated list", podKinds, "The resources whose host and port outputs must accept connections before the containers start."},
+	{WaitForImageAnnotation, AnnotationOnWorkload, "string", podKinds, "The image used by the wait-for init containers, defaults to busybox:1.36."},
+	{WaitForTimeoutAnnotation, AnnotationOnWorkload, "duration", podKinds, "How long the wait-for init containers wait before failing, defaults to 5m."},
+	{CommandShellAnnotation, AnnotationOnWorkload, "boolean", podKinds, "Wrap the container command and args in /bin/sh -c so that shell features can be used."},
+	{PodMonitorAnnotation, AnnotationOnWorkload, "<port> or <container>:<port>", append([]string{"PodMonitor"}, podKinds...), "Emit a Prometheus Operator PodMonitor scraping the port through a metrics container port."},
+	{PodMonitorPathAnnotation, AnnotationOnWorkload, "string", []string{"PodMonitor"}, "The path scraped by the PodMonitor, defaults to /metrics."},
+	{EnvFromExistingAnnotation, AnnotationOnWorkload, "yaml list", podKinds, "Existing Secrets and ConfigMaps to load into the container environment through envFrom."},
+	{WorkingDirAnnotation, AnnotationOnWorkload, "string or comma-separated list", podKinds, "The working directory for all containers, or a list of <container>=<path> entries."},
+	{InteractiveAnnotation, AnnotationOnWorkload, "boolean or comma-separated list", podKinds, "Set stdin and tty on all containers, or on the listed containers."},
+	{ProbesAnnotation, AnnotationOnWorkload, "yaml map", podKinds, "The startup, liveness, and readiness probe settings of each container, with optional exec commands for probes that the Score container does not have."},
+	{LifecycleAnnotation, AnnotationOnWorkload, "yaml map", podKinds, "The postStart and preStop exec commands of each container, as a list or a shell-like string."},
+	{PodSpecPatchAnnotation, AnnotationOnWorkload, "yaml object", podKinds, "A pod spec fragment that is strategically merged into the pod template."},
+	{PublishNotReadyAnnotation, AnnotationOnWorkload, "boolean", []string{"Service"}, "Set publishNotReadyAddresses on the headless Service of a StatefulSet."},
+	{TerminationMessageAnnotation, AnnotationOnWorkload, "string or comma-separated list", podKinds, "The terminationMessagePolicy and optional path of all containers, or a list of <container>=<policy>[:<path>] entries."},
+	{ContainerResourcesAnnotation, AnnotationOnWorkload, "json map", podKinds, "Extra container limits and requests, populated from container resources other than cpu and memory."},
+	{ShareProcessNamespaceAnnotation, AnnotationOnWorkload, "boolean", podKinds, "Set shareProcessNamespace on the pod."},
+	{RuntimeClassAnnotation, AnnotationOnWorkload, "string", podKinds, "The runtimeClassName of the pod."},
+	{ReadinessGatesAnnotation, AnnotationOnWorkload, "comma-separated list", podKinds, "Pod condition types to add to the pod readinessGates."},
+	{ImmutableConfigAnnotation, AnnotationOnWorkload, "boolean", []string{"ConfigMap", "Secret"}, "Mark the ConfigMaps and Secrets of the container files as immutable with a content hash in their names."},
+	{FileStorageAnnotation, AnnotationOnWorkload, "yaml map", []string{"ConfigMap", "Secret"}, "Whether the content of each container file is stored in a Secret or a ConfigMap."},
+	{ResourceAliasesAnnotation, AnnotationOnWorkload, "yaml map", workloadKinds, "Aliases for resources so that placeholders can refer to resources by another name."},
+	{NetworkPolicyAnnotation, AnnotationOnWorkload, "yaml object", []string{"NetworkPolicy"}, "Emit a NetworkPolicy that only allows egress to DNS and the listed resources, workloads, and cidrs."},
+	{CiliumNetworkPolicyAnnotation, AnnotationOnWorkload, "yaml object", []string{"CiliumNetworkPolicy"}, "Emit a CiliumNetworkPolicy with the ingress and egress rules selecting the workload pods."},
+	{WhenAnnotation, AnnotationOnWorkload, "yaml map", workloadKinds, "Conditions on the generate --env value that other workload annotations and containers are only applied under."},
+	{ResourcePriorityAnnotation, AnnotationOnResource, "integer", nil, "The provisioning priority of the resource, higher priorities are provisioned first."},
+	{WorkloadNameAnnotation, AnnotationOnOutput, "string", workloadKinds, "The name of the workload that the manifest was generated from."},
+	{GeneratorVersionAnnotation, AnnotationOnOutput, "string", nil, "The version of score-k8s that generated the manifest, added by generate --stamp-version."},
+}
+
+// FindAnnotationSpec returns the registered annotation with the key.
+func FindAnnotationSpec(key string) (AnnotationSpec, bool) {
+	i := slices.IndexFunc(Annotations, func(a AnnotationSpec) bool { return a.Key == key })
+	if i < 0 {
+		return AnnotationSpec{}, false
+	}
+	return Annotations[i], true
+}
+
 func ListAnnotations(metadata map[string]interface{}) []string {
 	a, ok := metadata["annotations"].(map[string]interface{})
 	if ok {
@@ -0,0 +1,68 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAnnotationsMatchReadme ensures that the registry and the workload annotations table in the README do not drift.
+func TestAnnotationsMatchReadme(t *testing.T) {
+	raw, err := os.ReadFile("../README.md")
+	require.NoError(t, err)
+	var documented []string
+	for _, m := range regexp.MustCompile("(?m)^\\| `("+regexp.QuoteMeta(AnnotationPrefix)+"[a-z-]+)`").FindAllStringSubmatch(string(raw), -1) {
+		documented = append(documented, m[1])
+	}
+	require.NotEmpty(t, documented)
+
+	// a blank line would end the markdown table early, so the documented rows must be contiguous
+	rows := regexp.MustCompile("(?m)^\\| `"+regexp.QuoteMeta(AnnotationPrefix)+"[a-z-]+`(?:.*\n\\| `"+regexp.QuoteMeta(AnnotationPrefix)+"[a-z-]+`)*").FindAllString(string(raw), -1)
+	require.NotEmpty(t, rows)
+	assert.Equal(t, len(documented), strings.Count(rows[0], "\n")+1, "the annotations table must not be interrupted")
+
+	var registered []string
+	for _, a := range Annotations {
+		if a.On == AnnotationOnWorkload {
+			registered = append(registered, a.Key)
+		}
+	}
+	assert.Equal(t, documented, registered)
+}
+
+func TestAnnotationsAreUnique(t *testing.T) {
+	seen := make(map[string]bool, len(Annotations))
+	for _, a := range Annotations {
+		assert.False(t, seen[a.Key], a.Key)
+		seen[a.Key] = true
+		assert.Contains(t, []string{AnnotationOnWorkload, AnnotationOnResource, AnnotationOnOutput}, a.On, a.Key)
+		assert.NotEmpty(t, a.Value, a.Key)
+		assert.NotEmpty(t, a.Description, a.Key)
+	}
+}
+
+func TestFindAnnotationSpec(t *testing.T) {
+	a, ok := FindAnnotationSpec(WaitForAnnotation)
+	assert.True(t, ok)
+	assert.Equal(t, "comma-separated list", a.Value)
+	_, ok = FindAnnotationSpec(AnnotationPrefix + "unknown")
+	assert.False(t, ok)
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os/exec"
+)
+
+// runClusterDiff passes the manifests to "kubectl diff" which fetches the live version of each object and prints the
+// difference. kubectl uses a server-side dry run for this so nothing is ever applied to the cluster.
+func runClusterDiff(ctx context.Context, manifests []byte, kubeconfig, kubeContext string, stdout, stderr io.Writer) error {
+	bin, err := exec.LookPath("kubectl")
+	if err != nil {
+		return fmt.Errorf("failed to find kubectl on path: %w", err)
+	}
+	args := []string{"diff", "--kubeconfig", kubeconfig, "--context", kubeContext, "-f", "-"}
+	slog.Debug(fmt.Sprintf("Executing '%s %v' for cluster diff", bin, args))
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Stdin = bytes.NewReader(manifests)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		// kubectl diff exits with 1 when there are differences, anything else is a failure
+		var ee *exec.ExitError
+		if errors.As(err, &ee) && ee.ExitCode() == 1 {
+			return nil
+		}
+		return fmt.Errorf("failed to diff against cluster: %w", err)
+	}
+	slog.Info("No differences found against the cluster")
+	return nil
+}
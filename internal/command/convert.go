@@ -0,0 +1,70 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/score-spec/score-k8s/pkg/generate"
+)
+
+const (
+	convertCmdImageFlag       = "image"
+	convertCmdNoProvisionFlag = "no-provision"
+)
+
+var convertCmd = &cobra.Command{
+	Use:   "convert <score-file>",
+	Args:  cobra.ExactArgs(1),
+	Short: "Convert a single Score file into Kubernetes manifests without a project directory",
+	Long: `The convert command is a stateless alternative to "score-k8s init" and "score-k8s generate". It converts a
+single Score file into Kubernetes manifests and prints them to stdout without reading or writing the '.score-k8s'
+directory.
+
+Resources are provisioned with the default provisioners only and no state is kept, so any generated values such as
+passwords will change on each run. Use --no-provision to leave the resource placeholders unresolved instead.
+`,
+	Example: `
+  # Print the manifests for a Score file
+  score-k8s convert score.yaml
+
+  # Provide a default container image for any containers with image=.
+  score-k8s convert score.yaml --image=nginx:latest
+
+  # Leave resource placeholders unresolved instead of provisioning them
+  score-k8s convert score.yaml --no-provision`,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+
+		opts := generate.ConvertOptions{ScoreFile: args[0]}
+		opts.Image, _ = cmd.Flags().GetString(convertCmdImageFlag)
+		opts.SkipProvisioning, _ = cmd.Flags().GetBool(convertCmdNoProvisionFlag)
+
+		outputManifests, err := generate.Convert(cmd.Context(), opts)
+		if err != nil {
+			return err
+		}
+		_, err = cmd.OutOrStdout().Write(encodeManifests(outputManifests))
+		return err
+	},
+}
+
+func init() {
+	convertCmd.Flags().String(convertCmdImageFlag, "", "An optional container image to use for any container with image == '.'")
+	convertCmd.Flags().Bool(convertCmdNoProvisionFlag, false, "Skip provisioning and leave any resource placeholders unresolved")
+
+	rootCmd.AddCommand(convertCmd)
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertWithoutProjectDirectory(t *testing.T) {
+	td := changeToTempDir(t)
+	require.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+    variables:
+      DB_HOST: ${resources.db.host}
+resources:
+  db:
+    type: postgres
+`), 0644))
+
+	stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"convert", "score.yaml"})
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "kind: Deployment\n")
+	assert.Contains(t, stdout, "value: pg-example-")
+
+	stdout, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"convert", "score.yaml", "--no-provision"})
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "value: ${resources.db.host}\n")
+	assert.NotContains(t, stdout, "kind: StatefulSet\n")
+
+	_, err = os.Stat(filepath.Join(td, ".score-k8s"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
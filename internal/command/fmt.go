@@ -0,0 +1,99 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/score-spec/score-k8s/pkg/generate"
+)
+
+const (
+	fmtCmdCheckFlag  = "check"
+	fmtCmdStdoutFlag = "stdout"
+)
+
+var fmtCmd = &cobra.Command{
+	Use:   "fmt <score-file>...",
+	Args:  cobra.MinimumNArgs(1),
+	Short: "Rewrite Score files in a canonical format",
+	Long: `The fmt command validates each Score file and rewrites it in place in block style with a 2-space indent and a
+stable key order, keeping any comments. The top level keys are ordered as apiVersion, metadata, service, containers,
+and resources, and all other keys are sorted alphabetically.
+
+Formatting never changes the content of the workloads. A file that cannot be formatted without changing its content,
+for example because it uses yaml anchors, is left untouched and reported as an error.
+`,
+	Example: `
+  # Format a Score file in place
+  score-k8s fmt score.yaml
+
+  # Print the formatted Score file instead of writing it
+  score-k8s fmt score.yaml --stdout
+
+  # Fail in CI if any Score file is not formatted
+  score-k8s fmt --check score.yaml other/score.yaml`,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		check, _ := cmd.Flags().GetBool(fmtCmdCheckFlag)
+		stdout, _ := cmd.Flags().GetBool(fmtCmdStdoutFlag)
+		if check && stdout {
+			return fmt.Errorf("cannot use --%s with --%s", fmtCmdCheckFlag, fmtCmdStdoutFlag)
+		}
+
+		unformatted := make([]string, 0)
+		for _, arg := range args {
+			formatted, err := generate.FormatScoreFile(arg)
+			if err != nil {
+				return err
+			}
+			if stdout {
+				_, _ = cmd.OutOrStdout().Write(formatted)
+				continue
+			}
+			original, err := os.ReadFile(arg)
+			if err != nil {
+				return fmt.Errorf("failed to read input score file: %s: %w", arg, err)
+			} else if bytes.Equal(original, formatted) {
+				continue
+			}
+			unformatted = append(unformatted, arg)
+			if check {
+				_, _ = fmt.Fprintln(cmd.OutOrStdout(), arg)
+			} else if err := writeFileAtomic(arg, formatted, defaultOutputMode); err != nil {
+				return err
+			} else {
+				slog.Info(fmt.Sprintf("Formatted '%s'", arg))
+			}
+		}
+		if check && len(unformatted) > 0 {
+			return fmt.Errorf("%d score files are not formatted, run \"score-k8s fmt\" to fix them", len(unformatted))
+		}
+		return nil
+	},
+}
+
+func init() {
+	fmtCmd.Flags().Bool(fmtCmdCheckFlag, false, "List the files that are not formatted and fail instead of rewriting them")
+	fmtCmd.Flags().Bool(fmtCmdStdoutFlag, false, "Write the formatted files to stdout instead of rewriting them")
+
+	rootCmd.AddCommand(fmtCmd)
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFmt(t *testing.T) {
+	td := changeToTempDir(t)
+	require.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+resources:
+    db:
+        type: postgres
+# the main workload
+containers:
+    main:
+        variables:
+            # resolved by the provisioner
+            DB_HOST: ${resources.db.host}
+            A: "1"
+        image: nginx
+metadata:
+    name: example
+apiVersion: score.dev/v1b1
+---
+---
+apiVersion: score.dev/v1b1
+metadata: {name: other}
+containers: {main: {image: busybox, args: [a, b]}}
+`), 0644))
+	expected := `apiVersion: score.dev/v1b1
+metadata:
+  name: example
+# the main workload
+containers:
+  main:
+    image: nginx
+    variables:
+      A: "1"
+      # resolved by the provisioner
+      DB_HOST: ${resources.db.host}
+resources:
+  db:
+    type: postgres
+---
+apiVersion: score.dev/v1b1
+metadata:
+  name: other
+containers:
+  main:
+    args:
+      - a
+      - b
+    image: busybox
+`
+
+	stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"fmt", "--check", "score.yaml"})
+	assert.EqualError(t, err, "1 score files are not formatted, run \"score-k8s fmt\" to fix them")
+	assert.Equal(t, "score.yaml\n", stdout)
+
+	stdout, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"fmt", "score.yaml", "--stdout"})
+	require.NoError(t, err)
+	assert.Equal(t, expected, stdout)
+
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"fmt", "score.yaml"})
+	require.NoError(t, err)
+	raw, err := os.ReadFile(filepath.Join(td, "score.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, expected, string(raw))
+
+	stdout, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"fmt", "--check", "score.yaml"})
+	require.NoError(t, err)
+	assert.Equal(t, "", stdout)
+}
+
+func TestFmtInvalid(t *testing.T) {
+	td := changeToTempDir(t)
+	original := []byte("apiVersion: score.dev/v1b1\nmetadata:\n  name: example\n")
+	require.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), original, 0644))
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"fmt", "score.yaml"})
+	assert.ErrorContains(t, err, "invalid score file: score.yaml: jsonschema: '' does not validate")
+	raw, err := os.ReadFile(filepath.Join(td, "score.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, original, raw)
+}
@@ -16,37 +16,78 @@ package command
 
 import (
 	"bytes"
-	"context"
-	"crypto/rand"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
 	"os"
+	"path/filepath"
 	"slices"
+	"strconv"
 	"strings"
+	"time"
 
-	"github.com/imdario/mergo"
-	"github.com/pkg/errors"
-	"github.com/score-spec/score-go/framework"
-	scoreloader "github.com/score-spec/score-go/loader"
-	scoreschema "github.com/score-spec/score-go/schema"
-	scoretypes "github.com/score-spec/score-go/types"
 	"github.com/spf13/cobra"
 	"gopkg.in/yaml.v3"
-	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/api/resource"
 
-	"github.com/score-spec/score-k8s/internal"
-	"github.com/score-spec/score-k8s/internal/convert"
-	"github.com/score-spec/score-k8s/internal/project"
-	"github.com/score-spec/score-k8s/internal/provisioners"
-	"github.com/score-spec/score-k8s/internal/provisioners/loader"
+	"github.com/score-spec/score-k8s/internal/logging"
+	"github.com/score-spec/score-k8s/internal/trace"
+	"github.com/score-spec/score-k8s/internal/version"
+	"github.com/score-spec/score-k8s/pkg/generate"
 )
 
 const (
-	generateCmdOverridesFileFlag    = "overrides-file"
-	generateCmdOverridePropertyFlag = "override-property"
-	generateCmdImageFlag            = "image"
-	generateCmdOutputFlag           = "output"
-	generateCmdPatchManifestsFlag   = "patch-manifests"
+	generateCmdOverridesFileFlag       = "overrides-file"
+	generateCmdOverridePropertyFlag    = "override-property"
+	generateCmdImageFlag               = "image"
+	generateCmdOutputFlag              = "output"
+	generateCmdPatchManifestsFlag      = "patch-manifests"
+	generateCmdPlanFlag                = "plan"
+	generateCmdOutputFormatFlag        = "output-format"
+	generateCmdWatchFlag               = "watch"
+	generateCmdClusterDiffFlag         = "cluster-diff"
+	generateCmdKubeconfigFlag          = "kubeconfig"
+	generateCmdContextFlag             = "context"
+	generateCmdClassDefaultFlag        = "class-default"
+	generateCmdAnnotationsFileFlag     = "annotations-file"
+	generateCmdManifestFilterFlag      = "manifest-filter"
+	generateCmdExpandEnvFlag           = "expand-env"
+	generateCmdVerboseProvisionerFlag  = "verbose-provisioner"
+	generateCmdNoStateFlag             = "no-state"
+	generateCmdOverridesFromEnvFlag    = "overrides-from-env"
+	generateCmdStampVersionFlag        = "stamp-version"
+	generateCmdNoManagedLabelsFlag     = "no-managed-labels"
+	generateCmdAsListFlag              = "as-list"
+	generateCmdPerWorkloadOutputFlag   = "per-workload-output"
+	generateCmdProvisionerTimeoutFlag  = "provisioner-timeout"
+	generateCmdNamespaceFlag           = "namespace"
+	generateCmdEmitNamespaceFlag       = "emit-namespace"
+	generateCmdNamespaceLabelFlag      = "namespace-label"
+	generateCmdNamespaceAnnotationFlag = "namespace-annotation"
+	generateCmdCapabilitiesFileFlag    = "capabilities-file"
+	generateCmdReportFlag              = "report"
+	generateCmdKeepGoingFlag           = "keep-going"
+	generateCmdAnnotateFromEnvFlag     = "annotate-from-env"
+	generateCmdLabelSchemeFlag         = "label-scheme"
+	generateCmdStrictSchemaFlag        = "strict-schema"
+	generateCmdOwnerRefFlag            = "owner-ref"
+	generateCmdOwnerRefFilterFlag      = "owner-ref-filter"
+	generateCmdRequireProbesFlag       = "require-probes"
+	generateCmdMaxManifestSizeFlag     = "max-manifest-size"
+	generateCmdIncrementalFlag         = "incremental"
+	generateCmdForceFlag               = "force"
+	generateCmdConsolidateSecretsFlag  = "consolidate-secrets"
+	generateCmdOutputModeFlag          = "output-mode"
+	generateCmdHeaderFlag              = "header"
+	generateCmdEnvFlag                 = "env"
+	generateCmdStrictImagesFlag        = "strict-images"
+	generateCmdResourceNamespaceFlag   = "resource-namespace"
+	generateCmdTraceFlag               = "trace"
+	generateCmdTraceFileFlag           = "trace-file"
+
+	// generateWatchDebounce is how long to wait for further changes before regenerating in watch mode
+	generateWatchDebounce = 500 * time.Millisecond
 )
 
 var generateCmd = &cobra.Command{
@@ -71,303 +112,539 @@ manifests. All resources and links between Workloads will be resolved and provis
   # Provide overrides when one score file is provided
   score-k8s generate score.yaml --override-file=./overrides.score.yaml --override-property=metadata.key=value
 
+  # Remove a property, or set it to an empty string
+  score-k8s generate score.yaml --override-property=metadata.annotations.key --override-property='metadata.other=""'
+
+  # Apply base64 encoded yaml overrides from an environment variable
+  SCORE_OVERRIDES=$(base64 -w0 overrides.yaml) score-k8s generate score.yaml --overrides-from-env SCORE_OVERRIDES
+
+  # Expand environment variables in override property values, failing if any are undefined
+  score-k8s generate score.yaml --override-property 'containers.main.image=${CI_IMAGE}' --expand-env=strict
+
+  # Select the 'prod' class of provisioners for any resources without an explicit class
+  score-k8s generate score.yaml --class-default=prod
+
+  # Add a shared set of annotations to every output manifest
+  score-k8s generate score.yaml --annotations-file=./annotations.yaml
+
+  # Annotate every output manifest with the git sha and build url from the CI environment
+  score-k8s generate score.yaml --annotate-from-env example.com/git-sha=GIT_SHA --annotate-from-env example.com/build-url=BUILD_URL
+
+  # Select the pods of each workload by an 'app' label to adopt existing Deployments
+  score-k8s generate score.yaml --label-scheme=legacy
+
+  # Record the score-k8s version in an annotation on every output manifest
+  score-k8s generate score.yaml --stamp-version
+
   # Patch resulting manifests
-  score-k8s generate score.yaml --patch-manifests */*/metadata.annotations.key=value --patch-manifests Deployment/foo/spec.replicas=4`,
-	SilenceErrors: true,
-	RunE: func(cmd *cobra.Command, args []string) error {
-		cmd.SilenceUsage = true
+  score-k8s generate score.yaml --patch-manifests */*/metadata.annotations.key=value --patch-manifests Deployment/foo/spec.replicas=4
 
-		sd, ok, err := project.LoadStateDirectory(".")
-		if err != nil {
-			return fmt.Errorf("failed to load existing state directory: %w", err)
-		} else if !ok {
-			return fmt.Errorf("state directory does not exist, please run \"score-k8s init\" first")
-		}
-		state := &sd.State
+  # Only write the Deployments and the 'web' Service to the output file
+  score-k8s generate score.yaml -o deployments.yaml --manifest-filter kind=Deployment --manifest-filter kind=Service,name=web
 
-		if len(args) != 1 && (cmd.Flags().Lookup(generateCmdOverridesFileFlag).Changed || cmd.Flags().Lookup(generateCmdOverridePropertyFlag).Changed || cmd.Flags().Lookup(generateCmdImageFlag).Changed) {
-			return errors.Errorf("cannot use --%s, --%s, or --%s when 0 or more than 1 score files are provided", generateCmdOverridePropertyFlag, generateCmdOverridesFileFlag, generateCmdImageFlag)
-		}
+  # Render the manifests in CI without reading or writing the state file
+  score-k8s generate score.yaml --no-state
 
-		slices.Sort(args)
-		for _, arg := range args {
-			var rawWorkload map[string]interface{}
-			if raw, err := os.ReadFile(arg); err != nil {
-				return errors.Wrapf(err, "failed to read input score file: %s", arg)
-			} else if err = yaml.Unmarshal(raw, &rawWorkload); err != nil {
-				return errors.Wrapf(err, "failed to decode input score file: %s", arg)
-			}
+  # Preview what the provisioners would do without updating the state or manifests
+  score-k8s generate score.yaml --plan
 
-			// apply overrides
+  # Only apply the workload annotations and containers gated by k8s.score.dev/when on the 'prod' environment
+  score-k8s generate score.yaml --env prod
 
-			if v, _ := cmd.Flags().GetString(generateCmdOverridesFileFlag); v != "" {
-				if err := parseAndApplyOverrideFile(v, generateCmdOverridesFileFlag, rawWorkload); err != nil {
-					return err
-				}
-			}
+  # Refer to the secret env values of each workload through a single <workload>-env Secret
+  score-k8s generate score.yaml --consolidate-secrets
 
-			// Now read, parse, and apply any override properties to the score files
-			if v, _ := cmd.Flags().GetStringArray(generateCmdOverridePropertyFlag); len(v) > 0 {
-				for _, overridePropertyEntry := range v {
-					if rawWorkload, err = parseAndApplyOverrideProperty(overridePropertyEntry, generateCmdOverridePropertyFlag, rawWorkload); err != nil {
-						return err
-					}
-				}
-			}
+  # Only provision the resources whose provisioner or inputs changed since the last incremental run
+  score-k8s generate score.yaml --incremental
 
-			// Ensure transforms are applied (be a good citizen)
-			if changes, err := scoreschema.ApplyCommonUpgradeTransforms(rawWorkload); err != nil {
-				return fmt.Errorf("failed to upgrade spec: %w", err)
-			} else if len(changes) > 0 {
-				for _, change := range changes {
-					slog.Info(fmt.Sprintf("Applying backwards compatible upgrade %s", change))
-				}
-			}
+  # Fail if provisioning all the resources takes longer than 5 minutes
+  score-k8s generate score.yaml --provisioner-timeout 5m
 
-			var workload scoretypes.Workload
-			if err = scoreschema.Validate(rawWorkload); err != nil {
-				return errors.Wrapf(err, "invalid score file: %s", arg)
-			} else if err = scoreloader.MapSpec(&workload, rawWorkload); err != nil {
-				return errors.Wrapf(err, "failed to decode input score file: %s", arg)
-			}
-			workloadName := workload.Metadata["name"].(string)
-
-			// Apply image override
-			for containerName, container := range workload.Containers {
-				if container.Image == "." {
-					if v, _ := cmd.Flags().GetString(generateCmdImageFlag); v != "" {
-						container.Image = v
-						slog.Info(fmt.Sprintf("Set container image for container '%s' to %s from --%s", containerName, v, generateCmdImageFlag))
-						workload.Containers[containerName] = container
-					} else {
-						return errors.Errorf("failed to convert '%s' because container '%s' has no image and --image was not provided", arg, containerName)
-					}
-				}
-			}
+  # Log the input and output of each provisioner with any secret values redacted
+  score-k8s generate score.yaml --verbose-provisioner
 
-			var extras project.WorkloadExtras
-			if existing, ok := state.Workloads[workloadName]; ok && existing.Extras.InstanceSuffix != "" {
-				extras = existing.Extras
-			} else {
-				extrasBytes := make([]byte, 5)
-				_, _ = rand.Read(extrasBytes)
-				extras.InstanceSuffix = fmt.Sprintf("-%x", extrasBytes)
-			}
+  # Sort the manifests so that Namespaces, CustomResourceDefinitions, and RBAC are applied before other resources
+  score-k8s generate score.yaml --output-format=kubectl-apply-order
 
-			if state, err = state.WithWorkload(&workload, &arg, extras); err != nil {
-				return errors.Wrapf(err, "failed to add score file to project: %s", arg)
-			}
-			slog.Info("Added score file to project", "file", arg)
-		}
+  # Write the replicas, images, and resources of the workloads as a values.yaml for a separately maintained Helm chart
+  score-k8s generate score.yaml --output-format=helm-values -o values.yaml
 
-		if len(state.Workloads) == 0 {
-			return errors.New("Project is empty, please add a score file")
-		}
+  # Deploy into the 'apps' namespace and create it first
+  score-k8s generate score.yaml --namespace apps --emit-namespace --namespace-label team=payments
 
-		if state, err = state.WithPrimedResources(); err != nil {
-			return errors.Wrap(err, "failed to prime resources")
-		}
+  # Deploy the workloads into the 'apps' namespace and the provisioned resources into the 'data' namespace
+  score-k8s generate score.yaml --namespace apps --resource-namespace data
 
-		slog.Info("Primed resources", "#workloads", len(state.Workloads), "#resources", len(state.Resources))
+  # Print how long loading, provisioning each resource, converting each workload, and writing took
+  score-k8s generate score.yaml --trace
 
-		localProvisioners, err := loader.LoadProvisionersFromDirectory(sd.Path, loader.DefaultSuffix)
-		if err != nil {
-			return errors.Wrapf(err, "failed to load provisioners")
-		}
-		slog.Info("Loaded provisioners", "#provisioners", len(localProvisioners))
+  # Skip manifests, like a PodMonitor, whose apiVersion is not available in the target cluster
+  kubectl api-versions > api-versions.txt && score-k8s generate score.yaml --capabilities-file api-versions.txt
 
-		state, err = provisioners.ProvisionResources(context.Background(), state, localProvisioners)
-		if err != nil {
-			return errors.Wrap(err, "failed to provision resources")
-		}
+  # Write a json summary of the manifests, resources, provisioners, and warnings for a CI dashboard
+  score-k8s generate score.yaml --report report.json
+
+  # Report the conversion errors of all the workloads rather than stopping at the first one
+  score-k8s generate *.score.yaml --keep-going
+
+  # Reject deprecated fields and unknown container resources rather than upgrading or passing them through
+  score-k8s generate score.yaml --strict-schema
+
+  # Fail if any workload container has no readinessProbe
+  score-k8s generate *.score.yaml --require-probes
+
+  # Fail if any workload container image has no tag or the 'latest' tag
+  score-k8s generate score.yaml --image=registry.example.com/app:${GIT_SHA} --strict-images
+
+  # Fail if a provisioner inlines a large blob into any manifest
+  score-k8s generate score.yaml --max-manifest-size 512Ki
+
+  # Garbage collect the manifests when their parent object is deleted
+  score-k8s generate score.yaml --owner-ref Application/my-app/7f2a8c4e-0b1d-4e6f-9a3b-5c8d2e1f4a6b/example.com/v1
+
+  # Upload the manifests to an S3 bucket using the aws cli and its credentials
+  score-k8s generate score.yaml --output s3://my-bucket/apps/manifests.yaml
+
+  # Start the output file with a comment block that records the score-k8s version
+  score-k8s generate score.yaml --header 'Code generated by score-k8s ${version}. DO NOT EDIT.'
+
+  # Write the manifests readable only by the current user
+  score-k8s generate score.yaml --output-mode 0600
+
+  # Write the manifests as the items of a single v1 List object
+  score-k8s generate score.yaml --as-list
+
+  # Write the manifests of each workload to manifests/<workload>.yaml and the resource manifests to manifests/resources.yaml
+  score-k8s generate *.score.yaml --per-workload-output manifests
+
+  # Regenerate the manifests each time the Score file changes
+  score-k8s generate score.yaml --watch
+
+  # Compare the manifests with the live objects in the cluster instead of writing them
+  score-k8s generate score.yaml --cluster-diff --kubeconfig ~/.kube/config --context my-cluster`,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
 
-		sd.State = *state
-		if err := sd.Persist(); err != nil {
-			return errors.Wrap(err, "failed to persist state file")
+		opts := generate.Options{ScoreFiles: args}
+		opts.OverridesFile, _ = cmd.Flags().GetString(generateCmdOverridesFileFlag)
+		opts.OverridesFromEnv, _ = cmd.Flags().GetString(generateCmdOverridesFromEnvFlag)
+		opts.OverrideProperties, _ = cmd.Flags().GetStringArray(generateCmdOverridePropertyFlag)
+		opts.Image, _ = cmd.Flags().GetString(generateCmdImageFlag)
+		opts.PatchManifests, _ = cmd.Flags().GetStringArray(generateCmdPatchManifestsFlag)
+		opts.OutputFormat, _ = cmd.Flags().GetString(generateCmdOutputFormatFlag)
+		opts.ClassDefault, _ = cmd.Flags().GetString(generateCmdClassDefaultFlag)
+		opts.AnnotationsFile, _ = cmd.Flags().GetString(generateCmdAnnotationsFileFlag)
+		opts.ManifestFilters, _ = cmd.Flags().GetStringArray(generateCmdManifestFilterFlag)
+		opts.ExpandEnv, _ = cmd.Flags().GetString(generateCmdExpandEnvFlag)
+		opts.VerboseProvisioner, _ = cmd.Flags().GetBool(generateCmdVerboseProvisionerFlag)
+		opts.NoState, _ = cmd.Flags().GetBool(generateCmdNoStateFlag)
+		opts.StampVersion, _ = cmd.Flags().GetBool(generateCmdStampVersionFlag)
+		opts.NoManagedLabels, _ = cmd.Flags().GetBool(generateCmdNoManagedLabelsFlag)
+		opts.ProvisionerTimeout, _ = cmd.Flags().GetDuration(generateCmdProvisionerTimeoutFlag)
+		opts.Namespace, _ = cmd.Flags().GetString(generateCmdNamespaceFlag)
+		opts.EmitNamespace, _ = cmd.Flags().GetBool(generateCmdEmitNamespaceFlag)
+		opts.ResourceNamespace, _ = cmd.Flags().GetString(generateCmdResourceNamespaceFlag)
+		opts.CapabilitiesFile, _ = cmd.Flags().GetString(generateCmdCapabilitiesFileFlag)
+		opts.KeepGoing, _ = cmd.Flags().GetBool(generateCmdKeepGoingFlag)
+		opts.LabelScheme, _ = cmd.Flags().GetString(generateCmdLabelSchemeFlag)
+		opts.StrictSchema, _ = cmd.Flags().GetBool(generateCmdStrictSchemaFlag)
+		opts.OwnerRef, _ = cmd.Flags().GetString(generateCmdOwnerRefFlag)
+		opts.OwnerRefFilters, _ = cmd.Flags().GetStringArray(generateCmdOwnerRefFilterFlag)
+		opts.RequireProbes, _ = cmd.Flags().GetBool(generateCmdRequireProbesFlag)
+		opts.StrictImages, _ = cmd.Flags().GetBool(generateCmdStrictImagesFlag)
+		opts.Incremental, _ = cmd.Flags().GetBool(generateCmdIncrementalFlag)
+		opts.Force, _ = cmd.Flags().GetBool(generateCmdForceFlag)
+		opts.ConsolidateSecrets, _ = cmd.Flags().GetBool(generateCmdConsolidateSecretsFlag)
+		opts.Env, _ = cmd.Flags().GetString(generateCmdEnvFlag)
+		var err error
+		if opts.NamespaceLabels, err = getKeyValueFlag(cmd, generateCmdNamespaceLabelFlag); err != nil {
+			return err
 		}
-		slog.Info("Persisted state file")
-
-		outputManifests := make([]map[string]interface{}, 0)
-		resIds, _ := state.GetSortedResourceUids()
-		for _, id := range resIds {
-			res := state.Resources[id]
-			if len(res.Extras.Manifests) > 0 {
-				for _, manifest := range res.Extras.Manifests {
-					if p, ok := internal.FindFirstUnresolvedSecretRef("", manifest); ok {
-						return errors.Errorf("unresolved secret ref in manifest: %s", p)
-					}
-					mSig := buildManifestSignature(manifest)
-					outputManifests = slices.DeleteFunc(outputManifests, func(other map[string]interface{}) bool {
-						if buildManifestSignature(other) == mSig {
-							slog.Info(fmt.Sprintf("Overriding duplicate resource manifest %s", mSig))
-							return true
-						}
-						return false
-					})
-					outputManifests = append(outputManifests, manifest)
-				}
-				slog.Info(fmt.Sprintf("Wrote %d resource manifests to manifests buffer for resource '%s'", len(res.Extras.Manifests), id))
+		if opts.NamespaceAnnotations, err = getKeyValueFlag(cmd, generateCmdNamespaceAnnotationFlag); err != nil {
+			return err
+		}
+		if opts.AnnotateFromEnv, err = getKeyValueFlag(cmd, generateCmdAnnotateFromEnvFlag); err != nil {
+			return err
+		}
+		if v, _ := cmd.Flags().GetString(generateCmdMaxManifestSizeFlag); v != "" {
+			q, err := resource.ParseQuantity(v)
+			if err != nil || q.Sign() < 0 {
+				return fmt.Errorf("--%s '%s' is invalid, expected a size in bytes such as 1Mi", generateCmdMaxManifestSizeFlag, v)
 			}
+			opts.MaxManifestSize = q.Value()
+		}
+		if _, err := getOutputMode(cmd); err != nil {
+			return err
 		}
 
-		for workloadName := range state.Workloads {
-			manifests, err := convert.ConvertWorkload(state, workloadName)
+		if v, _ := cmd.Flags().GetBool(generateCmdPlanFlag); v {
+			if w, _ := cmd.Flags().GetBool(generateCmdWatchFlag); w {
+				return fmt.Errorf("cannot use --%s with --%s", generateCmdWatchFlag, generateCmdPlanFlag)
+			}
+			planned, err := generate.Plan(cmd.Context(), opts)
 			if err != nil {
-				return errors.Wrapf(err, "workload: %s: failed to convert", workloadName)
+				return err
 			}
-			for _, m := range manifests {
-				subOut := new(bytes.Buffer)
-				if err = internal.YamlSerializerInfo.Serializer.Encode(m.(runtime.Object), subOut); err != nil {
-					return errors.Wrapf(err, "workload: %s: failed to serialise manifest %s", workloadName, m.GetName())
+			for _, pr := range planned {
+				if pr.Skipped {
+					_, _ = fmt.Fprintf(cmd.OutOrStdout(), "%s: %s: skipped, plan mode is not supported\n", pr.ResourceUid, pr.ProvisionerUri)
+					continue
 				}
-				var intermediate map[string]interface{}
-				_ = yaml.Unmarshal(subOut.Bytes(), &intermediate)
-				if p, ok := internal.FindFirstUnresolvedSecretRef("", intermediate); ok {
-					return errors.Errorf("unresolved secret ref in manifest: %s", p)
+				manifestNames := make([]string, 0, len(pr.Manifests))
+				for _, m := range pr.Manifests {
+					manifestNames = append(manifestNames, m.Kind+"/"+m.Name)
 				}
-				mSig := buildManifestSignature(intermediate)
-				outputManifests = slices.DeleteFunc(outputManifests, func(other map[string]interface{}) bool {
-					if buildManifestSignature(other) == mSig {
-						slog.Info(fmt.Sprintf("Overriding duplicate resource manifest %s", mSig))
-						return true
-					}
-					return false
-				})
-				outputManifests = append(outputManifests, intermediate)
+				_, _ = fmt.Fprintf(
+					cmd.OutOrStdout(), "%s: %s: outputs [%s], manifests [%s]\n", pr.ResourceUid, pr.ProvisionerUri,
+					strings.Join(pr.Outputs, ", "), strings.Join(manifestNames, ", "),
+				)
 			}
-			slog.Info(fmt.Sprintf("Wrote %d manifests to manifests buffer for workload '%s'", len(manifests), workloadName))
+			return nil
 		}
 
-		// patch manifests here
-		if v, _ := cmd.Flags().GetStringArray(generateCmdPatchManifestsFlag); len(v) > 0 {
-			for _, entry := range v {
-				if outputManifests, err = parseAndApplyManifestPatches(entry, generateCmdPatchManifestsFlag, outputManifests); err != nil {
-					return err
-				}
+		watch, _ := cmd.Flags().GetBool(generateCmdWatchFlag)
+		if watch && len(opts.ScoreFiles) == 0 {
+			return fmt.Errorf("--%s requires at least one score file", generateCmdWatchFlag)
+		}
+
+		if v, _ := cmd.Flags().GetBool(generateCmdClusterDiffFlag); v {
+			kubeconfig, _ := cmd.Flags().GetString(generateCmdKubeconfigFlag)
+			kubeContext, _ := cmd.Flags().GetString(generateCmdContextFlag)
+			if kubeconfig == "" || kubeContext == "" {
+				return fmt.Errorf("--%s requires both --%s and --%s to be set", generateCmdClusterDiffFlag, generateCmdKubeconfigFlag, generateCmdContextFlag)
+			} else if watch {
+				return fmt.Errorf("cannot use --%s with --%s", generateCmdWatchFlag, generateCmdClusterDiffFlag)
 			}
+			outputManifests, err := generate.Run(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			return runClusterDiff(cmd.Context(), encodeManifests(outputManifests), kubeconfig, kubeContext, cmd.OutOrStdout(), cmd.ErrOrStderr())
 		}
 
-		out := new(bytes.Buffer)
-		for _, manifest := range outputManifests {
-			out.WriteString("---\n")
-			_ = yaml.NewEncoder(out).Encode(manifest)
+		if err := generateAndWrite(cmd, opts); err != nil {
+			return err
 		}
-		v, _ := cmd.Flags().GetString(generateCmdOutputFlag)
-		if v == "" {
-			return fmt.Errorf("no output file specified")
-		} else if v == "-" {
-			_, _ = fmt.Fprint(cmd.OutOrStdout(), out.String())
-		} else if err := os.WriteFile(v+".tmp", out.Bytes(), 0644); err != nil {
-			return fmt.Errorf("failed to write output file: %w", err)
-		} else if err := os.Rename(v+".tmp", v); err != nil {
-			return fmt.Errorf("failed to complete writing output file: %w", err)
-		} else {
-			slog.Info(fmt.Sprintf("Wrote manifests to '%s'", v))
+
+		if watch {
+			files := slices.Clone(opts.ScoreFiles)
+			if opts.OverridesFile != "" {
+				files = append(files, opts.OverridesFile)
+			}
+			if opts.AnnotationsFile != "" {
+				files = append(files, opts.AnnotationsFile)
+			}
+			slog.Info(fmt.Sprintf("Watching %d files for changes, press Ctrl-C to stop", len(files)))
+			return watchFiles(cmd.Context(), files, generateWatchDebounce, func() {
+				if err := generateAndWrite(cmd, opts); err != nil {
+					slog.Error(fmt.Sprintf("Failed to regenerate manifests: %v", err))
+				}
+			})
 		}
 		return nil
 	},
 }
 
-func parseAndApplyOverrideFile(entry string, flagName string, spec map[string]interface{}) error {
-	if raw, err := os.ReadFile(entry); err != nil {
-		return fmt.Errorf("--%s '%s' is invalid, failed to read file: %w", flagName, entry, err)
-	} else {
-		slog.Info(fmt.Sprintf("Applying overrides from %s to workload", entry))
-		var out map[string]interface{}
-		if err := yaml.Unmarshal(raw, &out); err != nil {
-			return fmt.Errorf("--%s '%s' is invalid: failed to decode yaml: %w", flagName, entry, err)
-		} else if err := mergo.Merge(&spec, out, mergo.WithOverride); err != nil {
-			return fmt.Errorf("--%s '%s' failed to apply: %w", flagName, entry, err)
+// generateAndWrite runs the generate pipeline and writes the manifests to the output file. When --report is set, the
+// warnings logged during the run are recorded and a json report is written alongside the manifests. When --trace or
+// --trace-file is set, the timeline of the run is written once it finishes, even if it failed.
+func generateAndWrite(cmd *cobra.Command, opts generate.Options) (err error) {
+	ctx := cmd.Context()
+	traceFile, _ := cmd.Flags().GetString(generateCmdTraceFileFlag)
+	if v, _ := cmd.Flags().GetBool(generateCmdTraceFlag); v || traceFile != "" {
+		recorder := new(trace.Recorder)
+		ctx = trace.WithRecorder(ctx, recorder)
+		defer func() {
+			if traceErr := writeTrace(cmd, recorder, traceFile); traceErr != nil && err == nil {
+				err = traceErr
+			}
+		}()
+	}
+
+	reportFile, _ := cmd.Flags().GetString(generateCmdReportFlag)
+	if reportFile == "" {
+		outputManifests, err := generate.Run(ctx, opts)
+		if err != nil {
+			return err
 		}
+		_, endSpan := trace.Start(ctx, "write")
+		defer endSpan()
+		return writeOutput(cmd, outputManifests)
 	}
+
+	previous := slog.Default()
+	recorder := &logging.RecordingHandler{Inner: previous.Handler(), Level: slog.LevelWarn}
+	slog.SetDefault(slog.New(recorder))
+	defer slog.SetDefault(previous)
+
+	outputManifests, report, err := generate.RunWithReport(ctx, opts)
+	if err != nil {
+		return err
+	}
+	_, endSpan := trace.Start(ctx, "write")
+	defer endSpan()
+	if err := writeOutput(cmd, outputManifests); err != nil {
+		return err
+	}
+	report.Warnings = append(report.Warnings, recorder.Messages()...)
+	raw, _ := json.MarshalIndent(report, "", "  ")
+	if err := writeFileAtomic(reportFile, append(raw, '\n'), defaultOutputMode); err != nil {
+		return err
+	}
+	slog.Info(fmt.Sprintf("Wrote report to '%s'", reportFile))
 	return nil
 }
 
-func parseAndApplyOverrideProperty(entry string, flagName string, spec map[string]interface{}) (map[string]interface{}, error) {
-	parts := strings.SplitN(entry, "=", 2)
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("--%s '%s' is invalid, expected a =-separated path and value", flagName, entry)
+// writeTrace writes the timeline of the recorded spans to the trace file, or to stderr if there is none.
+func writeTrace(cmd *cobra.Command, recorder *trace.Recorder, traceFile string) error {
+	if traceFile == "" {
+		return recorder.WriteTimeline(cmd.ErrOrStderr())
 	}
-	if parts[1] == "" {
-		slog.Info(fmt.Sprintf("Overriding '%s' in workload", parts[0]))
-		after, err := framework.OverridePathInMap(spec, framework.ParseDotPathParts(parts[0]), true, nil)
-		if err != nil {
-			return nil, fmt.Errorf("--%s '%s' could not be applied: %w", flagName, entry, err)
+	buff := new(bytes.Buffer)
+	_ = recorder.WriteTimeline(buff)
+	if err := writeFileAtomic(traceFile, buff.Bytes(), defaultOutputMode); err != nil {
+		return err
+	}
+	slog.Info(fmt.Sprintf("Wrote trace to '%s'", traceFile))
+	return nil
+}
+
+// writeOutput writes the manifests to the output file or the --per-workload-output directory.
+func writeOutput(cmd *cobra.Command, outputManifests []generate.Manifest) error {
+	asList, _ := cmd.Flags().GetBool(generateCmdAsListFlag)
+	encode := encodeManifests
+	if asList {
+		encode = encodeManifestList
+	}
+	if v, _ := cmd.Flags().GetString(generateCmdOutputFormatFlag); v == generate.OutputFormatHelmValues {
+		if asList {
+			return fmt.Errorf("cannot use --%s with --%s=%s", generateCmdAsListFlag, generateCmdOutputFormatFlag, v)
+		} else if cmd.Flags().Changed(generateCmdPerWorkloadOutputFlag) {
+			return fmt.Errorf("cannot use --%s with --%s=%s", generateCmdPerWorkloadOutputFlag, generateCmdOutputFormatFlag, v)
+		}
+		encode = encodeHelmValues
+	}
+	if header, _ := cmd.Flags().GetString(generateCmdHeaderFlag); header != "" {
+		encodeContent, prefix := encode, renderOutputHeader(header, time.Now())
+		encode = func(manifests []generate.Manifest) []byte {
+			return append(slices.Clone(prefix), encodeContent(manifests)...)
 		}
-		return after, nil
+	}
+
+	mode, err := getOutputMode(cmd)
+	if err != nil {
+		return err
+	}
+
+	if dir, _ := cmd.Flags().GetString(generateCmdPerWorkloadOutputFlag); dir != "" {
+		if cmd.Flags().Changed(generateCmdOutputFlag) {
+			return fmt.Errorf("cannot use --%s with --%s", generateCmdOutputFlag, generateCmdPerWorkloadOutputFlag)
+		}
+		return writePerWorkloadOutput(dir, outputManifests, encode, mode)
+	}
+
+	out := encode(outputManifests)
+	v, _ := cmd.Flags().GetString(generateCmdOutputFlag)
+	if v == "" {
+		return fmt.Errorf("no output file specified")
+	} else if v == "-" {
+		_, _ = fmt.Fprint(cmd.OutOrStdout(), string(out))
+	} else if isRemoteOutput(v) {
+		if err := writeRemoteOutput(cmd.Context(), v, out); err != nil {
+			return err
+		}
+		slog.Info(fmt.Sprintf("Uploaded manifests to '%s'", v))
+	} else if err := writeFileAtomic(v, out, mode); err != nil {
+		return err
 	} else {
-		var value interface{}
-		if err := yaml.Unmarshal([]byte(parts[1]), &value); err != nil {
-			return nil, fmt.Errorf("--%s '%s' is invalid, failed to unmarshal value as json: %w", flagName, entry, err)
+		slog.Info(fmt.Sprintf("Wrote manifests to '%s'", v))
+	}
+	return nil
+}
+
+// perWorkloadResourcesFile is the file that holds the resource manifests in --per-workload-output mode.
+const perWorkloadResourcesFile = "resources.yaml"
+
+// writePerWorkloadOutput writes the manifests of each workload to <dir>/<workload>.yaml and the manifests of all
+// resources to <dir>/resources.yaml. Each file is written atomically.
+func writePerWorkloadOutput(dir string, manifests []generate.Manifest, encode func([]generate.Manifest) []byte, mode os.FileMode) error {
+	groups := make(map[string][]generate.Manifest)
+	for _, m := range manifests {
+		fileName := perWorkloadResourcesFile
+		if m.Workload != "" {
+			fileName = m.Workload + ".yaml"
+			if fileName == perWorkloadResourcesFile {
+				return fmt.Errorf("workload '%s' conflicts with the --%s resources file", m.Workload, generateCmdPerWorkloadOutputFlag)
+			}
 		}
-		slog.Info(fmt.Sprintf("Overriding '%s' in workload", parts[0]))
-		after, err := framework.OverridePathInMap(spec, framework.ParseDotPathParts(parts[0]), false, value)
-		if err != nil {
-			return nil, fmt.Errorf("--%s '%s' could not be applied: %w", flagName, entry, err)
+		groups[fileName] = append(groups[fileName], m)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	for _, fileName := range slices.Sorted(maps.Keys(groups)) {
+		p := filepath.Join(dir, fileName)
+		if err := writeFileAtomic(p, encode(groups[fileName]), mode); err != nil {
+			return err
 		}
-		return after, nil
+		slog.Info(fmt.Sprintf("Wrote %d manifests to '%s'", len(groups[fileName]), p))
 	}
+	return nil
 }
 
-func parseAndApplyManifestPatches(entry string, flagName string, manifests []map[string]interface{}) ([]map[string]interface{}, error) {
-	parts := strings.SplitN(entry, "=", 2)
-	if len(parts) != 2 {
-		return nil, fmt.Errorf("--%s '%s' is invalid, expected a =-separated path and value", flagName, entry)
+// defaultOutputMode is the file mode of the output files when --output-mode is not set.
+const defaultOutputMode os.FileMode = 0644
+
+// getOutputMode parses the --output-mode flag as an octal file mode. The mode must not have bits outside of 0777 and
+// must leave the file readable by its owner.
+func getOutputMode(cmd *cobra.Command) (os.FileMode, error) {
+	v, _ := cmd.Flags().GetString(generateCmdOutputModeFlag)
+	if v == "" {
+		return defaultOutputMode, nil
 	}
-	filter := strings.SplitN(parts[0], "/", 3)
-	if len(filter) != 3 {
-		return nil, fmt.Errorf("--%s '%s' is invalid, expected the patch path to have an initial <kind>/<name>/... prefix", flagName, entry)
+	m, err := strconv.ParseUint(v, 8, 32)
+	if err != nil || m&^0777 != 0 || m&0400 == 0 {
+		return 0, fmt.Errorf("--%s '%s' is invalid, expected an octal file mode readable by the owner such as 0644", generateCmdOutputModeFlag, v)
 	}
-	kindFilter, nameFilter, path := filter[0], filter[1], filter[2]
-	outManifests := slices.Clone(manifests)
-
-	for i, manifest := range manifests {
-		kind, kOk := manifest["kind"].(string)
-		meta, _ := manifest["metadata"].(map[string]interface{})
-		name, nOk := meta["name"].(string)
-		if (kindFilter == "*" || (kOk && kind == kindFilter)) && (nameFilter == "*" || (nOk && name == nameFilter)) {
-			if parts[1] == "" {
-				slog.Info(fmt.Sprintf("Overriding '%s' in manifest %s/%s", path, kind, name))
-				after, err := framework.OverridePathInMap(manifest, framework.ParseDotPathParts(path), true, nil)
-				if err != nil {
-					return nil, fmt.Errorf("--%s '%s' could not be applied to %s/%s: %w", flagName, entry, kind, name, err)
-				}
-				manifest = after
-			} else {
-				var value interface{}
-				if err := yaml.Unmarshal([]byte(parts[1]), &value); err != nil {
-					return nil, fmt.Errorf("--%s '%s' is invalid, failed to unmarshal value as yaml: %w", flagName, entry, err)
-				}
-				slog.Info(fmt.Sprintf("Overriding '%s' in manifest %s/%s", path, kind, name))
-				after, err := framework.OverridePathInMap(manifest, framework.ParseDotPathParts(path), false, value)
-				if err != nil {
-					return nil, fmt.Errorf("--%s '%s' could not be applied to %s/%s: %w", flagName, entry, kind, name, err)
-				}
-				manifest = after
-			}
+	return os.FileMode(m), nil
+}
+
+// writeFileAtomic writes the content to a temporary file next to the path and then renames it into place so that the
+// file is never left partially written. The file is given the mode regardless of the umask.
+func writeFileAtomic(path string, content []byte, mode os.FileMode) error {
+	if err := os.WriteFile(path+".tmp", content, mode); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
+	} else if err := os.Chmod(path+".tmp", mode); err != nil {
+		return fmt.Errorf("failed to set the mode of the output file: %w", err)
+	} else if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to complete writing output file: %w", err)
+	}
+	return nil
+}
+
+// getKeyValueFlag reads a string array flag of key=value entries into a map.
+func getKeyValueFlag(cmd *cobra.Command, name string) (map[string]string, error) {
+	entries, _ := cmd.Flags().GetStringArray(name)
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		k, v, ok := strings.Cut(entry, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("--%s '%s' is invalid, expected key=value", name, entry)
 		}
-		outManifests[i] = manifest
+		out[k] = v
 	}
-	return outManifests, nil
+	return out, nil
 }
 
-// buildManifestSignature builds a unique manifest signature for each manifest coming out of a resource. This is used
-// to deduplicate resource manifests when they share state.
-func buildManifestSignature(n map[string]interface{}) string {
-	apiVersion, _ := n["apiVersion"].(string)
-	kind, _ := n["kind"].(string)
-	metadata, _ := n["metadata"].(map[string]interface{})
-	namespace, _ := metadata["namespace"].(string)
-	name, _ := metadata["name"].(string)
-	return fmt.Sprintf("%s/%s/%s/%s", apiVersion, kind, namespace, name)
+// encodeManifests encodes the manifests as a multi-document yaml file.
+func encodeManifests(manifests []generate.Manifest) []byte {
+	out := new(bytes.Buffer)
+	for _, manifest := range manifests {
+		out.WriteString("---\n")
+		_ = yaml.NewEncoder(out).Encode(manifest.Object)
+	}
+	return out.Bytes()
+}
+
+// renderOutputHeader returns the --header text as a block of yaml comments to write before the first document of an
+// output file. Lines that are not already comments are prefixed with '# '. The ${version} and ${timestamp}
+// placeholders are replaced with the score-k8s version and the current time in UTC.
+func renderOutputHeader(header string, now time.Time) []byte {
+	header = strings.NewReplacer(
+		"${version}", version.GetBuildInfo().Version,
+		"${timestamp}", now.UTC().Format(time.RFC3339),
+	).Replace(strings.ReplaceAll(header, "\r\n", "\n"))
+	out := new(bytes.Buffer)
+	for _, line := range strings.Split(strings.TrimRight(header, "\n"), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		if line == "" {
+			out.WriteString("#\n")
+		} else if strings.HasPrefix(line, "#") {
+			out.WriteString(line + "\n")
+		} else {
+			out.WriteString("# " + line + "\n")
+		}
+	}
+	return out.Bytes()
+}
+
+// encodeHelmValues encodes the tunable fields of the workloads in the manifests as a Helm values.yaml.
+func encodeHelmValues(manifests []generate.Manifest) []byte {
+	out := new(bytes.Buffer)
+	enc := yaml.NewEncoder(out)
+	enc.SetIndent(2)
+	_ = enc.Encode(generate.HelmValues(manifests))
+	return out.Bytes()
+}
+
+// encodeManifestList encodes the manifests as the items of a single v1 List object.
+func encodeManifestList(manifests []generate.Manifest) []byte {
+	items := make([]map[string]interface{}, 0, len(manifests))
+	for _, manifest := range manifests {
+		items = append(items, manifest.Object)
+	}
+	out := new(bytes.Buffer)
+	_ = yaml.NewEncoder(out).Encode(map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      items,
+	})
+	return out.Bytes()
 }
 
 func init() {
-	generateCmd.Flags().StringP(generateCmdOutputFlag, "o", "manifests.yaml", "The output manifests file to write the manifests to")
+	generateCmd.Flags().StringP(generateCmdOutputFlag, "o", "manifests.yaml", "The output manifests file to write the manifests to, or an s3://<bucket>/<key> or gs://<bucket>/<key> object to upload them to")
 	generateCmd.Flags().String(generateCmdOverridesFileFlag, "", "An optional file of Score overrides to merge in")
-	generateCmd.Flags().StringArray(generateCmdOverridePropertyFlag, []string{}, "An optional set of path=key overrides to set or remove")
+	generateCmd.Flags().String(generateCmdOverridesFromEnvFlag, "", "An optional environment variable whose value must be base64 encoded yaml Score overrides to merge in")
+	generateCmd.Flags().StringArray(generateCmdOverridePropertyFlag, []string{}, "An optional set of path=value overrides to set, or path and path= overrides to remove")
+	generateCmd.Flags().String(generateCmdExpandEnvFlag, "", "Expand ${VAR} environment variables in --override-property values, 'strict' fails on undefined variables")
+	generateCmd.Flags().Lookup(generateCmdExpandEnvFlag).NoOptDefVal = generate.ExpandEnvEnabled
 	generateCmd.Flags().String(generateCmdImageFlag, "", "An optional container image to use for any container with image == '.'")
+	generateCmd.Flags().String(generateCmdAnnotationsFileFlag, "", "An optional yaml file containing a map of annotations to add to every output manifest")
+	generateCmd.Flags().StringArray(generateCmdAnnotateFromEnvFlag, []string{}, "An optional set of key=ENVVAR annotations to add to every output manifest from environment variables, unset variables are skipped unless --expand-env=strict")
+	generateCmd.Flags().Bool(generateCmdStampVersionFlag, false, "Add a k8s.score.dev/generator-version annotation with the score-k8s version to every output manifest")
+	generateCmd.Flags().String(generateCmdLabelSchemeFlag, "", "An optional label scheme for the workload selectors, 'recommended' (the default) selects on app.kubernetes.io/instance and 'legacy' selects on an 'app' label")
+	generateCmd.Flags().Bool(generateCmdNoManagedLabelsFlag, false, "Remove the app.kubernetes.io/name and app.kubernetes.io/managed-by labels from the workload manifests, keeping only the selector labels")
 	generateCmd.Flags().StringArray(generateCmdPatchManifestsFlag, []string{}, "An optional set of <kind|*>/<name|*>/path=key operations for the output manifests")
+	generateCmd.Flags().StringArray(generateCmdManifestFilterFlag, []string{}, "An optional set of kind=<kind>[,name=<name>] filters for the manifests to write, everything is still provisioned")
+	generateCmd.Flags().String(generateCmdOutputFormatFlag, "", "An optional output format, 'kubectl-apply-order' sorts the manifests into a safe order for kubectl apply, 'helm-values' writes a Helm values.yaml of the workload replicas, images, and resources instead of the manifests")
+	generateCmd.Flags().String(generateCmdNamespaceFlag, "", "An optional namespace to set on every namespaced output manifest that does not already have one")
+	generateCmd.Flags().Bool(generateCmdEmitNamespaceFlag, false, "Add a Namespace object for the --namespace to the start of the output manifests")
+	generateCmd.Flags().StringArray(generateCmdNamespaceLabelFlag, []string{}, "An optional set of key=value labels for the Namespace added by --emit-namespace")
+	generateCmd.Flags().StringArray(generateCmdNamespaceAnnotationFlag, []string{}, "An optional set of key=value annotations for the Namespace added by --emit-namespace")
+	generateCmd.Flags().String(generateCmdResourceNamespaceFlag, "", "An optional namespace for the manifests of provisioned resources, requires --namespace for the workloads")
+	generateCmd.Flags().Bool(generateCmdTraceFlag, false, "Print a timeline of how long each phase of the run took to stderr")
+	generateCmd.Flags().String(generateCmdTraceFileFlag, "", "Write the timeline of --trace to this file rather than stderr, implies --trace")
+	generateCmd.Flags().String(generateCmdCapabilitiesFileFlag, "", "An optional file listing the apiVersions available in the target cluster, as output by 'kubectl api-versions'. Manifests with other apiVersions are skipped with a warning")
+	generateCmd.Flags().Bool(generateCmdStrictSchemaFlag, false, "Validate the Score files exactly as written, rejecting deprecated fields, unknown container resources, and unknown k8s.score.dev/ annotations instead of upgrading, passing through, or warning about them")
+	generateCmd.Flags().String(generateCmdOwnerRefFlag, "", "An optional parent object in the form kind/name/uid/apiVersion to add to the ownerReferences of the namespaced output manifests")
+	generateCmd.Flags().StringArray(generateCmdOwnerRefFilterFlag, []string{}, "Only add the --owner-ref to the manifests matching kind=<kind>[,name=<name>], may be repeated")
+	generateCmd.Flags().Bool(generateCmdRequireProbesFlag, false, "Fail and list the workloads whose Deployment or StatefulSet has containers without a readinessProbe")
+	generateCmd.Flags().Bool(generateCmdStrictImagesFlag, false, "Fail and list the workloads with container images that have no tag or the 'latest' tag, images pinned to a digest are allowed")
+	generateCmd.Flags().String(generateCmdMaxManifestSizeFlag, "", "An optional maximum size of each output manifest, such as 1Mi. Larger manifests fail the generate with the kind, name, and provisioner of the manifest")
+	generateCmd.Flags().Bool(generateCmdIncrementalFlag, false, "Skip provisioning the resources whose provisioner and inputs are unchanged since the last incremental run, reusing their recorded outputs and manifests")
+	generateCmd.Flags().Bool(generateCmdForceFlag, false, "Provision every resource with --incremental, while still recording the state for the next incremental run")
+	generateCmd.Flags().Bool(generateCmdConsolidateSecretsFlag, false, "Copy the secret env values of each workload from the Secrets generated by provisioners into a single <workload>-env Secret and refer to that instead")
+	generateCmd.Flags().String(generateCmdEnvFlag, "", "An optional environment name, such as prod, that the k8s.score.dev/when conditions of the workloads are evaluated against")
+	generateCmd.Flags().Bool(generateCmdKeepGoingFlag, false, "Continue converting the remaining workloads when one fails and report all the conversion errors at the end")
+	generateCmd.Flags().String(generateCmdReportFlag, "", "An optional file to write a json report to, with the manifest counts per workload, the resources and provisioners used, and any warnings")
+	generateCmd.Flags().String(generateCmdPerWorkloadOutputFlag, "", "An optional directory to write the manifests of each workload to as <workload>.yaml, with the resource manifests in resources.yaml, instead of the output file")
+	generateCmd.Flags().String(generateCmdHeaderFlag, "", "An optional comment block to write at the start of each output file, such as a DO NOT EDIT notice. ${version} and ${timestamp} are replaced with the score-k8s version and the current time")
+	generateCmd.Flags().String(generateCmdOutputModeFlag, "", "An optional octal file mode for the written manifest files, such as 0600, defaults to 0644")
+	generateCmd.Flags().Bool(generateCmdAsListFlag, false, "Write the manifests as the items of a single v1 List object rather than as multiple yaml documents")
+	generateCmd.Flags().String(generateCmdClassDefaultFlag, "", "An optional resource class to use for any resource in the Score files without a class")
+	generateCmd.Flags().Bool(generateCmdPlanFlag, false, "Run the provisioners in plan mode and print a summary instead of updating the state and writing manifests")
+	generateCmd.Flags().Bool(generateCmdClusterDiffFlag, false, "Print the difference between the manifests and the live objects in the cluster using 'kubectl diff' instead of writing the output file")
+	generateCmd.Flags().String(generateCmdKubeconfigFlag, "", "The kubeconfig file to use with --cluster-diff")
+	generateCmd.Flags().String(generateCmdContextFlag, "", "The kubeconfig context to use with --cluster-diff")
+	generateCmd.Flags().Bool(generateCmdNoStateFlag, false, "Build the state in memory from the given score files, ignoring and not writing the state file")
+	generateCmd.Flags().Duration(generateCmdProvisionerTimeoutFlag, 0, "An optional limit on the total time spent provisioning resources, the state is not updated if it is exceeded")
+	generateCmd.Flags().Bool(generateCmdVerboseProvisionerFlag, false, "Log the json input and output of each provisioner at info level, secret looking values are redacted")
+	generateCmd.Flags().Bool(generateCmdWatchFlag, false, "Keep running and regenerate the manifests when the Score files or overrides file change")
 
 	rootCmd.AddCommand(generateCmd)
 }
@@ -16,13 +16,16 @@ package command
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 
 	"github.com/score-spec/score-k8s/internal/project"
 )
@@ -48,6 +51,20 @@ func TestGenerateWithoutInit(t *testing.T) {
 	assert.Equal(t, "", stdout)
 }
 
+func TestGenerateInvalidMaxManifestSize(t *testing.T) {
+	_ = changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "--max-manifest-size", "big"})
+	assert.EqualError(t, err, "--max-manifest-size 'big' is invalid, expected a size in bytes such as 1Mi")
+}
+
+func TestGenerateInvalidOutputMode(t *testing.T) {
+	_ = changeToTempDir(t)
+	for _, v := range []string{"rw", "0999", "01777", "0044"} {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "--output-mode", v})
+		assert.EqualError(t, err, "--output-mode '"+v+"' is invalid, expected an octal file mode readable by the owner such as 0644")
+	}
+}
+
 func TestGenerateWithoutScoreFiles(t *testing.T) {
 	_ = changeToTempDir(t)
 	stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init"})
@@ -273,3 +290,384 @@ resources:
 	require.NoError(t, err)
 	assert.Equal(t, strings.Count(string(rawManifests), "kind: Secret"), 1, "failed to find in", string(rawManifests))
 }
+
+func TestGeneratePlan(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init"})
+	require.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  hello:
+    image: foo
+resources:
+  foo:
+    type: example-provisioner-resource
+  bar:
+    type: thing
+`), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(td, ".score-k8s", "00.provisioners.yaml"), []byte(`
+- uri: cmd://sh
+  type: thing
+  args: ["-c", "echo '{}'"]
+`), 0644))
+	stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml", "--plan"})
+	require.NoError(t, err)
+	assert.Contains(t, stdout, "example-provisioner-resource.default#example.foo: template://example-provisioners/example-provisioner: outputs [nested, plaintext, secret-reference], manifests [ConfigMap/cfg-")
+	assert.Contains(t, stdout, "thing.default#example.bar: cmd://sh: skipped, plan mode is not supported\n")
+
+	// nothing should have been written
+	_, err = os.Stat(filepath.Join(td, "manifests.yaml"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	sd, ok, err := project.LoadStateDirectory(td)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Len(t, sd.State.Workloads, 0)
+	assert.Len(t, sd.State.Resources, 0)
+}
+
+func TestGenerateClusterDiff(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init"})
+	require.NoError(t, err)
+
+	t.Run("missing kubeconfig", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml", "--cluster-diff", "--context", "foo"})
+		assert.EqualError(t, err, "--cluster-diff requires both --kubeconfig and --context to be set")
+	})
+
+	t.Run("nominal", func(t *testing.T) {
+		// install a fake kubectl that echoes the args and input and exits with 1 to indicate differences
+		binDir := filepath.Join(td, "bin")
+		require.NoError(t, os.Mkdir(binDir, 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(binDir, "kubectl"), []byte("#!/bin/sh\necho \"$@\"\ngrep '^kind:'\nexit 1\n"), 0755))
+		t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+		stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml", "--cluster-diff", "--kubeconfig", "kc", "--context", "foo"})
+		require.NoError(t, err)
+		assert.Equal(t, "diff --kubeconfig kc --context foo -f -\nkind: Service\nkind: Deployment\n", stdout)
+		_, err = os.Stat(filepath.Join(td, "manifests.yaml"))
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestGenerateRemoteOutput(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init"})
+	require.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  hello:
+    image: foo
+`), 0644))
+
+	// install fake cloud clis that log their args and store the uploaded content in a local file
+	binDir := filepath.Join(td, "bin")
+	require.NoError(t, os.Mkdir(binDir, 0755))
+	fakeCli := "#!/bin/sh\necho \"$@\" >> " + filepath.Join(td, "calls.log") + "\ncase \"$*\" in *' cp '*) cat > " + filepath.Join(td, "uploaded.yaml") + ";; esac\n"
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "aws"), []byte(fakeCli), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "gcloud"), []byte(fakeCli), 0755))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	for _, tc := range []struct {
+		output   string
+		expected string
+	}{
+		{output: "s3://bucket/apps/manifests.yaml", expected: `s3 cp --only-show-errors - s3://bucket/apps/manifests\.yaml\.tmp-[0-9a-f]{8}
+s3 mv --only-show-errors s3://bucket/apps/manifests\.yaml\.tmp-[0-9a-f]{8} s3://bucket/apps/manifests\.yaml
+`},
+		{output: "gs://bucket/manifests.yaml", expected: `storage cp --quiet - gs://bucket/manifests\.yaml\.tmp-[0-9a-f]{8}
+storage mv --quiet gs://bucket/manifests\.yaml\.tmp-[0-9a-f]{8} gs://bucket/manifests\.yaml
+`},
+	} {
+		t.Run(tc.output, func(t *testing.T) {
+			_ = os.Remove(filepath.Join(td, "calls.log"))
+			_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml", "--output", tc.output})
+			require.NoError(t, err)
+			calls, err := os.ReadFile(filepath.Join(td, "calls.log"))
+			require.NoError(t, err)
+			assert.Regexp(t, "^"+tc.expected+"$", string(calls))
+			uploaded, err := os.ReadFile(filepath.Join(td, "uploaded.yaml"))
+			require.NoError(t, err)
+			assert.Contains(t, string(uploaded), "kind: Deployment")
+			_, err = os.Stat(filepath.Join(td, "manifests.yaml"))
+			assert.ErrorIs(t, err, os.ErrNotExist)
+		})
+	}
+
+	t.Run("invalid", func(t *testing.T) {
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "--output", "s3://bucket/"})
+		assert.EqualError(t, err, "--output 's3://bucket/' is not a valid object store uri, expected s3://<bucket>/<key>")
+	})
+
+	t.Run("failed upload", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(filepath.Join(binDir, "aws"), []byte("#!/bin/sh\necho 'access denied' >&2\nexit 1\n"), 0755))
+		_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "--output", "s3://bucket/manifests.yaml"})
+		assert.Regexp(t, `^failed to upload to 's3://bucket/manifests\.yaml\.tmp-[0-9a-f]{8}': exit status 1: access denied$`, err.Error())
+	})
+}
+
+func TestGenerateAsList(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init"})
+	require.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  hello:
+    image: foo
+service:
+  ports:
+    web:
+      port: 80
+`), 0644))
+	stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml", "--as-list", "-o", "-"})
+	require.NoError(t, err)
+	assert.NotContains(t, stdout, "---")
+	var out map[string]interface{}
+	require.NoError(t, yaml.Unmarshal([]byte(stdout), &out))
+	assert.Equal(t, "v1", out["apiVersion"])
+	assert.Equal(t, "List", out["kind"])
+	items := out["items"].([]interface{})
+	require.Len(t, items, 2)
+	assert.Equal(t, "Service", items[0].(map[string]interface{})["kind"])
+	assert.Equal(t, "Deployment", items[1].(map[string]interface{})["kind"])
+}
+
+func TestGenerateOutputMode(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init"})
+	require.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  hello:
+    image: foo
+`), 0644))
+
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml"})
+	require.NoError(t, err)
+	st, err := os.Stat(filepath.Join(td, "manifests.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0644), st.Mode().Perm())
+
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml", "--output-mode", "0600"})
+	require.NoError(t, err)
+	st, err = os.Stat(filepath.Join(td, "manifests.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), st.Mode().Perm())
+
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml", "--output-mode", "640", "--per-workload-output", "out"})
+	require.NoError(t, err)
+	st, err = os.Stat(filepath.Join(td, "out", "example.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0640), st.Mode().Perm())
+}
+
+func TestGenerateHeader(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init"})
+	require.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  hello:
+    image: foo
+service:
+  ports:
+    web:
+      port: 80
+`), 0644))
+	stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{
+		"generate", "score.yaml", "-o", "-", "--header", "Code generated by score-k8s ${version}. DO NOT EDIT.\n\n# See the README",
+	})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(stdout, "# Code generated by score-k8s 0.0.0. DO NOT EDIT.\n#\n# See the README\n---\n"), stdout)
+
+	dec := yaml.NewDecoder(strings.NewReader(stdout))
+	var kinds []string
+	for {
+		var doc map[string]interface{}
+		if err := dec.Decode(&doc); err != nil {
+			break
+		}
+		kinds = append(kinds, doc["kind"].(string))
+	}
+	assert.Equal(t, []string{"Service", "Deployment"}, kinds)
+}
+
+func TestGenerateTrace(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init"})
+	require.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  hello:
+    image: foo
+resources:
+  cache:
+    type: redis
+`), 0644))
+	_, stderr, err := executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml", "--trace"})
+	require.NoError(t, err)
+	for _, phase := range []string{
+		"  load\n", "  prime\n", "  load provisioners\n", "  provision\n",
+		"    provision redis.default#example.cache (template://default-provisioners/redis)\n",
+		"  persist state\n", "  convert\n", "    convert workload example\n", "  write\n",
+	} {
+		assert.Contains(t, stderr, phase)
+	}
+
+	// the timeline is still written when the run fails
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml", "--trace-file", "trace.txt", "--strict-images"})
+	require.Error(t, err)
+	raw, err := os.ReadFile(filepath.Join(td, "trace.txt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "    convert workload example\n")
+	assert.NotContains(t, string(raw), "  write\n")
+}
+
+func Test_renderOutputHeader(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 30, 0, 0, time.FixedZone("CEST", 2*60*60))
+	assert.Equal(t, "# generated at 2024-05-01T10:30:00Z\n#\n#  indented\n", string(renderOutputHeader("generated at ${timestamp}\r\n\n#  indented  \n\n", now)))
+}
+
+func TestGenerateHelmValues(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init"})
+	require.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+  annotations:
+    k8s.score.dev/init-containers: migrate
+containers:
+  hello:
+    image: foo
+    resources:
+      limits:
+        cpu: 500m
+        memory: 128Mi
+  migrate:
+    image: bar
+`), 0644))
+	stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{
+		"generate", "score.yaml", "--output-format", "helm-values", "-o", "-", "--patch-manifests", "Deployment/example/spec.replicas=3",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `workloads:
+  example:
+    containers:
+      hello:
+        image: foo
+        resources:
+          limits:
+            cpu: 500m
+            memory: 128Mi
+    initContainers:
+      migrate:
+        image: bar
+        resources: {}
+    replicas: 3
+`, stdout)
+
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "--output-format", "helm-values", "--as-list", "-o", "-"})
+	assert.EqualError(t, err, "cannot use --as-list with --output-format=helm-values")
+}
+
+func TestGenerateReport(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init"})
+	require.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(td, "score.yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  hello:
+    image: foo
+service:
+  ports:
+    web:
+      port: 80
+resources:
+  bar:
+    type: example-provisioner-resource
+`), 0644))
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml", "--report", "report.json", "--no-managed-labels"})
+	require.NoError(t, err)
+	raw, err := os.ReadFile(filepath.Join(td, "report.json"))
+	require.NoError(t, err)
+	var report map[string]interface{}
+	require.NoError(t, json.Unmarshal(raw, &report))
+	assert.Equal(t, map[string]interface{}{
+		"manifests": 3.0,
+		"workloads": map[string]interface{}{"example": map[string]interface{}{"manifests": 2.0}},
+		"resources": []interface{}{map[string]interface{}{
+			"uid":         "example-provisioner-resource.default#example.bar",
+			"type":        "example-provisioner-resource",
+			"class":       "default",
+			"provisioner": "template://example-provisioners/example-provisioner",
+			"manifests":   1.0,
+		}},
+		"provisioners": []interface{}{"template://example-provisioners/example-provisioner"},
+		"warnings": []interface{}{
+			"--no-managed-labels removes the app.kubernetes.io/managed-by label, tools that find the objects managed by score-k8s through this label will not work",
+		},
+	}, report)
+}
+
+func TestGeneratePerWorkloadOutput(t *testing.T) {
+	td := changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init"})
+	require.NoError(t, err)
+	for _, name := range []string{"example-a", "example-b"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(td, name+".yaml"), []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: `+name+`
+containers:
+  hello:
+    image: foo
+resources:
+  bar:
+    type: example-provisioner-resource
+`), 0644))
+	}
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "example-a.yaml", "example-b.yaml", "--per-workload-output", "out"})
+	require.NoError(t, err)
+	entries, err := os.ReadDir(filepath.Join(td, "out"))
+	require.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.Equal(t, []string{"example-a.yaml", "example-b.yaml", "resources.yaml"}, names)
+	raw, err := os.ReadFile(filepath.Join(td, "out", "example-a.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "name: example-a\n")
+	assert.NotContains(t, string(raw), "example-b")
+	raw, err = os.ReadFile(filepath.Join(td, "out", "resources.yaml"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), "kind: ConfigMap")
+	_, err = os.Stat(filepath.Join(td, "manifests.yaml"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "--per-workload-output", "out", "-o", "x.yaml"})
+	assert.EqualError(t, err, "cannot use --output with --per-workload-output")
+}
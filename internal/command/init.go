@@ -36,6 +36,8 @@ const (
 	initCmdFileFlag         = "file"
 	initCmdFileNoSampleFlag = "no-sample"
 	initCmdProvisionerFlag  = "provisioners"
+	initCmdWithExamplesFlag = "with-examples"
+	initCmdFromManifestFlag = "from-manifest"
 )
 
 var initCmd = &cobra.Command{
@@ -59,6 +61,12 @@ the new provisioners will take precedence.
   # Or disable the default score file generation if you already have a score file
   score-k8s init --no-sample
 
+  # Seed the Score file from the Deployment and Service of an existing application
+  score-k8s init --from-manifest deployment.yaml
+
+  # Write documented examples of the cmd, template, and http provisioners
+  score-k8s init --with-examples
+
   # Optionally loading in provisoners from a remote url
   score-k8s init --provisioners https://raw.githubusercontent.com/user/repo/main/example.yaml`,
 	SilenceErrors: true,
@@ -102,12 +110,29 @@ the new provisioners will take precedence.
 			slog.Info("Skipping creation of default provisioners file since it already exists", "file", defaultProvisioners)
 		}
 
+		if v, _ := cmd.Flags().GetBool(initCmdWithExamplesFlag); v {
+			exampleProvisioners := filepath.Join(sd.Path, "zz-examples.provisioners.yaml")
+			if _, err := os.Stat(exampleProvisioners); err == nil {
+				slog.Info("Skipping creation of example provisioners file since it already exists", "file", exampleProvisioners)
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return errors.Wrapf(err, "failed to check for existing example provisioners file")
+			} else if err := os.WriteFile(exampleProvisioners, []byte(_default.ExampleProvisioners), 0644); err != nil {
+				return errors.Wrap(err, "failed to write example provisioners file")
+			} else {
+				slog.Info("Created example provisioners file", "file", exampleProvisioners)
+			}
+		}
+
 		initCmdScoreFile, _ := cmd.Flags().GetString(initCmdFileFlag)
 		if _, err := os.Stat(initCmdScoreFile); err != nil {
 			if !errors.Is(err, os.ErrNotExist) {
 				return errors.Wrap(err, "failed to check for existing Score file")
 			}
+			fromManifest, _ := cmd.Flags().GetString(initCmdFromManifestFlag)
 			if v, _ := cmd.Flags().GetBool(initCmdFileNoSampleFlag); v {
+				if fromManifest != "" {
+					return errors.Errorf("cannot use --%s with --%s", initCmdFromManifestFlag, initCmdFileNoSampleFlag)
+				}
 				slog.Info("Initial Score file does not exist - and sample generation is disabled", "file", initCmdScoreFile)
 			} else {
 				workload := &scoretypes.Workload{
@@ -126,6 +151,19 @@ the new provisioners will take precedence.
 						},
 					},
 				}
+				if fromManifest != "" {
+					raw, err := os.ReadFile(fromManifest)
+					if err != nil {
+						return errors.Wrapf(err, "--%s: failed to read file", initCmdFromManifestFlag)
+					}
+					var unmapped []string
+					if workload, unmapped, err = workloadFromManifests(raw); err != nil {
+						return errors.Wrapf(err, "--%s: '%s'", initCmdFromManifestFlag, fromManifest)
+					}
+					for _, u := range unmapped {
+						slog.Warn(fmt.Sprintf("Could not map %s into the Score file, please convert it by hand", u))
+					}
+				}
 				if f, err := os.OpenFile(initCmdScoreFile, os.O_CREATE|os.O_WRONLY, 0755); err != nil {
 					return errors.Wrap(err, "failed to open empty Score file")
 				} else {
@@ -167,6 +205,8 @@ the new provisioners will take precedence.
 func init() {
 	initCmd.Flags().StringP(initCmdFileFlag, "f", "score.yaml", "The score file to initialize")
 	initCmd.Flags().Bool(initCmdFileNoSampleFlag, false, "Disable generation of the sample score file")
+	initCmd.Flags().String(initCmdFromManifestFlag, "", "An optional file of Kubernetes manifests with a Deployment or StatefulSet and its Service to derive the initial Score file from")
+	initCmd.Flags().Bool(initCmdWithExamplesFlag, false, "Write documented example provisioners of each built-in type")
 	initCmd.Flags().StringArray(initCmdProvisionerFlag, nil, "Provisioner files to install. May be specified multiple times. Supports:\n"+
 		"- HTTP        : http://host/file\n"+
 		"- HTTPS       : https://host/file\n"+
@@ -0,0 +1,231 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	scoretypes "github.com/score-spec/score-go/types"
+	"gopkg.in/yaml.v3"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// workloadFromManifests builds a best-effort Score workload from the first Deployment or StatefulSet in the raw
+// multi-document yaml and any Service that selects it. The workload covers the containers with their image, command,
+// args, env, and cpu and memory resources, and the service ports. Anything else that cannot be mapped is returned as
+// a list of human-readable notes so that the user can finish the conversion by hand.
+func workloadFromManifests(raw []byte) (*scoretypes.Workload, []string, error) {
+	var name string
+	var podTemplate *coreV1.PodTemplateSpec
+	services := make([]coreV1.Service, 0)
+	unmapped := make([]string, 0)
+
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	for i := 0; ; i++ {
+		var doc map[string]interface{}
+		if err := dec.Decode(&doc); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("document %d: failed to decode yaml: %w", i, err)
+		} else if doc == nil {
+			continue
+		}
+		kind, _ := doc["kind"].(string)
+		switch kind {
+		case "Deployment", "StatefulSet":
+			if podTemplate != nil {
+				unmapped = append(unmapped, fmt.Sprintf("document %d: only the first Deployment or StatefulSet is converted", i))
+				continue
+			}
+			var workload struct {
+				Metadata struct {
+					Name string `json:"name"`
+				} `json:"metadata"`
+				Spec struct {
+					Replicas *int32                 `json:"replicas"`
+					Template coreV1.PodTemplateSpec `json:"template"`
+				} `json:"spec"`
+			}
+			if err := decodeManifestInto(doc, &workload); err != nil {
+				return nil, nil, fmt.Errorf("document %d: %s: %w", i, kind, err)
+			}
+			name, podTemplate = workload.Metadata.Name, &workload.Spec.Template
+			if workload.Spec.Replicas != nil {
+				unmapped = append(unmapped, fmt.Sprintf("%s/%s: spec.replicas", kind, name))
+			}
+		case "Service":
+			var service coreV1.Service
+			if err := decodeManifestInto(doc, &service); err != nil {
+				return nil, nil, fmt.Errorf("document %d: Service: %w", i, err)
+			}
+			services = append(services, service)
+		default:
+			unmapped = append(unmapped, fmt.Sprintf("document %d: %s is not supported", i, kind))
+		}
+	}
+	if podTemplate == nil {
+		return nil, nil, fmt.Errorf("no Deployment or StatefulSet found")
+	}
+
+	workload := &scoretypes.Workload{
+		ApiVersion: "score.dev/v1b1",
+		Metadata:   map[string]interface{}{"name": name},
+		Containers: make(map[string]scoretypes.Container, len(podTemplate.Spec.Containers)),
+	}
+	prefix := "Pod/" + name
+	if len(podTemplate.Spec.InitContainers) > 0 {
+		unmapped = append(unmapped, prefix+": spec.initContainers")
+	}
+	if len(podTemplate.Spec.Volumes) > 0 {
+		unmapped = append(unmapped, prefix+": spec.volumes")
+	}
+	containerPorts := make([]coreV1.ContainerPort, 0)
+	for _, c := range podTemplate.Spec.Containers {
+		container := scoretypes.Container{Image: c.Image, Command: c.Command, Args: c.Args}
+		containerPrefix := fmt.Sprintf("%s: containers.%s", prefix, c.Name)
+		for _, e := range c.Env {
+			if e.ValueFrom != nil {
+				unmapped = append(unmapped, fmt.Sprintf("%s: env.%s.valueFrom", containerPrefix, e.Name))
+				continue
+			}
+			if container.Variables == nil {
+				container.Variables = make(scoretypes.ContainerVariables)
+			}
+			container.Variables[e.Name] = e.Value
+		}
+		if len(c.EnvFrom) > 0 {
+			unmapped = append(unmapped, containerPrefix+": envFrom")
+		}
+		if len(c.VolumeMounts) > 0 {
+			unmapped = append(unmapped, containerPrefix+": volumeMounts")
+		}
+		if c.LivenessProbe != nil || c.ReadinessProbe != nil || c.StartupProbe != nil {
+			unmapped = append(unmapped, containerPrefix+": probes")
+		}
+		limits, unmappedLimits := resourcesFromList(c.Resources.Limits)
+		requests, unmappedRequests := resourcesFromList(c.Resources.Requests)
+		if limits != nil || requests != nil {
+			container.Resources = &scoretypes.ContainerResources{Limits: limits, Requests: requests}
+		}
+		for _, r := range unmappedLimits {
+			unmapped = append(unmapped, fmt.Sprintf("%s: resources.limits.%s", containerPrefix, r))
+		}
+		for _, r := range unmappedRequests {
+			unmapped = append(unmapped, fmt.Sprintf("%s: resources.requests.%s", containerPrefix, r))
+		}
+		workload.Containers[c.Name] = container
+		containerPorts = append(containerPorts, c.Ports...)
+	}
+
+	ports := make(map[string]scoretypes.ServicePort)
+	selected := false
+	for _, service := range services {
+		if len(service.Spec.Selector) == 0 || !labels.SelectorFromSet(service.Spec.Selector).Matches(labels.Set(podTemplate.Labels)) {
+			unmapped = append(unmapped, fmt.Sprintf("Service/%s: does not select the pods of %s", service.Name, name))
+			continue
+		}
+		selected = true
+		for i, p := range service.Spec.Ports {
+			portName := p.Name
+			if portName == "" {
+				portName = fmt.Sprintf("port-%d", i)
+			}
+			sp := scoretypes.ServicePort{Port: int(p.Port)}
+			if p.Protocol == coreV1.ProtocolUDP {
+				sp.Protocol = internal.Ref(scoretypes.ServicePortProtocolUDP)
+			}
+			switch p.TargetPort.Type {
+			case intstr.Int:
+				if tp := p.TargetPort.IntValue(); tp != 0 && tp != sp.Port {
+					sp.TargetPort = &tp
+				}
+			case intstr.String:
+				if i := slices.IndexFunc(containerPorts, func(cp coreV1.ContainerPort) bool { return cp.Name == p.TargetPort.StrVal }); i >= 0 {
+					if tp := int(containerPorts[i].ContainerPort); tp != sp.Port {
+						sp.TargetPort = &tp
+					}
+				} else {
+					unmapped = append(unmapped, fmt.Sprintf("Service/%s: ports.%s: named target port '%s' was not found", service.Name, portName, p.TargetPort.StrVal))
+				}
+			}
+			ports[portName] = sp
+		}
+		if service.Spec.Type != "" && service.Spec.Type != coreV1.ServiceTypeClusterIP {
+			unmapped = append(unmapped, fmt.Sprintf("Service/%s: spec.type %s", service.Name, service.Spec.Type))
+		}
+	}
+	if !selected {
+		for i, cp := range containerPorts {
+			portName := cp.Name
+			if portName == "" {
+				portName = fmt.Sprintf("port-%d", i)
+			}
+			sp := scoretypes.ServicePort{Port: int(cp.ContainerPort)}
+			if cp.Protocol == coreV1.ProtocolUDP {
+				sp.Protocol = internal.Ref(scoretypes.ServicePortProtocolUDP)
+			}
+			ports[portName] = sp
+		}
+	}
+	if len(ports) > 0 {
+		workload.Service = &scoretypes.WorkloadService{Ports: ports}
+	}
+	return workload, unmapped, nil
+}
+
+// decodeManifestInto converts the generic manifest into the typed object through json.
+func decodeManifestInto(doc map[string]interface{}, out interface{}) error {
+	raw, err := json.Marshal(doc)
+	if err != nil {
+		return fmt.Errorf("failed to encode: %w", err)
+	}
+	if err := json.Unmarshal(raw, out); err != nil {
+		return fmt.Errorf("failed to decode: %w", err)
+	}
+	return nil
+}
+
+// resourcesFromList returns the cpu and memory quantities of the list, along with the names of any other resources.
+func resourcesFromList(list coreV1.ResourceList) (*scoretypes.ResourcesLimits, []string) {
+	if len(list) == 0 {
+		return nil, nil
+	}
+	out := &scoretypes.ResourcesLimits{}
+	unmapped := make([]string, 0)
+	for _, k := range slices.Sorted(maps.Keys(list)) {
+		v := list[k]
+		switch k {
+		case coreV1.ResourceCPU:
+			out.Cpu = internal.Ref(v.String())
+		case coreV1.ResourceMemory:
+			out.Memory = internal.Ref(v.String())
+		default:
+			unmapped = append(unmapped, string(k))
+		}
+	}
+	if out.Cpu == nil && out.Memory == nil {
+		return nil, unmapped
+	}
+	return out, unmapped
+}
@@ -159,3 +159,137 @@ func TestInitWithProvisioners(t *testing.T) {
 		}), fmt.Sprintf("Expected provisioner '%s' not found", expectedUri))
 	}
 }
+
+func TestInitWithExamples(t *testing.T) {
+	td := t.TempDir()
+	wd, _ := os.Getwd()
+	require.NoError(t, os.Chdir(td))
+	defer func() {
+		require.NoError(t, os.Chdir(wd))
+	}()
+
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--with-examples"})
+	assert.NoError(t, err)
+
+	provs, err := loader.LoadProvisionersFromDirectory(filepath.Join(td, ".score-k8s"), loader.DefaultSuffix)
+	assert.NoError(t, err)
+	for _, expectedUri := range []string{"template://examples/template", "cmd://bash#examples-cmd", "https://provisioner.example.com/provision"} {
+		assert.True(t, slices.ContainsFunc(provs, func(p provisioners.Provisioner) bool {
+			return p.Uri() == expectedUri
+		}), fmt.Sprintf("Expected provisioner '%s' not found", expectedUri))
+	}
+
+	// the sample score file still generates since the examples do not match any real resources
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml"})
+	assert.NoError(t, err)
+}
+
+func TestInitFromManifest(t *testing.T) {
+	td := changeToTempDir(t)
+	require.NoError(t, os.WriteFile(filepath.Join(td, "deployment.yaml"), []byte(`
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: main
+        image: nginx:1.27
+        args: ["-g", "daemon off;"]
+        ports:
+        - name: http
+          containerPort: 8080
+        env:
+        - name: MODE
+          value: production
+        - name: PASSWORD
+          valueFrom:
+            secretKeyRef:
+              name: web
+              key: password
+        resources:
+          limits:
+            memory: 256Mi
+            ephemeral-storage: 1Gi
+          requests:
+            cpu: 100m
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  selector:
+    app: web
+  ports:
+  - name: web
+    port: 80
+    targetPort: http
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web
+`), 0644))
+
+	_, stderr, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--from-manifest", "deployment.yaml"})
+	require.NoError(t, err)
+	for _, expected := range []string{
+		"document 2: ConfigMap is not supported",
+		"Deployment/web: spec.replicas",
+		"Pod/web: containers.main: env.PASSWORD.valueFrom",
+		"Pod/web: containers.main: resources.limits.ephemeral-storage",
+	} {
+		assert.Contains(t, stderr, "Could not map "+expected+" into the Score file")
+	}
+
+	raw, err := os.ReadFile(filepath.Join(td, "score.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, `apiVersion: score.dev/v1b1
+containers:
+    main:
+        args:
+            - -g
+            - daemon off;
+        image: nginx:1.27
+        resources:
+            limits:
+                memory: 256Mi
+            requests:
+                cpu: 100m
+        variables:
+            MODE: production
+metadata:
+    name: web
+service:
+    ports:
+        web:
+            port: 80
+            targetPort: 8080
+`, string(raw))
+
+	// the seeded file generates
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "score.yaml"})
+	assert.NoError(t, err)
+}
+
+func TestInitFromManifestNoWorkload(t *testing.T) {
+	td := changeToTempDir(t)
+	require.NoError(t, os.WriteFile(filepath.Join(td, "service.yaml"), []byte(`
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+`), 0644))
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--from-manifest", "service.yaml"})
+	assert.EqualError(t, err, "--from-manifest: 'service.yaml': no Deployment or StatefulSet found")
+}
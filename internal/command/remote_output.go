@@ -0,0 +1,95 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os/exec"
+	"strings"
+)
+
+// remoteOutputClients are the command line tools used to upload the output to each supported object store. The
+// tools pick up credentials from the standard chain of their cloud SDK, such as environment variables, config files,
+// or instance metadata.
+var remoteOutputClients = map[string]struct {
+	bin string
+	// args returns the arguments to run the sub command, such as "cp" or "mv", on the given arguments.
+	args func(sub string, args ...string) []string
+}{
+	"s3": {bin: "aws", args: func(sub string, args ...string) []string {
+		return append([]string{"s3", sub, "--only-show-errors"}, args...)
+	}},
+	"gs": {bin: "gcloud", args: func(sub string, args ...string) []string {
+		return append([]string{"storage", sub, "--quiet"}, args...)
+	}},
+}
+
+// isRemoteOutput returns true if the output is an object store uri like s3://bucket/key or gs://bucket/key.
+func isRemoteOutput(output string) bool {
+	scheme, _, ok := strings.Cut(output, "://")
+	_, supported := remoteOutputClients[scheme]
+	return ok && supported
+}
+
+// writeRemoteOutput uploads the content to the object store uri. Like writeFileAtomic, the content is first uploaded
+// to a temporary key next to the target and then moved into place so that readers never see a partial object.
+func writeRemoteOutput(ctx context.Context, output string, content []byte) error {
+	scheme, _, _ := strings.Cut(output, "://")
+	if u, err := url.Parse(output); err != nil || u.Host == "" || strings.Trim(u.Path, "/") == "" || strings.HasSuffix(u.Path, "/") {
+		return fmt.Errorf("--%s '%s' is not a valid object store uri, expected %s://<bucket>/<key>", generateCmdOutputFlag, output, scheme)
+	}
+	client := remoteOutputClients[scheme]
+	bin, err := exec.LookPath(client.bin)
+	if err != nil {
+		return fmt.Errorf("failed to find %s on path to upload to '%s': %w", client.bin, output, err)
+	}
+
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	tmp := output + ".tmp-" + hex.EncodeToString(suffix)
+
+	if err := runRemoteOutputClient(ctx, bin, client.args("cp", "-", tmp), bytes.NewReader(content)); err != nil {
+		return fmt.Errorf("failed to upload to '%s': %w", tmp, err)
+	}
+	if err := runRemoteOutputClient(ctx, bin, client.args("mv", tmp, output), nil); err != nil {
+		if err := runRemoteOutputClient(ctx, bin, client.args("rm", tmp), nil); err != nil {
+			slog.Warn(fmt.Sprintf("Failed to remove temporary object '%s': %v", tmp, err))
+		}
+		return fmt.Errorf("failed to move '%s' to '%s': %w", tmp, output, err)
+	}
+	return nil
+}
+
+func runRemoteOutputClient(ctx context.Context, bin string, args []string, stdin io.Reader) error {
+	slog.Debug(fmt.Sprintf("Executing '%s %v' for remote output", bin, args))
+	cmd := exec.CommandContext(ctx, bin, args...)
+	cmd.Stdin = stdin
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -15,6 +15,7 @@
 package command
 
 import (
+	"context"
 	"io"
 	"log/slog"
 
@@ -56,6 +57,6 @@ func init() {
 	rootCmd.PersistentFlags().CountP("verbose", "v", "Increase log verbosity and detail by specifying this flag one or more times")
 }
 
-func Execute() error {
-	return rootCmd.Execute()
+func Execute(ctx context.Context) error {
+	return rootCmd.ExecuteContext(ctx)
 }
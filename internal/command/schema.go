@@ -0,0 +1,83 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+const (
+	schemaAnnotationsCmdFormatFlag = "format"
+)
+
+var (
+	schemaGroup = &cobra.Command{
+		Use:   "schema",
+		Short: "Subcommands that describe the inputs recognized by score-k8s",
+	}
+	schemaAnnotations = &cobra.Command{
+		Use:   "annotations",
+		Short: "List the recognized annotations",
+		Long: `The annotations command lists the annotations recognized by score-k8s, the shape of their values, and the
+kinds of the output objects that they affect. Workload annotations are set on the Score workload metadata, resource
+annotations on the resource metadata, and output annotations are added by score-k8s to the generated manifests.
+`,
+		Example: `
+  # Print the annotations as a table
+  score-k8s schema annotations
+
+  # Print the annotations as json
+  score-k8s schema annotations --format json`,
+		Args:          cobra.ExactArgs(0),
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			switch format, _ := cmd.Flags().GetString(schemaAnnotationsCmdFormatFlag); format {
+			case "table":
+				w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+				_, _ = fmt.Fprintln(w, "ANNOTATION\tON\tVALUE\tAFFECTS")
+				for _, a := range internal.Annotations {
+					affects := strings.Join(a.Affects, ",")
+					if affects == "" {
+						affects = "-"
+					}
+					_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", a.Key, a.On, a.Value, affects)
+				}
+				return w.Flush()
+			case "json":
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(internal.Annotations)
+			default:
+				return fmt.Errorf("--%s must be one of table or json, got '%s'", schemaAnnotationsCmdFormatFlag, format)
+			}
+		},
+	}
+)
+
+func init() {
+	schemaAnnotations.Flags().StringP(schemaAnnotationsCmdFormatFlag, "f", "table", "Format of the output: table or json")
+
+	schemaGroup.AddCommand(schemaAnnotations)
+
+	rootCmd.AddCommand(schemaGroup)
+}
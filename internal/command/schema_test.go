@@ -0,0 +1,51 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+func TestSchemaAnnotationsTable(t *testing.T) {
+	stdout, stderr, err := executeAndResetCommand(context.Background(), rootCmd, []string{"schema", "annotations"})
+	require.NoError(t, err)
+	assert.Equal(t, "", stderr)
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	require.Len(t, lines, len(internal.Annotations)+1)
+	assert.Regexp(t, `^ANNOTATION\s+ON\s+VALUE\s+AFFECTS$`, lines[0])
+	assert.Regexp(t, `^k8s\.score\.dev/kind\s+workload\s+Deployment, StatefulSet, or Rollout\s+Deployment,StatefulSet,Rollout$`, lines[1])
+	assert.Regexp(t, `^k8s\.score\.dev/generator-version\s+output\s+string\s+-$`, lines[len(lines)-1])
+}
+
+func TestSchemaAnnotationsJson(t *testing.T) {
+	stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"schema", "annotations", "-f", "json"})
+	require.NoError(t, err)
+	var out []internal.AnnotationSpec
+	require.NoError(t, json.Unmarshal([]byte(stdout), &out))
+	assert.Equal(t, internal.Annotations, out)
+}
+
+func TestSchemaAnnotationsInvalidFormat(t *testing.T) {
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"schema", "annotations", "--format", "xml"})
+	assert.EqualError(t, err, "--format must be one of table or json, got 'xml'")
+}
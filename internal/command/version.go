@@ -0,0 +1,69 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/score-spec/score-k8s/internal/version"
+)
+
+const (
+	versionCmdShortFlag = "short"
+	versionCmdJsonFlag  = "json"
+)
+
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Args:  cobra.NoArgs,
+	Short: "Print the version and build information of score-k8s",
+	Example: `
+  # Print the version, git commit, and build date
+  score-k8s version
+
+  # Print only the version number
+  score-k8s version --short
+
+  # Print the build information as json
+  score-k8s version --json`,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		short, _ := cmd.Flags().GetBool(versionCmdShortFlag)
+		asJson, _ := cmd.Flags().GetBool(versionCmdJsonFlag)
+		if short && asJson {
+			return fmt.Errorf("cannot use --%s with --%s", versionCmdShortFlag, versionCmdJsonFlag)
+		}
+		info := version.GetBuildInfo()
+		if asJson {
+			raw, _ := json.MarshalIndent(info, "", "  ")
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), string(raw))
+		} else if short {
+			_, _ = fmt.Fprintln(cmd.OutOrStdout(), info.Version)
+		} else {
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "score-k8s %s\n", version.BuildVersionString())
+		}
+		return nil
+	},
+}
+
+func init() {
+	versionCmd.Flags().Bool(versionCmdShortFlag, false, "Print only the version number")
+	versionCmd.Flags().Bool(versionCmdJsonFlag, false, "Print the version, git commit, and build date as json")
+	rootCmd.AddCommand(versionCmd)
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/score-spec/score-k8s/internal/version"
+)
+
+func TestVersion(t *testing.T) {
+	stdout, stderr, err := executeAndResetCommand(context.Background(), rootCmd, []string{"version"})
+	assert.NoError(t, err)
+	pattern := regexp.MustCompile(`^score-k8s 0.0.0 \(build: \S+, sha: \S+\)\n$`)
+	assert.Truef(t, pattern.MatchString(stdout), "%s does not match: '%s'", pattern.String(), stdout)
+	assert.Equal(t, "", stderr)
+}
+
+func TestVersionShort(t *testing.T) {
+	stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"version", "--short"})
+	assert.NoError(t, err)
+	assert.Equal(t, "0.0.0\n", stdout)
+}
+
+func TestVersionJson(t *testing.T) {
+	stdout, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"version", "--json"})
+	assert.NoError(t, err)
+	var info version.BuildInfo
+	require.NoError(t, json.Unmarshal([]byte(stdout), &info))
+	assert.Equal(t, version.GetBuildInfo(), info)
+	assert.Equal(t, "0.0.0", info.Version)
+}
+
+func TestVersionShortAndJson(t *testing.T) {
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"version", "--short", "--json"})
+	assert.EqualError(t, err, "cannot use --short with --json")
+}
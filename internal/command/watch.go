@@ -0,0 +1,77 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchFiles calls onChange each time one of the files is written, created, or replaced until the context is
+// cancelled. Rapid changes are debounced into a single call. The parent directories are watched rather than the files
+// themselves since many editors save by replacing the file.
+func watchFiles(ctx context.Context, files []string, debounce time.Duration, onChange func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	watched := make([]string, 0, len(files))
+	for _, f := range files {
+		abs, err := filepath.Abs(f)
+		if err != nil {
+			return fmt.Errorf("failed to resolve path '%s': %w", f, err)
+		}
+		watched = append(watched, abs)
+		if err := watcher.Add(filepath.Dir(abs)); err != nil {
+			return fmt.Errorf("failed to watch '%s': %w", f, err)
+		}
+	}
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Write) || event.Has(fsnotify.Create) || event.Has(fsnotify.Rename) {
+				if abs, _ := filepath.Abs(event.Name); slices.Contains(watched, abs) {
+					slog.Debug(fmt.Sprintf("Detected change to '%s'", event.Name))
+					timer.Reset(debounce)
+				}
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Warn(fmt.Sprintf("File watcher error: %v", err))
+		case <-timer.C:
+			onChange()
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package command
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchFiles(t *testing.T) {
+	td := t.TempDir()
+	target := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(target, []byte("a"), 0644))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := make(chan struct{}, 10)
+	done := make(chan error)
+	go func() {
+		done <- watchFiles(ctx, []string{target}, 50*time.Millisecond, func() {
+			changes <- struct{}{}
+		})
+	}()
+
+	// give the watcher time to start, then write a burst of changes and an unrelated file
+	time.Sleep(100 * time.Millisecond)
+	for i := 0; i < 5; i++ {
+		require.NoError(t, os.WriteFile(target, []byte{byte('b' + i)}, 0644))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(td, "other.yaml"), []byte("x"), 0644))
+
+	select {
+	case <-changes:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for change")
+	}
+	time.Sleep(200 * time.Millisecond)
+	assert.Len(t, changes, 0, "expected rapid changes to be debounced")
+
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestGenerateWatchWithoutScoreFiles(t *testing.T) {
+	_ = changeToTempDir(t)
+	_, _, err := executeAndResetCommand(context.Background(), rootCmd, []string{"init", "--no-sample"})
+	require.NoError(t, err)
+	_, _, err = executeAndResetCommand(context.Background(), rootCmd, []string{"generate", "--watch"})
+	assert.EqualError(t, err, "--watch requires at least one score file")
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// ciliumNetworkPolicySpec is the decoded cilium-network-policy annotation. The rules are passed through to the
+// CiliumNetworkPolicy as they are, so that any Cilium rule including L7 rules can be used.
+type ciliumNetworkPolicySpec struct {
+	Ingress []map[string]interface{} `yaml:"ingress"`
+	Egress  []map[string]interface{} `yaml:"egress"`
+}
+
+// ciliumIngressRuleFields and ciliumEgressRuleFields are the fields of the cilium.io/v2 ingress and egress rules. These
+// are checked so that a typo does not silently produce a rule that allows or denies more than intended.
+var (
+	ciliumIngressRuleFields = []string{
+		"fromEndpoints", "fromRequires", "fromCIDR", "fromCIDRSet", "fromEntities", "fromGroups", "fromNodes",
+		"toPorts", "icmps", "authentication",
+	}
+	ciliumEgressRuleFields = []string{
+		"toEndpoints", "toRequires", "toCIDR", "toCIDRSet", "toEntities", "toServices", "toFQDNs", "toGroups", "toNodes",
+		"toPorts", "icmps", "authentication",
+	}
+)
+
+func parseCiliumNetworkPolicyAnnotation(metadata map[string]interface{}) (*ciliumNetworkPolicySpec, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.CiliumNetworkPolicyAnnotation)
+	if !ok {
+		return nil, nil
+	}
+	if _, ok := internal.FindAnnotation(metadata, internal.NetworkPolicyAnnotation); ok {
+		return nil, errors.Errorf("%s: cannot be used together with %s", internal.CiliumNetworkPolicyAnnotation, internal.NetworkPolicyAnnotation)
+	}
+	var out ciliumNetworkPolicySpec
+	dec := yaml.NewDecoder(bytes.NewReader([]byte(raw)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&out); err != nil {
+		return nil, errors.Wrapf(err, "%s: failed to decode", internal.CiliumNetworkPolicyAnnotation)
+	}
+	if out.Ingress == nil && out.Egress == nil {
+		return nil, errors.Errorf("%s: at least one of ingress or egress must be set", internal.CiliumNetworkPolicyAnnotation)
+	}
+	for _, section := range []struct {
+		name   string
+		rules  []map[string]interface{}
+		fields []string
+	}{{"ingress", out.Ingress, ciliumIngressRuleFields}, {"egress", out.Egress, ciliumEgressRuleFields}} {
+		for i, rule := range section.rules {
+			if len(rule) == 0 {
+				return nil, errors.Errorf("%s: %s.%d: rule is empty", internal.CiliumNetworkPolicyAnnotation, section.name, i)
+			}
+			for k := range rule {
+				if !slices.Contains(section.fields, k) {
+					return nil, errors.Errorf("%s: %s.%d: unknown field '%s', expected one of %s", internal.CiliumNetworkPolicyAnnotation, section.name, i, k, strings.Join(section.fields, ", "))
+				}
+			}
+		}
+	}
+	return &out, nil
+}
+
+// buildCiliumNetworkPolicy returns a CiliumNetworkPolicy with the ingress and egress rules from the annotation that
+// applies to the pods matching the selector. This is returned as an unstructured object since the Cilium types are
+// not in our scheme.
+func buildCiliumNetworkPolicy(name string, spec *ciliumNetworkPolicySpec, labels, annotations, selector map[string]string) (*unstructured.Unstructured, error) {
+	policySpec := map[string]interface{}{
+		"endpointSelector": map[string]interface{}{
+			"matchLabels": toInterfaceMap(selector),
+		},
+	}
+	// round trip the rules through json so that they only contain the value types supported by unstructured objects
+	for key, rules := range map[string][]map[string]interface{}{"ingress": spec.Ingress, "egress": spec.Egress} {
+		if rules == nil {
+			continue
+		}
+		raw, err := json.Marshal(rules)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: %s: failed to encode", internal.CiliumNetworkPolicyAnnotation, key)
+		}
+		var out []interface{}
+		if err := utiljson.Unmarshal(raw, &out); err != nil {
+			return nil, errors.Wrapf(err, "%s: %s: failed to decode", internal.CiliumNetworkPolicyAnnotation, key)
+		}
+		policySpec[key] = out
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cilium.io/v2",
+		"kind":       "CiliumNetworkPolicy",
+		"metadata": map[string]interface{}{
+			"name":        name,
+			"labels":      toInterfaceMap(labels),
+			"annotations": toInterfaceMap(annotations),
+		},
+		"spec": policySpec,
+	}}, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseCiliumNetworkPolicyAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		annotations map[string]interface{}
+		expected    *ciliumNetworkPolicySpec
+		err         string
+	}{
+		{name: "none", annotations: map[string]interface{}{}},
+		{name: "nominal", annotations: map[string]interface{}{
+			"k8s.score.dev/cilium-network-policy": "{ingress: [{fromEntities: [cluster], toPorts: [{ports: [{port: '80'}]}]}], egress: [{toFQDNs: [{matchName: api.example.com}]}]}",
+		}, expected: &ciliumNetworkPolicySpec{
+			Ingress: []map[string]interface{}{{
+				"fromEntities": []interface{}{"cluster"},
+				"toPorts":      []interface{}{map[string]interface{}{"ports": []interface{}{map[string]interface{}{"port": "80"}}}},
+			}},
+			Egress: []map[string]interface{}{{"toFQDNs": []interface{}{map[string]interface{}{"matchName": "api.example.com"}}}},
+		}},
+		{name: "with network policy", annotations: map[string]interface{}{
+			"k8s.score.dev/cilium-network-policy": "{egress: []}",
+			"k8s.score.dev/network-policy":        "{egress: []}",
+		}, err: "k8s.score.dev/cilium-network-policy: cannot be used together with k8s.score.dev/network-policy"},
+		{name: "unknown section", annotations: map[string]interface{}{
+			"k8s.score.dev/cilium-network-policy": "{ingressDeny: []}",
+		}, err: "k8s.score.dev/cilium-network-policy: failed to decode: yaml: unmarshal errors:\n  line 1: field ingressDeny not found in type convert.ciliumNetworkPolicySpec"},
+		{name: "no rules", annotations: map[string]interface{}{
+			"k8s.score.dev/cilium-network-policy": "{}",
+		}, err: "k8s.score.dev/cilium-network-policy: at least one of ingress or egress must be set"},
+		{name: "empty rule", annotations: map[string]interface{}{
+			"k8s.score.dev/cilium-network-policy": "{egress: [{}]}",
+		}, err: "k8s.score.dev/cilium-network-policy: egress.0: rule is empty"},
+		{name: "wrong direction", annotations: map[string]interface{}{
+			"k8s.score.dev/cilium-network-policy": "{ingress: [{toEndpoints: [{}]}]}",
+		}, err: "k8s.score.dev/cilium-network-policy: ingress.0: unknown field 'toEndpoints', expected one of fromEndpoints, fromRequires, fromCIDR, fromCIDRSet, fromEntities, fromGroups, fromNodes, toPorts, icmps, authentication"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := parseCiliumNetworkPolicyAnnotation(map[string]interface{}{"annotations": tc.annotations})
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, out)
+			}
+		})
+	}
+}
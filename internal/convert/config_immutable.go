@@ -0,0 +1,60 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	coreV1 "k8s.io/api/core/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// parseImmutableConfigAnnotation reads the immutable-config annotation which defaults to false.
+func parseImmutableConfigAnnotation(metadata map[string]interface{}) (bool, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.ImmutableConfigAnnotation)
+	if !ok {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(strings.TrimSpace(raw))
+	if err != nil {
+		return false, errors.Errorf("%s: '%s' is not a valid boolean", internal.ImmutableConfigAnnotation, raw)
+	}
+	return v, nil
+}
+
+// makeConfigMapImmutable marks the config map as immutable and appends a hash of its content to the name, updating the
+// volume that refers to it. Immutable config maps cannot be updated in place, so any change to the content results in
+// a new config map and a rollout of the pods that mount it.
+func makeConfigMapImmutable(cfg *coreV1.ConfigMap, vol *coreV1.Volume) {
+	h := fnv.New64a()
+	for _, k := range slices.Sorted(maps.Keys(cfg.Data)) {
+		_, _ = fmt.Fprintf(h, "%s=%s\n", k, cfg.Data[k])
+	}
+	for _, k := range slices.Sorted(maps.Keys(cfg.BinaryData)) {
+		_, _ = fmt.Fprintf(h, "%s=%s\n", k, cfg.BinaryData[k])
+	}
+	cfg.Name = fmt.Sprintf("%s-%x", cfg.Name, h.Sum(nil))[:len(cfg.Name)+1+10]
+	cfg.Immutable = internal.Ref(true)
+	if vol != nil && vol.ConfigMap != nil {
+		vol.ConfigMap.Name = cfg.Name
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+func Test_parseImmutableConfigAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		value    *string
+		expected bool
+		err      string
+	}{
+		{name: "none"},
+		{name: "true", value: internal.Ref("true"), expected: true},
+		{name: "false", value: internal.Ref("false")},
+		{name: "invalid", value: internal.Ref("yes please"), err: "k8s.score.dev/immutable-config: 'yes please' is not a valid boolean"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			metadata := map[string]interface{}{}
+			if tc.value != nil {
+				metadata["annotations"] = map[string]interface{}{"k8s.score.dev/immutable-config": *tc.value}
+			}
+			out, err := parseImmutableConfigAnnotation(metadata)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, out)
+			}
+		})
+	}
+}
+
+func Test_makeConfigMapImmutable(t *testing.T) {
+	build := func(content string) (*coreV1.ConfigMap, *coreV1.Volume) {
+		cfg := &coreV1.ConfigMap{BinaryData: map[string][]byte{"file": []byte(content)}}
+		cfg.Name = "my-workload-c1-file-0"
+		vol := &coreV1.Volume{Name: "file-0", VolumeSource: coreV1.VolumeSource{ConfigMap: &coreV1.ConfigMapVolumeSource{
+			LocalObjectReference: coreV1.LocalObjectReference{Name: cfg.Name},
+		}}}
+		makeConfigMapImmutable(cfg, vol)
+		return cfg, vol
+	}
+
+	cfg, vol := build("hello")
+	assert.Equal(t, internal.Ref(true), cfg.Immutable)
+	assert.Regexp(t, `^my-workload-c1-file-0-[0-9a-f]{10}$`, cfg.Name)
+	assert.Equal(t, cfg.Name, vol.ConfigMap.Name)
+
+	same, _ := build("hello")
+	assert.Equal(t, cfg.Name, same.Name)
+	other, _ := build("world")
+	assert.NotEqual(t, cfg.Name, other.Name)
+}
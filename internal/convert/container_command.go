@@ -0,0 +1,86 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/score-spec/score-go/framework"
+	coreV1 "k8s.io/api/core/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+var shellSafeWord = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes the word so that the shell passes it through as a single literal argument.
+func shellQuote(word string) string {
+	if shellSafeWord.MatchString(word) {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'"'"'`) + "'"
+}
+
+// wrapCommandInShell converts the command and args into a single /bin/sh -c invocation. The command is joined as-is
+// so that it may use shell features like pipes and variable expansion, while each arg is quoted so that it is passed
+// through literally.
+func wrapCommandInShell(command []string, args []string) ([]string, error) {
+	if len(command) == 0 {
+		return nil, errors.New("a command is required to wrap in a shell")
+	}
+	parts := make([]string, 0, len(command)+len(args))
+	parts = append(parts, command...)
+	for _, arg := range args {
+		parts = append(parts, shellQuote(arg))
+	}
+	return []string{"/bin/sh", "-c", strings.Join(parts, " ")}, nil
+}
+
+// convertContainerArgs resolves the placeholders in the command or args of a container. Placeholders are always
+// resolved to strings, so a numeric output such as a port becomes its decimal form within the arg. Secret references
+// cannot be written into the args directly, so each one is added as an env var and referred to with the $(VAR) syntax
+// which Kubernetes expands in the command and args.
+func convertContainerArgs(values []string, substitutionFunction func(string) (string, error)) ([]string, []coreV1.EnvVar, error) {
+	if values == nil {
+		return nil, nil, nil
+	}
+	out := make([]string, 0, len(values))
+	envVars := make([]coreV1.EnvVar, 0)
+	for i, value := range values {
+		resolvedValue, err := framework.SubstituteString(value, substitutionFunction)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "%d: failed to substitute placeholders", i)
+		}
+		parts, refs, err := internal.DecodeSecretReferences(resolvedValue)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "%d: failed to resolve secret references", i)
+		}
+		sb := new(strings.Builder)
+		for j, part := range parts {
+			if j > 0 {
+				ref := refs[j-1]
+				name := generateRefEnvVarName(ref)
+				sb.WriteString(fmt.Sprintf("$(%s)", name))
+				envVars = append(envVars, coreV1.EnvVar{Name: name, ValueFrom: ref.EnvVarSource()})
+			}
+			sb.WriteString(part)
+		}
+		out = append(out, sb.String())
+	}
+	return out, envVars, nil
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+func Test_wrapCommandInShell(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		command  []string
+		args     []string
+		expected []string
+		err      string
+	}{
+		{name: "no command", args: []string{"a"}, err: "a command is required to wrap in a shell"},
+		{name: "command only", command: []string{"echo $HOME | wc -c"}, expected: []string{"/bin/sh", "-c", "echo $HOME | wc -c"}},
+		{
+			name:     "quoted args",
+			command:  []string{"exec", "my-app"},
+			args:     []string{"--flag=value", "hello world", "it's", ""},
+			expected: []string{"/bin/sh", "-c", `exec my-app --flag=value 'hello world' 'it'"'"'s' ''`},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := wrapCommandInShell(tc.command, tc.args)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, out)
+			}
+		})
+	}
+}
+
+func Test_convertContainerArgs(t *testing.T) {
+	sf := func(ref string) (string, error) {
+		switch ref {
+		case "resources.db.port":
+			return "5432", nil
+		case "resources.db.password":
+			return internal.EncodeSecretReference("db", "password"), nil
+		}
+		return "", fmt.Errorf("invalid ref '%s'", ref)
+	}
+	refName := generateSecretRefEnvVarName("db", "password")
+	for _, tc := range []struct {
+		name        string
+		values      []string
+		expected    []string
+		expectedEnv []coreV1.EnvVar
+		err         string
+	}{
+		{name: "nil"},
+		{name: "literal", values: []string{"a", "$${b}"}, expected: []string{"a", "${b}"}, expectedEnv: []coreV1.EnvVar{}},
+		{name: "numeric", values: []string{"--port=${resources.db.port}", "${resources.db.port}"}, expected: []string{"--port=5432", "5432"}, expectedEnv: []coreV1.EnvVar{}},
+		{
+			name: "secret", values: []string{"--password=${resources.db.password}"},
+			expected: []string{"--password=$(" + refName + ")"},
+			expectedEnv: []coreV1.EnvVar{{Name: refName, ValueFrom: &coreV1.EnvVarSource{SecretKeyRef: &coreV1.SecretKeySelector{
+				LocalObjectReference: coreV1.LocalObjectReference{Name: "db"}, Key: "password",
+			}}}},
+		},
+		{name: "unknown", values: []string{"a", "${resources.other}"}, err: "1: failed to substitute placeholders: invalid ref 'resources.other'"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, env, err := convertContainerArgs(tc.values, sf)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, out)
+				assert.Equal(t, tc.expectedEnv, env)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// envFromExisting loads all the keys of exactly one of an existing Secret or ConfigMap into the container environment.
+type envFromExisting struct {
+	// Secret is the name of a Secret that is not managed by score-k8s.
+	Secret string `yaml:"secret,omitempty"`
+	// ConfigMap is the name of a ConfigMap that is not managed by score-k8s.
+	ConfigMap string `yaml:"configMap,omitempty"`
+	// Prefix is optionally prepended to each key to form the environment variable name.
+	Prefix string `yaml:"prefix,omitempty"`
+	// Containers optionally restricts the entry to the named containers, it defaults to all containers.
+	Containers []string `yaml:"containers,omitempty"`
+}
+
+// parseEnvFromExistingAnnotation reads the env-from-existing annotation which is a yaml list of existing Secrets and
+// ConfigMaps, such as those managed by an external secrets operator, and returns the envFrom sources of each container
+// in the order they are listed.
+func parseEnvFromExistingAnnotation(metadata map[string]interface{}, containerNames []string) (map[string][]coreV1.EnvFromSource, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.EnvFromExistingAnnotation)
+	if !ok {
+		return nil, nil
+	}
+	var entries []envFromExisting
+	dec := yaml.NewDecoder(bytes.NewReader([]byte(raw)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&entries); err != nil {
+		return nil, errors.Wrapf(err, "%s: failed to decode list of secrets and config maps", internal.EnvFromExistingAnnotation)
+	}
+	out := make(map[string][]coreV1.EnvFromSource)
+	for i, entry := range entries {
+		if (entry.Secret == "") == (entry.ConfigMap == "") {
+			return nil, errors.Errorf("%s: %d: exactly one of secret or configMap must be set", internal.EnvFromExistingAnnotation, i)
+		}
+		source := coreV1.EnvFromSource{Prefix: entry.Prefix}
+		name := entry.Secret
+		if entry.Secret != "" {
+			source.SecretRef = &coreV1.SecretEnvSource{LocalObjectReference: coreV1.LocalObjectReference{Name: entry.Secret}}
+		} else {
+			name = entry.ConfigMap
+			source.ConfigMapRef = &coreV1.ConfigMapEnvSource{LocalObjectReference: coreV1.LocalObjectReference{Name: entry.ConfigMap}}
+		}
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			return nil, errors.Errorf("%s: %d: '%s' is not a valid name: %s", internal.EnvFromExistingAnnotation, i, name, strings.Join(errs, ", "))
+		}
+		if entry.Prefix != "" {
+			if errs := validation.IsEnvVarName(entry.Prefix); len(errs) > 0 {
+				return nil, errors.Errorf("%s: %d: '%s' is not a valid prefix: %s", internal.EnvFromExistingAnnotation, i, entry.Prefix, strings.Join(errs, ", "))
+			}
+		}
+		targets := containerNames
+		if len(entry.Containers) > 0 {
+			targets = entry.Containers
+		}
+		for _, containerName := range targets {
+			if !slices.Contains(containerNames, containerName) {
+				return nil, errors.Errorf("%s: %d: container '%s' does not exist", internal.EnvFromExistingAnnotation, i, containerName)
+			}
+			out[containerName] = append(out[containerName], source)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+func Test_parseEnvFromExistingAnnotation(t *testing.T) {
+	secretRef := func(name, prefix string) coreV1.EnvFromSource {
+		return coreV1.EnvFromSource{Prefix: prefix, SecretRef: &coreV1.SecretEnvSource{LocalObjectReference: coreV1.LocalObjectReference{Name: name}}}
+	}
+	configMapRef := func(name, prefix string) coreV1.EnvFromSource {
+		return coreV1.EnvFromSource{Prefix: prefix, ConfigMapRef: &coreV1.ConfigMapEnvSource{LocalObjectReference: coreV1.LocalObjectReference{Name: name}}}
+	}
+	for _, tc := range []struct {
+		name     string
+		value    *string
+		expected map[string][]coreV1.EnvFromSource
+		err      string
+	}{
+		{name: "none"},
+		{name: "nominal", value: internal.Ref("[{secret: db-credentials, prefix: DB_}, {configMap: app.config, containers: [main]}]"), expected: map[string][]coreV1.EnvFromSource{
+			"debug": {secretRef("db-credentials", "DB_")},
+			"main":  {secretRef("db-credentials", "DB_"), configMapRef("app.config", "")},
+		}},
+		{name: "invalid yaml", value: internal.Ref("{a: b}"), err: "k8s.score.dev/env-from-existing: failed to decode list of secrets and config maps: yaml: unmarshal errors:\n  line 1: cannot unmarshal !!map into []convert.envFromExisting"},
+		{name: "unknown field", value: internal.Ref("[{secret: a, optional: true}]"), err: "k8s.score.dev/env-from-existing: failed to decode list of secrets and config maps: yaml: unmarshal errors:\n  line 1: field optional not found in type convert.envFromExisting"},
+		{name: "neither", value: internal.Ref("[{prefix: A_}]"), err: "k8s.score.dev/env-from-existing: 0: exactly one of secret or configMap must be set"},
+		{name: "both", value: internal.Ref("[{secret: a, configMap: b}]"), err: "k8s.score.dev/env-from-existing: 0: exactly one of secret or configMap must be set"},
+		{name: "invalid name", value: internal.Ref("[{secret: Not_Valid}]"), err: "k8s.score.dev/env-from-existing: 0: 'Not_Valid' is not a valid name: a lowercase RFC 1123 subdomain must consist of lower case alphanumeric characters, '-' or '.', and must start and end with an alphanumeric character (e.g. 'example.com', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?(\\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*')"},
+		{name: "invalid prefix", value: internal.Ref("[{configMap: a, prefix: '1='}]"), err: "k8s.score.dev/env-from-existing: 0: '1=' is not a valid prefix: a valid environment variable name must consist of alphabetic characters, digits, '_', '-', or '.', and must not start with a digit (e.g. 'my.env-name',  or 'MY_ENV.NAME',  or 'MyEnvName1', regex used for validation is '[-._a-zA-Z][-._a-zA-Z0-9]*')"},
+		{name: "unknown container", value: internal.Ref("[{configMap: a, containers: [other]}]"), err: "k8s.score.dev/env-from-existing: 0: container 'other' does not exist"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			metadata := map[string]interface{}{}
+			if tc.value != nil {
+				metadata["annotations"] = map[string]interface{}{"k8s.score.dev/env-from-existing": *tc.value}
+			}
+			out, err := parseEnvFromExistingAnnotation(metadata, []string{"debug", "main"})
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	coreV1 "k8s.io/api/core/v1"
+	machineryMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+const (
+	FileStorageSecret    = "Secret"
+	FileStorageConfigMap = "ConfigMap"
+)
+
+// parseFileStorageAnnotation reads the file-storage annotation which is a yaml map of file target path to the kind of
+// object the file content is stored in, either Secret or ConfigMap. Each path must be the target of a file in one of
+// the given containers.
+func parseFileStorageAnnotation(metadata map[string]interface{}, targets []string) (map[string]string, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.FileStorageAnnotation)
+	if !ok {
+		return nil, nil
+	}
+	var out map[string]string
+	if err := yaml.Unmarshal([]byte(raw), &out); err != nil {
+		return nil, errors.Wrapf(err, "%s: failed to decode map of file storage", internal.FileStorageAnnotation)
+	}
+	for _, target := range slices.Sorted(maps.Keys(out)) {
+		if v := out[target]; v != FileStorageSecret && v != FileStorageConfigMap {
+			return nil, errors.Errorf("%s: %s: unsupported storage '%s', expected '%s' or '%s'", internal.FileStorageAnnotation, target, v, FileStorageSecret, FileStorageConfigMap)
+		} else if !slices.Contains(targets, target) {
+			return nil, errors.Errorf("%s: %s: no container file has this target", internal.FileStorageAnnotation, target)
+		}
+	}
+	return out, nil
+}
+
+// convertFileConfigMapToSecret moves the content of the config map for a container file into a Secret with the same
+// name and updates the volume to refer to it. Plaintext content goes into the stringData of the Secret while binary
+// content goes into the data so that nothing is base64 encoded more than once.
+func convertFileConfigMapToSecret(cfg *coreV1.ConfigMap, vol *coreV1.Volume) *coreV1.Secret {
+	secret := &coreV1.Secret{
+		TypeMeta:   machineryMeta.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+		ObjectMeta: cfg.ObjectMeta,
+		Type:       coreV1.SecretTypeOpaque,
+		Immutable:  cfg.Immutable,
+		StringData: maps.Clone(cfg.Data),
+		Data:       maps.Clone(cfg.BinaryData),
+	}
+	if vol != nil && vol.ConfigMap != nil {
+		vol.Secret = &coreV1.SecretVolumeSource{
+			SecretName:  secret.Name,
+			Items:       vol.ConfigMap.Items,
+			DefaultMode: vol.ConfigMap.DefaultMode,
+		}
+		vol.ConfigMap = nil
+	}
+	return secret
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coreV1 "k8s.io/api/core/v1"
+	machineryMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+func Test_parseFileStorageAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		value    *string
+		expected map[string]string
+		err      string
+	}{
+		{name: "none"},
+		{name: "nominal", value: internal.Ref("{/etc/app/config.yaml: Secret, /etc/app/ca.crt: ConfigMap}"), expected: map[string]string{
+			"/etc/app/config.yaml": "Secret", "/etc/app/ca.crt": "ConfigMap",
+		}},
+		{name: "invalid yaml", value: internal.Ref("[a"), err: "k8s.score.dev/file-storage: failed to decode map of file storage: yaml: line 1: did not find expected ',' or ']'"},
+		{name: "unknown storage", value: internal.Ref("{/etc/app/config.yaml: Vault}"), err: "k8s.score.dev/file-storage: /etc/app/config.yaml: unsupported storage 'Vault', expected 'Secret' or 'ConfigMap'"},
+		{name: "unknown target", value: internal.Ref("{/etc/other: Secret}"), err: "k8s.score.dev/file-storage: /etc/other: no container file has this target"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			metadata := map[string]interface{}{}
+			if tc.value != nil {
+				metadata["annotations"] = map[string]interface{}{"k8s.score.dev/file-storage": *tc.value}
+			}
+			out, err := parseFileStorageAnnotation(metadata, []string{"/etc/app/config.yaml", "/etc/app/ca.crt"})
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, out)
+			}
+		})
+	}
+}
+
+func Test_convertFileConfigMapToSecret(t *testing.T) {
+	cfg := &coreV1.ConfigMap{
+		ObjectMeta: machineryMeta.ObjectMeta{Name: "example-c1-files"},
+		Data:       map[string]string{"file-0": "password: c2VjcmV0\n"},
+		BinaryData: map[string][]byte{"file-1": {0x00, 0xff, 0x10}},
+	}
+	vol := &coreV1.Volume{Name: "file-0", VolumeSource: coreV1.VolumeSource{ConfigMap: &coreV1.ConfigMapVolumeSource{
+		LocalObjectReference: coreV1.LocalObjectReference{Name: "example-c1-files"},
+	}}}
+	secret := convertFileConfigMapToSecret(cfg, vol)
+	assert.Equal(t, "example-c1-files", vol.Secret.SecretName)
+	assert.Nil(t, vol.ConfigMap)
+
+	// round trip through the serializer to make sure the plaintext is not base64 encoded and the binary content is
+	// only encoded once
+	out := new(bytes.Buffer)
+	require.NoError(t, internal.YamlSerializerInfo.Serializer.Encode(secret, out))
+	assert.Equal(t, `apiVersion: v1
+data:
+  file-1: AP8Q
+kind: Secret
+metadata:
+  creationTimestamp: null
+  name: example-c1-files
+stringData:
+  file-0: |
+    password: c2VjcmV0
+type: Opaque
+`, out.String())
+	decoded, _, err := internal.YamlSerializerInfo.StrictSerializer.Decode(out.Bytes(), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"file-0": "password: c2VjcmV0\n"}, decoded.(*coreV1.Secret).StringData)
+	assert.Equal(t, map[string][]byte{"file-1": {0x00, 0xff, 0x10}}, decoded.(*coreV1.Secret).Data)
+}
@@ -35,7 +35,7 @@ func convertContainerFile(
 ) (coreV1.VolumeMount, *coreV1.ConfigMap, *coreV1.Volume, error) {
 	mount := coreV1.VolumeMount{
 		Name:      fmt.Sprintf("file-%d", index),
-		ReadOnly:  false,
+		ReadOnly:  true,
 		MountPath: filepath.Dir(fileElem.Target),
 	}
 
@@ -104,6 +104,22 @@ func convertContainerFile(
 			nil
 	}
 
+	// If the file content is made up of a reference to just a ConfigMap key, we can mount that key directly
+	if len(refs) == 1 && parts[0] == "" && parts[1] == "" && refs[0].ConfigMap {
+		return mount, nil, &coreV1.Volume{
+			Name: mount.Name,
+			VolumeSource: coreV1.VolumeSource{
+				ConfigMap: &coreV1.ConfigMapVolumeSource{
+					LocalObjectReference: coreV1.LocalObjectReference{Name: refs[0].Name},
+					Items: []coreV1.KeyToPath{{
+						Key:  refs[0].Key,
+						Path: filepath.Base(fileElem.Target),
+						Mode: mountMode}},
+				},
+			},
+		}, nil
+	}
+
 	// If the file content is made up of a reference to just a secret, we can allow that
 	if len(refs) == 1 && parts[0] == "" && parts[1] == "" {
 		return mount, nil, &coreV1.Volume{
@@ -15,10 +15,13 @@
 package convert
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	scoretypes "github.com/score-spec/score-go/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	coreV1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -45,6 +48,38 @@ func Test_convertContainerFile_unreadable_source_relative(t *testing.T) {
 	assert.EqualError(t, err, "source: failed to read file 'my/file.that.does.not.exist': open my/file.that.does.not.exist: no such file or directory")
 }
 
+func Test_convertContainerFile_source_relative(t *testing.T) {
+	td := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(td, "config"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(td, "config", "app.conf"), []byte("host=${some.ref}"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(td, "config", "password"), []byte("${some.secret}"), 0644))
+	substitute := func(s string) (string, error) {
+		if s == "some.secret" {
+			return internal.EncodeSecretReference("default", "key"), nil
+		}
+		return "db.local", nil
+	}
+
+	_, cfg, _, err := convertContainerFile(0, scoretypes.ContainerFilesElem{
+		Source: internal.Ref("config/app.conf"),
+		Target: "/etc/app.conf",
+	}, "my-workload-c1-", internal.Ref(filepath.Join(td, "score.yaml")), substitute)
+	require.NoError(t, err)
+	if assert.NotNil(t, cfg) {
+		assert.Equal(t, map[string][]byte{"file": []byte("host=db.local")}, cfg.BinaryData)
+	}
+
+	_, cfg, vol, err := convertContainerFile(1, scoretypes.ContainerFilesElem{
+		Source: internal.Ref("config/password"),
+		Target: "/etc/password",
+	}, "my-workload-c1-", internal.Ref(filepath.Join(td, "score.yaml")), substitute)
+	require.NoError(t, err)
+	assert.Nil(t, cfg)
+	if assert.NotNil(t, vol) && assert.NotNil(t, vol.Secret) {
+		assert.Equal(t, "default", vol.Secret.SecretName)
+	}
+}
+
 func Test_convertContainerFile_content_no_expand(t *testing.T) {
 	mount, cfg, vol, err := convertContainerFile(0, scoretypes.ContainerFilesElem{
 		Content:  internal.Ref("raw content with ${some.ref}"),
@@ -53,6 +88,7 @@ func Test_convertContainerFile_content_no_expand(t *testing.T) {
 	}, "my-workload-c1-", nil, nil)
 	assert.Equal(t, coreV1.VolumeMount{
 		Name:      "file-0",
+		ReadOnly:  true,
 		MountPath: "/some",
 	}, mount)
 	if assert.NotNil(t, cfg) {
@@ -99,6 +135,7 @@ func Test_convertContainerFile_content_expand_with_secret(t *testing.T) {
 	})
 	assert.Equal(t, coreV1.VolumeMount{
 		Name:      "file-0",
+		ReadOnly:  true,
 		MountPath: "/some",
 	}, mount)
 	assert.Nil(t, cfg)
@@ -117,3 +154,27 @@ func Test_convertContainerFile_content_expand_with_secret(t *testing.T) {
 	}
 	assert.NoError(t, err)
 }
+
+func Test_convertContainerFile_content_expand_with_configmap(t *testing.T) {
+	_, cfg, vol, err := convertContainerFile(0, scoretypes.ContainerFilesElem{
+		Content: internal.Ref("${some.ref}"),
+		Target:  "/some/mount",
+	}, "my-workload-c1-", nil, func(s string) (string, error) {
+		return internal.EncodeConfigMapReference("app-config", "key"), nil
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, cfg)
+	if assert.NotNil(t, vol) {
+		assert.Equal(t, coreV1.Volume{
+			Name: "file-0",
+			VolumeSource: coreV1.VolumeSource{
+				ConfigMap: &coreV1.ConfigMapVolumeSource{
+					LocalObjectReference: coreV1.LocalObjectReference{Name: "app-config"},
+					Items: []coreV1.KeyToPath{
+						{Key: "key", Path: "mount"},
+					},
+				},
+			},
+		}, *vol)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// parseInteractiveAnnotation reads the interactive annotation and returns the names of the containers that should have
+// stdin and tty enabled. The annotation is either a boolean for all containers, or a comma-separated list of container
+// names.
+func parseInteractiveAnnotation(metadata map[string]interface{}, containerNames []string) ([]string, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.InteractiveAnnotation)
+	if !ok {
+		return nil, nil
+	}
+	if v, err := strconv.ParseBool(strings.TrimSpace(raw)); err == nil {
+		if v {
+			return slices.Clone(containerNames), nil
+		}
+		return nil, nil
+	}
+	out := make([]string, 0)
+	for _, part := range strings.Split(raw, ",") {
+		containerName := strings.TrimSpace(part)
+		if containerName == "" {
+			continue
+		} else if !slices.Contains(containerNames, containerName) {
+			return nil, errors.Errorf("%s: container '%s' does not exist", internal.InteractiveAnnotation, containerName)
+		} else if !slices.Contains(out, containerName) {
+			out = append(out, containerName)
+		}
+	}
+	if len(out) == 0 {
+		return nil, errors.Errorf("%s: must be a boolean or a list of container names", internal.InteractiveAnnotation)
+	}
+	return out, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	coreV1 "k8s.io/api/core/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// containerLifecycle are the exec lifecycle hooks of a single container.
+type containerLifecycle struct {
+	PostStart execCommand `yaml:"postStart,omitempty"`
+	PreStop   execCommand `yaml:"preStop,omitempty"`
+}
+
+// parseLifecycleAnnotation reads the lifecycle annotation which is a yaml map of container name to its postStart and
+// preStop commands.
+func parseLifecycleAnnotation(metadata map[string]interface{}, containerNames []string) (map[string]containerLifecycle, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.LifecycleAnnotation)
+	if !ok {
+		return nil, nil
+	}
+	var out map[string]containerLifecycle
+	dec := yaml.NewDecoder(bytes.NewReader([]byte(raw)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&out); err != nil {
+		return nil, errors.Wrapf(err, "%s: failed to decode", internal.LifecycleAnnotation)
+	}
+	for _, containerName := range slices.Sorted(maps.Keys(out)) {
+		if !slices.Contains(containerNames, containerName) {
+			return nil, errors.Errorf("%s: container '%s' does not exist", internal.LifecycleAnnotation, containerName)
+		} else if out[containerName].PostStart == nil && out[containerName].PreStop == nil {
+			return nil, errors.Errorf("%s: %s: at least one of postStart or preStop must be set", internal.LifecycleAnnotation, containerName)
+		}
+	}
+	return out, nil
+}
+
+// buildLifecycle returns the container lifecycle with an exec handler for each of the hooks.
+func buildLifecycle(hooks containerLifecycle) *coreV1.Lifecycle {
+	out := new(coreV1.Lifecycle)
+	if hooks.PostStart != nil {
+		out.PostStart = &coreV1.LifecycleHandler{Exec: &coreV1.ExecAction{Command: slices.Clone(hooks.PostStart)}}
+	}
+	if hooks.PreStop != nil {
+		out.PreStop = &coreV1.LifecycleHandler{Exec: &coreV1.ExecAction{Command: slices.Clone(hooks.PreStop)}}
+	}
+	return out
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+func Test_parseLifecycleAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		value    *string
+		expected map[string]containerLifecycle
+		err      string
+	}{
+		{name: "none"},
+		{name: "nominal", value: internal.Ref(`{main: {preStop: "sh -c 'sleep 5 && nginx -s quit'", postStart: [touch, /tmp/started]}}`), expected: map[string]containerLifecycle{
+			"main": {PreStop: execCommand{"sh", "-c", "sleep 5 && nginx -s quit"}, PostStart: execCommand{"touch", "/tmp/started"}},
+		}},
+		{name: "unknown container", value: internal.Ref("{other: {preStop: [sleep, '5']}}"), err: "k8s.score.dev/lifecycle: container 'other' does not exist"},
+		{name: "no hooks", value: internal.Ref("{main: {}}"), err: "k8s.score.dev/lifecycle: main: at least one of postStart or preStop must be set"},
+		{name: "unknown hook", value: internal.Ref("{main: {stop: sleep}}"), err: "k8s.score.dev/lifecycle: failed to decode: yaml: unmarshal errors:\n  line 1: field stop not found in type convert.containerLifecycle"},
+		{name: "bad quotes", value: internal.Ref(`{main: {preStop: "sh -c 'sleep"}}`), err: "k8s.score.dev/lifecycle: failed to decode: line 1: failed to split 'sh -c 'sleep': unterminated single quote"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			metadata := map[string]interface{}{}
+			if tc.value != nil {
+				metadata["annotations"] = map[string]interface{}{"k8s.score.dev/lifecycle": *tc.value}
+			}
+			out, err := parseLifecycleAnnotation(metadata, []string{"main"})
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, out)
+			}
+		})
+	}
+}
+
+func Test_buildLifecycle(t *testing.T) {
+	assert.Equal(t, &coreV1.Lifecycle{
+		PreStop: &coreV1.LifecycleHandler{Exec: &coreV1.ExecAction{Command: []string{"sleep", "5"}}},
+	}, buildLifecycle(containerLifecycle{PreStop: execCommand{"sleep", "5"}}))
+}
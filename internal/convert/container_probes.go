@@ -0,0 +1,169 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	coreV1 "k8s.io/api/core/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// probeSettings are the timing and threshold fields of a single probe, and an optional exec command for a probe that
+// the Score container does not have.
+type probeSettings struct {
+	Exec                execCommand `yaml:"exec,omitempty"`
+	InitialDelaySeconds *int32      `yaml:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       *int32      `yaml:"periodSeconds,omitempty"`
+	TimeoutSeconds      *int32      `yaml:"timeoutSeconds,omitempty"`
+	SuccessThreshold    *int32      `yaml:"successThreshold,omitempty"`
+	FailureThreshold    *int32      `yaml:"failureThreshold,omitempty"`
+}
+
+// containerProbes are the probe settings for a single container. Each probe carries its own settings so that, for
+// example, a slow starting container can have a generous startup probe and a tight liveness probe.
+type containerProbes struct {
+	Startup   *probeSettings `yaml:"startup,omitempty"`
+	Liveness  *probeSettings `yaml:"liveness,omitempty"`
+	Readiness *probeSettings `yaml:"readiness,omitempty"`
+}
+
+// validate checks the settings, liveness and startup probes must have a successThreshold of 1.
+func (s *probeSettings) validate(successMustBeOne bool) error {
+	if s.InitialDelaySeconds != nil && *s.InitialDelaySeconds < 0 {
+		return errors.New("initialDelaySeconds must not be negative")
+	}
+	for _, field := range []struct {
+		name  string
+		value *int32
+	}{
+		{"periodSeconds", s.PeriodSeconds}, {"timeoutSeconds", s.TimeoutSeconds},
+		{"successThreshold", s.SuccessThreshold}, {"failureThreshold", s.FailureThreshold},
+	} {
+		if field.value != nil && *field.value < 1 {
+			return errors.Errorf("%s must be at least 1", field.name)
+		}
+	}
+	if successMustBeOne && s.SuccessThreshold != nil && *s.SuccessThreshold != 1 {
+		return errors.New("successThreshold must be 1")
+	}
+	return nil
+}
+
+// apply sets the non-nil settings on the probe.
+func (s *probeSettings) apply(probe *coreV1.Probe) {
+	if s.InitialDelaySeconds != nil {
+		probe.InitialDelaySeconds = *s.InitialDelaySeconds
+	}
+	if s.PeriodSeconds != nil {
+		probe.PeriodSeconds = *s.PeriodSeconds
+	}
+	if s.TimeoutSeconds != nil {
+		probe.TimeoutSeconds = *s.TimeoutSeconds
+	}
+	if s.SuccessThreshold != nil {
+		probe.SuccessThreshold = *s.SuccessThreshold
+	}
+	if s.FailureThreshold != nil {
+		probe.FailureThreshold = *s.FailureThreshold
+	}
+}
+
+// parseProbesAnnotation reads the probes annotation which is a yaml map of container name to the settings of its
+// startup, liveness, and readiness probes.
+func parseProbesAnnotation(metadata map[string]interface{}, containerNames []string) (map[string]containerProbes, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.ProbesAnnotation)
+	if !ok {
+		return nil, nil
+	}
+	var out map[string]containerProbes
+	dec := yaml.NewDecoder(bytes.NewReader([]byte(raw)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&out); err != nil {
+		return nil, errors.Wrapf(err, "%s: failed to decode", internal.ProbesAnnotation)
+	}
+	for _, containerName := range slices.Sorted(maps.Keys(out)) {
+		probes := out[containerName]
+		if !slices.Contains(containerNames, containerName) {
+			return nil, errors.Errorf("%s: container '%s' does not exist", internal.ProbesAnnotation, containerName)
+		}
+		for _, probe := range []struct {
+			name             string
+			settings         *probeSettings
+			successMustBeOne bool
+		}{
+			{"startup", probes.Startup, true}, {"liveness", probes.Liveness, true}, {"readiness", probes.Readiness, false},
+		} {
+			if probe.settings != nil {
+				if err := probe.settings.validate(probe.successMustBeOne); err != nil {
+					return nil, errors.Wrapf(err, "%s: %s: %s", internal.ProbesAnnotation, containerName, probe.name)
+				}
+			}
+		}
+	}
+	return out, nil
+}
+
+// probeFor returns the existing probe with the settings applied. With an exec command, a new exec probe is returned
+// instead, which is only allowed when the container does not already have the probe.
+func (s *probeSettings) probeFor(existing *coreV1.Probe, field string) (*coreV1.Probe, error) {
+	if s.Exec != nil {
+		if existing != nil {
+			return nil, errors.Errorf("exec cannot be set since the container already has a %s", field)
+		}
+		existing = &coreV1.Probe{ProbeHandler: coreV1.ProbeHandler{Exec: &coreV1.ExecAction{Command: slices.Clone(s.Exec)}}}
+	} else if existing == nil {
+		return nil, errors.Errorf("container has no %s", field)
+	}
+	s.apply(existing)
+	return existing, nil
+}
+
+// applyContainerProbes applies the probe settings to the container. The startup probe uses its own exec command if it
+// has one, or else the same handler as the liveness probe, or the readiness probe if there is no liveness probe, but
+// keeps its own settings.
+func applyContainerProbes(container *coreV1.Container, probes containerProbes) error {
+	var err error
+	if probes.Liveness != nil {
+		if container.LivenessProbe, err = probes.Liveness.probeFor(container.LivenessProbe, "livenessProbe"); err != nil {
+			return errors.Wrap(err, "liveness")
+		}
+	}
+	if probes.Readiness != nil {
+		if container.ReadinessProbe, err = probes.Readiness.probeFor(container.ReadinessProbe, "readinessProbe"); err != nil {
+			return errors.Wrap(err, "readiness")
+		}
+	}
+	if probes.Startup != nil {
+		var handler *coreV1.ProbeHandler
+		if probes.Startup.Exec != nil {
+			handler = &coreV1.ProbeHandler{Exec: &coreV1.ExecAction{Command: slices.Clone(probes.Startup.Exec)}}
+		} else if container.LivenessProbe != nil {
+			handler = container.LivenessProbe.ProbeHandler.DeepCopy()
+		} else if container.ReadinessProbe != nil {
+			handler = container.ReadinessProbe.ProbeHandler.DeepCopy()
+		} else {
+			return errors.New("startup: container has no livenessProbe or readinessProbe to check")
+		}
+		container.StartupProbe = &coreV1.Probe{ProbeHandler: *handler}
+		probes.Startup.apply(container.StartupProbe)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+func Test_parseProbesAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		value    *string
+		expected map[string]containerProbes
+		err      string
+	}{
+		{name: "none"},
+		{name: "nominal", value: internal.Ref("{main: {startup: {failureThreshold: 30, periodSeconds: 10}, liveness: {failureThreshold: 3}}}"), expected: map[string]containerProbes{
+			"main": {
+				Startup:  &probeSettings{FailureThreshold: internal.Ref(int32(30)), PeriodSeconds: internal.Ref(int32(10))},
+				Liveness: &probeSettings{FailureThreshold: internal.Ref(int32(3))},
+			},
+		}},
+		{name: "unknown container", value: internal.Ref("{other: {startup: {}}}"), err: "k8s.score.dev/probes: container 'other' does not exist"},
+		{name: "unknown field", value: internal.Ref("{main: {startup: {threshold: 1}}}"), err: "k8s.score.dev/probes: failed to decode: yaml: unmarshal errors:\n  line 1: field threshold not found in type convert.probeSettings"},
+		{name: "zero period", value: internal.Ref("{main: {readiness: {periodSeconds: 0}}}"), err: "k8s.score.dev/probes: main: readiness: periodSeconds must be at least 1"},
+		{name: "negative delay", value: internal.Ref("{main: {liveness: {initialDelaySeconds: -1}}}"), err: "k8s.score.dev/probes: main: liveness: initialDelaySeconds must not be negative"},
+		{name: "startup success", value: internal.Ref("{main: {startup: {successThreshold: 2}}}"), err: "k8s.score.dev/probes: main: startup: successThreshold must be 1"},
+		{name: "quoted exec", value: internal.Ref(`{main: {readiness: {exec: 'pg_isready -U ''app user'' -d "my db"', periodSeconds: 5}}}`), expected: map[string]containerProbes{
+			"main": {Readiness: &probeSettings{Exec: execCommand{"pg_isready", "-U", "app user", "-d", "my db"}, PeriodSeconds: internal.Ref(int32(5))}},
+		}},
+		{name: "list exec", value: internal.Ref("{main: {liveness: {exec: [cat, /tmp/my file]}}}"), expected: map[string]containerProbes{
+			"main": {Liveness: &probeSettings{Exec: execCommand{"cat", "/tmp/my file"}}},
+		}},
+		{name: "unterminated exec", value: internal.Ref(`{main: {liveness: {exec: "cat '/tmp/my file"}}}`), err: "k8s.score.dev/probes: failed to decode: line 1: failed to split 'cat '/tmp/my file': unterminated single quote"},
+		{name: "readiness success", value: internal.Ref("{main: {readiness: {successThreshold: 2}}}"), expected: map[string]containerProbes{
+			"main": {Readiness: &probeSettings{SuccessThreshold: internal.Ref(int32(2))}},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			metadata := map[string]interface{}{}
+			if tc.value != nil {
+				metadata["annotations"] = map[string]interface{}{"k8s.score.dev/probes": *tc.value}
+			}
+			out, err := parseProbesAnnotation(metadata, []string{"main"})
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, out)
+			}
+		})
+	}
+}
+
+func Test_applyContainerProbes(t *testing.T) {
+	handler := coreV1.ProbeHandler{HTTPGet: &coreV1.HTTPGetAction{Path: "/healthz", Port: intstr.FromInt32(8080)}}
+
+	t.Run("independent startup and liveness", func(t *testing.T) {
+		container := &coreV1.Container{LivenessProbe: &coreV1.Probe{ProbeHandler: *handler.DeepCopy()}}
+		require.NoError(t, applyContainerProbes(container, containerProbes{
+			Startup:  &probeSettings{FailureThreshold: internal.Ref(int32(30)), PeriodSeconds: internal.Ref(int32(10))},
+			Liveness: &probeSettings{FailureThreshold: internal.Ref(int32(3)), PeriodSeconds: internal.Ref(int32(5))},
+		}))
+		assert.Equal(t, &coreV1.Probe{ProbeHandler: handler, FailureThreshold: 30, PeriodSeconds: 10}, container.StartupProbe)
+		assert.Equal(t, &coreV1.Probe{ProbeHandler: handler, FailureThreshold: 3, PeriodSeconds: 5}, container.LivenessProbe)
+		assert.NotSame(t, container.StartupProbe.HTTPGet, container.LivenessProbe.HTTPGet)
+	})
+
+	t.Run("startup from readiness", func(t *testing.T) {
+		container := &coreV1.Container{ReadinessProbe: &coreV1.Probe{ProbeHandler: *handler.DeepCopy()}}
+		require.NoError(t, applyContainerProbes(container, containerProbes{Startup: &probeSettings{FailureThreshold: internal.Ref(int32(60))}}))
+		assert.Equal(t, &coreV1.Probe{ProbeHandler: handler, FailureThreshold: 60}, container.StartupProbe)
+		assert.Equal(t, &coreV1.Probe{ProbeHandler: handler}, container.ReadinessProbe)
+	})
+
+	t.Run("exec fallback", func(t *testing.T) {
+		container := &coreV1.Container{}
+		require.NoError(t, applyContainerProbes(container, containerProbes{
+			Readiness: &probeSettings{Exec: execCommand{"pg_isready", "-U", "app user"}, PeriodSeconds: internal.Ref(int32(5))},
+			Startup:   &probeSettings{Exec: execCommand{"test", "-f", "/tmp/ready"}},
+		}))
+		assert.Equal(t, &coreV1.Probe{ProbeHandler: coreV1.ProbeHandler{Exec: &coreV1.ExecAction{Command: []string{"pg_isready", "-U", "app user"}}}, PeriodSeconds: 5}, container.ReadinessProbe)
+		assert.Equal(t, &coreV1.Probe{ProbeHandler: coreV1.ProbeHandler{Exec: &coreV1.ExecAction{Command: []string{"test", "-f", "/tmp/ready"}}}}, container.StartupProbe)
+		assert.Nil(t, container.LivenessProbe)
+	})
+
+	t.Run("exec with existing probe", func(t *testing.T) {
+		container := &coreV1.Container{LivenessProbe: &coreV1.Probe{ProbeHandler: *handler.DeepCopy()}}
+		assert.EqualError(t, applyContainerProbes(container, containerProbes{Liveness: &probeSettings{Exec: execCommand{"true"}}}), "liveness: exec cannot be set since the container already has a livenessProbe")
+	})
+
+	t.Run("missing probes", func(t *testing.T) {
+		assert.EqualError(t, applyContainerProbes(&coreV1.Container{}, containerProbes{Startup: &probeSettings{}}), "startup: container has no livenessProbe or readinessProbe to check")
+		assert.EqualError(t, applyContainerProbes(&coreV1.Container{}, containerProbes{Liveness: &probeSettings{}}), "liveness: container has no livenessProbe")
+		assert.EqualError(t, applyContainerProbes(&coreV1.Container{}, containerProbes{Readiness: &probeSettings{}}), "readiness: container has no readinessProbe")
+	})
+}
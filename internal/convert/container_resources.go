@@ -15,10 +15,19 @@
 package convert
 
 import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
 	"github.com/pkg/errors"
 	scoretypes "github.com/score-spec/score-go/types"
 	coreV1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/score-spec/score-k8s/internal"
 )
 
 func convertContainerResources(resources *scoretypes.ContainerResources) (coreV1.ResourceRequirements, error) {
@@ -58,3 +67,120 @@ func buildResourceList(input *scoretypes.ResourcesLimits) (coreV1.ResourceList,
 	}
 	return output, nil
 }
+
+// scoreResourceNames are the container resources supported by the Score resources block. Any other names, such as
+// ephemeral-storage or extended resources like nvidia.com/gpu, are moved into the container-resources annotation
+// before the Score file is validated.
+var scoreResourceNames = []string{"cpu", "memory"}
+
+// ExtraContainerResources are the requests and limits of a container that are not supported by the Score resources
+// block. These are stored as a json map of container name to ExtraContainerResources in the container-resources
+// annotation.
+type ExtraContainerResources struct {
+	Limits   map[string]string `json:"limits,omitempty"`
+	Requests map[string]string `json:"requests,omitempty"`
+}
+
+// ExtractContainerResources moves any extra resources out of the resources block of each container in the raw Score
+// workload and into the container-resources annotation so that the workload passes Score validation.
+func ExtractContainerResources(rawWorkload map[string]interface{}) error {
+	containers, _ := rawWorkload["containers"].(map[string]interface{})
+	extracted := make(map[string]ExtraContainerResources)
+	for containerName, rawContainer := range containers {
+		container, _ := rawContainer.(map[string]interface{})
+		resources, _ := container["resources"].(map[string]interface{})
+		var extra ExtraContainerResources
+		for _, section := range []string{"limits", "requests"} {
+			values, _ := resources[section].(map[string]interface{})
+			for name, v := range values {
+				if !slices.Contains(scoreResourceNames, name) {
+					delete(values, name)
+					target := &extra.Limits
+					if section == "requests" {
+						target = &extra.Requests
+					}
+					if *target == nil {
+						*target = make(map[string]string)
+					}
+					(*target)[name] = fmt.Sprint(v)
+				}
+			}
+		}
+		if extra.Limits != nil || extra.Requests != nil {
+			extracted[containerName] = extra
+		}
+	}
+	if len(extracted) == 0 {
+		return nil
+	}
+
+	metadata, ok := rawWorkload["metadata"].(map[string]interface{})
+	if !ok {
+		return errors.New("metadata: expected a map")
+	}
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	if annotations == nil {
+		annotations = make(map[string]interface{})
+		metadata["annotations"] = annotations
+	}
+	existing, err := parseContainerResourcesAnnotation(metadata)
+	if err != nil {
+		return err
+	}
+	for containerName, extra := range extracted {
+		e := existing[containerName]
+		if extra.Limits != nil {
+			if e.Limits == nil {
+				e.Limits = make(map[string]string)
+			}
+			maps.Copy(e.Limits, extra.Limits)
+		}
+		if extra.Requests != nil {
+			if e.Requests == nil {
+				e.Requests = make(map[string]string)
+			}
+			maps.Copy(e.Requests, extra.Requests)
+		}
+		existing[containerName] = e
+	}
+	raw, _ := json.Marshal(existing)
+	annotations[internal.ContainerResourcesAnnotation] = string(raw)
+	return nil
+}
+
+func parseContainerResourcesAnnotation(metadata map[string]interface{}) (map[string]ExtraContainerResources, error) {
+	out := make(map[string]ExtraContainerResources)
+	if raw, ok := internal.FindAnnotation(metadata, internal.ContainerResourcesAnnotation); ok {
+		if err := json.Unmarshal([]byte(raw), &out); err != nil {
+			return nil, errors.Wrapf(err, "%s: failed to decode", internal.ContainerResourcesAnnotation)
+		}
+	}
+	return out, nil
+}
+
+// applyExtraContainerResources adds the extra requests and limits to the resource requirements of the container.
+func applyExtraContainerResources(out *coreV1.ResourceRequirements, extra ExtraContainerResources) error {
+	for _, section := range []struct {
+		name   string
+		values map[string]string
+		target *coreV1.ResourceList
+	}{
+		{"limits", extra.Limits, &out.Limits},
+		{"requests", extra.Requests, &out.Requests},
+	} {
+		for name, value := range section.values {
+			if errs := validation.IsQualifiedName(name); len(errs) > 0 {
+				return errors.Errorf("%s: %s: invalid resource name: %s", section.name, name, strings.Join(errs, ", "))
+			}
+			q, err := resource.ParseQuantity(value)
+			if err != nil {
+				return errors.Wrapf(err, "%s: %s: failed to parse", section.name, name)
+			}
+			if *section.target == nil {
+				*section.target = make(coreV1.ResourceList)
+			}
+			(*section.target)[coreV1.ResourceName(name)] = q
+		}
+	}
+	return nil
+}
@@ -70,3 +70,57 @@ func Test_convertContainerResources_partial(t *testing.T) {
 		},
 	}, rl)
 }
+
+func TestExtractContainerResources(t *testing.T) {
+	raw := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "example"},
+		"containers": map[string]interface{}{
+			"main": map[string]interface{}{
+				"resources": map[string]interface{}{
+					"limits":   map[string]interface{}{"memory": "20Mi", "ephemeral-storage": "1Gi", "nvidia.com/gpu": 1},
+					"requests": map[string]interface{}{"ephemeral-storage": "500Mi"},
+				},
+			},
+			"other": map[string]interface{}{},
+		},
+	}
+	assert.NoError(t, ExtractContainerResources(raw))
+	assert.Equal(t, map[string]interface{}{"memory": "20Mi"}, raw["containers"].(map[string]interface{})["main"].(map[string]interface{})["resources"].(map[string]interface{})["limits"])
+
+	extra, err := parseContainerResourcesAnnotation(raw["metadata"].(map[string]interface{}))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]ExtraContainerResources{
+		"main": {Limits: map[string]string{"ephemeral-storage": "1Gi", "nvidia.com/gpu": "1"}, Requests: map[string]string{"ephemeral-storage": "500Mi"}},
+	}, extra)
+}
+
+func Test_applyExtraContainerResources(t *testing.T) {
+	rl, err := convertContainerResources(&scoretypes.ContainerResources{
+		Limits: &scoretypes.ResourcesLimits{Memory: internal.Ref("20Mi")},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, applyExtraContainerResources(&rl, ExtraContainerResources{
+		Limits:   map[string]string{"ephemeral-storage": "1Gi", "nvidia.com/gpu": "2"},
+		Requests: map[string]string{"ephemeral-storage": "500Mi"},
+	}))
+	assert.Equal(t, coreV1.ResourceRequirements{
+		Limits: map[coreV1.ResourceName]resource.Quantity{
+			"memory":            resource.MustParse("20Mi"),
+			"ephemeral-storage": resource.MustParse("1Gi"),
+			"nvidia.com/gpu":    resource.MustParse("2"),
+		},
+		Requests: map[coreV1.ResourceName]resource.Quantity{
+			"ephemeral-storage": resource.MustParse("500Mi"),
+		},
+	}, rl)
+
+	assert.EqualError(t, applyExtraContainerResources(&rl, ExtraContainerResources{
+		Limits: map[string]string{"ephemeral-storage": "lots"},
+	}), "limits: ephemeral-storage: failed to parse: quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'")
+	assert.EqualError(t, applyExtraContainerResources(&rl, ExtraContainerResources{
+		Limits: map[string]string{"nvidia.com/gpu": "two"},
+	}), "limits: nvidia.com/gpu: failed to parse: quantities must match the regular expression '^([+-]?[0-9.]+)([eEinumkKMGTP]*[-+]?[0-9]*)$'")
+	assert.ErrorContains(t, applyExtraContainerResources(&rl, ExtraContainerResources{
+		Limits: map[string]string{"bad name!": "1"},
+	}), "limits: bad name!: invalid resource name: ")
+}
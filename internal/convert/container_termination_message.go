@@ -0,0 +1,82 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	coreV1 "k8s.io/api/core/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+var terminationMessagePolicies = []coreV1.TerminationMessagePolicy{
+	coreV1.TerminationMessageReadFile,
+	coreV1.TerminationMessageFallbackToLogsOnError,
+}
+
+// terminationMessage is the termination message policy and optional path of a container.
+type terminationMessage struct {
+	Policy coreV1.TerminationMessagePolicy
+	Path   string
+}
+
+// parseTerminationMessageAnnotation reads the termination-message annotation and returns the policy and path for each
+// container. The annotation is a comma-separated list of entries that are either <policy>[:<path>] to use for all
+// containers, or <container>=<policy>[:<path>] to set them for a single container.
+func parseTerminationMessageAnnotation(metadata map[string]interface{}, containerNames []string) (map[string]terminationMessage, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.TerminationMessageAnnotation)
+	if !ok {
+		return nil, nil
+	}
+	var defaultMessage *terminationMessage
+	specific := make(map[string]terminationMessage)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		containerName, value, ok := strings.Cut(entry, "=")
+		if !ok {
+			containerName, value = "", entry
+		} else if !slices.Contains(containerNames, containerName) {
+			return nil, errors.Errorf("%s: container '%s' does not exist", internal.TerminationMessageAnnotation, containerName)
+		}
+		policy, messagePath, _ := strings.Cut(value, ":")
+		message := terminationMessage{Policy: coreV1.TerminationMessagePolicy(policy), Path: messagePath}
+		if !slices.Contains(terminationMessagePolicies, message.Policy) {
+			return nil, errors.Errorf("%s: unsupported policy '%s', expected one of %v", internal.TerminationMessageAnnotation, policy, terminationMessagePolicies)
+		} else if messagePath != "" && !path.IsAbs(messagePath) {
+			return nil, errors.Errorf("%s: '%s' is not an absolute path", internal.TerminationMessageAnnotation, messagePath)
+		}
+		if containerName == "" {
+			defaultMessage = &message
+		} else {
+			specific[containerName] = message
+		}
+	}
+	out := make(map[string]terminationMessage)
+	for _, containerName := range containerNames {
+		if message, ok := specific[containerName]; ok {
+			out[containerName] = message
+		} else if defaultMessage != nil {
+			out[containerName] = *defaultMessage
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+func Test_parseTerminationMessageAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		value    *string
+		expected map[string]terminationMessage
+		err      string
+	}{
+		{name: "none"},
+		{name: "all", value: internal.Ref("FallbackToLogsOnError"), expected: map[string]terminationMessage{
+			"a": {Policy: "FallbackToLogsOnError"},
+			"b": {Policy: "FallbackToLogsOnError"},
+		}},
+		{name: "specific", value: internal.Ref("File, b=FallbackToLogsOnError:/tmp/termination"), expected: map[string]terminationMessage{
+			"a": {Policy: "File"},
+			"b": {Policy: "FallbackToLogsOnError", Path: "/tmp/termination"},
+		}},
+		{name: "unknown policy", value: internal.Ref("Logs"), err: "k8s.score.dev/termination-message: unsupported policy 'Logs', expected one of [File FallbackToLogsOnError]"},
+		{name: "relative", value: internal.Ref("File:tmp/log"), err: "k8s.score.dev/termination-message: 'tmp/log' is not an absolute path"},
+		{name: "unknown container", value: internal.Ref("c=File"), err: "k8s.score.dev/termination-message: container 'c' does not exist"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			metadata := map[string]interface{}{}
+			if tc.value != nil {
+				metadata["annotations"] = map[string]interface{}{"k8s.score.dev/termination-message": *tc.value}
+			}
+			out, err := parseTerminationMessageAnnotation(metadata, []string{"a", "b"})
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, out)
+			}
+		})
+	}
+}
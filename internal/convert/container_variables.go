@@ -36,6 +36,15 @@ func generateSecretRefEnvVarName(secretName, key string) string {
 	return fmt.Sprintf("__ref_%s", strings.NewReplacer("_", "0", "-", "0").Replace(base64.RawURLEncoding.EncodeToString(h.Sum(nil))))
 }
 
+// generateRefEnvVarName returns the name of the env var that holds the referenced key. ConfigMap references are hashed
+// with a prefix so that they do not collide with a Secret of the same name.
+func generateRefEnvVarName(ref internal.SecretRef) string {
+	if ref.ConfigMap {
+		return generateSecretRefEnvVarName("configmap:"+ref.Name, ref.Key)
+	}
+	return generateSecretRefEnvVarName(ref.Name, ref.Key)
+}
+
 func convertContainerVariable(key, value string, substitutionFunction func(string) (string, error)) ([]coreV1.EnvVar, error) {
 	resolvedValue, err := framework.SubstituteString(value, substitutionFunction)
 	if err != nil {
@@ -54,12 +63,7 @@ func convertContainerVariable(key, value string, substitutionFunction func(strin
 
 	// One secret reference taking up the whole value
 	if len(refs) == 1 && parts[0] == "" && parts[1] == "" {
-		return []coreV1.EnvVar{{Name: key, ValueFrom: &coreV1.EnvVarSource{
-			SecretKeyRef: &coreV1.SecretKeySelector{
-				LocalObjectReference: coreV1.LocalObjectReference{Name: refs[0].Name},
-				Key:                  refs[0].Key,
-			},
-		}}}, nil
+		return []coreV1.EnvVar{{Name: key, ValueFrom: refs[0].EnvVarSource()}}, nil
 	}
 
 	// One or more secret references with a mix of other content
@@ -67,22 +71,14 @@ func convertContainerVariable(key, value string, substitutionFunction func(strin
 
 	// First build the referenced secrets
 	for _, ref := range refs {
-		out = append(out, coreV1.EnvVar{
-			Name: generateSecretRefEnvVarName(ref.Name, ref.Key),
-			ValueFrom: &coreV1.EnvVarSource{
-				SecretKeyRef: &coreV1.SecretKeySelector{
-					LocalObjectReference: coreV1.LocalObjectReference{Name: ref.Name},
-					Key:                  ref.Key,
-				},
-			},
-		})
+		out = append(out, coreV1.EnvVar{Name: generateRefEnvVarName(ref), ValueFrom: ref.EnvVarSource()})
 	}
 
 	// Then build the final env var string.
 	sb := new(strings.Builder)
 	for i, part := range parts {
 		if i > 0 {
-			sb.WriteString(fmt.Sprintf("$(%s)", generateSecretRefEnvVarName(refs[i-1].Name, refs[i-1].Key)))
+			sb.WriteString(fmt.Sprintf("$(%s)", generateRefEnvVarName(refs[i-1])))
 		}
 		sb.WriteString(part)
 	}
@@ -107,6 +103,13 @@ func convertContainerVariables(variables scoretypes.ContainerVariables, substitu
 			}
 		}
 	}
+	sortEnvVars(out)
+	return out, nil
+}
+
+// sortEnvVars sorts the env vars by name with the generated secret reference vars first so that the other vars can
+// refer to them.
+func sortEnvVars(out []coreV1.EnvVar) {
 	slices.SortFunc(out, func(a, b coreV1.EnvVar) int {
 		// note __ref-'s must always be first!
 		aRef, bRef := strings.HasPrefix(a.Name, "__ref_"), strings.HasPrefix(b.Name, "__ref_")
@@ -118,5 +121,4 @@ func convertContainerVariables(variables scoretypes.ContainerVariables, substitu
 		// anything else gets sorted naturally
 		return strings.Compare(a.Name, b.Name)
 	})
-	return out, nil
 }
@@ -68,6 +68,40 @@ func Test_convertContainerVariable_1_secret(t *testing.T) {
 	}}}, out)
 }
 
+func Test_convertContainerVariable_configmap(t *testing.T) {
+	out, err := convertContainerVariable("KEY", "${foo.bar}", func(s string) (string, error) {
+		return internal.EncodeConfigMapReference("app-config", "LOG_LEVEL"), nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []coreV1.EnvVar{{Name: "KEY", ValueFrom: &coreV1.EnvVarSource{
+		ConfigMapKeyRef: &coreV1.ConfigMapKeySelector{
+			LocalObjectReference: coreV1.LocalObjectReference{Name: "app-config"},
+			Key:                  "LOG_LEVEL",
+		},
+	}}}, out)
+
+	// mixed with a secret of the same name and key, each gets its own ref env var
+	out, err = convertContainerVariable("KEY", "${a.b}:${c.d}", func(s string) (string, error) {
+		return map[string]string{
+			"a.b": internal.EncodeConfigMapReference("app", "key"),
+			"c.d": internal.EncodeSecretReference("app", "key"),
+		}[s], nil
+	})
+	assert.NoError(t, err)
+	cmRef := generateRefEnvVarName(internal.SecretRef{Name: "app", Key: "key", ConfigMap: true})
+	secretRef := generateSecretRefEnvVarName("app", "key")
+	assert.NotEqual(t, cmRef, secretRef)
+	assert.Equal(t, []coreV1.EnvVar{
+		{Name: cmRef, ValueFrom: &coreV1.EnvVarSource{ConfigMapKeyRef: &coreV1.ConfigMapKeySelector{
+			LocalObjectReference: coreV1.LocalObjectReference{Name: "app"}, Key: "key",
+		}}},
+		{Name: secretRef, ValueFrom: &coreV1.EnvVarSource{SecretKeyRef: &coreV1.SecretKeySelector{
+			LocalObjectReference: coreV1.LocalObjectReference{Name: "app"}, Key: "key",
+		}}},
+		{Name: "KEY", Value: "$(" + cmRef + "):$(" + secretRef + ")"},
+	}, out)
+}
+
 func Test_convertContainerVariable_2_secret(t *testing.T) {
 	out, err := convertContainerVariable("KEY", "${foo.bar} ${a.b}", func(s string) (string, error) {
 		return map[string]string{
@@ -18,7 +18,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"path"
+	"reflect"
 	"slices"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/score-spec/score-go/framework"
@@ -56,14 +59,35 @@ func convertContainerVolume(
 	// convert the outputs into a spec
 	raw, _ := json.Marshal(res.Outputs)
 	var anon struct {
-		Source    *coreV1.VolumeSource              `json:"source"`
-		ClaimSpec *coreV1.PersistentVolumeClaimSpec `json:"claimSpec"`
+		Source      *coreV1.VolumeSource              `json:"source"`
+		ClaimSpec   *coreV1.PersistentVolumeClaimSpec `json:"claimSpec"`
+		SubPath     *string                           `json:"subPath"`
+		SubPathExpr *string                           `json:"subPathExpr"`
 	}
 	dec := json.NewDecoder(bytes.NewReader(raw))
 	dec.DisallowUnknownFields()
 	if err = dec.Decode(&anon); err != nil {
 		return mount, nil, nil, errors.Wrapf(err, "failed to convert resource '%s' outputs into a Kubernetes volume", resolvedVolumeSource)
 	}
+
+	// The path in the Score file takes precedence over any subPath from the resource outputs.
+	if err := validateSubPath(mount.SubPath); err != nil {
+		return mount, nil, nil, errors.Wrap(err, "path")
+	} else if mount.SubPath == "" && anon.SubPath != nil {
+		if err := validateSubPath(*anon.SubPath); err != nil {
+			return mount, nil, nil, errors.Wrapf(err, "failed to convert resource '%s' outputs into volume: subPath", resolvedVolumeSource)
+		}
+		mount.SubPath = *anon.SubPath
+	}
+	if anon.SubPathExpr != nil {
+		if err := validateSubPath(*anon.SubPathExpr); err != nil {
+			return mount, nil, nil, errors.Wrapf(err, "failed to convert resource '%s' outputs into volume: subPathExpr", resolvedVolumeSource)
+		} else if mount.SubPath != "" {
+			return mount, nil, nil, errors.Errorf("failed to convert resource '%s' outputs into volume: subPathExpr cannot be combined with a path or subPath", resolvedVolumeSource)
+		}
+		mount.SubPathExpr = *anon.SubPathExpr
+	}
+
 	if (anon.ClaimSpec == nil) == (anon.Source == nil) {
 		return mount, nil, nil, errors.Errorf("failed to convert resource '%s' outputs into volume: either 'source' or 'claimSpec' required", resolvedVolumeSource)
 	} else if anon.ClaimSpec != nil {
@@ -80,12 +104,56 @@ func convertContainerVolume(
 	if anon.Source.Size() == 0 {
 		return mount, nil, nil, errors.Errorf("failed to convert resource '%s' outputs into volume: source is empty", resolvedVolumeSource)
 	}
+	// Secrets, ConfigMaps, and the other generated volumes are always read-only, so the mount reflects this unless
+	// the Score file says otherwise.
+	if volume.ReadOnly == nil && isReadOnlySource(anon.Source) {
+		mount.ReadOnly = true
+	}
 	return mount, &coreV1.Volume{
 		Name:         volName,
 		VolumeSource: *anon.Source,
 	}, nil, nil
 }
 
+// isReadOnlySource returns true for the volume sources whose content is generated by the kubelet and cannot be written
+// to by the containers.
+func isReadOnlySource(source *coreV1.VolumeSource) bool {
+	return source.Secret != nil || source.ConfigMap != nil || source.DownwardAPI != nil || source.Projected != nil
+}
+
+// validateSubPath checks that a volume mount sub path stays within the volume.
+func validateSubPath(subPath string) error {
+	if path.IsAbs(subPath) {
+		return errors.Errorf("'%s' must be a relative path", subPath)
+	} else if slices.Contains(strings.Split(subPath, "/"), "..") {
+		return errors.Errorf("'%s' must not contain '..'", subPath)
+	}
+	return nil
+}
+
+// mergeContainerVolumes adds the volumes of a container to the volumes of the pod. The volume names are only unique
+// within the container, so an identical volume with the same name, such as the same resource mounted into more than
+// one container, is shared, while a different volume with the same name is renamed with the container name as a
+// prefix along with its mounts.
+func mergeContainerVolumes(volumes []coreV1.Volume, containerName string, containerVolumes []coreV1.Volume, mounts []coreV1.VolumeMount) ([]coreV1.Volume, []coreV1.VolumeMount) {
+	for _, vol := range containerVolumes {
+		i := slices.IndexFunc(volumes, func(v coreV1.Volume) bool { return v.Name == vol.Name })
+		if i >= 0 && reflect.DeepEqual(volumes[i], vol) {
+			continue
+		} else if i >= 0 {
+			newName := containerName + "-" + vol.Name
+			for j := range mounts {
+				if mounts[j].Name == vol.Name {
+					mounts[j].Name = newName
+				}
+			}
+			vol.Name = newName
+		}
+		volumes = append(volumes, vol)
+	}
+	return volumes, mounts
+}
+
 type volumeAndMount struct {
 	Volume      coreV1.Volume
 	VolumeMount coreV1.VolumeMount
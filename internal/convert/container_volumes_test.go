@@ -15,6 +15,7 @@
 package convert
 
 import (
+	"maps"
 	"testing"
 
 	"github.com/score-spec/score-go/framework"
@@ -220,3 +221,92 @@ func Test_collapseVolumeMounts_nominal(t *testing.T) {
 		{Name: "v4", MountPath: "/c"},
 	}, mounts)
 }
+
+func Test_convertContainerVolume_sub_path(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		path        *string
+		outputs     map[string]interface{}
+		subPath     string
+		subPathExpr string
+		err         string
+	}{
+		{name: "from outputs", outputs: map[string]interface{}{"subPath": "data/a"}, subPath: "data/a"},
+		{name: "score path wins", path: internal.Ref("sub"), outputs: map[string]interface{}{"subPath": "data/a"}, subPath: "sub"},
+		{name: "expr", outputs: map[string]interface{}{"subPathExpr": "$(POD_NAME)"}, subPathExpr: "$(POD_NAME)"},
+		{name: "absolute path", path: internal.Ref("/sub"), err: "path: '/sub' must be a relative path"},
+		{name: "absolute output", outputs: map[string]interface{}{"subPath": "/data"}, err: "failed to convert resource 'volume.default#my-workload.thing' outputs into volume: subPath: '/data' must be a relative path"},
+		{name: "parent output", outputs: map[string]interface{}{"subPath": "data/../.."}, err: "failed to convert resource 'volume.default#my-workload.thing' outputs into volume: subPath: 'data/../..' must not contain '..'"},
+		{name: "both", path: internal.Ref("sub"), outputs: map[string]interface{}{"subPathExpr": "$(POD_NAME)"}, err: "failed to convert resource 'volume.default#my-workload.thing' outputs into volume: subPathExpr cannot be combined with a path or subPath"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			outputs := map[string]interface{}{"source": map[string]interface{}{"emptyDir": map[string]interface{}{}}}
+			maps.Copy(outputs, tc.outputs)
+			mount, _, _, err := convertContainerVolume(0, scoretypes.ContainerVolumesElem{
+				Target: "/mount/path",
+				Source: "volume.default#my-workload.thing",
+				Path:   tc.path,
+			}, map[framework.ResourceUid]framework.ScoreResourceState[project.ResourceExtras]{
+				"volume.default#my-workload.thing": {Outputs: outputs},
+			}, noSubstitutesFunction)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.subPath, mount.SubPath)
+				assert.Equal(t, tc.subPathExpr, mount.SubPathExpr)
+			}
+		})
+	}
+}
+
+func Test_convertContainerVolume_read_only(t *testing.T) {
+	resources := map[framework.ResourceUid]framework.ScoreResourceState[project.ResourceExtras]{
+		"volume.default#my-workload.config": {
+			Outputs: map[string]interface{}{
+				"source": map[string]interface{}{"configMap": map[string]interface{}{"name": "shared-config"}},
+			},
+		},
+		"volume.default#my-workload.scratch": {
+			Outputs: map[string]interface{}{
+				"source": map[string]interface{}{"emptyDir": map[string]interface{}{}},
+			},
+		},
+	}
+	for _, tc := range []struct {
+		name     string
+		source   string
+		readOnly *bool
+		expected bool
+	}{
+		{name: "config map defaults to read-only", source: "volume.default#my-workload.config", expected: true},
+		{name: "config map read-write", source: "volume.default#my-workload.config", readOnly: internal.Ref(false)},
+		{name: "empty dir defaults to read-write", source: "volume.default#my-workload.scratch"},
+		{name: "empty dir read-only", source: "volume.default#my-workload.scratch", readOnly: internal.Ref(true), expected: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			mount, _, _, err := convertContainerVolume(0, scoretypes.ContainerVolumesElem{
+				Source: tc.source, Target: "/mnt", ReadOnly: tc.readOnly,
+			}, resources, noSubstitutesFunction)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, mount.ReadOnly)
+		})
+	}
+}
+
+func Test_mergeContainerVolumes(t *testing.T) {
+	shared := coreV1.Volume{Name: "vol-0", VolumeSource: coreV1.VolumeSource{ConfigMap: &coreV1.ConfigMapVolumeSource{LocalObjectReference: coreV1.LocalObjectReference{Name: "shared"}}}}
+	other := coreV1.Volume{Name: "vol-0", VolumeSource: coreV1.VolumeSource{EmptyDir: &coreV1.EmptyDirVolumeSource{}}}
+
+	volumes, mounts := mergeContainerVolumes(nil, "c1", []coreV1.Volume{shared}, []coreV1.VolumeMount{{Name: "vol-0", MountPath: "/a", ReadOnly: true}})
+	assert.Equal(t, []coreV1.VolumeMount{{Name: "vol-0", MountPath: "/a", ReadOnly: true}}, mounts)
+	volumes, mounts = mergeContainerVolumes(volumes, "c2", []coreV1.Volume{shared}, []coreV1.VolumeMount{{Name: "vol-0", MountPath: "/a"}})
+	assert.Equal(t, []coreV1.VolumeMount{{Name: "vol-0", MountPath: "/a"}}, mounts)
+	assert.Equal(t, []coreV1.Volume{shared}, volumes)
+
+	volumes, mounts = mergeContainerVolumes(volumes, "c3", []coreV1.Volume{other}, []coreV1.VolumeMount{{Name: "vol-0", MountPath: "/b"}})
+	assert.Equal(t, []coreV1.VolumeMount{{Name: "c3-vol-0", MountPath: "/b"}}, mounts)
+	renamed := other
+	renamed.Name = "c3-vol-0"
+	assert.Equal(t, []coreV1.Volume{shared, renamed}, volumes)
+}
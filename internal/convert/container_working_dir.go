@@ -0,0 +1,66 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// parseWorkingDirAnnotation reads the working-dir annotation and returns the working directory for each container.
+// The annotation is a comma-separated list of entries that are either an absolute path to use for all containers, or
+// <container>=<path> to set the path for a single container.
+func parseWorkingDirAnnotation(metadata map[string]interface{}, containerNames []string) (map[string]string, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.WorkingDirAnnotation)
+	if !ok {
+		return nil, nil
+	}
+	var defaultDir string
+	specific := make(map[string]string)
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		containerName, dir, ok := strings.Cut(entry, "=")
+		if !ok {
+			containerName, dir = "", entry
+		} else if !slices.Contains(containerNames, containerName) {
+			return nil, errors.Errorf("%s: container '%s' does not exist", internal.WorkingDirAnnotation, containerName)
+		}
+		if !path.IsAbs(dir) {
+			return nil, errors.Errorf("%s: '%s' is not an absolute path", internal.WorkingDirAnnotation, dir)
+		}
+		if containerName == "" {
+			defaultDir = dir
+		} else {
+			specific[containerName] = dir
+		}
+	}
+	out := make(map[string]string)
+	for _, containerName := range containerNames {
+		if dir, ok := specific[containerName]; ok {
+			out[containerName] = dir
+		} else if defaultDir != "" {
+			out[containerName] = defaultDir
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+func Test_parseWorkingDirAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		value    *string
+		expected map[string]string
+		err      string
+	}{
+		{name: "none"},
+		{name: "all", value: internal.Ref("/app"), expected: map[string]string{"a": "/app", "b": "/app"}},
+		{name: "specific", value: internal.Ref("/app, b=/srv"), expected: map[string]string{"a": "/app", "b": "/srv"}},
+		{name: "only specific", value: internal.Ref("b=/srv"), expected: map[string]string{"b": "/srv"}},
+		{name: "relative", value: internal.Ref("app"), err: "k8s.score.dev/working-dir: 'app' is not an absolute path"},
+		{name: "unknown container", value: internal.Ref("c=/app"), err: "k8s.score.dev/working-dir: container 'c' does not exist"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			metadata := map[string]interface{}{}
+			if tc.value != nil {
+				metadata["annotations"] = map[string]interface{}{"k8s.score.dev/working-dir": *tc.value}
+			}
+			out, err := parseWorkingDirAnnotation(metadata, []string{"a", "b"})
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// parseProgressDeadlineAnnotation reads the progress-deadline annotation and returns the progressDeadlineSeconds of the
+// Deployment, or nil if the annotation is not set so that the Kubernetes default applies.
+func parseProgressDeadlineAnnotation(metadata map[string]interface{}, kind string) (*int32, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.ProgressDeadlineAnnotation)
+	if !ok {
+		return nil, nil
+	}
+	if kind != WorkloadKindDeployment {
+		return nil, errors.Errorf("%s: is only supported for %s workloads", internal.ProgressDeadlineAnnotation, WorkloadKindDeployment)
+	}
+	v, err := strconv.ParseInt(strings.TrimSpace(raw), 10, 32)
+	if err != nil || v <= 0 {
+		return nil, errors.Errorf("%s: '%s' is not a positive number of seconds", internal.ProgressDeadlineAnnotation, raw)
+	}
+	out := int32(v)
+	return &out, nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+func Test_parseProgressDeadlineAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		value    *string
+		kind     string
+		expected *int32
+		err      string
+	}{
+		{name: "none"},
+		{name: "nominal", value: internal.Ref("600"), expected: internal.Ref(int32(600))},
+		{name: "zero", value: internal.Ref("0"), err: "k8s.score.dev/progress-deadline: '0' is not a positive number of seconds"},
+		{name: "negative", value: internal.Ref("-5"), err: "k8s.score.dev/progress-deadline: '-5' is not a positive number of seconds"},
+		{name: "invalid", value: internal.Ref("10m"), err: "k8s.score.dev/progress-deadline: '10m' is not a positive number of seconds"},
+		{name: "statefulset", value: internal.Ref("600"), kind: WorkloadKindStatefulSet, err: "k8s.score.dev/progress-deadline: is only supported for Deployment workloads"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			metadata := map[string]interface{}{}
+			if tc.value != nil {
+				metadata["annotations"] = map[string]interface{}{"k8s.score.dev/progress-deadline": *tc.value}
+			}
+			kind := tc.kind
+			if kind == "" {
+				kind = WorkloadKindDeployment
+			}
+			out, err := parseProgressDeadlineAnnotation(metadata, kind)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, out)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"maps"
+
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+// The supported label schemes. The label scheme decides which label the Service, NetworkPolicy, PodMonitor, and
+// workload selectors match the pods of a workload on.
+const (
+	// LabelSchemeRecommended selects the pods by the app.kubernetes.io/instance label. This is the default.
+	LabelSchemeRecommended = "recommended"
+	// LabelSchemeLegacy selects the pods by an additional "app" label, as used by many hand written manifests. This
+	// allows existing Deployments to be adopted since their selectors cannot be changed.
+	LabelSchemeLegacy = "legacy"
+
+	// SelectorLabelApp is the selector label of the legacy label scheme.
+	SelectorLabelApp = "app"
+)
+
+// LabelSchemes is the list of supported label schemes.
+var LabelSchemes = []string{LabelSchemeRecommended, LabelSchemeLegacy}
+
+// Options are the optional settings of ConvertWorkloadWithOptions.
+type Options struct {
+	// LabelScheme is one of the LabelSchemes, LabelSchemeRecommended is used when this is empty.
+	LabelScheme string
+	// StrictAnnotations fails the conversion on unknown workload annotations with the score-k8s prefix, instead of
+	// logging a warning.
+	StrictAnnotations bool
+	// ConsolidateSecrets copies the values of the env vars that refer to the Secrets generated by provisioners into a
+	// single <workload>-env Secret and refers to that instead.
+	ConsolidateSecrets bool
+	// Env is the environment that the expressions of the when annotation are evaluated against.
+	Env string
+}
+
+// selectorLabels returns the labels that select the pods of the workload under the label scheme. These are always a
+// subset of the labels on the pods.
+func selectorLabels(scheme string, workloadName string, extras project.WorkloadExtras) map[string]string {
+	instance := workloadName + extras.InstanceSuffix
+	if scheme == LabelSchemeLegacy {
+		return map[string]string{SelectorLabelApp: instance}
+	}
+	return map[string]string{SelectorLabelInstance: instance}
+}
+
+// workloadLabels returns the labels added to the workload manifests and pods, including the selector labels.
+func workloadLabels(scheme string, workloadName string, extras project.WorkloadExtras) map[string]string {
+	out := map[string]string{
+		SelectorLabelName:      workloadName,
+		SelectorLabelInstance:  workloadName + extras.InstanceSuffix,
+		SelectorLabelManagedBy: "score-k8s",
+	}
+	maps.Copy(out, selectorLabels(scheme, workloadName, extras))
+	return out
+}
@@ -0,0 +1,263 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/score-spec/score-go/framework"
+	"gopkg.in/yaml.v3"
+	coreV1 "k8s.io/api/core/v1"
+	networkingV1 "k8s.io/api/networking/v1"
+	machineryMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/score-spec/score-k8s/internal"
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+// networkPolicySpec is the decoded network-policy annotation.
+type networkPolicySpec struct {
+	// Egress is the list of destinations the workload may connect to. When nil, egress is not restricted.
+	Egress []networkPolicyEgressRule `yaml:"egress"`
+}
+
+// networkPolicyEgressRule allows egress to exactly one of a resource, a workload, or a cidr.
+type networkPolicyEgressRule struct {
+	// Resource is the name of a resource of the workload whose host and port outputs are the destination.
+	Resource string `yaml:"resource,omitempty"`
+	// Workload is the name of another workload in the project.
+	Workload string `yaml:"workload,omitempty"`
+	// Cidr is an ip range.
+	Cidr string `yaml:"cidr,omitempty"`
+	// Ports optionally restricts the destination ports. For resources this defaults to the port output.
+	Ports []int `yaml:"ports,omitempty"`
+}
+
+func parseNetworkPolicyAnnotation(metadata map[string]interface{}) (*networkPolicySpec, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.NetworkPolicyAnnotation)
+	if !ok {
+		return nil, nil
+	}
+	var out networkPolicySpec
+	dec := yaml.NewDecoder(bytes.NewReader([]byte(raw)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&out); err != nil {
+		return nil, errors.Wrapf(err, "%s: failed to decode", internal.NetworkPolicyAnnotation)
+	}
+	for i, rule := range out.Egress {
+		var set int
+		for _, v := range []string{rule.Resource, rule.Workload, rule.Cidr} {
+			if v != "" {
+				set++
+			}
+		}
+		if set != 1 {
+			return nil, errors.Errorf("%s: egress.%d: exactly one of resource, workload, or cidr must be set", internal.NetworkPolicyAnnotation, i)
+		}
+		for _, p := range rule.Ports {
+			if p < 1 || p > 65535 {
+				return nil, errors.Errorf("%s: egress.%d: %d is not a valid port number", internal.NetworkPolicyAnnotation, i, p)
+			}
+		}
+	}
+	return &out, nil
+}
+
+// buildNetworkPolicy returns an egress NetworkPolicy for the workload, or nil if the network-policy annotation does not
+// restrict egress. DNS is always allowed so that the workload can still resolve the allowed destinations.
+func buildNetworkPolicy(
+	state *project.State, workloadName string, labelScheme string, resOutputs map[string]framework.OutputLookupFunc,
+	labels, annotations, selector map[string]string,
+) (*networkingV1.NetworkPolicy, error) {
+	spec, err := parseNetworkPolicyAnnotation(state.Workloads[workloadName].Spec.Metadata)
+	if err != nil || spec == nil || spec.Egress == nil {
+		return nil, err
+	}
+
+	udp, tcp := coreV1.ProtocolUDP, coreV1.ProtocolTCP
+	dnsPort := intstr.FromInt32(53)
+	rules := []networkingV1.NetworkPolicyEgressRule{{
+		Ports: []networkingV1.NetworkPolicyPort{{Protocol: &udp, Port: &dnsPort}, {Protocol: &tcp, Port: &dnsPort}},
+	}}
+	for i, rule := range spec.Egress {
+		out, err := buildEgressRule(state, labelScheme, resOutputs, rule)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: egress.%d", internal.NetworkPolicyAnnotation, i)
+		}
+		rules = append(rules, out)
+	}
+
+	return &networkingV1.NetworkPolicy{
+		TypeMeta: machineryMeta.TypeMeta{Kind: "NetworkPolicy", APIVersion: "networking.k8s.io/v1"},
+		ObjectMeta: machineryMeta.ObjectMeta{
+			Name:        workloadName,
+			Annotations: annotations,
+			Labels:      labels,
+		},
+		Spec: networkingV1.NetworkPolicySpec{
+			PodSelector: machineryMeta.LabelSelector{MatchLabels: selector},
+			PolicyTypes: []networkingV1.PolicyType{networkingV1.PolicyTypeEgress},
+			Egress:      rules,
+		},
+	}, nil
+}
+
+// egressTarget is a resolved destination. The service ports map the port that is connected to, onto the port of the
+// destination pods that the NetworkPolicy must allow.
+type egressTarget struct {
+	peer         networkingV1.NetworkPolicyPeer
+	servicePorts map[int]int
+}
+
+func buildEgressRule(state *project.State, labelScheme string, resOutputs map[string]framework.OutputLookupFunc, rule networkPolicyEgressRule) (networkingV1.NetworkPolicyEgressRule, error) {
+	var target *egressTarget
+	ports := rule.Ports
+	switch {
+	case rule.Cidr != "":
+		if _, _, err := net.ParseCIDR(rule.Cidr); err != nil {
+			return networkingV1.NetworkPolicyEgressRule{}, errors.Errorf("'%s' is not a valid cidr", rule.Cidr)
+		}
+		target = &egressTarget{peer: networkingV1.NetworkPolicyPeer{IPBlock: &networkingV1.IPBlock{CIDR: rule.Cidr}}}
+	case rule.Workload != "":
+		if target = findWorkloadTarget(state, labelScheme, rule.Workload); target == nil {
+			return networkingV1.NetworkPolicyEgressRule{}, errors.Errorf("workload '%s' does not exist", rule.Workload)
+		}
+	default:
+		lookup, ok := resOutputs[rule.Resource]
+		if !ok {
+			return networkingV1.NetworkPolicyEgressRule{}, errors.Errorf("resource '%s' does not exist", rule.Resource)
+		}
+		host := lookupString(lookup, "host")
+		if host == "" {
+			host = lookupString(lookup, "hostname")
+		}
+		if host == "" {
+			return networkingV1.NetworkPolicyEgressRule{}, errors.Errorf("resource '%s' has no host output", rule.Resource)
+		}
+		if target = resolveHost(state, labelScheme, host); target == nil {
+			return networkingV1.NetworkPolicyEgressRule{}, errors.Errorf("resource '%s': cannot resolve host '%s' to a cidr or selector", rule.Resource, host)
+		}
+		if len(ports) == 0 {
+			if p, err := strconv.Atoi(lookupString(lookup, "port")); err == nil {
+				ports = []int{p}
+			}
+		}
+	}
+
+	out := networkingV1.NetworkPolicyEgressRule{To: []networkingV1.NetworkPolicyPeer{target.peer}}
+	for _, p := range ports {
+		if tp, ok := target.servicePorts[p]; ok {
+			p = tp
+		}
+		proto, port := coreV1.ProtocolTCP, intstr.FromInt32(int32(p))
+		out.Ports = append(out.Ports, networkingV1.NetworkPolicyPort{Protocol: &proto, Port: &port})
+	}
+	return out, nil
+}
+
+func lookupString(lookup framework.OutputLookupFunc, key string) string {
+	if v, err := lookup(key); err == nil && v != nil {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// resolveHost resolves an ip address into a cidr, or a <service>[.<namespace>[.svc...]] hostname into the selector of
+// a workload or a Service returned by a resource provisioner.
+func resolveHost(state *project.State, labelScheme string, host string) *egressTarget {
+	if ip := net.ParseIP(host); ip != nil {
+		bits := 32
+		if ip.To4() == nil {
+			bits = 128
+		}
+		return &egressTarget{peer: networkingV1.NetworkPolicyPeer{IPBlock: &networkingV1.IPBlock{CIDR: fmt.Sprintf("%s/%d", host, bits)}}}
+	}
+	parts := strings.Split(host, ".")
+	name, namespace := parts[0], ""
+	if len(parts) == 2 || (len(parts) > 2 && parts[2] == "svc") {
+		namespace = parts[1]
+	} else if len(parts) > 2 {
+		return nil
+	}
+
+	var target *egressTarget
+	for workloadName, workload := range state.Workloads {
+		if namespace == "" && WorkloadServiceName(workloadName, workload.Spec.Metadata) == name {
+			target = findWorkloadTarget(state, labelScheme, workloadName)
+		}
+	}
+	for _, res := range state.Resources {
+		for _, m := range res.Extras.Manifests {
+			if target == nil {
+				target = serviceTarget(m, name, namespace)
+			}
+		}
+	}
+	if target != nil && namespace != "" {
+		target.peer.NamespaceSelector = &machineryMeta.LabelSelector{MatchLabels: map[string]string{
+			"kubernetes.io/metadata.name": namespace,
+		}}
+	}
+	return target
+}
+
+// serviceTarget returns the selector and ports of the manifest if it is a Service with the given name and namespace.
+func serviceTarget(manifest map[string]interface{}, name, namespace string) *egressTarget {
+	metadata, _ := manifest["metadata"].(map[string]interface{})
+	spec, _ := manifest["spec"].(map[string]interface{})
+	selector, _ := spec["selector"].(map[string]interface{})
+	if manifest["kind"] != "Service" || metadata["name"] != name || len(selector) == 0 {
+		return nil
+	} else if ns, _ := metadata["namespace"].(string); namespace != "" && ns != "" && ns != namespace {
+		return nil
+	}
+	out := &egressTarget{servicePorts: make(map[int]int)}
+	out.peer.PodSelector = &machineryMeta.LabelSelector{MatchLabels: make(map[string]string, len(selector))}
+	for k, v := range selector {
+		out.peer.PodSelector.MatchLabels[k] = fmt.Sprint(v)
+	}
+	ports, _ := spec["ports"].([]interface{})
+	for _, rawPort := range ports {
+		port, _ := rawPort.(map[string]interface{})
+		p, err1 := strconv.Atoi(fmt.Sprint(port["port"]))
+		tp, err2 := strconv.Atoi(fmt.Sprint(port["targetPort"]))
+		if err1 == nil && err2 == nil {
+			out.servicePorts[p] = tp
+		}
+	}
+	return out
+}
+
+// findWorkloadTarget returns the selector and service ports of the workload, or nil if it does not exist.
+func findWorkloadTarget(state *project.State, labelScheme string, workloadName string) *egressTarget {
+	workload, ok := state.Workloads[workloadName]
+	if !ok {
+		return nil
+	}
+	out := &egressTarget{servicePorts: make(map[int]int)}
+	out.peer.PodSelector = &machineryMeta.LabelSelector{MatchLabels: selectorLabels(labelScheme, workloadName, workload.Extras)}
+	if workload.Spec.Service != nil {
+		for _, port := range workload.Spec.Service.Ports {
+			out.servicePorts[port.Port] = internal.DerefOr(port.TargetPort, port.Port)
+		}
+	}
+	return out
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	coreV1 "k8s.io/api/core/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// parseInitContainersAnnotation reads the comma-separated list of workload containers in the init-containers
+// annotation. These run to completion, in the listed order, before the other containers start. At least one container
+// must be left to run as a normal container.
+func parseInitContainersAnnotation(metadata map[string]interface{}, containerNames []string) ([]string, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.InitContainersAnnotation)
+	if !ok {
+		return nil, nil
+	}
+	out := make([]string, 0)
+	for _, part := range strings.Split(raw, ",") {
+		containerName := strings.TrimSpace(part)
+		if containerName == "" {
+			continue
+		} else if !slices.Contains(containerNames, containerName) {
+			return nil, errors.Errorf("%s: container '%s' does not exist", internal.InitContainersAnnotation, containerName)
+		} else if slices.Contains(out, containerName) {
+			return nil, errors.Errorf("%s: container '%s' is listed more than once", internal.InitContainersAnnotation, containerName)
+		}
+		out = append(out, containerName)
+	}
+	if len(out) == 0 {
+		return nil, errors.Errorf("%s: must contain at least one container name", internal.InitContainersAnnotation)
+	} else if len(out) == len(containerNames) {
+		return nil, errors.Errorf("%s: at least one container must not be an init container", internal.InitContainersAnnotation)
+	}
+	return out, nil
+}
+
+// extractInitContainers removes the named containers from the list of containers and returns them in the given order.
+// Init containers run to completion, so they cannot have probes, lifecycle hooks, or ports.
+func extractInitContainers(containers []coreV1.Container, initContainerNames []string) ([]coreV1.Container, []coreV1.Container, error) {
+	initContainers := make([]coreV1.Container, 0, len(initContainerNames))
+	for _, name := range initContainerNames {
+		i := slices.IndexFunc(containers, func(c coreV1.Container) bool { return c.Name == name })
+		c := containers[i]
+		if c.LivenessProbe != nil || c.ReadinessProbe != nil || c.StartupProbe != nil {
+			return nil, nil, errors.Errorf("%s: container '%s' cannot have probes", internal.InitContainersAnnotation, name)
+		} else if c.Lifecycle != nil {
+			return nil, nil, errors.Errorf("%s: container '%s' cannot have lifecycle hooks", internal.InitContainersAnnotation, name)
+		} else if len(c.Ports) > 0 {
+			return nil, nil, errors.Errorf("%s: container '%s' cannot have ports", internal.InitContainersAnnotation, name)
+		}
+		initContainers = append(initContainers, c)
+		containers = slices.Delete(containers, i, i+1)
+	}
+	return containers, initContainers, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+func Test_parseInitContainersAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		value    *string
+		expected []string
+		err      string
+	}{
+		{name: "none"},
+		{name: "nominal", value: internal.Ref("migrate, seed"), expected: []string{"migrate", "seed"}},
+		{name: "empty", value: internal.Ref(" , "), err: "k8s.score.dev/init-containers: must contain at least one container name"},
+		{name: "unknown", value: internal.Ref("other"), err: "k8s.score.dev/init-containers: container 'other' does not exist"},
+		{name: "duplicate", value: internal.Ref("seed,seed"), err: "k8s.score.dev/init-containers: container 'seed' is listed more than once"},
+		{name: "all", value: internal.Ref("main,migrate,seed"), err: "k8s.score.dev/init-containers: at least one container must not be an init container"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			metadata := map[string]interface{}{}
+			if tc.value != nil {
+				metadata["annotations"] = map[string]interface{}{"k8s.score.dev/init-containers": *tc.value}
+			}
+			out, err := parseInitContainersAnnotation(metadata, []string{"main", "migrate", "seed"})
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, out)
+			}
+		})
+	}
+}
+
+func Test_extractInitContainers(t *testing.T) {
+	containers := []coreV1.Container{{Name: "main"}, {Name: "migrate"}, {Name: "seed"}}
+	out, initContainers, err := extractInitContainers(containers, []string{"seed", "migrate"})
+	assert.NoError(t, err)
+	assert.Equal(t, []coreV1.Container{{Name: "main"}}, out)
+	assert.Equal(t, []coreV1.Container{{Name: "seed"}, {Name: "migrate"}}, initContainers)
+
+	_, _, err = extractInitContainers([]coreV1.Container{{Name: "main"}, {Name: "migrate", ReadinessProbe: &coreV1.Probe{}}}, []string{"migrate"})
+	assert.EqualError(t, err, "k8s.score.dev/init-containers: container 'migrate' cannot have probes")
+	_, _, err = extractInitContainers([]coreV1.Container{{Name: "main"}, {Name: "migrate", Ports: []coreV1.ContainerPort{{ContainerPort: 80}}}}, []string{"migrate"})
+	assert.EqualError(t, err, "k8s.score.dev/init-containers: container 'migrate' cannot have ports")
+}
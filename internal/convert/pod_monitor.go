@@ -0,0 +1,99 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+const (
+	podMonitorPortName    = "metrics"
+	defaultPodMonitorPath = "/metrics"
+)
+
+// podMonitorTarget is the container port that the PodMonitor scrapes.
+type podMonitorTarget struct {
+	Container string
+	Port      int
+	Path      string
+}
+
+// parsePodMonitorAnnotation reads the pod-monitor annotation which is either "<port>" or "<container>:<port>". The
+// container may only be omitted when the workload has a single container.
+func parsePodMonitorAnnotation(metadata map[string]interface{}, containerNames []string) (*podMonitorTarget, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.PodMonitorAnnotation)
+	if !ok {
+		return nil, nil
+	}
+	out := &podMonitorTarget{Path: defaultPodMonitorPath}
+	rawPort := raw
+	if c, p, ok := strings.Cut(raw, ":"); ok {
+		out.Container, rawPort = c, p
+		if !slices.Contains(containerNames, out.Container) {
+			return nil, errors.Errorf("%s: container '%s' does not exist", internal.PodMonitorAnnotation, out.Container)
+		}
+	} else if len(containerNames) != 1 {
+		return nil, errors.Errorf("%s: expected <container>:<port> since the workload has more than one container", internal.PodMonitorAnnotation)
+	} else {
+		out.Container = containerNames[0]
+	}
+	port, err := strconv.Atoi(rawPort)
+	if err != nil || port < 1 || port > 65535 {
+		return nil, errors.Errorf("%s: '%s' is not a valid port number", internal.PodMonitorAnnotation, rawPort)
+	}
+	out.Port = port
+	if v, ok := internal.FindAnnotation(metadata, internal.PodMonitorPathAnnotation); ok && v != "" {
+		out.Path = v
+	}
+	return out, nil
+}
+
+// buildPodMonitor returns a Prometheus Operator PodMonitor that scrapes the named metrics port of the pods matching the
+// selector. This is returned as an unstructured object since the Prometheus Operator types are not in our scheme.
+func buildPodMonitor(name string, target *podMonitorTarget, labels, annotations, selector map[string]string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "monitoring.coreos.com/v1",
+		"kind":       "PodMonitor",
+		"metadata": map[string]interface{}{
+			"name":        name,
+			"labels":      toInterfaceMap(labels),
+			"annotations": toInterfaceMap(annotations),
+		},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchLabels": toInterfaceMap(selector),
+			},
+			"podMetricsEndpoints": []interface{}{
+				map[string]interface{}{"port": podMonitorPortName, "path": target.Path},
+			},
+		},
+	}}
+}
+
+// toInterfaceMap converts a string map into the form used by unstructured objects.
+func toInterfaceMap(in map[string]string) map[string]interface{} {
+	out := make(map[string]interface{}, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
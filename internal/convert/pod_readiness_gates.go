@@ -0,0 +1,53 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// parseReadinessGatesAnnotation reads the comma-separated list of pod condition types in the readiness-gates
+// annotation and returns the readinessGates for the pod, or nil if the annotation is not set.
+func parseReadinessGatesAnnotation(metadata map[string]interface{}) ([]coreV1.PodReadinessGate, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.ReadinessGatesAnnotation)
+	if !ok {
+		return nil, nil
+	}
+	out := make([]coreV1.PodReadinessGate, 0)
+	seen := make([]string, 0)
+	for _, part := range strings.Split(raw, ",") {
+		conditionType := strings.TrimSpace(part)
+		if conditionType == "" {
+			continue
+		} else if errs := validation.IsQualifiedName(conditionType); len(errs) > 0 {
+			return nil, errors.Errorf("%s: '%s' is not a valid condition type: %s", internal.ReadinessGatesAnnotation, conditionType, strings.Join(errs, ", "))
+		} else if slices.Contains(seen, conditionType) {
+			return nil, errors.Errorf("%s: '%s' is listed more than once", internal.ReadinessGatesAnnotation, conditionType)
+		}
+		seen = append(seen, conditionType)
+		out = append(out, coreV1.PodReadinessGate{ConditionType: coreV1.PodConditionType(conditionType)})
+	}
+	if len(out) == 0 {
+		return nil, errors.Errorf("%s: must contain at least one condition type", internal.ReadinessGatesAnnotation)
+	}
+	return out, nil
+}
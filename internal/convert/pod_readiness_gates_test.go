@@ -0,0 +1,58 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+func Test_parseReadinessGatesAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		value    *string
+		expected []coreV1.PodReadinessGate
+		err      string
+	}{
+		{name: "none"},
+		{name: "single", value: internal.Ref("target-health.elbv2.k8s.aws/my-tg"), expected: []coreV1.PodReadinessGate{
+			{ConditionType: "target-health.elbv2.k8s.aws/my-tg"},
+		}},
+		{name: "multiple", value: internal.Ref("example.com/ready, MeshReady"), expected: []coreV1.PodReadinessGate{
+			{ConditionType: "example.com/ready"}, {ConditionType: "MeshReady"},
+		}},
+		{name: "empty", value: internal.Ref(" , "), err: "k8s.score.dev/readiness-gates: must contain at least one condition type"},
+		{name: "duplicate", value: internal.Ref("a,a"), err: "k8s.score.dev/readiness-gates: 'a' is listed more than once"},
+		{name: "invalid", value: internal.Ref("not valid"), err: "k8s.score.dev/readiness-gates: 'not valid' is not a valid condition type: name part must consist of alphanumeric characters, '-', '_' or '.', and must start and end with an alphanumeric character (e.g. 'MyName',  or 'my.name',  or '123-abc', regex used for validation is '([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]')"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			metadata := map[string]interface{}{}
+			if tc.value != nil {
+				metadata["annotations"] = map[string]interface{}{"k8s.score.dev/readiness-gates": *tc.value}
+			}
+			out, err := parseReadinessGatesAnnotation(metadata)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, out)
+			}
+		})
+	}
+}
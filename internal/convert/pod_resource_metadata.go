@@ -0,0 +1,76 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/score-spec/score-go/framework"
+	scoretypes "github.com/score-spec/score-go/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+// applyResourcePodMetadata adds the pod labels and annotations provided by the resources referenced by the workload
+// to the given pod labels and annotations. Keys that are already set, by the workload or by score-k8s itself, take
+// precedence and are left unchanged. Two resources that set the same key to different values is an error.
+func applyResourcePodMetadata(spec *scoretypes.Workload, workloadName string, resources map[framework.ResourceUid]framework.ScoreResourceState[project.ResourceExtras], podLabels, podAnnotations map[string]string) error {
+	labels := make(map[string]string)
+	labelSources := make(map[string]string)
+	annotations := make(map[string]string)
+	annotationSources := make(map[string]string)
+	for _, resName := range slices.Sorted(maps.Keys(spec.Resources)) {
+		res := spec.Resources[resName]
+		resUid := framework.NewResourceUid(workloadName, resName, res.Type, res.Class, res.Id)
+		extras := resources[resUid].Extras
+		for _, k := range slices.Sorted(maps.Keys(extras.PodLabels)) {
+			v := extras.PodLabels[k]
+			if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+				return fmt.Errorf("resources.%s: pod label '%s' is invalid: %s", resName, k, strings.Join(errs, "; "))
+			} else if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+				return fmt.Errorf("resources.%s: pod label '%s' value is invalid: %s", resName, k, strings.Join(errs, "; "))
+			}
+			if existing, ok := labels[k]; ok && existing != v {
+				return fmt.Errorf("resources.%s: pod label '%s' conflicts with the value from resources.%s", resName, k, labelSources[k])
+			}
+			labels[k], labelSources[k] = v, resName
+		}
+		for _, k := range slices.Sorted(maps.Keys(extras.PodAnnotations)) {
+			v := extras.PodAnnotations[k]
+			if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+				return fmt.Errorf("resources.%s: pod annotation '%s' is invalid: %s", resName, k, strings.Join(errs, "; "))
+			}
+			if existing, ok := annotations[k]; ok && existing != v {
+				return fmt.Errorf("resources.%s: pod annotation '%s' conflicts with the value from resources.%s", resName, k, annotationSources[k])
+			}
+			annotations[k], annotationSources[k] = v, resName
+		}
+	}
+	for k, v := range labels {
+		if _, ok := podLabels[k]; !ok {
+			podLabels[k] = v
+		}
+	}
+	for k, v := range annotations {
+		if _, ok := podAnnotations[k]; !ok {
+			podAnnotations[k] = v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// parseRuntimeClassAnnotation reads the runtime-class annotation and returns the runtimeClassName for the pod, or nil
+// if the annotation is not set.
+func parseRuntimeClassAnnotation(metadata map[string]interface{}) (*string, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.RuntimeClassAnnotation)
+	if !ok {
+		return nil, nil
+	}
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, errors.Errorf("%s: must not be empty", internal.RuntimeClassAnnotation)
+	} else if errs := validation.IsDNS1123Subdomain(raw); len(errs) > 0 {
+		return nil, errors.Errorf("%s: '%s' is not a valid name: %s", internal.RuntimeClassAnnotation, raw, strings.Join(errs, ", "))
+	}
+	return &raw, nil
+}
@@ -0,0 +1,39 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// parseShareProcessNamespaceAnnotation reads the share-process-namespace annotation which sets shareProcessNamespace
+// on the pod so that sidecars such as debugging tools can see and signal the processes of the other containers. This
+// defaults to false.
+func parseShareProcessNamespaceAnnotation(metadata map[string]interface{}) (bool, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.ShareProcessNamespaceAnnotation)
+	if !ok {
+		return false, nil
+	}
+	v, err := strconv.ParseBool(strings.TrimSpace(raw))
+	if err != nil {
+		return false, errors.Errorf("%s: '%s' is not a boolean", internal.ShareProcessNamespaceAnnotation, raw)
+	}
+	return v, nil
+}
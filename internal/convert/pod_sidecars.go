@@ -0,0 +1,123 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/score-spec/score-go/framework"
+	scoretypes "github.com/score-spec/score-go/types"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+// resourceSidecar is the decoded form of each item in the sidecars output of a provisioner.
+type resourceSidecar struct {
+	Container coreV1.Container `json:"container"`
+	Volumes   []coreV1.Volume  `json:"volumes,omitempty"`
+}
+
+// buildResourceSidecars returns the sidecar containers and volumes provided by the resources referenced by the
+// workload. When more than one resource provides a sidecar with the same name, the image must match and the env and
+// volume mounts are merged. Sidecars may not share a name with a workload container and volumes with the same name
+// must be identical.
+func buildResourceSidecars(spec *scoretypes.Workload, workloadName string, resources map[framework.ResourceUid]framework.ScoreResourceState[project.ResourceExtras], containerNames []string, volumes []coreV1.Volume) ([]coreV1.Container, []coreV1.Volume, error) {
+	sidecars := make([]coreV1.Container, 0)
+	sidecarVolumes := make([]coreV1.Volume, 0)
+	for _, resName := range slices.Sorted(maps.Keys(spec.Resources)) {
+		res := spec.Resources[resName]
+		resUid := framework.NewResourceUid(workloadName, resName, res.Type, res.Class, res.Id)
+		for i, raw := range resources[resUid].Extras.Sidecars {
+			sidecar, err := decodeResourceSidecar(raw)
+			if err != nil {
+				return nil, nil, fmt.Errorf("resources.%s: sidecars.%d: %w", resName, i, err)
+			}
+			name := sidecar.Container.Name
+			if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+				return nil, nil, fmt.Errorf("resources.%s: sidecars.%d: container name '%s' is invalid: %v", resName, i, name, errs)
+			} else if slices.Contains(containerNames, name) {
+				return nil, nil, fmt.Errorf("resources.%s: sidecars.%d: container name '%s' conflicts with a workload container", resName, i, name)
+			}
+			if j := slices.IndexFunc(sidecars, func(c coreV1.Container) bool { return c.Name == name }); j >= 0 {
+				if err := mergeSidecarContainer(&sidecars[j], sidecar.Container); err != nil {
+					return nil, nil, fmt.Errorf("resources.%s: sidecars.%d: %w", resName, i, err)
+				}
+			} else {
+				sidecars = append(sidecars, sidecar.Container)
+			}
+			for _, vol := range sidecar.Volumes {
+				existing := slices.IndexFunc(volumes, func(v coreV1.Volume) bool { return v.Name == vol.Name })
+				if existing >= 0 && !reflect.DeepEqual(volumes[existing], vol) {
+					return nil, nil, fmt.Errorf("resources.%s: sidecars.%d: volume '%s' conflicts with a workload volume", resName, i, vol.Name)
+				}
+				existing = slices.IndexFunc(sidecarVolumes, func(v coreV1.Volume) bool { return v.Name == vol.Name })
+				if existing < 0 {
+					sidecarVolumes = append(sidecarVolumes, vol)
+				} else if !reflect.DeepEqual(sidecarVolumes[existing], vol) {
+					return nil, nil, fmt.Errorf("resources.%s: sidecars.%d: volume '%s' conflicts with a volume from another sidecar", resName, i, vol.Name)
+				}
+			}
+		}
+	}
+	return sidecars, sidecarVolumes, nil
+}
+
+func decodeResourceSidecar(raw map[string]interface{}) (*resourceSidecar, error) {
+	intermediate, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(intermediate))
+	dec.DisallowUnknownFields()
+	out := new(resourceSidecar)
+	if err := dec.Decode(out); err != nil {
+		return nil, fmt.Errorf("failed to decode: %w", err)
+	}
+	if out.Container.Name == "" {
+		return nil, fmt.Errorf("container name is not set")
+	} else if out.Container.Image == "" {
+		return nil, fmt.Errorf("container image is not set")
+	}
+	return out, nil
+}
+
+// mergeSidecarContainer merges the env and volume mounts of a second definition of the same sidecar into the first.
+func mergeSidecarContainer(existing *coreV1.Container, other coreV1.Container) error {
+	if existing.Image != other.Image {
+		return fmt.Errorf("sidecar '%s' is already defined with image '%s'", existing.Name, existing.Image)
+	}
+	for _, env := range other.Env {
+		if j := slices.IndexFunc(existing.Env, func(e coreV1.EnvVar) bool { return e.Name == env.Name }); j < 0 {
+			existing.Env = append(existing.Env, env)
+		} else if !reflect.DeepEqual(existing.Env[j], env) {
+			return fmt.Errorf("sidecar '%s' env var '%s' conflicts with an existing definition", existing.Name, env.Name)
+		}
+	}
+	for _, mount := range other.VolumeMounts {
+		if j := slices.IndexFunc(existing.VolumeMounts, func(m coreV1.VolumeMount) bool { return m.MountPath == mount.MountPath }); j < 0 {
+			existing.VolumeMounts = append(existing.VolumeMounts, mount)
+		} else if !reflect.DeepEqual(existing.VolumeMounts[j], mount) {
+			return fmt.Errorf("sidecar '%s' volume mount '%s' conflicts with an existing definition", existing.Name, mount.MountPath)
+		}
+	}
+	return nil
+}
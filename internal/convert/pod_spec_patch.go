@@ -0,0 +1,102 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// reservedContainerPatchFields are the container fields that come from the Score workload and must be changed there
+// rather than through the pod-spec-patch annotation.
+var reservedContainerPatchFields = []string{"image", "command", "args"}
+
+// parsePodSpecPatchAnnotation reads the raw pod spec fragment in the pod-spec-patch annotation, or returns nil if
+// the annotation is not set. The fields of the Score containers listed in reservedContainerPatchFields may not be
+// patched.
+func parsePodSpecPatchAnnotation(metadata map[string]interface{}, containerNames []string) (map[string]interface{}, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.PodSpecPatchAnnotation)
+	if !ok {
+		return nil, nil
+	}
+	var patch map[string]interface{}
+	if err := yaml.Unmarshal([]byte(raw), &patch); err != nil {
+		return nil, errors.Wrapf(err, "%s: failed to parse", internal.PodSpecPatchAnnotation)
+	} else if len(patch) == 0 {
+		return nil, errors.Errorf("%s: must be a non-empty map", internal.PodSpecPatchAnnotation)
+	}
+	if rawContainers, ok := patch["containers"]; ok {
+		patchContainers, ok := rawContainers.([]interface{})
+		if !ok {
+			return nil, errors.Errorf("%s: containers: must be a list", internal.PodSpecPatchAnnotation)
+		}
+		for i, rawContainer := range patchContainers {
+			container, ok := rawContainer.(map[string]interface{})
+			if !ok {
+				return nil, errors.Errorf("%s: containers.%d: must be a map", internal.PodSpecPatchAnnotation, i)
+			}
+			name, _ := container["name"].(string)
+			if name == "" {
+				return nil, errors.Errorf("%s: containers.%d: name is not set", internal.PodSpecPatchAnnotation, i)
+			} else if !slices.Contains(containerNames, name) {
+				continue
+			}
+			for _, field := range reservedContainerPatchFields {
+				if _, ok := container[field]; ok {
+					return nil, errors.Errorf("%s: containers.%d: %s of container '%s' is set by the Score workload and cannot be patched", internal.PodSpecPatchAnnotation, i, field, name)
+				}
+			}
+		}
+	}
+	return patch, nil
+}
+
+// applyPodSpecPatch strategically merges the patch into the pod spec, the same way as kubectl patch does, and checks
+// that the result is still a valid pod spec.
+func applyPodSpecPatch(podSpec *coreV1.PodSpec, patch map[string]interface{}) error {
+	original, err := json.Marshal(podSpec)
+	if err != nil {
+		return errors.Wrapf(err, "%s: failed to encode pod spec", internal.PodSpecPatchAnnotation)
+	}
+	rawPatch, err := json.Marshal(patch)
+	if err != nil {
+		return errors.Wrapf(err, "%s: failed to encode patch", internal.PodSpecPatchAnnotation)
+	}
+	patched, err := strategicpatch.StrategicMergePatch(original, rawPatch, coreV1.PodSpec{})
+	if err != nil {
+		return errors.Wrapf(err, "%s: failed to patch", internal.PodSpecPatchAnnotation)
+	}
+	dec := json.NewDecoder(bytes.NewReader(patched))
+	dec.DisallowUnknownFields()
+	out := coreV1.PodSpec{}
+	if err := dec.Decode(&out); err != nil {
+		return errors.Wrapf(err, "%s: patched pod spec is invalid", internal.PodSpecPatchAnnotation)
+	}
+	for i, c := range out.Containers {
+		if c.Image == "" {
+			return errors.Errorf("%s: patched pod spec is invalid: containers.%d: image is not set", internal.PodSpecPatchAnnotation, i)
+		}
+	}
+	*podSpec = out
+	return nil
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coreV1 "k8s.io/api/core/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+func Test_parsePodSpecPatchAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		value    *string
+		expected map[string]interface{}
+		err      string
+	}{
+		{name: "none"},
+		{name: "valid", value: internal.Ref("{hostNetwork: true, containers: [{name: main, stdin: true}]}"), expected: map[string]interface{}{
+			"hostNetwork": true,
+			"containers":  []interface{}{map[string]interface{}{"name": "main", "stdin": true}},
+		}},
+		{name: "new container", value: internal.Ref("{containers: [{name: debug, image: busybox}]}"), expected: map[string]interface{}{
+			"containers": []interface{}{map[string]interface{}{"name": "debug", "image": "busybox"}},
+		}},
+		{name: "empty", value: internal.Ref("{}"), err: "k8s.score.dev/pod-spec-patch: must be a non-empty map"},
+		{name: "not a map", value: internal.Ref("[]"), err: "k8s.score.dev/pod-spec-patch: failed to parse: yaml: unmarshal errors:\n  line 1: cannot unmarshal !!seq into map[string]interface {}"},
+		{name: "container without name", value: internal.Ref("{containers: [{stdin: true}]}"), err: "k8s.score.dev/pod-spec-patch: containers.0: name is not set"},
+		{name: "reserved", value: internal.Ref("{containers: [{name: main, image: other}]}"), err: "k8s.score.dev/pod-spec-patch: containers.0: image of container 'main' is set by the Score workload and cannot be patched"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			metadata := map[string]interface{}{}
+			if tc.value != nil {
+				metadata["annotations"] = map[string]interface{}{"k8s.score.dev/pod-spec-patch": *tc.value}
+			}
+			out, err := parsePodSpecPatchAnnotation(metadata, []string{"main"})
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, out)
+			}
+		})
+	}
+}
+
+func Test_applyPodSpecPatch(t *testing.T) {
+	podSpec := coreV1.PodSpec{
+		Containers: []coreV1.Container{
+			{Name: "main", Image: "my-image", Env: []coreV1.EnvVar{{Name: "A", Value: "1"}}},
+			{Name: "other", Image: "other-image"},
+		},
+	}
+	require.NoError(t, applyPodSpecPatch(&podSpec, map[string]interface{}{
+		"dnsPolicy": "None",
+		"containers": []interface{}{
+			map[string]interface{}{"name": "main", "env": []interface{}{map[string]interface{}{"name": "B", "value": "2"}}},
+		},
+	}))
+	assert.Equal(t, coreV1.PodSpec{
+		DNSPolicy: coreV1.DNSNone,
+		Containers: []coreV1.Container{
+			{Name: "main", Image: "my-image", Env: []coreV1.EnvVar{{Name: "B", Value: "2"}, {Name: "A", Value: "1"}}},
+			{Name: "other", Image: "other-image"},
+		},
+	}, podSpec)
+
+	assert.EqualError(t, applyPodSpecPatch(&podSpec, map[string]interface{}{"unknown": true}), "k8s.score.dev/pod-spec-patch: patched pod spec is invalid: json: unknown field \"unknown\"")
+	assert.EqualError(t, applyPodSpecPatch(&podSpec, map[string]interface{}{
+		"containers": []interface{}{map[string]interface{}{"name": "debug", "stdin": true}},
+	}), "k8s.score.dev/pod-spec-patch: patched pod spec is invalid: containers.0: image is not set")
+}
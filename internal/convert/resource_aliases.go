@@ -0,0 +1,67 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/score-spec/score-go/framework"
+	"gopkg.in/yaml.v3"
+
+	"github.com/score-spec/score-k8s/internal"
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+// addResourceAliases reads the resource-aliases annotation of the workload and adds each alias to the resource outputs
+// so that ${resources.<alias>.<key>} placeholders resolve to the target resource. The annotation is a yaml map of
+// alias to target, where the target is either the name of a resource in the workload, or <type>.<class>.<id> of any
+// resource in the project.
+func addResourceAliases(state *project.State, workloadName string, resOutputs map[string]framework.OutputLookupFunc) error {
+	metadata := state.Workloads[workloadName].Spec.Metadata
+	raw, ok := internal.FindAnnotation(metadata, internal.ResourceAliasesAnnotation)
+	if !ok {
+		return nil
+	}
+	var aliases map[string]string
+	if err := yaml.Unmarshal([]byte(raw), &aliases); err != nil {
+		return errors.Wrapf(err, "%s: failed to decode map of aliases", internal.ResourceAliasesAnnotation)
+	}
+
+	// resolve all aliases against the original outputs so that aliases cannot refer to other aliases
+	original := maps.Clone(resOutputs)
+	for _, alias := range slices.Sorted(maps.Keys(aliases)) {
+		target := aliases[alias]
+		if _, ok := original[alias]; ok {
+			return errors.Errorf("%s: alias '%s' conflicts with an existing resource", internal.ResourceAliasesAnnotation, alias)
+		}
+		if lookup, ok := original[target]; ok {
+			resOutputs[alias] = lookup
+			continue
+		}
+		parts := strings.SplitN(target, ".", 3)
+		if len(parts) != 3 {
+			return errors.Errorf("%s: alias '%s': resource '%s' does not exist", internal.ResourceAliasesAnnotation, alias, target)
+		}
+		resState, ok := state.Resources[framework.ResourceUid(parts[0]+"."+parts[1]+"#"+parts[2])]
+		if !ok {
+			return errors.Errorf("%s: alias '%s': resource '%s' does not exist", internal.ResourceAliasesAnnotation, alias, target)
+		}
+		resOutputs[alias] = resState.OutputLookup
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	coreV1 "k8s.io/api/core/v1"
+	machineryMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	utiljson "k8s.io/apimachinery/pkg/util/json"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// rolloutStrategy is the decoded rollout-strategy annotation. Exactly one of the strategies is set and its fields are
+// passed through to the Rollout as they are.
+type rolloutStrategy struct {
+	Canary    map[string]interface{} `yaml:"canary"`
+	BlueGreen map[string]interface{} `yaml:"blueGreen"`
+}
+
+// rolloutCanaryFields and rolloutBlueGreenFields are the fields of the argoproj.io/v1alpha1 strategies. These are
+// checked so that a typo does not silently fall back to the default behavior of the Argo Rollouts controller.
+var (
+	rolloutCanaryFields = []string{
+		"canaryService", "stableService", "steps", "trafficRouting", "maxUnavailable", "maxSurge", "analysis",
+		"antiAffinity", "canaryMetadata", "stableMetadata", "scaleDownDelaySeconds", "scaleDownDelayRevisionLimit",
+		"abortScaleDownDelaySeconds", "dynamicStableScale", "minPodsPerReplicaSet", "pingPong",
+	}
+	rolloutBlueGreenFields = []string{
+		"activeService", "previewService", "prePromotionAnalysis", "postPromotionAnalysis", "previewReplicaCount",
+		"autoPromotionEnabled", "autoPromotionSeconds", "scaleDownDelaySeconds", "scaleDownDelayRevisionLimit",
+		"abortScaleDownDelaySeconds", "antiAffinity", "previewMetadata", "activeMetadata",
+	}
+)
+
+// parseRolloutStrategyAnnotation reads the rollout-strategy annotation. A Rollout without the annotation gets a canary
+// strategy without steps, which the Argo Rollouts controller handles like a rolling update.
+func parseRolloutStrategyAnnotation(metadata map[string]interface{}, kind string) (*rolloutStrategy, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.RolloutStrategyAnnotation)
+	if !ok {
+		if kind == WorkloadKindRollout {
+			return &rolloutStrategy{Canary: map[string]interface{}{}}, nil
+		}
+		return nil, nil
+	}
+	if kind != WorkloadKindRollout {
+		return nil, errors.Errorf("%s: is only supported for %s workloads", internal.RolloutStrategyAnnotation, WorkloadKindRollout)
+	}
+	var out rolloutStrategy
+	dec := yaml.NewDecoder(bytes.NewReader([]byte(raw)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&out); err != nil {
+		return nil, errors.Wrapf(err, "%s: failed to decode", internal.RolloutStrategyAnnotation)
+	}
+	if (out.Canary == nil) == (out.BlueGreen == nil) {
+		return nil, errors.Errorf("%s: exactly one of canary or blueGreen must be set", internal.RolloutStrategyAnnotation)
+	}
+	for _, section := range []struct {
+		name     string
+		strategy map[string]interface{}
+		fields   []string
+	}{{"canary", out.Canary, rolloutCanaryFields}, {"blueGreen", out.BlueGreen, rolloutBlueGreenFields}} {
+		for k := range section.strategy {
+			if !slices.Contains(section.fields, k) {
+				return nil, errors.Errorf("%s: %s: unknown field '%s', expected one of %s", internal.RolloutStrategyAnnotation, section.name, k, strings.Join(section.fields, ", "))
+			}
+		}
+	}
+	return &out, nil
+}
+
+// buildRollout returns an Argo Rollout for the pod template along with the Services that its strategy switches
+// between. A canary strategy gets <service>-canary and <service>-stable Services and a blueGreen strategy uses the
+// workload Service as the active Service and gets a <service>-preview Service. These are only added when the
+// workload has a Service and the strategy does not already name them. The Rollout is returned as an unstructured
+// object since the Argo Rollouts types are not in our scheme.
+func buildRollout(
+	name string, strategy *rolloutStrategy, service *coreV1.Service, template *coreV1.PodTemplateSpec,
+	labels, annotations, selector map[string]string,
+) ([]machineryMeta.Object, error) {
+	manifests := make([]machineryMeta.Object, 0, 3)
+	addService := func(suffix string) string {
+		out := service.DeepCopy()
+		out.Name = service.Name + "-" + suffix
+		manifests = append(manifests, out)
+		return out.Name
+	}
+
+	var strategyKey string
+	var strategySpec map[string]interface{}
+	if strategy.Canary != nil {
+		strategyKey, strategySpec = "canary", strategy.Canary
+		_, hasCanary := strategySpec["canaryService"]
+		_, hasStable := strategySpec["stableService"]
+		if service != nil && !hasCanary && !hasStable {
+			strategySpec["canaryService"] = addService("canary")
+			strategySpec["stableService"] = addService("stable")
+		}
+	} else {
+		strategyKey, strategySpec = "blueGreen", strategy.BlueGreen
+		if _, ok := strategySpec["activeService"]; !ok {
+			if service == nil {
+				return nil, errors.Errorf("%s: blueGreen: activeService must be set when the workload has no service", internal.RolloutStrategyAnnotation)
+			}
+			strategySpec["activeService"] = service.Name
+			if _, ok := strategySpec["previewService"]; !ok {
+				strategySpec["previewService"] = addService("preview")
+			}
+		}
+	}
+
+	// round trip the strategy through json so that it only contains the value types supported by unstructured objects
+	raw, err := json.Marshal(strategySpec)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%s: %s: failed to encode", internal.RolloutStrategyAnnotation, strategyKey)
+	}
+	var outStrategy map[string]interface{}
+	if err := utiljson.Unmarshal(raw, &outStrategy); err != nil {
+		return nil, errors.Wrapf(err, "%s: %s: failed to decode", internal.RolloutStrategyAnnotation, strategyKey)
+	}
+
+	outTemplate, err := runtime.DefaultUnstructuredConverter.ToUnstructured(template)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to convert the pod template")
+	}
+
+	manifests = append(manifests, &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "argoproj.io/v1alpha1",
+		"kind":       WorkloadKindRollout,
+		"metadata": map[string]interface{}{
+			"name":        name,
+			"labels":      toInterfaceMap(labels),
+			"annotations": toInterfaceMap(annotations),
+		},
+		"spec": map[string]interface{}{
+			"selector": map[string]interface{}{
+				"matchLabels": toInterfaceMap(selector),
+			},
+			"template": outTemplate,
+			"strategy": map[string]interface{}{strategyKey: outStrategy},
+		},
+	}})
+	return manifests, nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+func Test_parseRolloutStrategyAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		value    *string
+		kind     string
+		expected *rolloutStrategy
+		err      string
+	}{
+		{name: "none"},
+		{name: "default canary", kind: WorkloadKindDeployment},
+		{name: "rollout default", kind: WorkloadKindRollout, expected: &rolloutStrategy{Canary: map[string]interface{}{}}},
+		{
+			name: "canary", kind: WorkloadKindRollout, value: internal.Ref("{canary: {steps: [{setWeight: 20}]}}"),
+			expected: &rolloutStrategy{Canary: map[string]interface{}{"steps": []interface{}{map[string]interface{}{"setWeight": 20}}}},
+		},
+		{
+			name: "blue green", kind: WorkloadKindRollout, value: internal.Ref("{blueGreen: {autoPromotionEnabled: false}}"),
+			expected: &rolloutStrategy{BlueGreen: map[string]interface{}{"autoPromotionEnabled": false}},
+		},
+		{name: "deployment", kind: WorkloadKindDeployment, value: internal.Ref("{canary: {}}"), err: "k8s.score.dev/rollout-strategy: is only supported for Rollout workloads"},
+		{name: "neither", kind: WorkloadKindRollout, value: internal.Ref("{}"), err: "k8s.score.dev/rollout-strategy: exactly one of canary or blueGreen must be set"},
+		{name: "both", kind: WorkloadKindRollout, value: internal.Ref("{canary: {}, blueGreen: {}}"), err: "k8s.score.dev/rollout-strategy: exactly one of canary or blueGreen must be set"},
+		{name: "unknown strategy", kind: WorkloadKindRollout, value: internal.Ref("{recreate: {}}"), err: "k8s.score.dev/rollout-strategy: failed to decode: yaml: unmarshal errors:\n  line 1: field recreate not found in type convert.rolloutStrategy"},
+		{
+			name: "unknown field", kind: WorkloadKindRollout, value: internal.Ref("{blueGreen: {autoPromote: true}}"),
+			err: "k8s.score.dev/rollout-strategy: blueGreen: unknown field 'autoPromote', expected one of activeService, previewService, prePromotionAnalysis, postPromotionAnalysis, previewReplicaCount, autoPromotionEnabled, autoPromotionSeconds, scaleDownDelaySeconds, scaleDownDelayRevisionLimit, abortScaleDownDelaySeconds, antiAffinity, previewMetadata, activeMetadata",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			metadata := map[string]interface{}{}
+			if tc.value != nil {
+				metadata["annotations"] = map[string]interface{}{"k8s.score.dev/rollout-strategy": *tc.value}
+			}
+			kind := tc.kind
+			if kind == "" {
+				kind = WorkloadKindDeployment
+			}
+			out, err := parseRolloutStrategyAnnotation(metadata, kind)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, out)
+			}
+		})
+	}
+}
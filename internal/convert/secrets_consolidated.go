@@ -0,0 +1,118 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"encoding/base64"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"slices"
+
+	"github.com/pkg/errors"
+	"github.com/score-spec/score-go/framework"
+	coreV1 "k8s.io/api/core/v1"
+	machineryMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+// resourceSecretValues returns the decoded values of the Secrets in the manifests of the provisioned resources, by
+// Secret name and key. Values in stringData take precedence over data like they do in Kubernetes.
+func resourceSecretValues(resources map[framework.ResourceUid]framework.ScoreResourceState[project.ResourceExtras]) (map[string]map[string][]byte, error) {
+	out := make(map[string]map[string][]byte)
+	for _, resUid := range slices.Sorted(maps.Keys(resources)) {
+		for i, manifest := range resources[resUid].Extras.Manifests {
+			if manifest["apiVersion"] != "v1" || manifest["kind"] != "Secret" {
+				continue
+			}
+			metadata, _ := manifest["metadata"].(map[string]interface{})
+			name, _ := metadata["name"].(string)
+			if name == "" {
+				continue
+			}
+			values := make(map[string][]byte)
+			data, _ := manifest["data"].(map[string]interface{})
+			for k, v := range data {
+				decoded, err := base64.StdEncoding.DecodeString(fmt.Sprint(v))
+				if err != nil {
+					return nil, errors.Errorf("resource '%s': manifests.%d: data.%s: invalid base64 value", resUid, i, k)
+				}
+				values[k] = decoded
+			}
+			stringData, _ := manifest["stringData"].(map[string]interface{})
+			for k, v := range stringData {
+				values[k] = []byte(fmt.Sprint(v))
+			}
+			out[name] = values
+		}
+	}
+	return out, nil
+}
+
+// consolidateSecretEnv copies the values of the env vars of the containers that refer to a key of a Secret generated by
+// a provisioner into a single Secret for the workload, and rewrites these env vars to refer to it. The keys of the
+// Secret are <secret>.<key> so that the same value is only stored once. Env vars that refer to other Secrets, such as
+// existing Secrets in the cluster, are left as they are since their values are unknown. No Secret is returned when no
+// env var was rewritten.
+func consolidateSecretEnv(workloadName string, secretValues map[string]map[string][]byte, immutable bool, containers ...[]coreV1.Container) *coreV1.Secret {
+	secret := &coreV1.Secret{
+		TypeMeta:   machineryMeta.TypeMeta{Kind: "Secret", APIVersion: "v1"},
+		ObjectMeta: machineryMeta.ObjectMeta{Name: workloadName + "-env"},
+		Type:       coreV1.SecretTypeOpaque,
+		Data:       make(map[string][]byte),
+	}
+	refs := make([]*coreV1.SecretKeySelector, 0)
+	for _, list := range containers {
+		for i := range list {
+			for _, env := range list[i].Env {
+				if env.ValueFrom == nil || env.ValueFrom.SecretKeyRef == nil {
+					continue
+				}
+				ref := env.ValueFrom.SecretKeyRef
+				value, ok := secretValues[ref.Name][ref.Key]
+				if !ok {
+					continue
+				}
+				key := ref.Name + "." + ref.Key
+				secret.Data[key] = value
+				ref.Name, ref.Key = "", key
+				refs = append(refs, ref)
+			}
+		}
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+	if immutable {
+		makeSecretImmutable(secret)
+	}
+	for _, ref := range refs {
+		ref.Name = secret.Name
+	}
+	return secret
+}
+
+// makeSecretImmutable marks the secret as immutable and appends a hash of its content to the name in the same way as
+// makeConfigMapImmutable.
+func makeSecretImmutable(secret *coreV1.Secret) {
+	h := fnv.New64a()
+	for _, k := range slices.Sorted(maps.Keys(secret.Data)) {
+		_, _ = fmt.Fprintf(h, "%s=%s\n", k, secret.Data[k])
+	}
+	secret.Name = fmt.Sprintf("%s-%x", secret.Name, h.Sum(nil))[:len(secret.Name)+1+10]
+	secret.Immutable = internal.Ref(true)
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/score-spec/score-go/framework"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	coreV1 "k8s.io/api/core/v1"
+
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+func Test_resourceSecretValues(t *testing.T) {
+	resources := map[framework.ResourceUid]framework.ScoreResourceState[project.ResourceExtras]{
+		"postgres.default#example.db": {Extras: project.ResourceExtras{Manifests: []map[string]interface{}{
+			{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "pg-config"}, "data": map[string]interface{}{"a": "b"}},
+			{
+				"apiVersion": "v1", "kind": "Secret", "metadata": map[string]interface{}{"name": "pg-secret"},
+				"data":       map[string]interface{}{"password": "cGFzcw==", "username": "dXNlcg=="},
+				"stringData": map[string]interface{}{"username": "admin", "port": 5432},
+			},
+		}}},
+	}
+	out, err := resourceSecretValues(resources)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]map[string][]byte{
+		"pg-secret": {"password": []byte("pass"), "username": []byte("admin"), "port": []byte("5432")},
+	}, out)
+
+	resources["postgres.default#example.db"].Extras.Manifests[1]["data"] = map[string]interface{}{"password": "%%%"}
+	_, err = resourceSecretValues(resources)
+	assert.EqualError(t, err, "resource 'postgres.default#example.db': manifests.1: data.password: invalid base64 value")
+}
+
+func Test_consolidateSecretEnv(t *testing.T) {
+	secretRef := func(name, key string) *coreV1.EnvVarSource {
+		return &coreV1.EnvVarSource{SecretKeyRef: &coreV1.SecretKeySelector{LocalObjectReference: coreV1.LocalObjectReference{Name: name}, Key: key}}
+	}
+	values := map[string]map[string][]byte{"pg-secret": {"password": []byte("pass"), "username": []byte("admin")}}
+
+	t.Run("none", func(t *testing.T) {
+		containers := []coreV1.Container{{Name: "main", Env: []coreV1.EnvVar{
+			{Name: "PLAIN", Value: "x"},
+			{Name: "EXTERNAL", ValueFrom: secretRef("existing", "token")},
+		}}}
+		assert.Nil(t, consolidateSecretEnv("example", values, false, containers))
+		assert.Equal(t, secretRef("existing", "token"), containers[0].Env[1].ValueFrom)
+	})
+
+	t.Run("rewritten", func(t *testing.T) {
+		containers := []coreV1.Container{{Name: "main", Env: []coreV1.EnvVar{
+			{Name: "PASSWORD", ValueFrom: secretRef("pg-secret", "password")},
+			{Name: "EXTERNAL", ValueFrom: secretRef("existing", "token")},
+		}}}
+		initContainers := []coreV1.Container{{Name: "migrate", Env: []coreV1.EnvVar{
+			{Name: "DB_PASSWORD", ValueFrom: secretRef("pg-secret", "password")},
+			{Name: "DB_USER", ValueFrom: secretRef("pg-secret", "username")},
+		}}}
+		secret := consolidateSecretEnv("example", values, false, containers, initContainers)
+		require.NotNil(t, secret)
+		assert.Equal(t, "example-env", secret.Name)
+		assert.Equal(t, map[string][]byte{"pg-secret.password": []byte("pass"), "pg-secret.username": []byte("admin")}, secret.Data)
+		assert.Equal(t, secretRef("example-env", "pg-secret.password"), containers[0].Env[0].ValueFrom)
+		assert.Equal(t, secretRef("existing", "token"), containers[0].Env[1].ValueFrom)
+		assert.Equal(t, secretRef("example-env", "pg-secret.password"), initContainers[0].Env[0].ValueFrom)
+		assert.Equal(t, secretRef("example-env", "pg-secret.username"), initContainers[0].Env[1].ValueFrom)
+	})
+
+	t.Run("immutable", func(t *testing.T) {
+		containers := []coreV1.Container{{Name: "main", Env: []coreV1.EnvVar{
+			{Name: "PASSWORD", ValueFrom: secretRef("pg-secret", "password")},
+		}}}
+		secret := consolidateSecretEnv("example", values, true, containers)
+		require.NotNil(t, secret)
+		assert.Regexp(t, `^example-env-[0-9a-f]{10}$`, secret.Name)
+		assert.Equal(t, true, *secret.Immutable)
+		assert.Equal(t, secretRef(secret.Name, "pg-secret.password"), containers[0].Env[0].ValueFrom)
+	})
+}
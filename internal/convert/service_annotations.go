@@ -0,0 +1,54 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/score-spec/score-go/framework"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// parseServiceAnnotationsAnnotation reads the service-annotations annotation which is a yaml map of extra annotations
+// for the workload Service. The values may contain ${resources.<name>.<key>} placeholders, for example to pass the id
+// of a provisioned certificate or load balancer to a cloud controller.
+func parseServiceAnnotationsAnnotation(metadata map[string]interface{}, substitutionFunc func(string) (string, error)) (map[string]string, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.ServiceAnnotationsAnnotation)
+	if !ok {
+		return nil, nil
+	}
+	var annotations map[string]string
+	if err := yaml.Unmarshal([]byte(raw), &annotations); err != nil {
+		return nil, errors.Wrapf(err, "%s: failed to decode map of annotations", internal.ServiceAnnotationsAnnotation)
+	}
+	out := make(map[string]string, len(annotations))
+	for _, k := range slices.Sorted(maps.Keys(annotations)) {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return nil, errors.Errorf("%s: '%s' is not a valid annotation key: %s", internal.ServiceAnnotationsAnnotation, k, strings.Join(errs, ", "))
+		}
+		v, err := framework.SubstituteString(annotations[k], substitutionFunc)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: %s: failed to substitute placeholders", internal.ServiceAnnotationsAnnotation, k)
+		}
+		out[k] = v
+	}
+	return out, nil
+}
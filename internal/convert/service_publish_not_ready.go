@@ -0,0 +1,42 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// parsePublishNotReadyAnnotation reads the publish-not-ready-addresses annotation which sets publishNotReadyAddresses
+// on the headless Service of a StatefulSet so that peers can discover each other before they are ready. This defaults
+// to false.
+func parsePublishNotReadyAnnotation(metadata map[string]interface{}, kind string) (bool, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.PublishNotReadyAnnotation)
+	if !ok {
+		return false, nil
+	}
+	if kind != WorkloadKindStatefulSet {
+		return false, errors.Errorf("%s: is only supported for %s workloads", internal.PublishNotReadyAnnotation, WorkloadKindStatefulSet)
+	}
+	v, err := strconv.ParseBool(strings.TrimSpace(raw))
+	if err != nil {
+		return false, errors.Errorf("%s: '%s' is not a boolean", internal.PublishNotReadyAnnotation, raw)
+	}
+	return v, nil
+}
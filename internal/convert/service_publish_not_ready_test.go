@@ -0,0 +1,57 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+func Test_parsePublishNotReadyAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		value    *string
+		kind     string
+		expected bool
+		err      string
+	}{
+		{name: "none"},
+		{name: "true", value: internal.Ref("true"), expected: true},
+		{name: "false", value: internal.Ref("false")},
+		{name: "invalid", value: internal.Ref("yes"), err: "k8s.score.dev/publish-not-ready-addresses: 'yes' is not a boolean"},
+		{name: "deployment", value: internal.Ref("true"), kind: WorkloadKindDeployment, err: "k8s.score.dev/publish-not-ready-addresses: is only supported for StatefulSet workloads"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			metadata := map[string]interface{}{}
+			if tc.value != nil {
+				metadata["annotations"] = map[string]interface{}{"k8s.score.dev/publish-not-ready-addresses": *tc.value}
+			}
+			kind := tc.kind
+			if kind == "" {
+				kind = WorkloadKindStatefulSet
+			}
+			out, err := parsePublishNotReadyAnnotation(metadata, kind)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, out)
+			}
+		})
+	}
+}
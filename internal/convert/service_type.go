@@ -0,0 +1,175 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+var serviceTypes = []coreV1.ServiceType{
+	coreV1.ServiceTypeClusterIP,
+	coreV1.ServiceTypeNodePort,
+	coreV1.ServiceTypeLoadBalancer,
+}
+
+var externalTrafficPolicies = []coreV1.ServiceExternalTrafficPolicy{
+	coreV1.ServiceExternalTrafficPolicyCluster,
+	coreV1.ServiceExternalTrafficPolicyLocal,
+}
+
+var ipFamilyPolicies = []coreV1.IPFamilyPolicy{
+	coreV1.IPFamilyPolicySingleStack,
+	coreV1.IPFamilyPolicyPreferDualStack,
+	coreV1.IPFamilyPolicyRequireDualStack,
+}
+
+var ipFamilies = []coreV1.IPFamily{
+	coreV1.IPv4Protocol,
+	coreV1.IPv6Protocol,
+}
+
+// serviceTypeSpec is the decoded service-type annotation.
+type serviceTypeSpec struct {
+	Type                  coreV1.ServiceType                  `yaml:"type"`
+	ExternalTrafficPolicy coreV1.ServiceExternalTrafficPolicy `yaml:"externalTrafficPolicy,omitempty"`
+	// IPFamilyPolicy and IPFamilies apply to every Service of the workload, including the groups and the headless
+	// Service of a StatefulSet. When unset, the cluster default of a single stack is used.
+	IPFamilyPolicy coreV1.IPFamilyPolicy `yaml:"ipFamilyPolicy,omitempty"`
+	IPFamilies     []coreV1.IPFamily     `yaml:"ipFamilies,omitempty"`
+	// Groups are named groups of ports that each produce an additional Service selecting the same pods.
+	Groups map[string]serviceGroupSpec `yaml:"groups,omitempty"`
+}
+
+// serviceGroupSpec is a named group of workload ports exposed by its own Service.
+type serviceGroupSpec struct {
+	Type                  coreV1.ServiceType                  `yaml:"type"`
+	ExternalTrafficPolicy coreV1.ServiceExternalTrafficPolicy `yaml:"externalTrafficPolicy,omitempty"`
+	Ports                 []string                            `yaml:"ports"`
+}
+
+// parseServiceTypeAnnotation reads the service-type annotation. This is either the name of the Service type, or a
+// yaml object with the type and other options that depend on it. The object may also contain named groups of the
+// given workload port names, each of which produces an additional Service.
+func parseServiceTypeAnnotation(metadata map[string]interface{}, portNames []string) (*serviceTypeSpec, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.ServiceTypeAnnotation)
+	if !ok {
+		return nil, nil
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal([]byte(raw), &node); err != nil {
+		return nil, errors.Wrapf(err, "%s: failed to decode", internal.ServiceTypeAnnotation)
+	}
+	out := new(serviceTypeSpec)
+	if len(node.Content) == 1 && node.Content[0].Kind == yaml.ScalarNode {
+		out.Type = coreV1.ServiceType(node.Content[0].Value)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader([]byte(raw)))
+		dec.KnownFields(true)
+		if err := dec.Decode(out); err != nil {
+			return nil, errors.Wrapf(err, "%s: failed to decode", internal.ServiceTypeAnnotation)
+		}
+	}
+	// The type may be left out when the object only sets the groups or the ip families
+	if out.Type != "" || (len(out.Groups) == 0 && out.IPFamilyPolicy == "" && len(out.IPFamilies) == 0) {
+		if err := validateServiceType(out.Type, out.ExternalTrafficPolicy); err != nil {
+			return nil, errors.Wrapf(err, "%s", internal.ServiceTypeAnnotation)
+		}
+	} else if out.ExternalTrafficPolicy != "" {
+		return nil, errors.Errorf("%s: externalTrafficPolicy can only be set for NodePort or LoadBalancer services", internal.ServiceTypeAnnotation)
+	}
+
+	if err := validateIPFamilies(out.IPFamilyPolicy, out.IPFamilies); err != nil {
+		return nil, errors.Wrapf(err, "%s", internal.ServiceTypeAnnotation)
+	}
+
+	groupNames := slices.Sorted(maps.Keys(out.Groups))
+	portGroups := make(map[string]string)
+	for _, groupName := range groupNames {
+		group := out.Groups[groupName]
+		if errs := validation.IsDNS1035Label(groupName); len(errs) > 0 {
+			return nil, errors.Errorf("%s: groups: '%s' is not a valid group name: %s", internal.ServiceTypeAnnotation, groupName, strings.Join(errs, ", "))
+		} else if err := validateServiceType(group.Type, group.ExternalTrafficPolicy); err != nil {
+			return nil, errors.Wrapf(err, "%s: groups: %s", internal.ServiceTypeAnnotation, groupName)
+		} else if len(group.Ports) == 0 {
+			return nil, errors.Errorf("%s: groups: %s: ports must not be empty", internal.ServiceTypeAnnotation, groupName)
+		}
+		for _, portName := range group.Ports {
+			if !slices.Contains(portNames, portName) {
+				return nil, errors.Errorf("%s: groups: %s: port '%s' is not a workload service port", internal.ServiceTypeAnnotation, groupName, portName)
+			} else if other, ok := portGroups[portName]; ok {
+				return nil, errors.Errorf("%s: groups: %s: port '%s' is already in group '%s'", internal.ServiceTypeAnnotation, groupName, portName, other)
+			}
+			portGroups[portName] = groupName
+		}
+	}
+	return out, nil
+}
+
+// validateServiceType checks the type of a Service and the options that depend on it.
+func validateServiceType(serviceType coreV1.ServiceType, policy coreV1.ServiceExternalTrafficPolicy) error {
+	if !slices.Contains(serviceTypes, serviceType) {
+		return errors.Errorf("unsupported type '%s', expected one of %v", serviceType, serviceTypes)
+	}
+	if policy != "" {
+		if !slices.Contains(externalTrafficPolicies, policy) {
+			return errors.Errorf("unsupported externalTrafficPolicy '%s', expected one of %v", policy, externalTrafficPolicies)
+		} else if serviceType != coreV1.ServiceTypeNodePort && serviceType != coreV1.ServiceTypeLoadBalancer {
+			return errors.Errorf("externalTrafficPolicy can only be set for NodePort or LoadBalancer services")
+		}
+	}
+	return nil
+}
+
+// validateIPFamilies checks the ip family policy and families of a Service. Two families need a dual stack policy
+// since a single stack Service can only have one.
+func validateIPFamilies(policy coreV1.IPFamilyPolicy, families []coreV1.IPFamily) error {
+	if policy != "" && !slices.Contains(ipFamilyPolicies, policy) {
+		return errors.Errorf("unsupported ipFamilyPolicy '%s', expected one of %v", policy, ipFamilyPolicies)
+	}
+	for i, family := range families {
+		if !slices.Contains(ipFamilies, family) {
+			return errors.Errorf("ipFamilies: unsupported family '%s', expected one of %v", family, ipFamilies)
+		} else if slices.Contains(families[:i], family) {
+			return errors.Errorf("ipFamilies: family '%s' is listed more than once", family)
+		}
+	}
+	if len(families) > 1 && (policy == "" || policy == coreV1.IPFamilyPolicySingleStack) {
+		return errors.Errorf("ipFamilies: two families require an ipFamilyPolicy of %s or %s", coreV1.IPFamilyPolicyPreferDualStack, coreV1.IPFamilyPolicyRequireDualStack)
+	}
+	return nil
+}
+
+// applyIPFamilies sets the ip family policy and families of the service type annotation on the Service.
+func applyIPFamilies(service *coreV1.Service, serviceType *serviceTypeSpec) {
+	if serviceType == nil {
+		return
+	}
+	if serviceType.IPFamilyPolicy != "" {
+		service.Spec.IPFamilyPolicy = internal.Ref(serviceType.IPFamilyPolicy)
+	}
+	if len(serviceType.IPFamilies) > 0 {
+		service.Spec.IPFamilies = slices.Clone(serviceType.IPFamilies)
+	}
+}
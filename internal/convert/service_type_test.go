@@ -0,0 +1,72 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+func Test_parseServiceTypeAnnotation(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		value    *string
+		expected *serviceTypeSpec
+		err      string
+	}{
+		{name: "none"},
+		{name: "type only", value: internal.Ref("NodePort"), expected: &serviceTypeSpec{Type: "NodePort"}},
+		{name: "object", value: internal.Ref("{type: LoadBalancer, externalTrafficPolicy: Local}"), expected: &serviceTypeSpec{Type: "LoadBalancer", ExternalTrafficPolicy: "Local"}},
+		{name: "unknown type", value: internal.Ref("External"), err: "k8s.score.dev/service-type: unsupported type 'External', expected one of [ClusterIP NodePort LoadBalancer]"},
+		{name: "unknown policy", value: internal.Ref("{type: NodePort, externalTrafficPolicy: Remote}"), err: "k8s.score.dev/service-type: unsupported externalTrafficPolicy 'Remote', expected one of [Cluster Local]"},
+		{name: "policy on cluster ip", value: internal.Ref("{type: ClusterIP, externalTrafficPolicy: Local}"), err: "k8s.score.dev/service-type: externalTrafficPolicy can only be set for NodePort or LoadBalancer services"},
+		{name: "groups", value: internal.Ref("{groups: {public: {type: LoadBalancer, externalTrafficPolicy: Local, ports: [web]}, internal: {type: ClusterIP, ports: [admin, metrics]}}}"), expected: &serviceTypeSpec{Groups: map[string]serviceGroupSpec{
+			"public":   {Type: "LoadBalancer", ExternalTrafficPolicy: "Local", Ports: []string{"web"}},
+			"internal": {Type: "ClusterIP", Ports: []string{"admin", "metrics"}},
+		}}},
+		{name: "groups with type", value: internal.Ref("{type: NodePort, groups: {public: {type: LoadBalancer, ports: [web]}}}"), expected: &serviceTypeSpec{Type: "NodePort", Groups: map[string]serviceGroupSpec{
+			"public": {Type: "LoadBalancer", Ports: []string{"web"}},
+		}}},
+		{name: "group invalid name", value: internal.Ref("{groups: {Public: {type: LoadBalancer, ports: [web]}}}"), err: "k8s.score.dev/service-type: groups: 'Public' is not a valid group name: a DNS-1035 label must consist of lower case alphanumeric characters or '-', start with an alphabetic character, and end with an alphanumeric character (e.g. 'my-name',  or 'abc-123', regex used for validation is '[a-z]([-a-z0-9]*[a-z0-9])?')"},
+		{name: "group unknown type", value: internal.Ref("{groups: {public: {type: External, ports: [web]}}}"), err: "k8s.score.dev/service-type: groups: public: unsupported type 'External', expected one of [ClusterIP NodePort LoadBalancer]"},
+		{name: "group no ports", value: internal.Ref("{groups: {public: {type: LoadBalancer}}}"), err: "k8s.score.dev/service-type: groups: public: ports must not be empty"},
+		{name: "group unknown port", value: internal.Ref("{groups: {public: {type: LoadBalancer, ports: [grpc]}}}"), err: "k8s.score.dev/service-type: groups: public: port 'grpc' is not a workload service port"},
+		{name: "group conflicting port", value: internal.Ref("{groups: {public: {type: LoadBalancer, ports: [web]}, internal: {type: ClusterIP, ports: [admin, web]}}}"), err: "k8s.score.dev/service-type: groups: public: port 'web' is already in group 'internal'"},
+		{name: "dual stack", value: internal.Ref("{type: LoadBalancer, ipFamilyPolicy: PreferDualStack, ipFamilies: [IPv6, IPv4]}"), expected: &serviceTypeSpec{Type: "LoadBalancer", IPFamilyPolicy: "PreferDualStack", IPFamilies: []coreV1.IPFamily{"IPv6", "IPv4"}}},
+		{name: "ip families without type", value: internal.Ref("{ipFamilyPolicy: SingleStack, ipFamilies: [IPv6]}"), expected: &serviceTypeSpec{IPFamilyPolicy: "SingleStack", IPFamilies: []coreV1.IPFamily{"IPv6"}}},
+		{name: "unknown ip family policy", value: internal.Ref("{ipFamilyPolicy: DualStack}"), err: "k8s.score.dev/service-type: unsupported ipFamilyPolicy 'DualStack', expected one of [SingleStack PreferDualStack RequireDualStack]"},
+		{name: "unknown ip family", value: internal.Ref("{ipFamilyPolicy: PreferDualStack, ipFamilies: [IPv5]}"), err: "k8s.score.dev/service-type: ipFamilies: unsupported family 'IPv5', expected one of [IPv4 IPv6]"},
+		{name: "duplicate ip family", value: internal.Ref("{ipFamilyPolicy: PreferDualStack, ipFamilies: [IPv4, IPv4]}"), err: "k8s.score.dev/service-type: ipFamilies: family 'IPv4' is listed more than once"},
+		{name: "two ip families on single stack", value: internal.Ref("{ipFamilies: [IPv4, IPv6]}"), err: "k8s.score.dev/service-type: ipFamilies: two families require an ipFamilyPolicy of PreferDualStack or RequireDualStack"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			metadata := map[string]interface{}{}
+			if tc.value != nil {
+				metadata["annotations"] = map[string]interface{}{"k8s.score.dev/service-type": *tc.value}
+			}
+			out, err := parseServiceTypeAnnotation(metadata, []string{"web", "admin", "metrics"})
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, out)
+			}
+		})
+	}
+}
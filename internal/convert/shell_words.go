@@ -0,0 +1,113 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// splitShellWords splits the command into arguments the way a POSIX shell would, without any expansion. Arguments are
+// separated by unquoted whitespace, single quotes preserve everything up to the next single quote, double quotes
+// preserve everything except a backslash before one of $ ` " \ or a newline, and outside of quotes a backslash
+// preserves the next character.
+func splitShellWords(command string) ([]string, error) {
+	out := make([]string, 0)
+	var word strings.Builder
+	inWord := false
+	runes := []rune(command)
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; {
+		case r == '\'':
+			inWord = true
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '\'' {
+					closed = true
+					break
+				}
+				word.WriteRune(runes[i])
+			}
+			if !closed {
+				return nil, errors.New("unterminated single quote")
+			}
+		case r == '"':
+			inWord = true
+			closed := false
+			for i++; i < len(runes); i++ {
+				if runes[i] == '"' {
+					closed = true
+					break
+				} else if runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune("$`\"\\\n", runes[i+1]) {
+					i++
+					if runes[i] != '\n' {
+						word.WriteRune(runes[i])
+					}
+				} else {
+					word.WriteRune(runes[i])
+				}
+			}
+			if !closed {
+				return nil, errors.New("unterminated double quote")
+			}
+		case r == '\\':
+			if i+1 >= len(runes) {
+				return nil, errors.New("trailing backslash")
+			}
+			i++
+			if runes[i] != '\n' {
+				inWord = true
+				word.WriteRune(runes[i])
+			}
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				out = append(out, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			inWord = true
+			word.WriteRune(r)
+		}
+	}
+	if inWord {
+		out = append(out, word.String())
+	}
+	return out, nil
+}
+
+// execCommand is the command of an exec probe or lifecycle hook. It is either a yaml list of arguments, or a single
+// string that is split into arguments with splitShellWords.
+type execCommand []string
+
+func (c *execCommand) UnmarshalYAML(node *yaml.Node) error {
+	var out []string
+	if node.Kind == yaml.ScalarNode {
+		words, err := splitShellWords(node.Value)
+		if err != nil {
+			return errors.Wrapf(err, "line %d: failed to split '%s'", node.Line, node.Value)
+		}
+		out = words
+	} else if err := node.Decode(&out); err != nil {
+		return err
+	}
+	if len(out) == 0 {
+		return errors.Errorf("line %d: command is empty", node.Line)
+	}
+	*c = out
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_splitShellWords(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		command  string
+		expected []string
+		err      string
+	}{
+		{name: "empty", command: "", expected: []string{}},
+		{name: "plain", command: "  pg_isready  -U\tpostgres ", expected: []string{"pg_isready", "-U", "postgres"}},
+		{name: "single quotes", command: `pg_isready -U 'app user'`, expected: []string{"pg_isready", "-U", "app user"}},
+		{name: "single quotes are literal", command: `echo '$HOME \"x\"'`, expected: []string{"echo", `$HOME \"x\"`}},
+		{name: "double quotes", command: `sh -c "echo \"hello world\" > /tmp/out"`, expected: []string{"sh", "-c", `echo "hello world" > /tmp/out`}},
+		{name: "double quotes keep other backslashes", command: `grep "a\.b"`, expected: []string{"grep", `a\.b`}},
+		{name: "adjacent quotes join", command: `--name='a b'"c d"e`, expected: []string{"--name=a bc de"}},
+		{name: "empty quotes", command: `echo '' ""`, expected: []string{"echo", "", ""}},
+		{name: "escaped space", command: `cat /tmp/my\ file`, expected: []string{"cat", "/tmp/my file"}},
+		{name: "line continuation", command: "sleep \\\n5", expected: []string{"sleep", "5"}},
+		{name: "unterminated single", command: `echo 'oops`, err: "unterminated single quote"},
+		{name: "unterminated double", command: `echo "oops`, err: "unterminated double quote"},
+		{name: "trailing backslash", command: `echo \`, err: "trailing backslash"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := splitShellWords(tc.command)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, out)
+			}
+		})
+	}
+}
+
+func Test_execCommand(t *testing.T) {
+	var out struct {
+		A execCommand `yaml:"a"`
+		B execCommand `yaml:"b"`
+	}
+	assert.NoError(t, yaml.Unmarshal([]byte(`{a: "sh -c 'sleep 5'", b: [sh, -c, sleep 5]}`), &out))
+	assert.Equal(t, execCommand{"sh", "-c", "sleep 5"}, out.A)
+	assert.Equal(t, execCommand{"sh", "-c", "sleep 5"}, out.B)
+
+	assert.EqualError(t, yaml.Unmarshal([]byte(`{a: "  "}`), &out), "line 1: command is empty")
+	assert.EqualError(t, yaml.Unmarshal([]byte(`{a: []}`), &out), "line 1: command is empty")
+	assert.EqualError(t, yaml.Unmarshal([]byte(`{a: "echo 'x"}`), &out), "line 1: failed to split 'echo 'x': unterminated single quote")
+}
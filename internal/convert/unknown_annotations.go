@@ -0,0 +1,88 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// maxAnnotationSuggestionDistance is the largest edit distance at which a registered annotation is suggested for an
+// unknown one.
+const maxAnnotationSuggestionDistance = 3
+
+// checkUnknownAnnotations reports the workload annotations with the score-k8s prefix that are not registered workload
+// annotations, since these would otherwise be silently ignored. They are an error when strict is set and a warning
+// otherwise.
+func checkUnknownAnnotations(metadata map[string]interface{}, strict bool) error {
+	for _, key := range internal.ListAnnotations(metadata) {
+		if !strings.HasPrefix(key, internal.AnnotationPrefix) {
+			continue
+		}
+		if a, ok := internal.FindAnnotationSpec(key); ok && a.On == internal.AnnotationOnWorkload {
+			continue
+		}
+		msg := fmt.Sprintf("%s: unknown annotation", key)
+		if suggestion := closestAnnotation(key); suggestion != "" {
+			msg += fmt.Sprintf(", did you mean '%s'?", suggestion)
+		}
+		if strict {
+			return errors.New(msg)
+		}
+		slog.Warn(fmt.Sprintf("Workload '%s': metadata: annotations: %s", metadata["name"], msg))
+	}
+	return nil
+}
+
+// closestAnnotation returns the registered workload annotation with the smallest edit distance to the key, or an
+// empty string when none is close enough.
+func closestAnnotation(key string) string {
+	best, bestDistance := "", maxAnnotationSuggestionDistance+1
+	for _, a := range internal.Annotations {
+		if a.On != internal.AnnotationOnWorkload {
+			continue
+		}
+		if d := editDistance(key, a.Key); d < bestDistance {
+			best, bestDistance = a.Key, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
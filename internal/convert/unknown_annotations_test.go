@@ -0,0 +1,55 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_checkUnknownAnnotations(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		annotations map[string]interface{}
+		err         string
+	}{
+		{name: "none"},
+		{name: "known and foreign", annotations: map[string]interface{}{"k8s.score.dev/kind": "Deployment", "example.com/replica": "2"}},
+		{name: "typo", annotations: map[string]interface{}{"k8s.score.dev/wait-for-timout": "1m"}, err: "k8s.score.dev/wait-for-timout: unknown annotation, did you mean 'k8s.score.dev/wait-for-timeout'?"},
+		{name: "no close match", annotations: map[string]interface{}{"k8s.score.dev/replicas": "2"}, err: "k8s.score.dev/replicas: unknown annotation"},
+		{name: "not a workload annotation", annotations: map[string]interface{}{"k8s.score.dev/priority": "1"}, err: "k8s.score.dev/priority: unknown annotation"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			metadata := map[string]interface{}{"name": "example"}
+			if tc.annotations != nil {
+				metadata["annotations"] = tc.annotations
+			}
+			assert.NoError(t, checkUnknownAnnotations(metadata, false))
+			if err := checkUnknownAnnotations(metadata, true); tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func Test_editDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("kind", "kind"))
+	assert.Equal(t, 1, editDistance("replica", "replicas"))
+	assert.Equal(t, 3, editDistance("kitten", "sitting"))
+	assert.Equal(t, 4, editDistance("", "kind"))
+}
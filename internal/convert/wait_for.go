@@ -0,0 +1,102 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	score "github.com/score-spec/score-go/types"
+	coreV1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+const (
+	DefaultWaitForImage   = "busybox:1.36"
+	DefaultWaitForTimeout = 5 * time.Minute
+)
+
+// buildWaitForContainers returns an init container for each resource listed in the wait-for annotation. Each init
+// container polls the host and port outputs of the resource until they accept tcp connections, or fails once the
+// timeout is reached. This is commonly used with service-port resources to wait for another workload to start. Since
+// the outputs are used in a shell script, the host must be a hostname or IP address and the port must be a number.
+func buildWaitForContainers(metadata map[string]interface{}, resources map[string]score.Resource, sf func(string) (string, error)) ([]coreV1.Container, error) {
+	raw, ok := internal.FindAnnotation(metadata, internal.WaitForAnnotation)
+	if !ok {
+		return nil, nil
+	}
+
+	image := DefaultWaitForImage
+	if v, ok := internal.FindAnnotation(metadata, internal.WaitForImageAnnotation); ok && v != "" {
+		image = v
+	}
+
+	timeout := DefaultWaitForTimeout
+	if v, ok := internal.FindAnnotation(metadata, internal.WaitForTimeoutAnnotation); ok {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: failed to parse duration", internal.WaitForTimeoutAnnotation)
+		} else if d <= 0 {
+			return nil, errors.Errorf("%s: duration must be positive", internal.WaitForTimeoutAnnotation)
+		}
+		timeout = d
+	}
+
+	out := make([]coreV1.Container, 0)
+	for _, resName := range strings.Split(raw, ",") {
+		resName = strings.TrimSpace(resName)
+		if resName == "" {
+			continue
+		}
+		if _, ok := resources[resName]; !ok {
+			return nil, errors.Errorf("%s: %s: the workload has no resource with this name", internal.WaitForAnnotation, resName)
+		}
+		containerName := "wait-for-" + resName
+		if errs := validation.IsDNS1123Label(containerName); len(errs) > 0 {
+			return nil, errors.Errorf("%s: %s: '%s' is not a valid container name: %s", internal.WaitForAnnotation, resName, containerName, strings.Join(errs, ", "))
+		}
+		host, err := sf(fmt.Sprintf("resources.%s.hostname", resName))
+		if err != nil {
+			if host, err = sf(fmt.Sprintf("resources.%s.host", resName)); err != nil {
+				return nil, errors.Wrapf(err, "%s: %s: failed to find a host or hostname output", internal.WaitForAnnotation, resName)
+			}
+		}
+		port, err := sf(fmt.Sprintf("resources.%s.port", resName))
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: %s: failed to find a port output", internal.WaitForAnnotation, resName)
+		}
+		if net.ParseIP(host) == nil && len(validation.IsDNS1123Subdomain(host)) > 0 {
+			return nil, errors.Errorf("%s: %s: host output '%s' is not a valid hostname or IP address", internal.WaitForAnnotation, resName, host)
+		}
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			return nil, errors.Errorf("%s: %s: port output '%s' is not a valid port number", internal.WaitForAnnotation, resName, port)
+		}
+		out = append(out, coreV1.Container{
+			Name:  containerName,
+			Image: image,
+			Command: []string{"sh", "-c", fmt.Sprintf(
+				`end=$(($(date +%%s)+%d)); until nc -z -w 2 %s %s; do if [ $(date +%%s) -ge $end ]; then echo "timed out waiting for %s:%s"; exit 1; fi; sleep 2; done`,
+				int(timeout.Seconds()), host, port, host, port,
+			)},
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"testing"
+
+	"github.com/score-spec/score-go/framework"
+	scoretypes "github.com/score-spec/score-go/types"
+	"github.com/stretchr/testify/assert"
+	coreV1 "k8s.io/api/core/v1"
+)
+
+func Test_buildWaitForContainers(t *testing.T) {
+	sf := framework.BuildSubstitutionFunction(map[string]interface{}{}, map[string]framework.OutputLookupFunc{
+		"svc": func(keys ...string) (interface{}, error) {
+			return map[string]interface{}{"hostname": "other", "port": 8080}[keys[0]], nil
+		},
+		"db": func(keys ...string) (interface{}, error) {
+			if keys[0] == "hostname" {
+				return nil, assert.AnError
+			}
+			return map[string]interface{}{"host": "pg", "port": "5432"}[keys[0]], nil
+		},
+		"vol": func(keys ...string) (interface{}, error) {
+			return nil, assert.AnError
+		},
+		"inject": func(keys ...string) (interface{}, error) {
+			return map[string]interface{}{"hostname": "x; rm -rf /", "port": 80}[keys[0]], nil
+		},
+		"named-port": func(keys ...string) (interface{}, error) {
+			return map[string]interface{}{"hostname": "10.0.0.1", "port": "$(id)"}[keys[0]], nil
+		},
+		"Upper_Case": func(keys ...string) (interface{}, error) {
+			return map[string]interface{}{"hostname": "other", "port": 80}[keys[0]], nil
+		},
+	})
+	resources := map[string]scoretypes.Resource{}
+	for _, name := range []string{"svc", "db", "vol", "inject", "named-port", "Upper_Case"} {
+		resources[name] = scoretypes.Resource{Type: "service-port"}
+	}
+
+	t.Run("none", func(t *testing.T) {
+		c, err := buildWaitForContainers(map[string]interface{}{}, resources, sf)
+		assert.NoError(t, err)
+		assert.Nil(t, c)
+	})
+
+	t.Run("nominal", func(t *testing.T) {
+		c, err := buildWaitForContainers(map[string]interface{}{
+			"annotations": map[string]interface{}{
+				"k8s.score.dev/wait-for":         "svc, db",
+				"k8s.score.dev/wait-for-image":   "my-image",
+				"k8s.score.dev/wait-for-timeout": "30s",
+			},
+		}, resources, sf)
+		assert.NoError(t, err)
+		assert.Equal(t, []coreV1.Container{
+			{
+				Name:    "wait-for-svc",
+				Image:   "my-image",
+				Command: []string{"sh", "-c", `end=$(($(date +%s)+30)); until nc -z -w 2 other 8080; do if [ $(date +%s) -ge $end ]; then echo "timed out waiting for other:8080"; exit 1; fi; sleep 2; done`},
+			},
+			{
+				Name:    "wait-for-db",
+				Image:   "my-image",
+				Command: []string{"sh", "-c", `end=$(($(date +%s)+30)); until nc -z -w 2 pg 5432; do if [ $(date +%s) -ge $end ]; then echo "timed out waiting for pg:5432"; exit 1; fi; sleep 2; done`},
+			},
+		}, c)
+	})
+
+	t.Run("default image", func(t *testing.T) {
+		c, err := buildWaitForContainers(map[string]interface{}{
+			"annotations": map[string]interface{}{"k8s.score.dev/wait-for": "svc"},
+		}, resources, sf)
+		assert.NoError(t, err)
+		if assert.Len(t, c, 1) {
+			assert.Equal(t, DefaultWaitForImage, c[0].Image)
+			assert.Contains(t, c[0].Command[2], "+300));")
+		}
+	})
+
+	t.Run("bad timeout", func(t *testing.T) {
+		_, err := buildWaitForContainers(map[string]interface{}{
+			"annotations": map[string]interface{}{"k8s.score.dev/wait-for": "svc", "k8s.score.dev/wait-for-timeout": "-1s"},
+		}, resources, sf)
+		assert.EqualError(t, err, "k8s.score.dev/wait-for-timeout: duration must be positive")
+	})
+
+	t.Run("missing outputs", func(t *testing.T) {
+		_, err := buildWaitForContainers(map[string]interface{}{
+			"annotations": map[string]interface{}{"k8s.score.dev/wait-for": "vol"},
+		}, resources, sf)
+		assert.EqualError(t, err, "k8s.score.dev/wait-for: vol: failed to find a host or hostname output: invalid ref 'resources.vol.host': assert.AnError general error for testing")
+	})
+
+	for name, tc := range map[string]struct {
+		value string
+		err   string
+	}{
+		"unknown resource": {"svcc", "k8s.score.dev/wait-for: svcc: the workload has no resource with this name"},
+		"invalid host":     {"inject", "k8s.score.dev/wait-for: inject: host output 'x; rm -rf /' is not a valid hostname or IP address"},
+		"invalid port":     {"named-port", "k8s.score.dev/wait-for: named-port: port output '$(id)' is not a valid port number"},
+		"invalid name": {"Upper_Case", "k8s.score.dev/wait-for: Upper_Case: 'wait-for-Upper_Case' is not a valid container name: " +
+			"a lowercase RFC 1123 label must consist of lower case alphanumeric characters or '-', and must start and end with an alphanumeric character (e.g. 'my-name',  or '123-abc', regex used for validation is '[a-z0-9]([-a-z0-9]*[a-z0-9])?')"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := buildWaitForContainers(map[string]interface{}{
+				"annotations": map[string]interface{}{"k8s.score.dev/wait-for": tc.value},
+			}, resources, sf)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"bytes"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+
+	"github.com/score-spec/score-k8s/internal"
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+// whenContainerPrefix is the prefix of the when annotation keys that gate a workload container rather than another
+// workload annotation.
+const whenContainerPrefix = "containers."
+
+// evaluateWhen evaluates a when expression against the environment. The supported expressions are 'env == <value>',
+// 'env != <value>', 'env in (<value>, ...)', and 'env not in (<value>, ...)'.
+func evaluateWhen(expression string, env string) (bool, error) {
+	rest, ok := strings.CutPrefix(strings.TrimSpace(expression), "env")
+	if !ok || (rest != "" && !strings.ContainsAny(rest[:1], " =!")) {
+		return false, errors.Errorf("'%s': expected an expression on 'env'", expression)
+	}
+	rest = strings.TrimSpace(rest)
+	negate := false
+	var values []string
+	if v, ok := strings.CutPrefix(rest, "=="); ok {
+		values = []string{strings.TrimSpace(v)}
+	} else if v, ok := strings.CutPrefix(rest, "!="); ok {
+		negate, values = true, []string{strings.TrimSpace(v)}
+	} else {
+		if v, ok := strings.CutPrefix(rest, "not "); ok {
+			negate, rest = true, strings.TrimSpace(v)
+		}
+		v, ok := strings.CutPrefix(rest, "in ")
+		v = strings.TrimSpace(v)
+		if !ok || !strings.HasPrefix(v, "(") || !strings.HasSuffix(v, ")") {
+			return false, errors.Errorf("'%s': expected one of ==, !=, in (...), or not in (...)", expression)
+		}
+		for _, item := range strings.Split(v[1:len(v)-1], ",") {
+			values = append(values, strings.TrimSpace(item))
+		}
+	}
+	if slices.Contains(values, "") {
+		return false, errors.Errorf("'%s': values cannot be empty", expression)
+	}
+	return slices.Contains(values, env) != negate, nil
+}
+
+// applyWhenAnnotation reads the when annotation, a yaml map of workload annotation key or containers.<name> to a when
+// expression, and returns a copy of the state in which the annotations and containers whose expression is false for
+// the environment are removed from the workload. The state is returned as it is when the annotation is not set.
+func applyWhenAnnotation(state *project.State, workloadName string, env string) (*project.State, error) {
+	workload := state.Workloads[workloadName]
+	raw, ok := internal.FindAnnotation(workload.Spec.Metadata, internal.WhenAnnotation)
+	if !ok {
+		return state, nil
+	}
+	var conditions map[string]string
+	dec := yaml.NewDecoder(bytes.NewReader([]byte(raw)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&conditions); err != nil {
+		return nil, errors.Wrapf(err, "%s: failed to decode", internal.WhenAnnotation)
+	}
+
+	annotations, _ := workload.Spec.Metadata["annotations"].(map[string]interface{})
+	annotations = maps.Clone(annotations)
+	containers := maps.Clone(workload.Spec.Containers)
+	for _, key := range slices.Sorted(maps.Keys(conditions)) {
+		ok, err := evaluateWhen(conditions[key], env)
+		if err != nil {
+			return nil, errors.Wrapf(err, "%s: %s", internal.WhenAnnotation, key)
+		}
+		if containerName, isContainer := strings.CutPrefix(key, whenContainerPrefix); isContainer {
+			if _, exists := workload.Spec.Containers[containerName]; !exists {
+				return nil, errors.Errorf("%s: %s: container '%s' does not exist", internal.WhenAnnotation, key, containerName)
+			} else if !ok {
+				delete(containers, containerName)
+			}
+			continue
+		}
+		if !strings.HasPrefix(key, internal.AnnotationPrefix) || key == internal.WhenAnnotation {
+			return nil, errors.Errorf("%s: %s: expected a %s annotation or %s<name>", internal.WhenAnnotation, key, internal.AnnotationPrefix, whenContainerPrefix)
+		} else if _, exists := annotations[key]; !exists {
+			return nil, errors.Errorf("%s: %s: annotation is not set on the workload", internal.WhenAnnotation, key)
+		} else if !ok {
+			delete(annotations, key)
+		}
+	}
+	if len(containers) == 0 {
+		return nil, errors.Errorf("%s: at least one container must be left for env '%s'", internal.WhenAnnotation, env)
+	}
+
+	metadata := maps.Clone(workload.Spec.Metadata)
+	metadata["annotations"] = annotations
+	workload.Spec.Metadata = metadata
+	workload.Spec.Containers = containers
+	out := *state
+	out.Workloads = maps.Clone(state.Workloads)
+	out.Workloads[workloadName] = workload
+	return &out, nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"maps"
+	"slices"
+	"testing"
+
+	scoretypes "github.com/score-spec/score-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+func Test_evaluateWhen(t *testing.T) {
+	for _, tc := range []struct {
+		expression string
+		env        string
+		expected   bool
+		err        string
+	}{
+		{expression: "env == prod", env: "prod", expected: true},
+		{expression: "env==prod", env: "dev"},
+		{expression: "env == prod", env: ""},
+		{expression: "env != prod", env: "dev", expected: true},
+		{expression: "env != prod", env: "prod"},
+		{expression: "env in (prod, staging)", env: "staging", expected: true},
+		{expression: "env in (prod, staging)", env: "dev"},
+		{expression: "env not in (dev)", env: "prod", expected: true},
+		{expression: "env not in (dev)", env: "dev"},
+		{expression: "region == eu", err: "'region == eu': expected an expression on 'env'"},
+		{expression: "environment == prod", err: "'environment == prod': expected an expression on 'env'"},
+		{expression: "env = prod", err: "'env = prod': expected one of ==, !=, in (...), or not in (...)"},
+		{expression: "env in prod", err: "'env in prod': expected one of ==, !=, in (...), or not in (...)"},
+		{expression: "env ==", err: "'env ==': values cannot be empty"},
+		{expression: "env in (prod,)", err: "'env in (prod,)': values cannot be empty"},
+	} {
+		t.Run(tc.expression+"/"+tc.env, func(t *testing.T) {
+			out, err := evaluateWhen(tc.expression, tc.env)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, out)
+			}
+		})
+	}
+}
+
+func Test_applyWhenAnnotation(t *testing.T) {
+	build := func(when string) *project.State {
+		state, err := new(project.State).WithWorkload(&scoretypes.Workload{
+			Metadata: map[string]interface{}{
+				"name": "example",
+				"annotations": map[string]interface{}{
+					"k8s.score.dev/when":        when,
+					"k8s.score.dev/pod-monitor": "main:9090",
+				},
+			},
+			Containers: map[string]scoretypes.Container{
+				"main":    {Image: "my-image"},
+				"monitor": {Image: "my-monitor"},
+			},
+		}, nil, project.WorkloadExtras{})
+		require.NoError(t, err)
+		return state
+	}
+
+	t.Run("not set", func(t *testing.T) {
+		state := new(project.State)
+		state, _ = state.WithWorkload(&scoretypes.Workload{Metadata: map[string]interface{}{"name": "example"}}, nil, project.WorkloadExtras{})
+		out, err := applyWhenAnnotation(state, "example", "prod")
+		assert.NoError(t, err)
+		assert.Same(t, state, out)
+	})
+
+	t.Run("matched", func(t *testing.T) {
+		state := build("{k8s.score.dev/pod-monitor: env == prod, containers.monitor: env == prod}")
+		out, err := applyWhenAnnotation(state, "example", "prod")
+		require.NoError(t, err)
+		assert.Len(t, out.Workloads["example"].Spec.Containers, 2)
+		assert.Contains(t, out.Workloads["example"].Spec.Metadata["annotations"], "k8s.score.dev/pod-monitor")
+	})
+
+	t.Run("not matched", func(t *testing.T) {
+		state := build("{k8s.score.dev/pod-monitor: env == prod, containers.monitor: env == prod}")
+		out, err := applyWhenAnnotation(state, "example", "dev")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"main"}, slices.Collect(maps.Keys(out.Workloads["example"].Spec.Containers)))
+		assert.NotContains(t, out.Workloads["example"].Spec.Metadata["annotations"], "k8s.score.dev/pod-monitor")
+		// the original state is not modified
+		assert.Len(t, state.Workloads["example"].Spec.Containers, 2)
+		assert.Contains(t, state.Workloads["example"].Spec.Metadata["annotations"], "k8s.score.dev/pod-monitor")
+	})
+
+	for _, tc := range []struct {
+		name string
+		when string
+		err  string
+	}{
+		{name: "invalid", when: "[a]", err: "k8s.score.dev/when: failed to decode: yaml: unmarshal errors:\n  line 1: cannot unmarshal !!seq into map[string]string"},
+		{name: "bad expression", when: "{containers.monitor: env ~ prod}", err: "k8s.score.dev/when: containers.monitor: 'env ~ prod': expected one of ==, !=, in (...), or not in (...)"},
+		{name: "unknown container", when: "{containers.other: env == prod}", err: "k8s.score.dev/when: containers.other: container 'other' does not exist"},
+		{name: "unset annotation", when: "{k8s.score.dev/wait-for: env == prod}", err: "k8s.score.dev/when: k8s.score.dev/wait-for: annotation is not set on the workload"},
+		{name: "other annotation", when: "{example.com/thing: env == prod}", err: "k8s.score.dev/when: example.com/thing: expected a k8s.score.dev/ annotation or containers.<name>"},
+		{name: "no containers", when: "{containers.main: env == prod, containers.monitor: env == prod}", err: "k8s.score.dev/when: at least one container must be left for env 'dev'"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := applyWhenAnnotation(build(tc.when), "example", "dev")
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
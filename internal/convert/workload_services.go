@@ -0,0 +1,92 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/score-spec/score-go/framework"
+	scoretypes "github.com/score-spec/score-go/types"
+
+	"github.com/score-spec/score-k8s/internal"
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+// workloadsPlaceholderRoot is the placeholder root used to refer to the Service of any workload in the project.
+const workloadsPlaceholderRoot = "workloads"
+
+// withWorkloadServices wraps the substitution function so that placeholders under the workloads root resolve to the
+// in-cluster Service of the named workload:
+//
+//   - ${workloads.<name>.service.host} is the Service name, which resolves within the namespace
+//   - ${workloads.<name>.service.ports.<port>} is the published port number, the port may be the port name or number
+//   - ${workloads.<name>.service.ports.<port>.targetPort} and .protocol are the target port and protocol
+//
+// Any other placeholder is passed to the inner substitution function.
+func withWorkloadServices(state *project.State, inner func(string) (string, error)) func(string) (string, error) {
+	return func(ref string) (string, error) {
+		parts := framework.SplitRefParts(ref)
+		if parts[0] != workloadsPlaceholderRoot {
+			return inner(ref)
+		}
+		if len(parts) < 4 || parts[2] != "service" {
+			return "", fmt.Errorf("invalid ref '%s': expected %s.<name>.service.<field>", ref, workloadsPlaceholderRoot)
+		}
+		workload, ok := state.Workloads[parts[1]]
+		if !ok {
+			return "", fmt.Errorf("invalid ref '%s': no known workload '%s'", ref, parts[1])
+		}
+		switch parts[3] {
+		case "host":
+			if len(parts) == 4 {
+				return WorkloadServiceName(parts[1], workload.Spec.Metadata), nil
+			}
+		case "ports":
+			if len(parts) < 5 {
+				break
+			}
+			port, ok := findWorkloadServicePort(workload.Spec.Service, parts[4])
+			if !ok {
+				return "", fmt.Errorf("invalid ref '%s': workload '%s' has no service port '%s'", ref, parts[1], parts[4])
+			}
+			if len(parts) == 5 || (len(parts) == 6 && parts[5] == "port") {
+				return strconv.Itoa(port.Port), nil
+			} else if len(parts) == 6 && parts[5] == "targetPort" {
+				return strconv.Itoa(internal.DerefOr(port.TargetPort, port.Port)), nil
+			} else if len(parts) == 6 && parts[5] == "protocol" {
+				return string(internal.DerefOr(port.Protocol, scoretypes.ServicePortProtocolTCP)), nil
+			}
+		}
+		return "", fmt.Errorf("invalid ref '%s': unknown service field '%s'", ref, strings.Join(parts[3:], "."))
+	}
+}
+
+// findWorkloadServicePort finds the service port by name, or by its port number as a secondary name.
+func findWorkloadServicePort(service *scoretypes.WorkloadService, name string) (scoretypes.ServicePort, bool) {
+	if service == nil {
+		return scoretypes.ServicePort{}, false
+	}
+	if port, ok := service.Ports[name]; ok {
+		return port, true
+	}
+	for _, port := range service.Ports {
+		if strconv.Itoa(port.Port) == name {
+			return port, true
+		}
+	}
+	return scoretypes.ServicePort{}, false
+}
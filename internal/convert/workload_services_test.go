@@ -0,0 +1,77 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert
+
+import (
+	"fmt"
+	"testing"
+
+	scoretypes "github.com/score-spec/score-go/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/score-spec/score-k8s/internal"
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+func Test_withWorkloadServices(t *testing.T) {
+	state, err := new(project.State).WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{
+			"name":        "backend",
+			"annotations": map[string]interface{}{"k8s.score.dev/service-name": "backend-svc"},
+		},
+		Containers: map[string]scoretypes.Container{"c1": {Image: "my-image"}},
+		Service: &scoretypes.WorkloadService{Ports: map[string]scoretypes.ServicePort{
+			"web": {Port: 80, TargetPort: internal.Ref(8080)},
+		}},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	state, err = state.WithWorkload(&scoretypes.Workload{
+		Metadata:   map[string]interface{}{"name": "worker"},
+		Containers: map[string]scoretypes.Container{"c1": {Image: "my-image"}},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+
+	sf := withWorkloadServices(state, func(ref string) (string, error) {
+		return "", fmt.Errorf("inner '%s'", ref)
+	})
+	for _, tc := range []struct {
+		ref      string
+		expected string
+		err      string
+	}{
+		{ref: "workloads.backend.service.host", expected: "backend-svc"},
+		{ref: "workloads.backend.service.ports.web", expected: "80"},
+		{ref: "workloads.backend.service.ports.web.port", expected: "80"},
+		{ref: "workloads.backend.service.ports.80.targetPort", expected: "8080"},
+		{ref: "workloads.backend.service.ports.web.protocol", expected: "TCP"},
+		{ref: "metadata.name", err: "inner 'metadata.name'"},
+		{ref: "workloads.backend", err: "invalid ref 'workloads.backend': expected workloads.<name>.service.<field>"},
+		{ref: "workloads.unknown.service.host", err: "invalid ref 'workloads.unknown.service.host': no known workload 'unknown'"},
+		{ref: "workloads.backend.service.ports.grpc", err: "invalid ref 'workloads.backend.service.ports.grpc': workload 'backend' has no service port 'grpc'"},
+		{ref: "workloads.worker.service.ports.web", err: "invalid ref 'workloads.worker.service.ports.web': workload 'worker' has no service port 'web'"},
+		{ref: "workloads.backend.service.ports.web.other", err: "invalid ref 'workloads.backend.service.ports.web.other': unknown service field 'ports.web.other'"},
+	} {
+		t.Run(tc.ref, func(t *testing.T) {
+			out, err := sf(tc.ref)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, out)
+			}
+		})
+	}
+}
@@ -16,7 +16,9 @@ package convert
 
 import (
 	"fmt"
+	"maps"
 	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -34,18 +36,40 @@ import (
 const (
 	WorkloadKindDeployment  = "Deployment"
 	WorkloadKindStatefulSet = "StatefulSet"
+	WorkloadKindRollout     = "Rollout"
 
 	SelectorLabelName      = "app.kubernetes.io/name"
 	SelectorLabelInstance  = "app.kubernetes.io/instance"
 	SelectorLabelManagedBy = "app.kubernetes.io/managed-by"
 )
 
+// ConvertWorkload converts the named workload in the provisioned state into its Kubernetes manifests. A Service is
+// only included when the workload declares at least one service port, apart from the headless Service that every
+// StatefulSet requires.
 func ConvertWorkload(state *project.State, workloadName string) ([]machineryMeta.Object, error) {
+	return ConvertWorkloadWithOptions(state, workloadName, Options{})
+}
+
+// ConvertWorkloadWithOptions is ConvertWorkload with optional settings such as the label scheme.
+func ConvertWorkloadWithOptions(state *project.State, workloadName string, opts Options) ([]machineryMeta.Object, error) {
+	if opts.LabelScheme != "" && !slices.Contains(LabelSchemes, opts.LabelScheme) {
+		return nil, errors.Errorf("label scheme '%s' is not supported, expected one of %s", opts.LabelScheme, strings.Join(LabelSchemes, ", "))
+	}
+	if err := checkUnknownAnnotations(state.Workloads[workloadName].Spec.Metadata, opts.StrictAnnotations); err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+	state, err := applyWhenAnnotation(state, workloadName, opts.Env)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
 	resOutputs, err := state.GetResourceOutputForWorkload(workloadName)
 	if err != nil {
 		return nil, errors.Wrapf(err, "failed to generate outputs")
 	}
-	sf := framework.BuildSubstitutionFunction(state.Workloads[workloadName].Spec.Metadata, resOutputs)
+	if err := addResourceAliases(state, workloadName, resOutputs); err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+	sf := withWorkloadServices(state, framework.BuildSubstitutionFunction(state.Workloads[workloadName].Spec.Metadata, resOutputs))
 
 	spec := state.Workloads[workloadName].Spec
 	manifests := make([]machineryMeta.Object, 0, 1)
@@ -53,8 +77,8 @@ func ConvertWorkload(state *project.State, workloadName string) ([]machineryMeta
 	kind := WorkloadKindDeployment
 	if d, ok := internal.FindAnnotation(spec.Metadata, internal.WorkloadKindAnnotation); ok {
 		kind = d
-		if kind != WorkloadKindDeployment && kind != WorkloadKindStatefulSet {
-			return nil, errors.Wrapf(err, "metadata: annotations: %s: unsupported workload kind", internal.WorkloadKindAnnotation)
+		if kind != WorkloadKindDeployment && kind != WorkloadKindStatefulSet && kind != WorkloadKindRollout {
+			return nil, errors.Errorf("metadata: annotations: %s: unsupported workload kind", internal.WorkloadKindAnnotation)
 		}
 	}
 
@@ -71,12 +95,37 @@ func ConvertWorkload(state *project.State, workloadName string) ([]machineryMeta
 	}
 	slices.Sort(containerNames)
 
-	commonLabels := map[string]string{
-		SelectorLabelName:      workloadName,
-		SelectorLabelInstance:  workloadName + state.Workloads[workloadName].Extras.InstanceSuffix,
-		SelectorLabelManagedBy: "score-k8s",
+	var commandShell bool
+	if v, ok := internal.FindAnnotation(spec.Metadata, internal.CommandShellAnnotation); ok {
+		if commandShell, err = strconv.ParseBool(v); err != nil {
+			return nil, errors.Wrapf(err, "metadata: annotations: %s: failed to parse", internal.CommandShellAnnotation)
+		}
+	}
+
+	extraResources, err := parseContainerResourcesAnnotation(spec.Metadata)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+
+	immutableConfig, err := parseImmutableConfigAnnotation(spec.Metadata)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+
+	fileTargets := make([]string, 0)
+	for _, container := range spec.Containers {
+		for _, f := range container.Files {
+			fileTargets = append(fileTargets, f.Target)
+		}
+	}
+	fileStorage, err := parseFileStorageAnnotation(spec.Metadata, fileTargets)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
 	}
 
+	commonLabels := workloadLabels(opts.LabelScheme, workloadName, state.Workloads[workloadName].Extras)
+	selector := selectorLabels(opts.LabelScheme, workloadName, state.Workloads[workloadName].Extras)
+
 	for _, containerName := range containerNames {
 		container := spec.Containers[containerName]
 		c := coreV1.Container{
@@ -91,12 +140,38 @@ func ConvertWorkload(state *project.State, workloadName string) ([]machineryMeta
 		if err != nil {
 			return nil, errors.Wrapf(err, "containers.%s.resources: failed to convert", containerName)
 		}
+		if err := applyExtraContainerResources(&c.Resources, extraResources[containerName]); err != nil {
+			return nil, errors.Wrapf(err, "containers.%s.resources: failed to convert", containerName)
+		}
 
 		c.Env, err = convertContainerVariables(container.Variables, sf)
 		if err != nil {
 			return nil, errors.Wrapf(err, "containers.%s.variables: failed to convert", containerName)
 		}
 
+		command, commandEnv, err := convertContainerArgs(container.Command, sf)
+		if err != nil {
+			return nil, errors.Wrapf(err, "containers.%s.command", containerName)
+		}
+		args, argsEnv, err := convertContainerArgs(container.Args, sf)
+		if err != nil {
+			return nil, errors.Wrapf(err, "containers.%s.args", containerName)
+		}
+		c.Command, c.Args = command, args
+		for _, envVar := range slices.Concat(commandEnv, argsEnv) {
+			if !slices.ContainsFunc(c.Env, func(e coreV1.EnvVar) bool { return e.Name == envVar.Name }) {
+				c.Env = append(c.Env, envVar)
+			}
+		}
+		sortEnvVars(c.Env)
+
+		if commandShell {
+			if c.Command, err = wrapCommandInShell(c.Command, c.Args); err != nil {
+				return nil, errors.Wrapf(err, "containers.%s.command", containerName)
+			}
+			c.Args = nil
+		}
+
 		containerVolumes := make([]coreV1.Volume, 0)
 		containerVolumeMounts := make([]coreV1.VolumeMount, 0)
 
@@ -132,7 +207,18 @@ func ConvertWorkload(state *project.State, workloadName string) ([]machineryMeta
 			} else {
 				containerVolumeMounts = append(containerVolumeMounts, mount)
 				if cfg != nil {
-					manifests = append(manifests, cfg)
+					if immutableConfig {
+						makeConfigMapImmutable(cfg, vol)
+					}
+					if fileStorage[f.Target] == FileStorageSecret {
+						manifests = append(manifests, convertFileConfigMapToSecret(cfg, vol))
+					} else {
+						manifests = append(manifests, cfg)
+					}
+				} else if fileStorage[f.Target] == FileStorageConfigMap && vol.Secret != nil {
+					return nil, errors.Errorf("containers.%s.files.%d: content contains a secret reference and cannot be stored in a %s", containerName, i, FileStorageConfigMap)
+				} else if fileStorage[f.Target] == FileStorageSecret && vol.ConfigMap != nil {
+					return nil, errors.Errorf("containers.%s.files.%d: content contains a config map reference and cannot be stored in a %s", containerName, i, FileStorageSecret)
 				}
 				if vol != nil {
 					containerVolumes = append(containerVolumes, *vol)
@@ -145,8 +231,7 @@ func ConvertWorkload(state *project.State, workloadName string) ([]machineryMeta
 		if err != nil {
 			return nil, errors.Wrapf(err, "containers.%s.volumes: failed to combine projected volumes", containerName)
 		}
-		c.VolumeMounts = containerVolumeMounts
-		volumes = append(volumes, containerVolumes...)
+		volumes, c.VolumeMounts = mergeContainerVolumes(volumes, containerName, containerVolumes, containerVolumeMounts)
 
 		if container.LivenessProbe != nil {
 			c.LivenessProbe = &coreV1.Probe{ProbeHandler: buildProbe(container.LivenessProbe.HttpGet)}
@@ -158,13 +243,185 @@ func ConvertWorkload(state *project.State, workloadName string) ([]machineryMeta
 		containers = append(containers, c)
 	}
 
+	workingDirs, err := parseWorkingDirAnnotation(spec.Metadata, containerNames)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+	for i := range containers {
+		containers[i].WorkingDir = workingDirs[containers[i].Name]
+	}
+
+	interactive, err := parseInteractiveAnnotation(spec.Metadata, containerNames)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+	for i := range containers {
+		if slices.Contains(interactive, containers[i].Name) {
+			containers[i].Stdin = true
+			containers[i].TTY = true
+		}
+	}
+
+	envFrom, err := parseEnvFromExistingAnnotation(spec.Metadata, containerNames)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+	for i := range containers {
+		containers[i].EnvFrom = append(containers[i].EnvFrom, envFrom[containers[i].Name]...)
+	}
+
+	probes, err := parseProbesAnnotation(spec.Metadata, containerNames)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+	for i := range containers {
+		if containerProbes, ok := probes[containers[i].Name]; ok {
+			if err := applyContainerProbes(&containers[i], containerProbes); err != nil {
+				return nil, errors.Wrapf(err, "metadata: annotations: %s: %s", internal.ProbesAnnotation, containers[i].Name)
+			}
+		}
+	}
+
+	lifecycles, err := parseLifecycleAnnotation(spec.Metadata, containerNames)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+	for i := range containers {
+		if hooks, ok := lifecycles[containers[i].Name]; ok {
+			containers[i].Lifecycle = buildLifecycle(hooks)
+		}
+	}
+
+	terminationMessages, err := parseTerminationMessageAnnotation(spec.Metadata, containerNames)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+	for i := range containers {
+		if message, ok := terminationMessages[containers[i].Name]; ok {
+			containers[i].TerminationMessagePolicy = message.Policy
+			containers[i].TerminationMessagePath = message.Path
+		}
+	}
+
+	podMonitor, err := parsePodMonitorAnnotation(spec.Metadata, containerNames)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	} else if podMonitor != nil {
+		i := slices.Index(containerNames, podMonitor.Container)
+		containers[i].Ports = append(containers[i].Ports, coreV1.ContainerPort{
+			Name:          podMonitorPortName,
+			ContainerPort: int32(podMonitor.Port),
+			Protocol:      coreV1.ProtocolTCP,
+		})
+	}
+
+	initContainerNames, err := parseInitContainersAnnotation(spec.Metadata, containerNames)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+	containers, workloadInitContainers, err := extractInitContainers(containers, initContainerNames)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+
+	if opts.ConsolidateSecrets {
+		secretValues, err := resourceSecretValues(state.Resources)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to consolidate secrets")
+		}
+		if secret := consolidateSecretEnv(workloadName, secretValues, immutableConfig, containers, workloadInitContainers); secret != nil {
+			manifests = append(manifests, secret)
+		}
+	}
+
+	sidecars, sidecarVolumes, err := buildResourceSidecars(&spec, workloadName, state.Resources, containerNames, volumes)
+	if err != nil {
+		return nil, err
+	}
+	containers = append(containers, sidecars...)
+	volumes = append(volumes, sidecarVolumes...)
+
+	initContainers, err := buildWaitForContainers(spec.Metadata, spec.Resources, sf)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+	initContainers = append(initContainers, workloadInitContainers...)
+
+	runtimeClassName, err := parseRuntimeClassAnnotation(spec.Metadata)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+
+	readinessGates, err := parseReadinessGatesAnnotation(spec.Metadata)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+
+	progressDeadline, err := parseProgressDeadlineAnnotation(spec.Metadata, kind)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+
+	publishNotReady, err := parsePublishNotReadyAnnotation(spec.Metadata, kind)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+
+	rolloutStrategy, err := parseRolloutStrategyAnnotation(spec.Metadata, kind)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+
+	shareProcessNamespace, err := parseShareProcessNamespaceAnnotation(spec.Metadata)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+
+	podSpec := coreV1.PodSpec{
+		InitContainers:   initContainers,
+		Containers:       containers,
+		Volumes:          volumes,
+		RuntimeClassName: runtimeClassName,
+		ReadinessGates:   readinessGates,
+	}
+	if shareProcessNamespace {
+		podSpec.ShareProcessNamespace = internal.Ref(true)
+	}
+	podSpecPatch, err := parsePodSpecPatchAnnotation(spec.Metadata, containerNames)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	} else if podSpecPatch != nil {
+		if err := applyPodSpecPatch(&podSpec, podSpecPatch); err != nil {
+			return nil, errors.Wrapf(err, "metadata: annotations")
+		}
+	}
+
 	// We want to apply the annotations from the workload onto the pod.
 	// See the doc of buildPodAnnotations for what gets included here.
 	podAnnotations := buildPodAnnotations(spec.Metadata)
+	podLabels := maps.Clone(commonLabels)
+	if err := applyResourcePodMetadata(&spec, workloadName, state.Resources, podLabels, podAnnotations); err != nil {
+		return nil, err
+	}
 	topLevelAnnotations := map[string]string{
-		internal.AnnotationPrefix + "workload-name": workloadName,
+		internal.WorkloadNameAnnotation: workloadName,
 	}
 
+	var servicePortNames []string
+	if spec.Service != nil {
+		servicePortNames = slices.Collect(maps.Keys(spec.Service.Ports))
+	}
+	serviceType, err := parseServiceTypeAnnotation(spec.Metadata, servicePortNames)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+
+	serviceAnnotations, err := parseServiceAnnotationsAnnotation(spec.Metadata, sf)
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	}
+
+	var workloadService *coreV1.Service
 	if spec.Service != nil && len(spec.Service.Ports) > 0 {
 		portList := make([]coreV1.ServicePort, 0, len(spec.Service.Ports))
 		for portName, port := range spec.Service.Ports {
@@ -183,7 +440,7 @@ func ConvertWorkload(state *project.State, workloadName string) ([]machineryMeta
 				Protocol:   proto,
 			})
 		}
-		manifests = append(manifests, &coreV1.Service{
+		service := &coreV1.Service{
 			TypeMeta: machineryMeta.TypeMeta{Kind: "Service", APIVersion: "v1"},
 			ObjectMeta: machineryMeta.ObjectMeta{
 				Name:        WorkloadServiceName(workloadName, spec.Metadata),
@@ -191,12 +448,57 @@ func ConvertWorkload(state *project.State, workloadName string) ([]machineryMeta
 				Labels:      commonLabels,
 			},
 			Spec: coreV1.ServiceSpec{
-				Selector: map[string]string{
-					SelectorLabelInstance: commonLabels[SelectorLabelInstance],
-				},
-				Ports: portList,
+				Selector: maps.Clone(selector),
+				Ports:    portList,
 			},
-		})
+		}
+		if len(serviceAnnotations) > 0 {
+			service.Annotations = maps.Clone(serviceAnnotations)
+			maps.Copy(service.Annotations, topLevelAnnotations)
+		}
+		if serviceType != nil {
+			service.Spec.Type = serviceType.Type
+			service.Spec.ExternalTrafficPolicy = serviceType.ExternalTrafficPolicy
+		}
+		applyIPFamilies(service, serviceType)
+		manifests = append(manifests, service)
+		workloadService = service
+
+		// Each group of ports gets an additional Service of its own type selecting the same pods.
+		if serviceType != nil {
+			for _, groupName := range slices.Sorted(maps.Keys(serviceType.Groups)) {
+				group := serviceType.Groups[groupName]
+				groupService := service.DeepCopy()
+				groupService.Name = service.Name + "-" + groupName
+				groupService.Spec.Type = group.Type
+				groupService.Spec.ExternalTrafficPolicy = group.ExternalTrafficPolicy
+				groupService.Spec.Ports = slices.DeleteFunc(groupService.Spec.Ports, func(port coreV1.ServicePort) bool {
+					return !slices.Contains(group.Ports, port.Name)
+				})
+				manifests = append(manifests, groupService)
+			}
+		}
+	}
+
+	networkPolicy, err := buildNetworkPolicy(state, workloadName, opts.LabelScheme, resOutputs, commonLabels, topLevelAnnotations, maps.Clone(selector))
+	if err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	} else if networkPolicy != nil {
+		manifests = append(manifests, networkPolicy)
+	}
+
+	if ciliumPolicy, err := parseCiliumNetworkPolicyAnnotation(spec.Metadata); err != nil {
+		return nil, errors.Wrapf(err, "metadata: annotations")
+	} else if ciliumPolicy != nil {
+		out, err := buildCiliumNetworkPolicy(workloadName, ciliumPolicy, commonLabels, topLevelAnnotations, maps.Clone(selector))
+		if err != nil {
+			return nil, errors.Wrapf(err, "metadata: annotations")
+		}
+		manifests = append(manifests, out)
+	}
+
+	if podMonitor != nil {
+		manifests = append(manifests, buildPodMonitor(workloadName, podMonitor, commonLabels, topLevelAnnotations, maps.Clone(selector)))
 	}
 
 	switch kind {
@@ -209,20 +511,16 @@ func ConvertWorkload(state *project.State, workloadName string) ([]machineryMeta
 				Labels:      commonLabels,
 			},
 			Spec: v1.DeploymentSpec{
+				ProgressDeadlineSeconds: progressDeadline,
 				Selector: &machineryMeta.LabelSelector{
-					MatchLabels: map[string]string{
-						SelectorLabelInstance: commonLabels[SelectorLabelInstance],
-					},
+					MatchLabels: maps.Clone(selector),
 				},
 				Template: coreV1.PodTemplateSpec{
 					ObjectMeta: machineryMeta.ObjectMeta{
-						Labels:      commonLabels,
+						Labels:      podLabels,
 						Annotations: podAnnotations,
 					},
-					Spec: coreV1.PodSpec{
-						Containers: containers,
-						Volumes:    volumes,
-					},
+					Spec: podSpec,
 				},
 			},
 		})
@@ -230,7 +528,7 @@ func ConvertWorkload(state *project.State, workloadName string) ([]machineryMeta
 
 		// need to allocate a headless service here
 		headlessServiceName := fmt.Sprintf("%s-headless-svc", workloadName)
-		manifests = append(manifests, &coreV1.Service{
+		headlessService := &coreV1.Service{
 			TypeMeta: machineryMeta.TypeMeta{Kind: "Service", APIVersion: "v1"},
 			ObjectMeta: machineryMeta.ObjectMeta{
 				Name:        headlessServiceName,
@@ -238,13 +536,14 @@ func ConvertWorkload(state *project.State, workloadName string) ([]machineryMeta
 				Labels:      commonLabels,
 			},
 			Spec: coreV1.ServiceSpec{
-				Selector: map[string]string{
-					SelectorLabelInstance: commonLabels[SelectorLabelInstance],
-				},
-				ClusterIP: "None",
-				Ports:     []coreV1.ServicePort{{Name: "default", Port: 99, TargetPort: intstr.FromInt32(99)}},
+				Selector:                 maps.Clone(selector),
+				ClusterIP:                "None",
+				Ports:                    []coreV1.ServicePort{{Name: "default", Port: 99, TargetPort: intstr.FromInt32(99)}},
+				PublishNotReadyAddresses: publishNotReady,
 			},
-		})
+		}
+		applyIPFamilies(headlessService, serviceType)
+		manifests = append(manifests, headlessService)
 
 		manifests = append(manifests, &v1.StatefulSet{
 			TypeMeta: machineryMeta.TypeMeta{Kind: WorkloadKindStatefulSet, APIVersion: "apps/v1"},
@@ -255,25 +554,32 @@ func ConvertWorkload(state *project.State, workloadName string) ([]machineryMeta
 			},
 			Spec: v1.StatefulSetSpec{
 				Selector: &machineryMeta.LabelSelector{
-					MatchLabels: map[string]string{
-						SelectorLabelInstance: commonLabels[SelectorLabelInstance],
-					},
+					MatchLabels: maps.Clone(selector),
 				},
 				ServiceName: headlessServiceName,
 				Template: coreV1.PodTemplateSpec{
 					ObjectMeta: machineryMeta.ObjectMeta{
-						Labels:      commonLabels,
+						Labels:      podLabels,
 						Annotations: podAnnotations,
 					},
-					Spec: coreV1.PodSpec{
-						Containers: containers,
-						Volumes:    volumes,
-					},
+					Spec: podSpec,
 				},
 				// So the puzzle here is how to get this from our volumes...
 				VolumeClaimTemplates: volumeClaimTemplates,
 			},
 		})
+	case WorkloadKindRollout:
+		rolloutManifests, err := buildRollout(workloadName, rolloutStrategy, workloadService, &coreV1.PodTemplateSpec{
+			ObjectMeta: machineryMeta.ObjectMeta{
+				Labels:      podLabels,
+				Annotations: podAnnotations,
+			},
+			Spec: podSpec,
+		}, commonLabels, topLevelAnnotations, maps.Clone(selector))
+		if err != nil {
+			return nil, errors.Wrapf(err, "metadata: annotations")
+		}
+		manifests = append(manifests, rolloutManifests...)
 	}
 
 	return manifests, nil
@@ -314,6 +620,6 @@ func buildPodAnnotations(metadata map[string]interface{}) map[string]string {
 			out[s] = v
 		}
 	}
-	out[internal.AnnotationPrefix+"workload-name"] = metadata["name"].(string)
+	out[internal.WorkloadNameAnnotation] = metadata["name"].(string)
 	return out
 }
@@ -22,7 +22,13 @@ import (
 	scoretypes "github.com/score-spec/score-go/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	networkingV1 "k8s.io/api/networking/v1"
+	machineryMeta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	"github.com/score-spec/score-k8s/internal"
 	"github.com/score-spec/score-k8s/internal/project"
@@ -43,7 +49,7 @@ func TestMassive(t *testing.T) {
 			"c1": {
 				Image:   "my-image",
 				Command: []string{"do", "thing"},
-				Args:    []string{"with", "${args}"},
+				Args:    []string{"with", "$${args}"},
 				Variables: map[string]string{
 					"VAR":  "RAW",
 					"VAR2": "",
@@ -228,6 +234,7 @@ spec:
           name: vol-0
         - mountPath: /
           name: file-0
+          readOnly: true
       - image: other-image
         name: c2
         resources: {}
@@ -243,3 +250,1082 @@ spec:
 status: {}
 `, out.String())
 }
+
+func TestCommandShell(t *testing.T) {
+	state := new(project.State)
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{
+			"name":        "example",
+			"annotations": map[string]interface{}{"k8s.score.dev/command-shell": "true"},
+		},
+		Containers: map[string]scoretypes.Container{
+			"c1": {Image: "my-image", Command: []string{"echo $A |", "cat"}, Args: []string{"a b"}},
+		},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	manifests, err := ConvertWorkload(state, "example")
+	require.NoError(t, err)
+	c := manifests[0].(*v1.Deployment).Spec.Template.Spec.Containers[0]
+	assert.Equal(t, []string{"/bin/sh", "-c", "echo $A | cat 'a b'"}, c.Command)
+	assert.Nil(t, c.Args)
+}
+
+func TestPodMonitor(t *testing.T) {
+	state := new(project.State)
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{
+			"name": "example",
+			"annotations": map[string]interface{}{
+				"k8s.score.dev/pod-monitor":      "c2:9090",
+				"k8s.score.dev/pod-monitor-path": "/stats",
+			},
+		},
+		Containers: map[string]scoretypes.Container{
+			"c1": {Image: "my-image"},
+			"c2": {Image: "my-image"},
+		},
+	}, nil, project.WorkloadExtras{InstanceSuffix: "-abc"})
+	require.NoError(t, err)
+	manifests, err := ConvertWorkload(state, "example")
+	require.NoError(t, err)
+	require.Len(t, manifests, 2)
+
+	out := new(bytes.Buffer)
+	assert.NoError(t, internal.YamlSerializerInfo.Serializer.Encode(manifests[0].(runtime.Object), out))
+	assert.Equal(t, `apiVersion: monitoring.coreos.com/v1
+kind: PodMonitor
+metadata:
+  annotations:
+    k8s.score.dev/workload-name: example
+  labels:
+    app.kubernetes.io/instance: example-abc
+    app.kubernetes.io/managed-by: score-k8s
+    app.kubernetes.io/name: example
+  name: example
+spec:
+  podMetricsEndpoints:
+  - path: /stats
+    port: metrics
+  selector:
+    matchLabels:
+      app.kubernetes.io/instance: example-abc
+`, out.String())
+	containers := manifests[1].(*v1.Deployment).Spec.Template.Spec.Containers
+	assert.Len(t, containers[0].Ports, 0)
+	assert.Equal(t, []coreV1.ContainerPort{{Name: "metrics", ContainerPort: 9090, Protocol: coreV1.ProtocolTCP}}, containers[1].Ports)
+
+	t.Run("ambiguous container", func(t *testing.T) {
+		_, err := parsePodMonitorAnnotation(map[string]interface{}{
+			"annotations": map[string]interface{}{"k8s.score.dev/pod-monitor": "9090"},
+		}, []string{"c1", "c2"})
+		assert.EqualError(t, err, "k8s.score.dev/pod-monitor: expected <container>:<port> since the workload has more than one container")
+	})
+}
+
+func TestCiliumNetworkPolicy(t *testing.T) {
+	state := new(project.State)
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{
+			"name": "example",
+			"annotations": map[string]interface{}{
+				"k8s.score.dev/cilium-network-policy": `
+ingress:
+- fromEndpoints:
+  - matchLabels:
+      app.kubernetes.io/name: frontend
+  toPorts:
+  - ports:
+    - port: "8080"
+      protocol: TCP
+    rules:
+      http:
+      - method: GET
+        path: /api/.*
+`,
+			},
+		},
+		Containers: map[string]scoretypes.Container{
+			"c1": {Image: "my-image"},
+		},
+	}, nil, project.WorkloadExtras{InstanceSuffix: "-abc"})
+	require.NoError(t, err)
+	manifests, err := ConvertWorkload(state, "example")
+	require.NoError(t, err)
+	require.Len(t, manifests, 2)
+
+	out := new(bytes.Buffer)
+	assert.NoError(t, internal.YamlSerializerInfo.Serializer.Encode(manifests[0].(runtime.Object), out))
+	assert.Equal(t, `apiVersion: cilium.io/v2
+kind: CiliumNetworkPolicy
+metadata:
+  annotations:
+    k8s.score.dev/workload-name: example
+  labels:
+    app.kubernetes.io/instance: example-abc
+    app.kubernetes.io/managed-by: score-k8s
+    app.kubernetes.io/name: example
+  name: example
+spec:
+  endpointSelector:
+    matchLabels:
+      app.kubernetes.io/instance: example-abc
+  ingress:
+  - fromEndpoints:
+    - matchLabels:
+        app.kubernetes.io/name: frontend
+    toPorts:
+    - ports:
+      - port: "8080"
+        protocol: TCP
+      rules:
+        http:
+        - method: GET
+          path: /api/.*
+`, out.String())
+}
+
+func TestInitContainerVolumeMounts(t *testing.T) {
+	state := new(project.State)
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{
+			"name":        "example",
+			"annotations": map[string]interface{}{"k8s.score.dev/init-containers": "migrate"},
+		},
+		Containers: map[string]scoretypes.Container{
+			"app": {Image: "my-image", Volumes: []scoretypes.ContainerVolumesElem{
+				{Source: "${resources.config}", Target: "/etc/config"},
+			}},
+			"migrate": {Image: "my-migrations", Volumes: []scoretypes.ContainerVolumesElem{
+				{Source: "${resources.config}", Target: "/etc/config", ReadOnly: internal.Ref(false)},
+			}},
+		},
+		Resources: map[string]scoretypes.Resource{
+			"config": {Type: "volume"},
+		},
+	}, nil, project.WorkloadExtras{InstanceSuffix: "-abc"})
+	require.NoError(t, err)
+	state.Resources = map[framework.ResourceUid]framework.ScoreResourceState[project.ResourceExtras]{
+		"volume.default#example.config": {
+			Type: "volume", Class: "default", Id: "example.config",
+			Outputs: map[string]interface{}{"source": map[string]interface{}{"configMap": map[string]interface{}{"name": "shared-config"}}},
+		},
+	}
+	manifests, err := ConvertWorkload(state, "example")
+	require.NoError(t, err)
+	require.Len(t, manifests, 1)
+
+	podSpec := manifests[0].(*v1.Deployment).Spec.Template.Spec
+	require.Len(t, podSpec.Containers, 1)
+	assert.Equal(t, "app", podSpec.Containers[0].Name)
+	assert.Equal(t, []coreV1.VolumeMount{{Name: "vol-0", MountPath: "/etc/config", ReadOnly: true}}, podSpec.Containers[0].VolumeMounts)
+	require.Len(t, podSpec.InitContainers, 1)
+	assert.Equal(t, "migrate", podSpec.InitContainers[0].Name)
+	assert.Equal(t, []coreV1.VolumeMount{{Name: "vol-0", MountPath: "/etc/config"}}, podSpec.InitContainers[0].VolumeMounts)
+	assert.Equal(t, []coreV1.Volume{{Name: "vol-0", VolumeSource: coreV1.VolumeSource{
+		ConfigMap: &coreV1.ConfigMapVolumeSource{LocalObjectReference: coreV1.LocalObjectReference{Name: "shared-config"}},
+	}}}, podSpec.Volumes)
+}
+
+func TestInitContainerResourcePlaceholders(t *testing.T) {
+	state := new(project.State)
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{
+			"name":        "example",
+			"annotations": map[string]interface{}{"k8s.score.dev/init-containers": "migrate"},
+		},
+		Containers: map[string]scoretypes.Container{
+			"app": {Image: "my-image"},
+			"migrate": {
+				Image:     "my-migrations",
+				Command:   []string{"migrate", "--host=${resources.db.host}"},
+				Args:      []string{"--database=${resources.db.connection}"},
+				Variables: map[string]string{"DATABASE_URL": "${resources.db.connection}"},
+			},
+		},
+		Resources: map[string]scoretypes.Resource{
+			"db": {Type: "postgres"},
+		},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	state.Resources = map[framework.ResourceUid]framework.ScoreResourceState[project.ResourceExtras]{
+		"postgres.default#example.db": {
+			Type: "postgres", Class: "default", Id: "example.db",
+			Outputs: map[string]interface{}{
+				"host":       "pg",
+				"connection": "postgres://app:" + internal.EncodeSecretReference("pg-secret", "password") + "@pg:5432/app",
+			},
+		},
+	}
+	manifests, err := ConvertWorkload(state, "example")
+	require.NoError(t, err)
+
+	podSpec := manifests[0].(*v1.Deployment).Spec.Template.Spec
+	require.Len(t, podSpec.InitContainers, 1)
+	c := podSpec.InitContainers[0]
+	refName := generateSecretRefEnvVarName("pg-secret", "password")
+	assert.Equal(t, "migrate", c.Name)
+	assert.Equal(t, []string{"migrate", "--host=pg"}, c.Command)
+	assert.Equal(t, []string{"--database=postgres://app:$(" + refName + ")@pg:5432/app"}, c.Args)
+	assert.Equal(t, []coreV1.EnvVar{
+		{Name: refName, ValueFrom: &coreV1.EnvVarSource{SecretKeyRef: &coreV1.SecretKeySelector{
+			LocalObjectReference: coreV1.LocalObjectReference{Name: "pg-secret"}, Key: "password",
+		}}},
+		{Name: "DATABASE_URL", Value: "postgres://app:$(" + refName + ")@pg:5432/app"},
+	}, c.Env)
+}
+
+func TestResourceAliases(t *testing.T) {
+	buildState := func(aliases string) *project.State {
+		state := new(project.State)
+		state, err := state.WithWorkload(&scoretypes.Workload{
+			Metadata: map[string]interface{}{
+				"name":        "example",
+				"annotations": map[string]interface{}{"k8s.score.dev/resource-aliases": aliases},
+			},
+			Containers: map[string]scoretypes.Container{
+				"c1": {Image: "my-image", Variables: map[string]string{
+					"DB_HOST":    "${resources.db.host}",
+					"CACHE_HOST": "${resources.cache.host}",
+				}},
+			},
+			Resources: map[string]scoretypes.Resource{
+				"main-database": {Type: "postgres", Class: internal.Ref("prod"), Id: internal.Ref("main")},
+				"redis-cache":   {Type: "redis"},
+			},
+		}, nil, project.WorkloadExtras{})
+		require.NoError(t, err)
+		state.Resources = map[framework.ResourceUid]framework.ScoreResourceState[project.ResourceExtras]{
+			"postgres.prod#main":                {Type: "postgres", Class: "prod", Id: "main", Outputs: map[string]interface{}{"host": "pg"}},
+			"redis.default#example.redis-cache": {Type: "redis", Class: "default", Id: "example.redis-cache", Outputs: map[string]interface{}{"host": "redis"}},
+		}
+		return state
+	}
+
+	manifests, err := ConvertWorkload(buildState("{db: postgres.prod.main, cache: redis-cache}"), "example")
+	require.NoError(t, err)
+	assert.Equal(t, []coreV1.EnvVar{
+		{Name: "CACHE_HOST", Value: "redis"},
+		{Name: "DB_HOST", Value: "pg"},
+	}, manifests[0].(*v1.Deployment).Spec.Template.Spec.Containers[0].Env)
+
+	_, err = ConvertWorkload(buildState("{db: postgres.prod.other, cache: redis-cache}"), "example")
+	assert.EqualError(t, err, "metadata: annotations: k8s.score.dev/resource-aliases: alias 'db': resource 'postgres.prod.other' does not exist")
+
+	_, err = ConvertWorkload(buildState("{redis-cache: postgres.prod.main}"), "example")
+	assert.EqualError(t, err, "metadata: annotations: k8s.score.dev/resource-aliases: alias 'redis-cache' conflicts with an existing resource")
+}
+
+func TestServiceTypeGroups(t *testing.T) {
+	state := new(project.State)
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{
+			"name": "example",
+			"annotations": map[string]interface{}{
+				"k8s.score.dev/service-type": "{groups: {public: {type: LoadBalancer, ports: [web]}}}",
+			},
+		},
+		Containers: map[string]scoretypes.Container{
+			"c1": {Image: "my-image"},
+		},
+		Service: &scoretypes.WorkloadService{Ports: map[string]scoretypes.ServicePort{
+			"web":   {Port: 80, TargetPort: internal.Ref(8080)},
+			"admin": {Port: 9000},
+		}},
+	}, nil, project.WorkloadExtras{InstanceSuffix: "-abc"})
+	require.NoError(t, err)
+	manifests, err := ConvertWorkload(state, "example")
+	require.NoError(t, err)
+	require.Len(t, manifests, 3)
+
+	service := manifests[0].(*coreV1.Service)
+	assert.Equal(t, "example", service.Name)
+	assert.Equal(t, coreV1.ServiceType(""), service.Spec.Type)
+	assert.Len(t, service.Spec.Ports, 2)
+
+	groupService := manifests[1].(*coreV1.Service)
+	assert.Equal(t, "example-public", groupService.Name)
+	assert.Equal(t, coreV1.ServiceTypeLoadBalancer, groupService.Spec.Type)
+	assert.Equal(t, service.Spec.Selector, groupService.Spec.Selector)
+	assert.Equal(t, []coreV1.ServicePort{{Name: "web", Port: 80, TargetPort: intstr.FromInt32(8080), Protocol: coreV1.ProtocolTCP}}, groupService.Spec.Ports)
+}
+
+func TestServiceTypeIPFamilies(t *testing.T) {
+	state := new(project.State)
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{
+			"name": "example",
+			"annotations": map[string]interface{}{
+				"k8s.score.dev/kind":         "StatefulSet",
+				"k8s.score.dev/service-type": "{ipFamilyPolicy: RequireDualStack, ipFamilies: [IPv6, IPv4], groups: {public: {type: LoadBalancer, ports: [web]}}}",
+			},
+		},
+		Containers: map[string]scoretypes.Container{
+			"c1": {Image: "my-image"},
+		},
+		Service: &scoretypes.WorkloadService{Ports: map[string]scoretypes.ServicePort{
+			"web":   {Port: 80},
+			"admin": {Port: 9000},
+		}},
+	}, nil, project.WorkloadExtras{InstanceSuffix: "-abc"})
+	require.NoError(t, err)
+	manifests, err := ConvertWorkload(state, "example")
+	require.NoError(t, err)
+
+	var services []*coreV1.Service
+	for _, m := range manifests {
+		if svc, ok := m.(*coreV1.Service); ok {
+			services = append(services, svc)
+		}
+	}
+	require.Len(t, services, 3)
+	for _, svc := range services {
+		assert.Equal(t, internal.Ref(coreV1.IPFamilyPolicyRequireDualStack), svc.Spec.IPFamilyPolicy, svc.Name)
+		assert.Equal(t, []coreV1.IPFamily{coreV1.IPv6Protocol, coreV1.IPv4Protocol}, svc.Spec.IPFamilies, svc.Name)
+	}
+}
+
+func TestRollout(t *testing.T) {
+	build := func(strategy string, withService bool) (*project.State, error) {
+		annotations := map[string]interface{}{"k8s.score.dev/kind": "Rollout"}
+		if strategy != "" {
+			annotations["k8s.score.dev/rollout-strategy"] = strategy
+		}
+		workload := &scoretypes.Workload{
+			Metadata:   map[string]interface{}{"name": "example", "annotations": annotations},
+			Containers: map[string]scoretypes.Container{"c1": {Image: "my-image"}},
+		}
+		if withService {
+			workload.Service = &scoretypes.WorkloadService{Ports: map[string]scoretypes.ServicePort{"web": {Port: 80}}}
+		}
+		return new(project.State).WithWorkload(workload, nil, project.WorkloadExtras{})
+	}
+	manifestNames := func(manifests []machineryMeta.Object) []string {
+		out := make([]string, 0, len(manifests))
+		for _, m := range manifests {
+			out = append(out, m.GetName())
+		}
+		return out
+	}
+
+	t.Run("default canary", func(t *testing.T) {
+		state, err := build("", true)
+		require.NoError(t, err)
+		manifests, err := ConvertWorkload(state, "example")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"example", "example-canary", "example-stable", "example"}, manifestNames(manifests))
+		rollout := manifests[3].(*unstructured.Unstructured)
+		assert.Equal(t, "argoproj.io/v1alpha1", rollout.GetAPIVersion())
+		assert.Equal(t, "Rollout", rollout.GetKind())
+		assert.Equal(t, map[string]interface{}{"canary": map[string]interface{}{
+			"canaryService": "example-canary", "stableService": "example-stable",
+		}}, rollout.Object["spec"].(map[string]interface{})["strategy"])
+		assert.Equal(t, manifests[0].(*coreV1.Service).Spec.Selector, manifests[1].(*coreV1.Service).Spec.Selector)
+		containers, _, _ := unstructured.NestedSlice(rollout.Object, "spec", "template", "spec", "containers")
+		require.Len(t, containers, 1)
+		assert.Equal(t, "my-image", containers[0].(map[string]interface{})["image"])
+	})
+
+	t.Run("canary with steps and no service", func(t *testing.T) {
+		state, err := build("{canary: {steps: [{setWeight: 20}, {pause: {duration: 10m}}]}}", false)
+		require.NoError(t, err)
+		manifests, err := ConvertWorkload(state, "example")
+		require.NoError(t, err)
+		require.Len(t, manifests, 1)
+		steps, _, _ := unstructured.NestedSlice(manifests[0].(*unstructured.Unstructured).Object, "spec", "strategy", "canary", "steps")
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"setWeight": int64(20)},
+			map[string]interface{}{"pause": map[string]interface{}{"duration": "10m"}},
+		}, steps)
+	})
+
+	t.Run("blue green", func(t *testing.T) {
+		state, err := build("{blueGreen: {autoPromotionEnabled: false}}", true)
+		require.NoError(t, err)
+		manifests, err := ConvertWorkload(state, "example")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"example", "example-preview", "example"}, manifestNames(manifests))
+		strategy, _, _ := unstructured.NestedMap(manifests[2].(*unstructured.Unstructured).Object, "spec", "strategy", "blueGreen")
+		assert.Equal(t, map[string]interface{}{
+			"activeService": "example", "previewService": "example-preview", "autoPromotionEnabled": false,
+		}, strategy)
+	})
+
+	t.Run("blue green without service", func(t *testing.T) {
+		state, err := build("{blueGreen: {}}", false)
+		require.NoError(t, err)
+		_, err = ConvertWorkload(state, "example")
+		assert.EqualError(t, err, "metadata: annotations: k8s.score.dev/rollout-strategy: blueGreen: activeService must be set when the workload has no service")
+	})
+}
+
+func TestWhenEnv(t *testing.T) {
+	state, err := new(project.State).WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{
+			"name": "example",
+			"annotations": map[string]interface{}{
+				"k8s.score.dev/pod-monitor": "main:9090",
+				"k8s.score.dev/when":        "{k8s.score.dev/pod-monitor: env == prod, containers.monitor: \"env in (prod, staging)\"}",
+			},
+		},
+		Containers: map[string]scoretypes.Container{
+			"main":    {Image: "my-image"},
+			"monitor": {Image: "my-monitor"},
+		},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+
+	manifests, err := ConvertWorkloadWithOptions(state, "example", Options{Env: "prod"})
+	require.NoError(t, err)
+	require.Len(t, manifests, 2)
+	assert.Equal(t, "PodMonitor", manifests[0].(*unstructured.Unstructured).GetKind())
+	assert.Len(t, manifests[1].(*v1.Deployment).Spec.Template.Spec.Containers, 2)
+
+	manifests, err = ConvertWorkloadWithOptions(state, "example", Options{Env: "staging"})
+	require.NoError(t, err)
+	require.Len(t, manifests, 1)
+	assert.Len(t, manifests[0].(*v1.Deployment).Spec.Template.Spec.Containers, 2)
+
+	manifests, err = ConvertWorkload(state, "example")
+	require.NoError(t, err)
+	require.Len(t, manifests, 1)
+	containers := manifests[0].(*v1.Deployment).Spec.Template.Spec.Containers
+	require.Len(t, containers, 1)
+	assert.Equal(t, "main", containers[0].Name)
+}
+
+func TestFileStorage(t *testing.T) {
+	build := func(storage string, content string) (*project.State, error) {
+		return new(project.State).WithWorkload(&scoretypes.Workload{
+			Metadata: map[string]interface{}{
+				"name": "example",
+				"annotations": map[string]interface{}{
+					"k8s.score.dev/file-storage": "{/etc/app/config.yaml: " + storage + "}",
+				},
+			},
+			Containers: map[string]scoretypes.Container{
+				"c1": {Image: "my-image", Files: []scoretypes.ContainerFilesElem{
+					{Target: "/etc/app/config.yaml", Content: internal.Ref(content)},
+				}},
+			},
+		}, nil, project.WorkloadExtras{})
+	}
+
+	t.Run("secret", func(t *testing.T) {
+		state, err := build("Secret", "not secret looking")
+		require.NoError(t, err)
+		manifests, err := ConvertWorkload(state, "example")
+		require.NoError(t, err)
+		require.Len(t, manifests, 2)
+		secret := manifests[0].(*coreV1.Secret)
+		assert.Equal(t, "example-c1-file-0", secret.Name)
+		assert.Equal(t, map[string][]byte{"file": []byte("not secret looking")}, secret.Data)
+		podSpec := manifests[1].(*v1.Deployment).Spec.Template.Spec
+		require.Len(t, podSpec.Volumes, 1)
+		assert.Nil(t, podSpec.Volumes[0].ConfigMap)
+		assert.Equal(t, &coreV1.SecretVolumeSource{
+			SecretName: "example-c1-file-0",
+			Items:      []coreV1.KeyToPath{{Key: "file", Path: "config.yaml"}},
+		}, podSpec.Volumes[0].Secret)
+	})
+
+	t.Run("config map with secret ref", func(t *testing.T) {
+		state, err := build("ConfigMap", internal.EncodeSecretReference("my-secret", "key"))
+		require.NoError(t, err)
+		_, err = ConvertWorkload(state, "example")
+		assert.EqualError(t, err, "containers.c1.files.0: content contains a secret reference and cannot be stored in a ConfigMap")
+	})
+}
+
+func TestServiceAnnotations(t *testing.T) {
+	build := func(annotation string) (*project.State, error) {
+		state, err := new(project.State).WithWorkload(&scoretypes.Workload{
+			Metadata: map[string]interface{}{
+				"name": "example",
+				"annotations": map[string]interface{}{
+					"k8s.score.dev/service-annotations": annotation,
+				},
+			},
+			Containers: map[string]scoretypes.Container{
+				"c1": {Image: "my-image"},
+			},
+			Service: &scoretypes.WorkloadService{Ports: map[string]scoretypes.ServicePort{
+				"web": {Port: 443},
+			}},
+			Resources: map[string]scoretypes.Resource{
+				"cert": {Type: "certificate"},
+			},
+		}, nil, project.WorkloadExtras{})
+		if err != nil {
+			return nil, err
+		}
+		state.Resources = map[framework.ResourceUid]framework.ScoreResourceState[project.ResourceExtras]{
+			"certificate.default#example.cert": {
+				Type: "certificate", Class: "default", Id: "example.cert",
+				Outputs: map[string]interface{}{"arn": "arn:aws:acm:eu-west-1:123456789012:certificate/abc"},
+			},
+		}
+		return state, nil
+	}
+
+	t.Run("resolved", func(t *testing.T) {
+		state, err := build(`{service.beta.kubernetes.io/aws-load-balancer-ssl-cert: "${resources.cert.arn}"}`)
+		require.NoError(t, err)
+		manifests, err := ConvertWorkload(state, "example")
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"k8s.score.dev/workload-name":                           "example",
+			"service.beta.kubernetes.io/aws-load-balancer-ssl-cert": "arn:aws:acm:eu-west-1:123456789012:certificate/abc",
+		}, manifests[0].(*coreV1.Service).Annotations)
+		// the annotations are only added to the Service
+		assert.Equal(t, map[string]string{"k8s.score.dev/workload-name": "example"}, manifests[1].(*v1.Deployment).Annotations)
+	})
+
+	t.Run("unresolved", func(t *testing.T) {
+		state, err := build(`{service.beta.kubernetes.io/aws-load-balancer-ssl-cert: "${resources.cert.id}"}`)
+		require.NoError(t, err)
+		_, err = ConvertWorkload(state, "example")
+		assert.EqualError(t, err, "metadata: annotations: k8s.score.dev/service-annotations: service.beta.kubernetes.io/aws-load-balancer-ssl-cert: failed to substitute placeholders: invalid ref 'resources.cert.id': key 'id' not found")
+	})
+}
+
+func TestContainerProbes(t *testing.T) {
+	state, err := new(project.State).WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{
+			"name": "example",
+			"annotations": map[string]interface{}{
+				"k8s.score.dev/probes": "{c1: {startup: {failureThreshold: 30, periodSeconds: 10}, liveness: {failureThreshold: 3, periodSeconds: 5}}}",
+			},
+		},
+		Containers: map[string]scoretypes.Container{
+			"c1": {Image: "my-image", LivenessProbe: &scoretypes.ContainerProbe{HttpGet: scoretypes.HttpProbe{Path: "/healthz", Port: 8080}}},
+		},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	manifests, err := ConvertWorkload(state, "example")
+	require.NoError(t, err)
+	container := manifests[0].(*v1.Deployment).Spec.Template.Spec.Containers[0]
+	require.NotNil(t, container.StartupProbe)
+	assert.Equal(t, int32(30), container.StartupProbe.FailureThreshold)
+	assert.Equal(t, int32(10), container.StartupProbe.PeriodSeconds)
+	assert.Equal(t, "/healthz", container.StartupProbe.HTTPGet.Path)
+	assert.Equal(t, int32(3), container.LivenessProbe.FailureThreshold)
+	assert.Equal(t, int32(5), container.LivenessProbe.PeriodSeconds)
+}
+
+func TestResourceSidecars(t *testing.T) {
+	build := func(sidecars ...[]map[string]interface{}) (*project.State, error) {
+		state, err := new(project.State).WithWorkload(&scoretypes.Workload{
+			Metadata: map[string]interface{}{"name": "example"},
+			Containers: map[string]scoretypes.Container{
+				"main": {Image: "my-image"},
+			},
+			Resources: map[string]scoretypes.Resource{
+				"tracing":  {Type: "tracing"},
+				"tracing2": {Type: "tracing", Id: internal.Ref("shared")},
+			},
+		}, nil, project.WorkloadExtras{})
+		if err != nil {
+			return nil, err
+		}
+		state.Resources = map[framework.ResourceUid]framework.ScoreResourceState[project.ResourceExtras]{
+			"tracing.default#example.tracing": {Type: "tracing", Class: "default", Id: "example.tracing", Extras: project.ResourceExtras{Sidecars: sidecars[0]}},
+			"tracing.default#shared":          {Type: "tracing", Class: "default", Id: "shared", Extras: project.ResourceExtras{Sidecars: sidecars[1]}},
+		}
+		return state, nil
+	}
+	otel := func(env map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"container": map[string]interface{}{
+				"name": "otel", "image": "otel/opentelemetry-collector:0.110.0",
+				"env":          []interface{}{env},
+				"volumeMounts": []interface{}{map[string]interface{}{"name": "otel-config", "mountPath": "/etc/otel"}},
+			},
+			"volumes": []interface{}{map[string]interface{}{"name": "otel-config", "emptyDir": map[string]interface{}{}}},
+		}
+	}
+
+	t.Run("merged", func(t *testing.T) {
+		state, err := build(
+			[]map[string]interface{}{otel(map[string]interface{}{"name": "A", "value": "1"})},
+			[]map[string]interface{}{otel(map[string]interface{}{"name": "B", "value": "2"})},
+		)
+		require.NoError(t, err)
+		manifests, err := ConvertWorkload(state, "example")
+		require.NoError(t, err)
+		podSpec := manifests[0].(*v1.Deployment).Spec.Template.Spec
+		require.Len(t, podSpec.Containers, 2)
+		assert.Equal(t, "main", podSpec.Containers[0].Name)
+		assert.Equal(t, coreV1.Container{
+			Name:         "otel",
+			Image:        "otel/opentelemetry-collector:0.110.0",
+			Env:          []coreV1.EnvVar{{Name: "A", Value: "1"}, {Name: "B", Value: "2"}},
+			VolumeMounts: []coreV1.VolumeMount{{Name: "otel-config", MountPath: "/etc/otel"}},
+		}, podSpec.Containers[1])
+		assert.Equal(t, []coreV1.Volume{{Name: "otel-config", VolumeSource: coreV1.VolumeSource{EmptyDir: &coreV1.EmptyDirVolumeSource{}}}}, podSpec.Volumes)
+	})
+
+	t.Run("conflicting env", func(t *testing.T) {
+		state, err := build(
+			[]map[string]interface{}{otel(map[string]interface{}{"name": "A", "value": "1"})},
+			[]map[string]interface{}{otel(map[string]interface{}{"name": "A", "value": "2"})},
+		)
+		require.NoError(t, err)
+		_, err = ConvertWorkload(state, "example")
+		assert.EqualError(t, err, "resources.tracing2: sidecars.0: sidecar 'otel' env var 'A' conflicts with an existing definition")
+	})
+
+	t.Run("workload container name", func(t *testing.T) {
+		state, err := build(
+			[]map[string]interface{}{{"container": map[string]interface{}{"name": "main", "image": "busybox"}}},
+			nil,
+		)
+		require.NoError(t, err)
+		_, err = ConvertWorkload(state, "example")
+		assert.EqualError(t, err, "resources.tracing: sidecars.0: container name 'main' conflicts with a workload container")
+	})
+
+	t.Run("unknown field", func(t *testing.T) {
+		state, err := build(
+			[]map[string]interface{}{{"container": map[string]interface{}{"name": "otel", "image": "busybox"}, "unknown": true}},
+			nil,
+		)
+		require.NoError(t, err)
+		_, err = ConvertWorkload(state, "example")
+		assert.EqualError(t, err, "resources.tracing: sidecars.0: failed to decode: json: unknown field \"unknown\"")
+	})
+}
+
+func TestPodSpecPatch(t *testing.T) {
+	state, err := new(project.State).WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{
+			"name": "example",
+			"annotations": map[string]interface{}{
+				"k8s.score.dev/kind":           "StatefulSet",
+				"k8s.score.dev/pod-spec-patch": "{hostAliases: [{ip: 10.0.0.1, hostnames: [db.local]}], containers: [{name: c1, stdin: true}]}",
+			},
+		},
+		Containers: map[string]scoretypes.Container{
+			"c1": {Image: "my-image"},
+		},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	manifests, err := ConvertWorkload(state, "example")
+	require.NoError(t, err)
+	podSpec := manifests[1].(*v1.StatefulSet).Spec.Template.Spec
+	assert.Equal(t, []coreV1.HostAlias{{IP: "10.0.0.1", Hostnames: []string{"db.local"}}}, podSpec.HostAliases)
+	assert.Equal(t, "my-image", podSpec.Containers[0].Image)
+	assert.True(t, podSpec.Containers[0].Stdin)
+}
+
+func TestContainerArgsPlaceholders(t *testing.T) {
+	state, err := new(project.State).WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{"name": "example"},
+		Containers: map[string]scoretypes.Container{
+			"c1": {
+				Image:   "my-image",
+				Command: []string{"my-app"},
+				Args:    []string{"--db=${resources.db.host}:${resources.db.port}", "--password=${resources.db.password}"},
+			},
+		},
+		Resources: map[string]scoretypes.Resource{
+			"db": {Type: "postgres"},
+		},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	state.Resources = map[framework.ResourceUid]framework.ScoreResourceState[project.ResourceExtras]{
+		"postgres.default#example.db": {
+			Type: "postgres", Class: "default", Id: "example.db",
+			Outputs: map[string]interface{}{
+				"host":     "pg",
+				"port":     5432,
+				"password": internal.EncodeSecretReference("pg-secret", "password"),
+			},
+		},
+	}
+	manifests, err := ConvertWorkload(state, "example")
+	require.NoError(t, err)
+	c := manifests[0].(*v1.Deployment).Spec.Template.Spec.Containers[0]
+	refName := generateSecretRefEnvVarName("pg-secret", "password")
+	assert.Equal(t, []string{"my-app"}, c.Command)
+	assert.Equal(t, []string{"--db=pg:5432", "--password=$(" + refName + ")"}, c.Args)
+	assert.Equal(t, []coreV1.EnvVar{{Name: refName, ValueFrom: &coreV1.EnvVarSource{SecretKeyRef: &coreV1.SecretKeySelector{
+		LocalObjectReference: coreV1.LocalObjectReference{Name: "pg-secret"}, Key: "password",
+	}}}}, c.Env)
+}
+
+func TestNoServiceWithoutPorts(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		service *scoretypes.WorkloadService
+	}{
+		{name: "no service"},
+		{name: "empty ports", service: &scoretypes.WorkloadService{Ports: map[string]scoretypes.ServicePort{}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			state, err := new(project.State).WithWorkload(&scoretypes.Workload{
+				Metadata: map[string]interface{}{
+					"name": "example",
+					"annotations": map[string]interface{}{
+						"k8s.score.dev/service-annotations": "{a: b}",
+					},
+				},
+				Containers: map[string]scoretypes.Container{
+					"c1": {Image: "my-image"},
+				},
+				Service: tc.service,
+			}, nil, project.WorkloadExtras{})
+			require.NoError(t, err)
+			manifests, err := ConvertWorkload(state, "example")
+			require.NoError(t, err)
+			require.Len(t, manifests, 1)
+			assert.IsType(t, &v1.Deployment{}, manifests[0])
+		})
+	}
+}
+
+func TestPublishNotReadyAddresses(t *testing.T) {
+	state, err := new(project.State).WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{
+			"name": "example",
+			"annotations": map[string]interface{}{
+				"k8s.score.dev/kind":                        "StatefulSet",
+				"k8s.score.dev/publish-not-ready-addresses": "true",
+			},
+		},
+		Containers: map[string]scoretypes.Container{
+			"c1": {Image: "my-image"},
+		},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	manifests, err := ConvertWorkload(state, "example")
+	require.NoError(t, err)
+	headless := manifests[0].(*coreV1.Service)
+	assert.Equal(t, "example-headless-svc", headless.Name)
+	assert.True(t, headless.Spec.PublishNotReadyAddresses)
+}
+
+func TestWorkloadServicePlaceholders(t *testing.T) {
+	state, err := new(project.State).WithWorkload(&scoretypes.Workload{
+		Metadata:   map[string]interface{}{"name": "backend"},
+		Containers: map[string]scoretypes.Container{"c1": {Image: "my-image"}},
+		Service: &scoretypes.WorkloadService{Ports: map[string]scoretypes.ServicePort{
+			"web": {Port: 80},
+		}},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	state, err = state.WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{"name": "frontend"},
+		Containers: map[string]scoretypes.Container{"c1": {
+			Image:     "my-image",
+			Variables: map[string]string{"BACKEND_URL": "http://${workloads.backend.service.host}:${workloads.backend.service.ports.web}"},
+		}},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	manifests, err := ConvertWorkload(state, "frontend")
+	require.NoError(t, err)
+	assert.Equal(t, []coreV1.EnvVar{{Name: "BACKEND_URL", Value: "http://backend:80"}}, manifests[0].(*v1.Deployment).Spec.Template.Spec.Containers[0].Env)
+}
+
+func TestInteractive(t *testing.T) {
+	state, err := new(project.State).WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{
+			"name":        "example",
+			"annotations": map[string]interface{}{"k8s.score.dev/interactive": "debug"},
+		},
+		Containers: map[string]scoretypes.Container{
+			"debug": {Image: "busybox"},
+			"main":  {Image: "my-image"},
+		},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	manifests, err := ConvertWorkload(state, "example")
+	require.NoError(t, err)
+	containers := manifests[0].(*v1.Deployment).Spec.Template.Spec.Containers
+	assert.True(t, containers[0].Stdin)
+	assert.True(t, containers[0].TTY)
+	assert.False(t, containers[1].Stdin)
+	assert.False(t, containers[1].TTY)
+}
+
+func TestEnvFromExisting(t *testing.T) {
+	state, err := new(project.State).WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{
+			"name": "example",
+			"annotations": map[string]interface{}{
+				"k8s.score.dev/env-from-existing": "[{secret: db-credentials, prefix: DB_, containers: [main]}]",
+			},
+		},
+		Containers: map[string]scoretypes.Container{
+			"debug": {Image: "busybox"},
+			"main":  {Image: "my-image"},
+		},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	manifests, err := ConvertWorkload(state, "example")
+	require.NoError(t, err)
+	containers := manifests[0].(*v1.Deployment).Spec.Template.Spec.Containers
+	assert.Empty(t, containers[0].EnvFrom)
+	assert.Equal(t, []coreV1.EnvFromSource{{
+		Prefix:    "DB_",
+		SecretRef: &coreV1.SecretEnvSource{LocalObjectReference: coreV1.LocalObjectReference{Name: "db-credentials"}},
+	}}, containers[1].EnvFrom)
+}
+
+func TestResourcePodMetadata(t *testing.T) {
+	build := func(extras ...project.ResourceExtras) (*project.State, error) {
+		state, err := new(project.State).WithWorkload(&scoretypes.Workload{
+			Metadata: map[string]interface{}{
+				"name": "example",
+				"annotations": map[string]interface{}{
+					"mesh/mode": "strict",
+				},
+			},
+			Containers: map[string]scoretypes.Container{
+				"main": {Image: "my-image"},
+			},
+			Resources: map[string]scoretypes.Resource{
+				"mesh":  {Type: "mesh"},
+				"mesh2": {Type: "mesh", Id: internal.Ref("shared")},
+			},
+		}, nil, project.WorkloadExtras{})
+		if err != nil {
+			return nil, err
+		}
+		state.Resources = map[framework.ResourceUid]framework.ScoreResourceState[project.ResourceExtras]{
+			"mesh.default#example.mesh": {Type: "mesh", Class: "default", Id: "example.mesh", Extras: extras[0]},
+			"mesh.default#shared":       {Type: "mesh", Class: "default", Id: "shared", Extras: extras[1]},
+		}
+		return state, nil
+	}
+
+	t.Run("merged", func(t *testing.T) {
+		state, err := build(
+			project.ResourceExtras{
+				PodLabels:      map[string]string{"sidecar.istio.io/inject": "true", SelectorLabelName: "other"},
+				PodAnnotations: map[string]string{"mesh/mode": "permissive", "mesh/inject": "enabled"},
+			},
+			project.ResourceExtras{
+				PodLabels: map[string]string{"sidecar.istio.io/inject": "true"},
+			},
+		)
+		require.NoError(t, err)
+		manifests, err := ConvertWorkload(state, "example")
+		require.NoError(t, err)
+		deployment := manifests[0].(*v1.Deployment)
+		assert.Equal(t, map[string]string{
+			SelectorLabelName:         "example",
+			SelectorLabelInstance:     "example",
+			SelectorLabelManagedBy:    "score-k8s",
+			"sidecar.istio.io/inject": "true",
+		}, deployment.Spec.Template.Labels)
+		assert.Equal(t, map[string]string{
+			"mesh/mode":                   "strict",
+			"mesh/inject":                 "enabled",
+			"k8s.score.dev/workload-name": "example",
+		}, deployment.Spec.Template.Annotations)
+		assert.NotContains(t, deployment.Labels, "sidecar.istio.io/inject")
+	})
+
+	t.Run("conflicting resources", func(t *testing.T) {
+		state, err := build(
+			project.ResourceExtras{PodAnnotations: map[string]string{"mesh/inject": "enabled"}},
+			project.ResourceExtras{PodAnnotations: map[string]string{"mesh/inject": "disabled"}},
+		)
+		require.NoError(t, err)
+		_, err = ConvertWorkload(state, "example")
+		assert.EqualError(t, err, "resources.mesh2: pod annotation 'mesh/inject' conflicts with the value from resources.mesh")
+	})
+
+	t.Run("invalid label value", func(t *testing.T) {
+		state, err := build(
+			project.ResourceExtras{PodLabels: map[string]string{"mesh": "not valid!"}},
+			project.ResourceExtras{},
+		)
+		require.NoError(t, err)
+		_, err = ConvertWorkload(state, "example")
+		assert.ErrorContains(t, err, "resources.mesh: pod label 'mesh' value is invalid: ")
+	})
+}
+
+func TestConvertWorkloadLabelScheme(t *testing.T) {
+	state := new(project.State)
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata:   map[string]interface{}{"name": "backend"},
+		Containers: map[string]scoretypes.Container{"c1": {Image: "my-image"}},
+	}, nil, project.WorkloadExtras{InstanceSuffix: "-def"})
+	require.NoError(t, err)
+	state, err = state.WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{
+			"name": "example",
+			"annotations": map[string]interface{}{
+				"k8s.score.dev/network-policy": "egress:\n- workload: backend\n",
+			},
+		},
+		Containers: map[string]scoretypes.Container{"c1": {Image: "my-image"}},
+		Service: &scoretypes.WorkloadService{Ports: map[string]scoretypes.ServicePort{
+			"web": {Port: 80},
+		}},
+	}, nil, project.WorkloadExtras{InstanceSuffix: "-abc"})
+	require.NoError(t, err)
+
+	t.Run("legacy", func(t *testing.T) {
+		manifests, err := ConvertWorkloadWithOptions(state, "example", Options{LabelScheme: LabelSchemeLegacy})
+		require.NoError(t, err)
+		require.Len(t, manifests, 3)
+		selector := map[string]string{"app": "example-abc"}
+		service := manifests[0].(*coreV1.Service)
+		assert.Equal(t, selector, service.Spec.Selector)
+		networkPolicy := manifests[1].(*networkingV1.NetworkPolicy)
+		assert.Equal(t, selector, networkPolicy.Spec.PodSelector.MatchLabels)
+		assert.Equal(t, map[string]string{"app": "backend-def"}, networkPolicy.Spec.Egress[1].To[0].PodSelector.MatchLabels)
+		deployment := manifests[2].(*v1.Deployment)
+		assert.Equal(t, selector, deployment.Spec.Selector.MatchLabels)
+		assert.Equal(t, map[string]string{
+			"app":                          "example-abc",
+			"app.kubernetes.io/instance":   "example-abc",
+			"app.kubernetes.io/managed-by": "score-k8s",
+			"app.kubernetes.io/name":       "example",
+		}, deployment.Spec.Template.Labels)
+	})
+
+	t.Run("recommended", func(t *testing.T) {
+		manifests, err := ConvertWorkloadWithOptions(state, "example", Options{LabelScheme: LabelSchemeRecommended})
+		require.NoError(t, err)
+		defaults, err := ConvertWorkload(state, "example")
+		require.NoError(t, err)
+		assert.Equal(t, defaults, manifests)
+		deployment := manifests[2].(*v1.Deployment)
+		assert.Equal(t, map[string]string{"app.kubernetes.io/instance": "example-abc"}, deployment.Spec.Selector.MatchLabels)
+		assert.NotContains(t, deployment.Spec.Template.Labels, "app")
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		_, err := ConvertWorkloadWithOptions(state, "example", Options{LabelScheme: "other"})
+		assert.EqualError(t, err, "label scheme 'other' is not supported, expected one of recommended, legacy")
+	})
+}
+
+func TestNetworkPolicyEgress(t *testing.T) {
+	state := new(project.State)
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{"name": "backend"},
+		Containers: map[string]scoretypes.Container{
+			"c1": {Image: "my-image"},
+		},
+		Service: &scoretypes.WorkloadService{Ports: map[string]scoretypes.ServicePort{
+			"web": {Port: 80, TargetPort: internal.Ref(8080)},
+		}},
+	}, nil, project.WorkloadExtras{InstanceSuffix: "-def"})
+	require.NoError(t, err)
+	state, err = state.WithWorkload(&scoretypes.Workload{
+		Metadata: map[string]interface{}{
+			"name": "example",
+			"annotations": map[string]interface{}{
+				"k8s.score.dev/network-policy": `
+egress:
+- resource: db
+- resource: external
+  ports: [443]
+- workload: backend
+  ports: [80]
+- cidr: 10.0.0.0/8
+`,
+			},
+		},
+		Containers: map[string]scoretypes.Container{
+			"c1": {Image: "my-image"},
+		},
+		Resources: map[string]scoretypes.Resource{
+			"db":       {Type: "postgres"},
+			"external": {Type: "api"},
+		},
+	}, nil, project.WorkloadExtras{InstanceSuffix: "-abc"})
+	require.NoError(t, err)
+	state.Resources = map[framework.ResourceUid]framework.ScoreResourceState[project.ResourceExtras]{
+		"postgres.default#example.db": {
+			Type: "postgres", Class: "default", Id: "example.db",
+			Outputs: map[string]interface{}{"host": "pg-db.apps.svc.cluster.local", "port": 5432},
+			Extras: project.ResourceExtras{Manifests: []map[string]interface{}{{
+				"apiVersion": "v1",
+				"kind":       "Service",
+				"metadata":   map[string]interface{}{"name": "pg-db"},
+				"spec": map[string]interface{}{
+					"selector": map[string]interface{}{"app.kubernetes.io/instance": "pg-db"},
+					"ports":    []interface{}{map[string]interface{}{"port": 5432, "targetPort": 15432}},
+				},
+			}}},
+		},
+		"api.default#example.external": {
+			Type: "api", Class: "default", Id: "example.external",
+			Outputs: map[string]interface{}{"host": "203.0.113.10"},
+		},
+	}
+	manifests, err := ConvertWorkload(state, "example")
+	require.NoError(t, err)
+	require.Len(t, manifests, 2)
+
+	out := new(bytes.Buffer)
+	assert.NoError(t, internal.YamlSerializerInfo.Serializer.Encode(manifests[0].(runtime.Object), out))
+	assert.Equal(t, `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  annotations:
+    k8s.score.dev/workload-name: example
+  creationTimestamp: null
+  labels:
+    app.kubernetes.io/instance: example-abc
+    app.kubernetes.io/managed-by: score-k8s
+    app.kubernetes.io/name: example
+  name: example
+spec:
+  egress:
+  - ports:
+    - port: 53
+      protocol: UDP
+    - port: 53
+      protocol: TCP
+  - ports:
+    - port: 15432
+      protocol: TCP
+    to:
+    - namespaceSelector:
+        matchLabels:
+          kubernetes.io/metadata.name: apps
+      podSelector:
+        matchLabels:
+          app.kubernetes.io/instance: pg-db
+  - ports:
+    - port: 443
+      protocol: TCP
+    to:
+    - ipBlock:
+        cidr: 203.0.113.10/32
+  - ports:
+    - port: 8080
+      protocol: TCP
+    to:
+    - podSelector:
+        matchLabels:
+          app.kubernetes.io/instance: backend-def
+  - to:
+    - ipBlock:
+        cidr: 10.0.0.0/8
+  podSelector:
+    matchLabels:
+      app.kubernetes.io/instance: example-abc
+  policyTypes:
+  - Egress
+`, out.String())
+
+	// errors are reported against the annotation
+	state.Workloads["example"].Spec.Metadata["annotations"] = map[string]interface{}{
+		"k8s.score.dev/network-policy": "egress: [{workload: missing}]",
+	}
+	_, err = ConvertWorkload(state, "example")
+	assert.EqualError(t, err, "metadata: annotations: k8s.score.dev/network-policy: egress.0: workload 'missing' does not exist")
+	state.Workloads["example"].Spec.Metadata["annotations"] = map[string]interface{}{
+		"k8s.score.dev/network-policy": "egress: [{cidr: 10.0.0.0/8, workload: backend}]",
+	}
+	_, err = ConvertWorkload(state, "example")
+	assert.EqualError(t, err, "metadata: annotations: k8s.score.dev/network-policy: egress.0: exactly one of resource, workload, or cidr must be set")
+}
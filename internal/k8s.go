@@ -15,12 +15,19 @@
 package internal
 
 import (
+	"encoding/base64"
+	"fmt"
+	"maps"
+	"slices"
+
+	"gopkg.in/yaml.v3"
 	appsV1 "k8s.io/api/apps/v1"
 	appsV1b1 "k8s.io/api/apps/v1beta1"
 	appsV1b2 "k8s.io/api/apps/v1beta2"
 	coreV1 "k8s.io/api/core/v1"
 	networkingV1 "k8s.io/api/networking/v1"
 	networkingV1b1 "k8s.io/api/networking/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 )
@@ -28,14 +35,84 @@ import (
 var K8sCodecFactory = serializer.CodecFactory{}
 var YamlSerializerInfo = runtime.SerializerInfo{}
 
+// schemeFuncs are the functions used to build the scheme behind the serializers, including any registered through
+// AddToScheme.
+var schemeFuncs = []func(*runtime.Scheme) error{
+	coreV1.AddToScheme,
+	appsV1.AddToScheme,
+	appsV1b1.AddToScheme,
+	appsV1b2.AddToScheme,
+	networkingV1.AddToScheme,
+	networkingV1b1.AddToScheme,
+}
+
 func init() {
+	_ = buildSerializers()
+}
+
+func buildSerializers() error {
 	scheme := runtime.NewScheme()
-	_ = coreV1.AddToScheme(scheme)
-	_ = appsV1.AddToScheme(scheme)
-	_ = appsV1b1.AddToScheme(scheme)
-	_ = appsV1b2.AddToScheme(scheme)
-	_ = networkingV1.AddToScheme(scheme)
-	_ = networkingV1b1.AddToScheme(scheme)
+	for _, f := range schemeFuncs {
+		if err := f(scheme); err != nil {
+			return err
+		}
+	}
 	K8sCodecFactory = serializer.NewCodecFactory(scheme)
 	YamlSerializerInfo, _ = runtime.SerializerInfoForMediaType(K8sCodecFactory.SupportedMediaTypes(), runtime.ContentTypeYAML)
+	return nil
+}
+
+// AddToScheme registers additional types, such as the Go types of custom resources, with the scheme used to validate
+// and serialize manifests. Manifests of registered kinds are strictly validated against the Go types.
+func AddToScheme(funcs ...func(*runtime.Scheme) error) error {
+	schemeFuncs = append(schemeFuncs, funcs...)
+	if err := buildSerializers(); err != nil {
+		schemeFuncs = schemeFuncs[:len(schemeFuncs)-len(funcs)]
+		_ = buildSerializers()
+		return err
+	}
+	return nil
+}
+
+// checkSecretData checks that the data values of a Secret are base64 encoded. Plaintext values belong in the
+// stringData instead, this gives a clearer error than the Secret failing to decode.
+func checkSecretData(manifest map[string]interface{}) error {
+	data, _ := manifest["data"].(map[string]interface{})
+	for _, k := range slices.Sorted(maps.Keys(data)) {
+		if v, ok := data[k].(string); ok {
+			if _, err := base64.StdEncoding.DecodeString(v); err != nil {
+				return fmt.Errorf("secret data.%s is not valid base64, put plaintext values in stringData instead", k)
+			}
+		}
+	}
+	return nil
+}
+
+// NormalizeManifest checks that a raw manifest, such as one returned by a provisioner, is a valid object and returns
+// it re-encoded through the unstructured serializer so that every manifest has the same value types regardless of
+// whether it came from yaml or json. Manifests of kinds known to the scheme must also decode strictly into the Go type.
+func NormalizeManifest(manifest map[string]interface{}) (map[string]interface{}, error) {
+	u := &unstructured.Unstructured{Object: manifest}
+	if u.GetAPIVersion() == "" {
+		return nil, fmt.Errorf("apiVersion is not set")
+	} else if u.GetKind() == "" {
+		return nil, fmt.Errorf("kind is not set")
+	}
+	if u.GetAPIVersion() == "v1" && u.GetKind() == "Secret" {
+		if err := checkSecretData(manifest); err != nil {
+			return nil, err
+		}
+	}
+	raw, err := u.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode: %w", err)
+	}
+	if _, _, err := YamlSerializerInfo.StrictSerializer.Decode(raw, nil, nil); err != nil && !runtime.IsNotRegisteredError(err) {
+		return nil, fmt.Errorf("matched a known kind but was not valid: %w", err)
+	}
+	var out map[string]interface{}
+	if err := yaml.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("failed to decode: %w", err)
+	}
+	return out, nil
 }
@@ -0,0 +1,106 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package internal
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type testWidget struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              testWidgetSpec `json:"spec"`
+}
+
+type testWidgetSpec struct {
+	Size int `json:"size"`
+}
+
+func (w *testWidget) DeepCopyObject() runtime.Object {
+	out := *w
+	w.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
+
+func TestNormalizeManifest(t *testing.T) {
+	widget := func(spec map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Widget",
+			"metadata":   map[string]interface{}{"name": "my-widget"},
+			"spec":       spec,
+		}
+	}
+
+	t.Run("unregistered custom resource", func(t *testing.T) {
+		// json decoded numbers are float64, they are normalized to the same types as yaml decoded values
+		out, err := NormalizeManifest(widget(map[string]interface{}{"size": float64(3), "unknown": true}))
+		require.NoError(t, err)
+		assert.Equal(t, widget(map[string]interface{}{"size": 3, "unknown": true}), out)
+	})
+
+	t.Run("missing kind", func(t *testing.T) {
+		_, err := NormalizeManifest(map[string]interface{}{"apiVersion": "example.com/v1"})
+		assert.EqualError(t, err, "kind is not set")
+	})
+
+	t.Run("invalid known kind", func(t *testing.T) {
+		_, err := NormalizeManifest(map[string]interface{}{
+			"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "c"}, "data": []interface{}{"a"},
+		})
+		assert.ErrorContains(t, err, "matched a known kind but was not valid")
+	})
+
+	t.Run("secret data", func(t *testing.T) {
+		secret := func(data map[string]interface{}) map[string]interface{} {
+			return map[string]interface{}{
+				"apiVersion": "v1", "kind": "Secret", "metadata": map[string]interface{}{"name": "s"},
+				"data": data, "stringData": map[string]interface{}{"plain": "not base64!"},
+			}
+		}
+		out, err := NormalizeManifest(secret(map[string]interface{}{"password": "cGFzc3dvcmQ="}))
+		require.NoError(t, err)
+		assert.Equal(t, secret(map[string]interface{}{"password": "cGFzc3dvcmQ="}), out)
+
+		_, err = NormalizeManifest(secret(map[string]interface{}{"password": "pass word"}))
+		assert.EqualError(t, err, "secret data.password is not valid base64, put plaintext values in stringData instead")
+	})
+
+	t.Run("registered custom resource", func(t *testing.T) {
+		original := slices.Clone(schemeFuncs)
+		t.Cleanup(func() {
+			schemeFuncs = original
+			_ = buildSerializers()
+		})
+		require.NoError(t, AddToScheme(func(s *runtime.Scheme) error {
+			s.AddKnownTypeWithName(schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}, &testWidget{})
+			return nil
+		}))
+
+		out, err := NormalizeManifest(widget(map[string]interface{}{"size": 3}))
+		require.NoError(t, err)
+		assert.Equal(t, widget(map[string]interface{}{"size": 3}), out)
+
+		_, err = NormalizeManifest(widget(map[string]interface{}{"size": 3, "unknown": true}))
+		assert.ErrorContains(t, err, "matched a known kind but was not valid: strict decoding error: unknown field \"spec.unknown\"")
+	})
+}
@@ -51,3 +51,47 @@ func (h *SimpleHandler) WithGroup(name string) slog.Handler {
 }
 
 var _ slog.Handler = (*SimpleHandler)(nil)
+
+// RecordingHandler passes records through to the Inner handler and records the messages of those at or above Level.
+type RecordingHandler struct {
+	Inner slog.Handler
+	Level slog.Leveler
+
+	mu       sync.Mutex
+	messages []string
+}
+
+func (h *RecordingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.Level.Level() || h.Inner.Enabled(ctx, level)
+}
+
+func (h *RecordingHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level >= h.Level.Level() {
+		h.mu.Lock()
+		h.messages = append(h.messages, record.Message)
+		h.mu.Unlock()
+	}
+	if h.Inner.Enabled(ctx, record.Level) {
+		return h.Inner.Handle(ctx, record)
+	}
+	return nil
+}
+
+func (h *RecordingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	// no support for attrs here
+	return h
+}
+
+func (h *RecordingHandler) WithGroup(name string) slog.Handler {
+	// no support for attrs here
+	return h
+}
+
+// Messages returns a copy of the recorded messages in the order they were logged.
+func (h *RecordingHandler) Messages() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string{}, h.messages...)
+}
+
+var _ slog.Handler = (*RecordingHandler)(nil)
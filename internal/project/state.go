@@ -22,6 +22,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/score-spec/score-go/framework"
+	score "github.com/score-spec/score-go/types"
 	"gopkg.in/yaml.v3"
 )
 
@@ -37,6 +38,29 @@ type WorkloadExtras struct {
 type ResourceExtras struct {
 	// Don't actually persist these manifests, we just hold them here so we can pass them around.
 	Manifests []map[string]interface{} `yaml:"-"`
+	// Sidecars are the sidecar containers to inject into each workload that references the resource. Like the
+	// manifests, these are not persisted.
+	Sidecars []map[string]interface{} `yaml:"-"`
+	// PodLabels and PodAnnotations are added to the pod template of each workload that references the resource.
+	PodLabels      map[string]string `yaml:"-"`
+	PodAnnotations map[string]string `yaml:"-"`
+	// Namespace is the namespace that the manifests of the resource are emitted into when it differs from the
+	// namespace of the workloads. Like the manifests, this is not persisted.
+	Namespace string `yaml:"-"`
+	// ProvisionCache is only recorded by incremental provisioning. It holds the hash of the provisioner and inputs of
+	// the last provisioning along with the outputs that are not otherwise persisted, so that the resource does not need
+	// to be provisioned again while these are unchanged.
+	ProvisionCache *ProvisionCache `yaml:"provision_cache,omitempty"`
+}
+
+// ProvisionCache is the persisted result of the last incremental provisioning of a resource.
+type ProvisionCache struct {
+	Hash           string                    `yaml:"hash"`
+	Manifests      []map[string]interface{}  `yaml:"manifests,omitempty"`
+	Sidecars       []map[string]interface{}  `yaml:"sidecars,omitempty"`
+	PodLabels      map[string]string         `yaml:"pod_labels,omitempty"`
+	PodAnnotations map[string]string         `yaml:"pod_annotations,omitempty"`
+	Resources      map[string]score.Resource `yaml:"resources,omitempty"`
 }
 
 type State = framework.State[framework.NoExtras, WorkloadExtras, ResourceExtras]
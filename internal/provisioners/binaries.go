@@ -0,0 +1,96 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioners
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+)
+
+type binaryCacheKey struct{}
+
+// binaryCache holds the executables that provisioner uris resolved to during a single run.
+type binaryCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedBinary
+}
+
+// cachedBinary is a resolved executable along with the digest of its content, if known.
+type cachedBinary struct {
+	path   string
+	digest digest.Digest
+}
+
+// WithBinaryCache returns a context in which ResolveBinary remembers the executable that each provisioner uri resolved
+// to, so that many resources using the same provisioner only resolve or pull it once. The cache lasts as long as the
+// context, which is a single provisioning run.
+func WithBinaryCache(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(binaryCacheKey{}).(*binaryCache); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, binaryCacheKey{}, &binaryCache{entries: make(map[string]cachedBinary)})
+}
+
+// ResolveBinary returns the executable for the provisioner uri, calling resolve if the context has no binary cache or
+// the uri has not been resolved yet. The resolve function may return the digest of the executable, in which case a
+// cached executable whose content no longer matches is resolved again. Otherwise, a cached executable that no longer
+// exists is resolved again.
+func ResolveBinary(ctx context.Context, uri string, resolve func() (string, digest.Digest, error)) (string, error) {
+	cache, ok := ctx.Value(binaryCacheKey{}).(*binaryCache)
+	if !ok {
+		path, _, err := resolve()
+		return path, err
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if entry, ok := cache.entries[uri]; ok {
+		err := entry.verify()
+		if err == nil {
+			return entry.path, nil
+		}
+		slog.Warn(fmt.Sprintf("Resolving provisioner %s again since the cached executable is invalid: %v", uri, err))
+		delete(cache.entries, uri)
+	}
+	path, d, err := resolve()
+	if err != nil {
+		return "", err
+	}
+	cache.entries[uri] = cachedBinary{path: path, digest: d}
+	return path, nil
+}
+
+// verify checks that the executable still exists and, if the digest is known, that it still matches the digest.
+func (b cachedBinary) verify() error {
+	if b.digest == "" {
+		_, err := os.Stat(b.path)
+		return err
+	}
+	f, err := os.Open(b.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if actual, err := b.digest.Algorithm().FromReader(f); err != nil {
+		return err
+	} else if actual != b.digest {
+		return fmt.Errorf("digest %s does not match %s", actual, b.digest)
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioners
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveBinary(t *testing.T) {
+	bin := filepath.Join(t.TempDir(), "provisioner")
+	require.NoError(t, os.WriteFile(bin, []byte("#!/bin/sh\n"), 0755))
+	resolves := 0
+	resolve := func() (string, digest.Digest, error) {
+		resolves++
+		return bin, "", nil
+	}
+
+	for i := 0; i < 2; i++ {
+		path, err := ResolveBinary(context.Background(), "cmd://provisioner", resolve)
+		require.NoError(t, err)
+		assert.Equal(t, bin, path)
+	}
+	assert.Equal(t, 2, resolves, "without a cache every call resolves")
+
+	resolves = 0
+	ctx := WithBinaryCache(context.Background())
+	assert.Equal(t, ctx, WithBinaryCache(ctx))
+	for i := 0; i < 2; i++ {
+		path, err := ResolveBinary(ctx, "cmd://provisioner", resolve)
+		require.NoError(t, err)
+		assert.Equal(t, bin, path)
+	}
+	assert.Equal(t, 1, resolves)
+
+	require.NoError(t, os.Remove(bin))
+	_, err := ResolveBinary(ctx, "cmd://provisioner", resolve)
+	require.NoError(t, err)
+	assert.Equal(t, 2, resolves, "a missing executable is resolved again")
+}
@@ -27,6 +27,7 @@ import (
 	"slices"
 	"strings"
 
+	"github.com/opencontainers/go-digest"
 	"github.com/score-spec/score-go/framework"
 	"gopkg.in/yaml.v3"
 
@@ -39,12 +40,32 @@ type Provisioner struct {
 	ResClass       *string  `yaml:"class,omitempty"`
 	ResId          *string  `yaml:"id,omitempty"`
 	Args           []string `yaml:"args"`
+	// ResParamsSchema is an optional JSON Schema that the resource params must match.
+	ResParamsSchema map[string]interface{} `yaml:"paramsSchema,omitempty"`
+	// ResNamespace is an optional namespace for the manifests of this provisioner, it overrides the resource namespace
+	// of the run.
+	ResNamespace string `yaml:"namespace,omitempty"`
+	// ResReadinessCheck is an optional check that the outputs must pass before they are accepted. The provisioner is
+	// invoked again until the check passes or times out.
+	ResReadinessCheck *provisioners.ReadinessCheck `yaml:"readinessCheck,omitempty"`
 }
 
 func (p *Provisioner) Uri() string {
 	return p.ProvisionerUri
 }
 
+func (p *Provisioner) ParamsSchema() map[string]interface{} {
+	return p.ResParamsSchema
+}
+
+func (p *Provisioner) Namespace() string {
+	return p.ResNamespace
+}
+
+func (p *Provisioner) ReadinessCheck() *provisioners.ReadinessCheck {
+	return p.ResReadinessCheck
+}
+
 func (p *Provisioner) Match(resUid framework.ResourceUid) bool {
 	if resUid.Type() != p.ResType {
 		return false
@@ -93,23 +114,47 @@ func decodeBinary(uri string) (string, error) {
 	return filepath.Join(pathParts...), nil
 }
 
+// ModeArg is replaced with the current mode, "provision" or "plan", when the command is executed.
+const ModeArg = "<mode>"
+
 func (p *Provisioner) Provision(ctx context.Context, input *provisioners.Input) (*provisioners.ProvisionOutput, error) {
-	bin, err := decodeBinary(p.Uri())
+	return p.run(ctx, input, "provision")
+}
+
+// Plan executes the command with the <mode> arg set to "plan". Commands without a <mode> arg cannot distinguish
+// between modes and so do not support planning.
+func (p *Provisioner) Plan(ctx context.Context, input *provisioners.Input) (*provisioners.ProvisionOutput, error) {
+	if !slices.Contains(p.Args, ModeArg) {
+		return nil, provisioners.ErrPlanNotSupported
+	}
+	return p.run(ctx, input, "plan")
+}
+
+func (p *Provisioner) run(ctx context.Context, input *provisioners.Input, mode string) (*provisioners.ProvisionOutput, error) {
+	bin, err := provisioners.ResolveBinary(ctx, p.Uri(), func() (string, digest.Digest, error) {
+		bin, err := decodeBinary(p.Uri())
+		return bin, "", err
+	})
 	if err != nil {
 		return nil, err
 	}
+	return Execute(ctx, bin, p.Args, input, mode)
+}
 
+// Execute runs the binary with the json encoded input on stdin and decodes the provisioner output from stdout. Any
+// ModeArg in the args is replaced with the mode. This is shared by the provisioners that run a local executable.
+func Execute(ctx context.Context, bin string, args []string, input *provisioners.Input, mode string) (*provisioners.ProvisionOutput, error) {
 	rawInput, err := json.Marshal(input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to encode json input: %w", err)
 	}
 	outputBuffer := new(bytes.Buffer)
 
-	// if there is a <mode> arg, we mark it as "provision".
-	args := slices.Clone(p.Args)
+	// if there is a <mode> arg, we replace it with the current mode.
+	args = slices.Clone(args)
 	for i, arg := range args {
-		if arg == "<mode>" {
-			args[i] = "provision"
+		if arg == ModeArg {
+			args[i] = mode
 		}
 	}
 
@@ -126,7 +171,7 @@ func (p *Provisioner) Provision(ctx context.Context, input *provisioners.Input)
 	dec := json.NewDecoder(bytes.NewReader(outputBuffer.Bytes()))
 	dec.DisallowUnknownFields()
 	if err := dec.Decode(&output); err != nil {
-		slog.Debug("Output from command provisioner:\n" + outputBuffer.String())
+		slog.Debug("Output from command provisioner:\n" + provisioners.RedactText(outputBuffer.String()))
 		return nil, fmt.Errorf("failed to decode output from cmd provisioner: %w", err)
 	}
 
@@ -146,6 +191,21 @@ func Parse(raw map[string]interface{}) (*Provisioner, error) {
 	} else if p.ResType == "" {
 		return nil, fmt.Errorf("type not set")
 	}
+	if p.ResParamsSchema != nil {
+		if _, err := provisioners.CompileParamsSchema(p.ResParamsSchema); err != nil {
+			return nil, fmt.Errorf("paramsSchema: %w", err)
+		}
+	}
+	if p.ResNamespace != "" {
+		if err := provisioners.ValidateNamespace(p.ResNamespace); err != nil {
+			return nil, fmt.Errorf("namespace: %w", err)
+		}
+	}
+	if p.ResReadinessCheck != nil {
+		if err := p.ResReadinessCheck.Validate(); err != nil {
+			return nil, fmt.Errorf("readinessCheck: %w", err)
+		}
+	}
 
 	parts, err := url.Parse(p.ProvisionerUri)
 	if err != nil {
@@ -160,3 +220,9 @@ func Parse(raw map[string]interface{}) (*Provisioner, error) {
 
 	return p, nil
 }
+
+var _ provisioners.Provisioner = (*Provisioner)(nil)
+var _ provisioners.Planner = (*Provisioner)(nil)
+var _ provisioners.ParamsSchemaProvider = (*Provisioner)(nil)
+var _ provisioners.NamespaceProvider = (*Provisioner)(nil)
+var _ provisioners.ReadinessCheckProvider = (*Provisioner)(nil)
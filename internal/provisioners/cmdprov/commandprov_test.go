@@ -129,3 +129,35 @@ func TestProvision_fail_decode(t *testing.T) {
 	})
 	require.EqualError(t, err, "failed to decode output from cmd provisioner: invalid character 'b' looking for beginning of value")
 }
+
+func TestPlan_success(t *testing.T) {
+	p, err := Parse(map[string]interface{}{
+		"uri":  "cmd://sh",
+		"type": "thing",
+		"args": []string{"-c", "echo '{\"resource_outputs\":{\"mode\":\"'$0'\"}}'", "<mode>"},
+	})
+	require.NoError(t, err)
+	po, err := p.Plan(context.Background(), &provisioners.Input{
+		ResourceUid: "thing.default#w.r",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "plan", po.ResourceOutputs["mode"])
+	po, err = p.Provision(context.Background(), &provisioners.Input{
+		ResourceUid: "thing.default#w.r",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "provision", po.ResourceOutputs["mode"])
+}
+
+func TestPlan_not_supported(t *testing.T) {
+	p, err := Parse(map[string]interface{}{
+		"uri":  "cmd://sh",
+		"type": "thing",
+		"args": []string{"-c", "echo '{}'"},
+	})
+	require.NoError(t, err)
+	_, err = p.Plan(context.Background(), &provisioners.Input{
+		ResourceUid: "thing.default#w.r",
+	})
+	require.ErrorIs(t, err, provisioners.ErrPlanNotSupported)
+}
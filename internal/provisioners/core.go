@@ -16,11 +16,14 @@ package provisioners
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"maps"
+	"reflect"
 	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/score-spec/score-go/framework"
 	score "github.com/score-spec/score-go/types"
@@ -28,6 +31,7 @@ import (
 	util "github.com/score-spec/score-k8s/internal"
 	"github.com/score-spec/score-k8s/internal/convert"
 	"github.com/score-spec/score-k8s/internal/project"
+	"github.com/score-spec/score-k8s/internal/trace"
 )
 
 // Input is the set of thins passed to the provisioner implementation. It provides context, previous state, and shared
@@ -51,6 +55,11 @@ type Input struct {
 	// WorkloadServices is a map from workload name to the network NetworkService of another workload which defines
 	// the hostname and the set of ports it exposes.
 	WorkloadServices map[string]NetworkService `json:"workload_services"`
+	// Namespace is the namespace that the manifests of this resource are emitted into, and WorkloadNamespace is the
+	// namespace of the workloads. Both are empty when no namespace is set. When they differ, outputs that refer to a
+	// service in the manifests must use a hostname qualified with the namespace, see ServiceHost.
+	Namespace         string `json:"namespace,omitempty"`
+	WorkloadNamespace string `json:"workload_namespace,omitempty"`
 
 	// -- current state --
 
@@ -82,17 +91,101 @@ type ProvisionOutput struct {
 	ResourceOutputs map[string]interface{}   `json:"resource_outputs"`
 	SharedState     map[string]interface{}   `json:"shared_state"`
 	Manifests       []map[string]interface{} `json:"manifests"`
+	// Resources is an optional set of new resources to add to the source workload. These are primed and provisioned in
+	// a further pass after the current set of resources and may be referenced by the workload like any other resource.
+	Resources map[string]score.Resource `json:"resources,omitempty"`
+	// Sidecars is an optional list of sidecars to inject into the pod of each workload that references this resource.
+	// Each item has a "container" holding a Kubernetes container spec and optional "volumes" to add to the pod.
+	Sidecars []map[string]interface{} `json:"sidecars,omitempty"`
+	// PodLabels and PodAnnotations are optional labels and annotations to add to the pod template of each workload that
+	// references this resource, for example to enable service mesh injection.
+	PodLabels      map[string]string `json:"pod_labels,omitempty"`
+	PodAnnotations map[string]string `json:"pod_annotations,omitempty"`
+	// Messages are optional diagnostics for the user, such as a note that a dev-grade database is used. These are
+	// logged with the resource uid after the resource is provisioned.
+	Messages []ProvisionMessage `json:"messages,omitempty"`
 
 	// For testing and legacy reasons, built in provisioners can set a direct lookup function
 	OutputLookupFunc framework.OutputLookupFunc `json:"-"`
 }
 
+// The levels of a ProvisionMessage.
+const (
+	MessageLevelInfo = "info"
+	MessageLevelWarn = "warn"
+)
+
+// ProvisionMessage is a diagnostic message from a provisioner.
+type ProvisionMessage struct {
+	// Level is one of MessageLevelInfo or MessageLevelWarn, MessageLevelInfo is used when this is empty.
+	Level   string `json:"level,omitempty"`
+	Message string `json:"message"`
+}
+
 type Provisioner interface {
 	Uri() string
 	Match(resUid framework.ResourceUid) bool
 	Provision(ctx context.Context, input *Input) (*ProvisionOutput, error)
 }
 
+// ProvisionError is returned when a resource cannot be provisioned, for example because no provisioner matches it,
+// its params are invalid, or the provisioner failed.
+type ProvisionError struct {
+	ResourceUid framework.ResourceUid
+	// ProvisionerUri is the uri of the matching provisioner, or empty if there is none.
+	ProvisionerUri string
+	Err            error
+}
+
+func (e *ProvisionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ProvisionError) Unwrap() error {
+	return e.Err
+}
+
+// UnmatchedResourcesError is returned before provisioning starts when one or more resources are not matched by any
+// of the loaded provisioners. Each item is a *ProvisionError for one resource, in provisioning order.
+type UnmatchedResourcesError []*ProvisionError
+
+func (e UnmatchedResourcesError) Error() string {
+	lines := make([]string, 0, len(e)+2)
+	lines = append(lines, fmt.Sprintf("%d resources are not supported by any provisioner:", len(e)))
+	for _, pe := range e {
+		lines = append(lines, fmt.Sprintf("- '%s' (type '%s', class '%s', id '%s')", pe.ResourceUid, pe.ResourceUid.Type(), pe.ResourceUid.Class(), pe.ResourceUid.Id()))
+	}
+	lines = append(lines, "Please add a provisioner for these resource types to a .provisioners.yaml file in the state directory.")
+	return strings.Join(lines, "\n")
+}
+
+func (e UnmatchedResourcesError) Unwrap() []error {
+	out := make([]error, len(e))
+	for i, pe := range e {
+		out[i] = pe
+	}
+	return out
+}
+
+// ErrPlanNotSupported is returned by a Planner when it cannot plan a particular resource.
+var ErrPlanNotSupported = errors.New("provisioner does not support plan mode")
+
+// Planner is an optional interface implemented by provisioners that can report what they would provision without
+// making any changes. Provisioners that do not implement it are skipped in plan mode.
+type Planner interface {
+	Plan(ctx context.Context, input *Input) (*ProvisionOutput, error)
+}
+
+// PlanResult is the planned outcome for a single resource.
+type PlanResult struct {
+	ResourceUid    framework.ResourceUid
+	ProvisionerUri string
+	// Skipped is true when the matching provisioner does not support plan mode.
+	Skipped bool
+	// Output is the output the provisioner would return. This is nil if Skipped is true.
+	Output *ProvisionOutput
+}
+
 type ephemeralProvisioner struct {
 	uri       string
 	matchUid  framework.ResourceUid
@@ -165,6 +258,13 @@ func (po *ProvisionOutput) ApplyToStateAndProject(state *project.State, resUid f
 		existing.Extras.Manifests = make([]map[string]interface{}, 0)
 	}
 
+	// Sidecars and pod metadata are replaced in the same way as the manifests.
+	existing.Extras.Sidecars = po.Sidecars
+	existing.Extras.PodLabels = po.PodLabels
+	existing.Extras.PodAnnotations = po.PodAnnotations
+	// The cache is only valid for the provisioning that recorded it.
+	existing.Extras.ProvisionCache = nil
+
 	out.Resources[resUid] = existing
 	return &out, nil
 }
@@ -199,46 +299,132 @@ func buildWorkloadServices(state *project.State) map[string]NetworkService {
 	return out
 }
 
+// ProvisionResources provisions all the resources in the state in dependency order and returns the new state. If the
+// context is cancelled, provisioning stops and an error is returned so that no partial state is kept.
 func ProvisionResources(ctx context.Context, state *project.State, provisioners []Provisioner) (*project.State, error) {
+	out, _, err := provisionResources(ctx, state, provisioners, false)
+	return out, err
+}
+
+// PlanResources runs each resource through the Plan function of its provisioner and returns the planned results in
+// provisioning order. The planned outputs are only applied to a copy of the state so that dependent resources can be
+// planned too, the state itself is not modified.
+func PlanResources(ctx context.Context, state *project.State, provisioners []Provisioner) ([]PlanResult, error) {
+	_, results, err := provisionResources(ctx, state, provisioners, true)
+	return results, err
+}
+
+// MaxSpawnDepth is the maximum number of provisioning passes. Each pass after the first provisions the resources
+// spawned by the previous pass, so this limits how deeply resources may spawn other resources.
+const MaxSpawnDepth = 5
+
+func provisionResources(ctx context.Context, state *project.State, provisioners []Provisioner, plan bool) (*project.State, []PlanResult, error) {
+	ctx = WithBinaryCache(ctx)
+	if incremental, ok := ctx.Value(incrementalKey{}).(*incrementalRun); ok {
+		incremental.skipped = 0
+	}
 	out := state
+	results := make([]PlanResult, 0)
+	provisioned := make(map[framework.ResourceUid]bool)
+	workloadServices := buildWorkloadServices(state)
+
+	for depth := 0; ; depth++ {
+		var spawned bool
+		var err error
+		out, results, spawned, err = provisionPass(ctx, out, provisioners, plan, workloadServices, provisioned, results)
+		if err != nil {
+			return nil, nil, err
+		} else if !spawned {
+			if incremental, ok := ctx.Value(incrementalKey{}).(*incrementalRun); ok && !plan {
+				slog.Info(fmt.Sprintf("Skipped provisioning %d of %d resources since their provisioner and inputs are unchanged", incremental.skipped, len(provisioned)))
+			}
+			return out, results, nil
+		} else if depth+1 >= MaxSpawnDepth {
+			return nil, nil, fmt.Errorf("resources spawned more resources beyond the maximum depth of %d", MaxSpawnDepth)
+		}
+		if out, err = out.WithPrimedResources(); err != nil {
+			return nil, nil, fmt.Errorf("failed to prime spawned resources: %w", err)
+		}
+	}
+}
+
+// provisionPass provisions any resources in the state that have not been provisioned yet. It returns true if any of
+// the provisioners spawned new resources which must be primed and provisioned in another pass.
+func provisionPass(
+	ctx context.Context, out *project.State, provisioners []Provisioner, plan bool,
+	workloadServices map[string]NetworkService, provisioned map[framework.ResourceUid]bool, results []PlanResult,
+) (*project.State, []PlanResult, bool, error) {
+	var spawned bool
 
 	// provision in sorted order
 	orderedResources, err := out.GetSortedResourceUids()
 	if err != nil {
-		return nil, fmt.Errorf("failed to determine sort order for provisioning: %w", err)
+		return nil, nil, false, fmt.Errorf("failed to determine sort order for provisioning: %w", err)
+	}
+	if orderedResources, err = sortByPriority(out, orderedResources); err != nil {
+		return nil, nil, false, fmt.Errorf("failed to determine sort order for provisioning: %w", err)
 	}
 
-	workloadServices := buildWorkloadServices(state)
-
+	// match all the resources up front so that every unmatched resource is reported at once
+	var unmatched UnmatchedResourcesError
+	matched := make(map[framework.ResourceUid]Provisioner, len(orderedResources))
 	for _, resUid := range orderedResources {
-		resState := out.Resources[resUid]
+		if provisioned[resUid] {
+			continue
+		}
 		provisionerIndex := slices.IndexFunc(provisioners, func(provisioner Provisioner) bool {
 			return provisioner.Match(resUid)
 		})
 		if provisionerIndex < 0 {
-			return nil, fmt.Errorf("resource '%s' is not supported by any provisioner. "+
-				"Please implement a custom resource provisioner to support this resource type.", resUid)
+			unmatched = append(unmatched, &ProvisionError{ResourceUid: resUid, Err: fmt.Errorf("resource '%s' is not supported by any provisioner. "+
+				"Please implement a custom resource provisioner to support this resource type.", resUid)})
+			continue
+		}
+		matched[resUid] = provisioners[provisionerIndex]
+	}
+	if len(unmatched) > 0 {
+		return nil, nil, false, unmatched
+	}
+
+	for _, resUid := range orderedResources {
+		if provisioned[resUid] {
+			continue
 		}
-		provisioner := provisioners[provisionerIndex]
+		provisioned[resUid] = true
+
+		// stop before the next provisioner if we've been cancelled, the state is discarded by the caller
+		if err := ctx.Err(); err != nil {
+			return nil, nil, false, fmt.Errorf("resource '%s': provisioning cancelled: %w", resUid, err)
+		}
+
+		resState := out.Resources[resUid]
+		provisioner := matched[resUid]
 		if resState.ProvisionerUri != "" && resState.ProvisionerUri != provisioner.Uri() {
-			return nil, fmt.Errorf("resource '%s' was previously provisioned by a different provider - undefined behavior", resUid)
+			return nil, nil, false, &ProvisionError{ResourceUid: resUid, ProvisionerUri: provisioner.Uri(), Err: fmt.Errorf("resource '%s' was previously provisioned by a different provider - undefined behavior", resUid)}
 		}
+		_, endSpan := trace.Start(ctx, fmt.Sprintf("provision %s (%s)", resUid, provisioner.Uri()))
 
 		var params map[string]interface{}
 		if resState.Params != nil && len(resState.Params) > 0 {
 			resOutputs, err := out.GetResourceOutputForWorkload(resState.SourceWorkload)
 			if err != nil {
-				return nil, fmt.Errorf("failed to find resource params for resource '%s': %w", resUid, err)
+				return nil, nil, false, &ProvisionError{ResourceUid: resUid, ProvisionerUri: provisioner.Uri(), Err: fmt.Errorf("failed to find resource params for resource '%s': %w", resUid, err)}
 			}
 			sf := framework.BuildSubstitutionFunction(out.Workloads[resState.SourceWorkload].Spec.Metadata, resOutputs)
 			rawParams, err := framework.Substitute(resState.Params, sf)
 			if err != nil {
-				return nil, fmt.Errorf("failed to substitute params for resource '%s': %w", resUid, err)
+				return nil, nil, false, &ProvisionError{ResourceUid: resUid, ProvisionerUri: provisioner.Uri(), Err: fmt.Errorf("failed to substitute params for resource '%s': %w", resUid, err)}
 			}
 			params = rawParams.(map[string]interface{})
 		}
 
-		output, err := provisioner.Provision(ctx, &Input{
+		if psp, ok := provisioner.(ParamsSchemaProvider); ok && psp.ParamsSchema() != nil {
+			if err := ValidateParams(psp.ParamsSchema(), params); err != nil {
+				return nil, nil, false, &ProvisionError{ResourceUid: resUid, ProvisionerUri: provisioner.Uri(), Err: fmt.Errorf("resource '%s': invalid params: %w", resUid, err)}
+			}
+		}
+
+		input := &Input{
 			ResourceGuid:     resState.Guid,
 			ResourceUid:      string(resUid),
 			ResourceType:     resUid.Type(),
@@ -250,17 +436,111 @@ func ProvisionResources(ctx context.Context, state *project.State, provisioners
 			SourceWorkload:   resState.SourceWorkload,
 			WorkloadServices: workloadServices,
 			SharedState:      out.SharedState,
-		})
+		}
+		input.Namespace, input.WorkloadNamespace = resourceNamespaces(ctx, provisioner)
+		out = withResourceNamespace(out, resUid, input.Namespace, input.WorkloadNamespace)
+
+		var hash string
+		if incremental, ok := ctx.Value(incrementalKey{}).(*incrementalRun); ok && !plan {
+			if hash, err = provisionHash(provisioner, input); err != nil {
+				return nil, nil, false, &ProvisionError{ResourceUid: resUid, ProvisionerUri: provisioner.Uri(), Err: fmt.Errorf("resource '%s': %w", resUid, err)}
+			}
+			if cache := resState.Extras.ProvisionCache; !incremental.force && cache != nil && cache.Hash == hash && resState.ProvisionerUri == provisioner.Uri() {
+				slog.Debug(fmt.Sprintf("Skipping provisioning of resource '%s' since its provisioner and inputs are unchanged", resUid))
+				incremental.skipped++
+				out = applyProvisionCache(out, resUid)
+				if len(cache.Resources) > 0 {
+					var added bool
+					if out, added, err = addSpawnedResources(out, resUid, resState.SourceWorkload, cache.Resources); err != nil {
+						return nil, nil, false, err
+					}
+					spawned = spawned || added
+				}
+				endSpan()
+				continue
+			}
+		}
+
+		logProvisionerIO(ctx, resUid, provisioner.Uri(), "input", input)
+
+		var output *ProvisionOutput
+		if plan {
+			planner, ok := provisioner.(Planner)
+			if ok {
+				output, err = planner.Plan(ctx, input)
+			}
+			if !ok || errors.Is(err, ErrPlanNotSupported) {
+				slog.Info(fmt.Sprintf("Skipping resource '%s' since provisioner '%s' does not support plan mode", resUid, provisioner.Uri()))
+				results = append(results, PlanResult{ResourceUid: resUid, ProvisionerUri: provisioner.Uri(), Skipped: true})
+				endSpan()
+				continue
+			}
+		} else {
+			output, err = provisionUntilReady(ctx, provisioner, input)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("resource '%s': failed to provision: %w", resUid, err)
+			if ctx.Err() != nil {
+				return nil, nil, false, fmt.Errorf("resource '%s': provisioning cancelled: %w", resUid, ctx.Err())
+			}
+			return nil, nil, false, &ProvisionError{ResourceUid: resUid, ProvisionerUri: provisioner.Uri(), Err: fmt.Errorf("resource '%s': failed to provision: %w", resUid, err)}
 		}
 
 		output.ProvisionerUri = provisioner.Uri()
+		logProvisionerIO(ctx, resUid, provisioner.Uri(), "output", output)
+		if err := logProvisionerMessages(ctx, resUid, provisioner.Uri(), output.Messages); err != nil {
+			return nil, nil, false, &ProvisionError{ResourceUid: resUid, ProvisionerUri: provisioner.Uri(), Err: fmt.Errorf("resource '%s': %w", resUid, err)}
+		}
 		out, err = output.ApplyToStateAndProject(out, resUid)
 		if err != nil {
-			return nil, fmt.Errorf("resource '%s': failed to apply outputs: %w", resUid, err)
+			return nil, nil, false, &ProvisionError{ResourceUid: resUid, ProvisionerUri: provisioner.Uri(), Err: fmt.Errorf("resource '%s': failed to apply outputs: %w", resUid, err)}
 		}
+		if hash != "" {
+			out = recordProvisionCache(out, resUid, hash, output)
+		}
+		if plan {
+			results = append(results, PlanResult{ResourceUid: resUid, ProvisionerUri: provisioner.Uri(), Output: output})
+		}
+
+		if len(output.Resources) > 0 {
+			var added bool
+			out, added, err = addSpawnedResources(out, resUid, out.Resources[resUid].SourceWorkload, output.Resources)
+			if err != nil {
+				return nil, nil, false, err
+			}
+			spawned = spawned || added
+		}
+		endSpan()
 	}
 
-	return out, nil
+	return out, results, spawned, nil
+}
+
+// addSpawnedResources adds the resources spawned by a provisioner to the source workload. Resources that are already
+// defined identically are ignored so that provisioning is repeatable, while conflicting definitions are an error.
+func addSpawnedResources(state *project.State, resUid framework.ResourceUid, workloadName string, resources map[string]score.Resource) (*project.State, bool, error) {
+	workload, ok := state.Workloads[workloadName]
+	if !ok {
+		return nil, false, fmt.Errorf("resource '%s': cannot spawn resources without a source workload", resUid)
+	}
+	out := *state
+	out.Workloads = maps.Clone(state.Workloads)
+	workload.Spec.Resources = maps.Clone(workload.Spec.Resources)
+	if workload.Spec.Resources == nil {
+		workload.Spec.Resources = make(map[string]score.Resource)
+	}
+	var added bool
+	for _, resName := range slices.Sorted(maps.Keys(resources)) {
+		res := resources[resName]
+		if existing, ok := workload.Spec.Resources[resName]; ok {
+			if !reflect.DeepEqual(existing, res) {
+				return nil, false, fmt.Errorf("resource '%s': spawned resource '%s' conflicts with an existing resource in workload '%s'", resUid, resName, workloadName)
+			}
+			continue
+		}
+		slog.Info(fmt.Sprintf("Resource '%s' spawned resource '%s' in workload '%s'", resUid, resName, workloadName))
+		workload.Spec.Resources[resName] = res
+		added = true
+	}
+	out.Workloads[workloadName] = workload
+	return &out, added, nil
 }
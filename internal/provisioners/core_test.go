@@ -15,12 +15,18 @@
 package provisioners
 
 import (
+	"bytes"
+	"context"
+	"log/slog"
 	"testing"
 
 	"github.com/score-spec/score-go/framework"
+	scoretypes "github.com/score-spec/score-go/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 
+	util "github.com/score-spec/score-k8s/internal"
 	"github.com/score-spec/score-k8s/internal/project"
 )
 
@@ -79,3 +85,476 @@ func TestApplyToStateAndProject(t *testing.T) {
 	})
 
 }
+
+func TestProvisionResourcesCancelled(t *testing.T) {
+	state := new(project.State)
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata:   map[string]interface{}{"name": "w"},
+		Containers: map[string]scoretypes.Container{"c": {Image: "busybox"}},
+		Resources:  map[string]scoretypes.Resource{"r": {Type: "t"}},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	state, err = state.WithPrimedResources()
+	require.NoError(t, err)
+
+	var called bool
+	p := NewEphemeralProvisioner("blah://", framework.NewResourceUid("w", "r", "t", nil, nil), func(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+		called = true
+		return &ProvisionOutput{}, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	after, err := ProvisionResources(ctx, state, []Provisioner{p})
+	assert.EqualError(t, err, "resource 't.default#w.r': provisioning cancelled: context canceled")
+	assert.Nil(t, after)
+	assert.False(t, called)
+}
+
+type plannerProvisioner struct {
+	Provisioner
+}
+
+func (p *plannerProvisioner) Plan(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+	return &ProvisionOutput{ResourceOutputs: map[string]interface{}{"planned": input.ResourceUid}}, nil
+}
+
+func TestPlanResources(t *testing.T) {
+	state := new(project.State)
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata:   map[string]interface{}{"name": "w"},
+		Containers: map[string]scoretypes.Container{"c": {Image: "busybox"}},
+		Resources: map[string]scoretypes.Resource{
+			"a": {Type: "t"},
+			"b": {Type: "u"},
+		},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	state, err = state.WithPrimedResources()
+	require.NoError(t, err)
+
+	noop := func(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+		t.Fatal("provision should not be called in plan mode")
+		return nil, nil
+	}
+	a, b := framework.NewResourceUid("w", "a", "t", nil, nil), framework.NewResourceUid("w", "b", "u", nil, nil)
+	results, err := PlanResources(context.Background(), state, []Provisioner{
+		&plannerProvisioner{NewEphemeralProvisioner("planner://", a, noop)},
+		NewEphemeralProvisioner("ephemeral://", b, noop),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []PlanResult{
+		{ResourceUid: a, ProvisionerUri: "planner://", Output: &ProvisionOutput{
+			ProvisionerUri: "planner://", ResourceOutputs: map[string]interface{}{"planned": string(a)},
+		}},
+		{ResourceUid: b, ProvisionerUri: "ephemeral://", Skipped: true},
+	}, results)
+
+	// the original state must be untouched
+	assert.Equal(t, map[string]interface{}{}, state.Resources[a].Outputs)
+	assert.Equal(t, "", state.Resources[a].ProvisionerUri)
+}
+
+type schemaProvisioner struct {
+	Provisioner
+	schema map[string]interface{}
+}
+
+func (p *schemaProvisioner) ParamsSchema() map[string]interface{} {
+	return p.schema
+}
+
+func TestProvisionResourcesInvalidParams(t *testing.T) {
+	state := new(project.State)
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata:   map[string]interface{}{"name": "w"},
+		Containers: map[string]scoretypes.Container{"c": {Image: "busybox"}},
+		Resources: map[string]scoretypes.Resource{"r": {Type: "t", Params: map[string]interface{}{
+			"port": "eighty", "extra": true,
+		}}},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	state, err = state.WithPrimedResources()
+	require.NoError(t, err)
+
+	var called bool
+	p := &schemaProvisioner{
+		Provisioner: NewEphemeralProvisioner("blah://", framework.NewResourceUid("w", "r", "t", nil, nil), func(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+			called = true
+			return &ProvisionOutput{}, nil
+		}),
+		schema: map[string]interface{}{
+			"type":                 "object",
+			"required":             []interface{}{"host"},
+			"additionalProperties": false,
+			"properties": map[string]interface{}{
+				"host": map[string]interface{}{"type": "string"},
+				"port": map[string]interface{}{"type": "integer"},
+			},
+		},
+	}
+	_, err = ProvisionResources(context.Background(), state, []Provisioner{p})
+	assert.EqualError(t, err, "resource 't.default#w.r': invalid params: /: additionalProperties 'extra' not allowed; /: missing properties: 'host'; /port: expected integer, but got string")
+	assert.False(t, called)
+
+	state.Resources["t.default#w.r"] = func() framework.ScoreResourceState[project.ResourceExtras] {
+		r := state.Resources["t.default#w.r"]
+		r.Params = map[string]interface{}{"host": "localhost", "port": 80}
+		return r
+	}()
+	_, err = ProvisionResources(context.Background(), state, []Provisioner{p})
+	assert.NoError(t, err)
+	assert.True(t, called)
+}
+
+func TestProvisionResourcesUnmatched(t *testing.T) {
+	state := new(project.State)
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata:   map[string]interface{}{"name": "w"},
+		Containers: map[string]scoretypes.Container{"c": {Image: "busybox"}},
+		Resources: map[string]scoretypes.Resource{
+			"a": {Type: "known"},
+			"b": {Type: "unknown", Class: util.Ref("large")},
+			"c": {Type: "other", Id: util.Ref("shared")},
+		},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	state, err = state.WithPrimedResources()
+	require.NoError(t, err)
+
+	var called bool
+	p := NewEphemeralProvisioner("known://", framework.NewResourceUid("w", "a", "known", nil, nil), func(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+		called = true
+		return &ProvisionOutput{}, nil
+	})
+	_, err = ProvisionResources(context.Background(), state, []Provisioner{p})
+	assert.EqualError(t, err, `2 resources are not supported by any provisioner:
+- 'other.default#shared' (type 'other', class 'default', id 'shared')
+- 'unknown.large#w.b' (type 'unknown', class 'large', id 'w.b')
+Please add a provisioner for these resource types to a .provisioners.yaml file in the state directory.`)
+	var unmatched UnmatchedResourcesError
+	require.ErrorAs(t, err, &unmatched)
+	assert.Len(t, unmatched, 2)
+	var pe *ProvisionError
+	require.ErrorAs(t, err, &pe)
+	assert.Equal(t, "", pe.ProvisionerUri)
+	// nothing is provisioned when any resource is unmatched
+	assert.False(t, called)
+}
+
+func TestProvisionResourcesSpawned(t *testing.T) {
+	state := new(project.State)
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata:   map[string]interface{}{"name": "w"},
+		Containers: map[string]scoretypes.Container{"c": {Image: "busybox"}},
+		Resources:  map[string]scoretypes.Resource{"r": {Type: "t"}},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	state, err = state.WithPrimedResources()
+	require.NoError(t, err)
+
+	spawner := NewEphemeralProvisioner("spawner://", framework.NewResourceUid("w", "r", "t", nil, nil), func(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+		return &ProvisionOutput{Resources: map[string]scoretypes.Resource{
+			"helper": {Type: "h", Params: map[string]interface{}{"x": "y"}},
+		}}, nil
+	})
+	var helperParams map[string]interface{}
+	helper := NewEphemeralProvisioner("helper://", framework.NewResourceUid("w", "helper", "h", nil, nil), func(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+		helperParams = input.ResourceParams
+		return &ProvisionOutput{ResourceOutputs: map[string]interface{}{"k": "v"}}, nil
+	})
+
+	after, err := ProvisionResources(context.Background(), state, []Provisioner{spawner, helper})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"x": "y"}, helperParams)
+	assert.Equal(t, "h", after.Workloads["w"].Spec.Resources["helper"].Type)
+	assert.Equal(t, map[string]interface{}{"k": "v"}, after.Resources["h.default#w.helper"].Outputs)
+	// the original state is untouched
+	assert.Len(t, state.Workloads["w"].Spec.Resources, 1)
+
+	// provisioning again is repeatable
+	again, err := ProvisionResources(context.Background(), after, []Provisioner{spawner, helper})
+	require.NoError(t, err)
+	assert.Len(t, again.Resources, 2)
+}
+
+func TestProvisionResourcesIncremental(t *testing.T) {
+	workload := &scoretypes.Workload{
+		Metadata:   map[string]interface{}{"name": "w"},
+		Containers: map[string]scoretypes.Container{"c": {Image: "busybox"}},
+		Resources:  map[string]scoretypes.Resource{"r": {Type: "t", Params: map[string]interface{}{"size": "small"}}},
+	}
+	// reload mimics a new run, which only has the persisted state and the workload as written in the Score file
+	reload := func(t *testing.T, state *project.State, workload *scoretypes.Workload) *project.State {
+		raw, err := yaml.Marshal(state)
+		require.NoError(t, err)
+		out := new(project.State)
+		require.NoError(t, yaml.Unmarshal(raw, out))
+		out, err = out.WithWorkload(workload, nil, project.WorkloadExtras{})
+		require.NoError(t, err)
+		out, err = out.WithPrimedResources()
+		require.NoError(t, err)
+		return out
+	}
+
+	var calls, helperCalls int
+	p := NewEphemeralProvisioner("blah://", framework.NewResourceUid("w", "r", "t", nil, nil), func(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+		calls++
+		return &ProvisionOutput{
+			ResourceOutputs: map[string]interface{}{"size": input.ResourceParams["size"]},
+			Manifests:       []map[string]interface{}{{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "thing"}}},
+			PodLabels:       map[string]string{"a": "b"},
+			Resources:       map[string]scoretypes.Resource{"helper": {Type: "h"}},
+		}, nil
+	})
+	helper := NewEphemeralProvisioner("helper://", framework.NewResourceUid("w", "helper", "h", nil, nil), func(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+		helperCalls++
+		return &ProvisionOutput{ResourceOutputs: map[string]interface{}{"k": "v"}}, nil
+	})
+	provisioners := []Provisioner{p, helper}
+
+	state, err := ProvisionResources(WithIncrementalProvisioning(context.Background(), false), reload(t, new(project.State), workload), provisioners)
+	require.NoError(t, err)
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, 1, helperCalls)
+	require.NotNil(t, state.Resources["t.default#w.r"].Extras.ProvisionCache)
+
+	t.Run("unchanged resources are skipped", func(t *testing.T) {
+		after, err := ProvisionResources(WithIncrementalProvisioning(context.Background(), false), reload(t, state, workload), provisioners)
+		require.NoError(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, 1, helperCalls)
+		res := after.Resources["t.default#w.r"]
+		assert.Equal(t, map[string]interface{}{"size": "small"}, res.Outputs)
+		assert.Equal(t, []map[string]interface{}{{"apiVersion": "v1", "kind": "ConfigMap", "metadata": map[string]interface{}{"name": "thing"}}}, res.Extras.Manifests)
+		assert.Equal(t, map[string]string{"a": "b"}, res.Extras.PodLabels)
+		assert.Equal(t, "h", after.Workloads["w"].Spec.Resources["helper"].Type)
+		assert.Equal(t, map[string]interface{}{"k": "v"}, after.Resources["h.default#w.helper"].Outputs)
+	})
+
+	t.Run("force provisions every resource", func(t *testing.T) {
+		calls, helperCalls = 0, 0
+		after, err := ProvisionResources(WithIncrementalProvisioning(context.Background(), true), reload(t, state, workload), provisioners)
+		require.NoError(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, 1, helperCalls)
+		assert.NotNil(t, after.Resources["t.default#w.r"].Extras.ProvisionCache)
+	})
+
+	t.Run("changed params are provisioned", func(t *testing.T) {
+		calls, helperCalls = 0, 0
+		changed := *workload
+		changed.Resources = map[string]scoretypes.Resource{"r": {Type: "t", Params: map[string]interface{}{"size": "large"}}}
+		after, err := ProvisionResources(WithIncrementalProvisioning(context.Background(), false), reload(t, state, &changed), provisioners)
+		require.NoError(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Equal(t, 0, helperCalls)
+		assert.Equal(t, map[string]interface{}{"size": "large"}, after.Resources["t.default#w.r"].Outputs)
+	})
+
+	t.Run("provisioning without incremental clears the cache", func(t *testing.T) {
+		calls, helperCalls = 0, 0
+		after, err := ProvisionResources(context.Background(), reload(t, state, workload), provisioners)
+		require.NoError(t, err)
+		assert.Equal(t, 1, calls)
+		assert.Nil(t, after.Resources["t.default#w.r"].Extras.ProvisionCache)
+	})
+}
+
+type recursiveProvisioner struct{}
+
+func (r *recursiveProvisioner) Uri() string {
+	return "recursive://"
+}
+
+func (r *recursiveProvisioner) Match(resUid framework.ResourceUid) bool {
+	return resUid.Type() == "t"
+}
+
+func (r *recursiveProvisioner) Provision(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+	return &ProvisionOutput{Resources: map[string]scoretypes.Resource{
+		input.ResourceId[len("w."):] + "x": {Type: "t"},
+	}}, nil
+}
+
+func TestProvisionResourcesSpawnedTooDeep(t *testing.T) {
+	state := new(project.State)
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata:   map[string]interface{}{"name": "w"},
+		Containers: map[string]scoretypes.Container{"c": {Image: "busybox"}},
+		Resources:  map[string]scoretypes.Resource{"r": {Type: "t"}},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	state, err = state.WithPrimedResources()
+	require.NoError(t, err)
+
+	_, err = ProvisionResources(context.Background(), state, []Provisioner{&recursiveProvisioner{}})
+	assert.EqualError(t, err, "resources spawned more resources beyond the maximum depth of 5")
+}
+
+func TestProvisionResourcesMessages(t *testing.T) {
+	state := new(project.State)
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata:   map[string]interface{}{"name": "w"},
+		Containers: map[string]scoretypes.Container{"c": {Image: "busybox"}},
+		Resources:  map[string]scoretypes.Resource{"r": {Type: "t"}},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	state, err = state.WithPrimedResources()
+	require.NoError(t, err)
+
+	buff := new(bytes.Buffer)
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(buff, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	t.Cleanup(func() {
+		slog.SetDefault(prev)
+	})
+
+	messages := []ProvisionMessage{
+		{Message: "created database"},
+		{Level: MessageLevelWarn, Message: "using dev-grade postgres with password=hunter2"},
+	}
+	provisioners := []Provisioner{NewEphemeralProvisioner("blah://", framework.NewResourceUid("w", "r", "t", nil, nil), func(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+		return &ProvisionOutput{Messages: messages}, nil
+	})}
+
+	_, err = ProvisionResources(context.Background(), state, provisioners)
+	require.NoError(t, err)
+	assert.Contains(t, buff.String(), `level=INFO msg="Provisioner 'blah://' for resource 't.default#w.r': created database"`)
+	assert.Contains(t, buff.String(), `level=WARN msg="Provisioner 'blah://' for resource 't.default#w.r': using dev-grade postgres with password=<redacted>"`)
+	assert.NotContains(t, buff.String(), "hunter2")
+
+	messages = []ProvisionMessage{{Level: "error", Message: "oops"}}
+	_, err = ProvisionResources(context.Background(), state, provisioners)
+	assert.EqualError(t, err, "resource 't.default#w.r': messages.0: unknown level 'error', expected info or warn")
+}
+
+type namespacedProvisioner struct {
+	Provisioner
+	namespace string
+}
+
+func (n *namespacedProvisioner) Namespace() string {
+	return n.namespace
+}
+
+func TestProvisionResourcesNamespaces(t *testing.T) {
+	state := new(project.State)
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata:   map[string]interface{}{"name": "w"},
+		Containers: map[string]scoretypes.Container{"c": {Image: "busybox"}},
+		Resources:  map[string]scoretypes.Resource{"r": {Type: "t"}},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	state, err = state.WithPrimedResources()
+	require.NoError(t, err)
+
+	resUid := framework.NewResourceUid("w", "r", "t", nil, nil)
+	var input *Input
+	inner := NewEphemeralProvisioner("blah://", resUid, func(ctx context.Context, i *Input) (*ProvisionOutput, error) {
+		input = i
+		return &ProvisionOutput{}, nil
+	})
+
+	for _, tc := range []struct {
+		name              string
+		ctx               context.Context
+		provisioner       Provisioner
+		namespace         string
+		workloadNamespace string
+		extrasNamespace   string
+	}{
+		{name: "none", ctx: context.Background(), provisioner: inner},
+		{name: "workload only", ctx: WithNamespaces(context.Background(), "apps", ""), provisioner: inner, namespace: "apps", workloadNamespace: "apps"},
+		{name: "resource namespace", ctx: WithNamespaces(context.Background(), "apps", "data"), provisioner: inner, namespace: "data", workloadNamespace: "apps", extrasNamespace: "data"},
+		{name: "same namespace", ctx: WithNamespaces(context.Background(), "apps", "apps"), provisioner: inner, namespace: "apps", workloadNamespace: "apps"},
+		{name: "provisioner namespace", ctx: WithNamespaces(context.Background(), "apps", "data"), provisioner: &namespacedProvisioner{inner, "cache"}, namespace: "cache", workloadNamespace: "apps", extrasNamespace: "cache"},
+		{name: "provisioner namespace without workload namespace", ctx: context.Background(), provisioner: &namespacedProvisioner{inner, "cache"}, namespace: "cache", extrasNamespace: "cache"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := ProvisionResources(tc.ctx, state, []Provisioner{tc.provisioner})
+			require.NoError(t, err)
+			assert.Equal(t, tc.namespace, input.Namespace)
+			assert.Equal(t, tc.workloadNamespace, input.WorkloadNamespace)
+			assert.Equal(t, tc.extrasNamespace, out.Resources[resUid].Extras.Namespace)
+		})
+	}
+}
+
+func TestServiceHost(t *testing.T) {
+	assert.Equal(t, "pg", ServiceHost("pg", "", ""))
+	assert.Equal(t, "pg", ServiceHost("pg", "apps", "apps"))
+	assert.Equal(t, "pg.data.svc", ServiceHost("pg", "data", "apps"))
+	assert.Equal(t, "pg.data.svc", ServiceHost("pg", "data", ""))
+}
+
+type orderProvisioner struct {
+	order []string
+}
+
+func (o *orderProvisioner) Uri() string {
+	return "order://"
+}
+
+func (o *orderProvisioner) Match(resUid framework.ResourceUid) bool {
+	return true
+}
+
+func (o *orderProvisioner) Provision(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+	o.order = append(o.order, input.ResourceId)
+	return &ProvisionOutput{ResourceOutputs: map[string]interface{}{"x": "y"}}, nil
+}
+
+func TestProvisionResourcesPriority(t *testing.T) {
+	priority := func(v string) map[string]interface{} {
+		return map[string]interface{}{"annotations": map[string]interface{}{"k8s.score.dev/priority": v}}
+	}
+	state := new(project.State)
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata:   map[string]interface{}{"name": "w"},
+		Containers: map[string]scoretypes.Container{"c": {Image: "busybox"}},
+		Resources: map[string]scoretypes.Resource{
+			"a": {Type: "t"},
+			"b": {Type: "t", Metadata: priority("10")},
+			"c": {Type: "t", Metadata: priority("5"), Params: map[string]interface{}{"x": "${resources.a.x}"}},
+			"d": {Type: "t", Metadata: priority("-1")},
+		},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	state, err = state.WithPrimedResources()
+	require.NoError(t, err)
+
+	p := &orderProvisioner{}
+	_, err = ProvisionResources(context.Background(), state, []Provisioner{p})
+	require.NoError(t, err)
+	// c has a higher priority than a, but it depends on a so it must still come after it
+	assert.Equal(t, []string{"w.b", "w.a", "w.c", "w.d"}, p.order)
+
+	t.Run("invalid", func(t *testing.T) {
+		state.Resources["t.default#w.d"] = framework.ScoreResourceState[project.ResourceExtras]{
+			Type: "t", Class: "default", Id: "w.d", Metadata: priority("high"),
+		}
+		_, err = ProvisionResources(context.Background(), state, []Provisioner{&orderProvisioner{}})
+		assert.EqualError(t, err, "failed to determine sort order for provisioning: resource 't.default#w.d': metadata: annotations: k8s.score.dev/priority: 'high' is not an integer")
+	})
+}
+
+func TestResourceDependencies(t *testing.T) {
+	state := new(project.State)
+	state, err := state.WithWorkload(&scoretypes.Workload{
+		Metadata:   map[string]interface{}{"name": "w"},
+		Containers: map[string]scoretypes.Container{"c": {Image: "busybox"}},
+		Resources: map[string]scoretypes.Resource{
+			"a": {Type: "t"},
+			"b": {Type: "t", Id: util.Ref("shared")},
+			"c": {Type: "t", Params: map[string]interface{}{"x": []interface{}{"${resources.a.x}", map[string]interface{}{"y": "${resources.b.y}"}}}},
+			// escaped placeholders are not references
+			"d": {Type: "t", Params: map[string]interface{}{"x": "$${resources.a.x}", "y": "${resources.c.x}"}},
+		},
+	}, nil, project.WorkloadExtras{})
+	require.NoError(t, err)
+	assert.Equal(t, map[framework.ResourceUid]map[framework.ResourceUid]bool{
+		"t.default#w.c": {"t.default#w.a": true, "t.default#shared": true},
+		"t.default#w.d": {"t.default#w.c": true},
+	}, resourceDependencies(state))
+}
@@ -20,3 +20,9 @@ import (
 
 //go:embed zz-default.provisioners.yaml
 var DefaultProvisioners string
+
+// ExampleProvisioners contains a documented example of each built-in provisioner type. These match only the
+// 'example-*' resource types with the 'example' class.
+//
+//go:embed zz-examples.provisioners.yaml
+var ExampleProvisioners string
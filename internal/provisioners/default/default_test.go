@@ -15,10 +15,15 @@
 package _default
 
 import (
+	"context"
 	"testing"
 
+	"github.com/score-spec/score-go/framework"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/score-spec/score-k8s/internal"
+	"github.com/score-spec/score-k8s/internal/provisioners"
 	"github.com/score-spec/score-k8s/internal/provisioners/loader"
 )
 
@@ -28,3 +33,203 @@ func TestDefaultProvisioners(t *testing.T) {
 	assert.NotNil(t, p)
 	assert.Greater(t, len(p), 0)
 }
+
+func TestExampleProvisioners(t *testing.T) {
+	p, err := loader.LoadProvisioners([]byte(ExampleProvisioners))
+	assert.NoError(t, err)
+	uris := make([]string, 0, len(p))
+	for _, pi := range p {
+		uris = append(uris, pi.Uri())
+		assert.False(t, pi.Match(framework.NewResourceUid("w", "r", "postgres", internal.Ref("default"), nil)))
+	}
+	assert.Equal(t, []string{"template://examples/template", "cmd://bash#examples-cmd", "https://provisioner.example.com/provision"}, uris)
+
+	out, err := p[0].Provision(context.Background(), &provisioners.Input{
+		ResourceGuid:   "0123456789abcdef",
+		ResourceUid:    "example-template.example#w.r",
+		ResourceType:   "example-template",
+		ResourceParams: map[string]interface{}{"size": "large"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "example-01234567", "size": "large"}, out.ResourceOutputs)
+	assert.Len(t, out.Manifests, 1)
+}
+
+func TestDefaultSecretsUseStringData(t *testing.T) {
+	p, err := loader.LoadProvisioners([]byte(DefaultProvisioners))
+	require.NoError(t, err)
+	var redis provisioners.Provisioner
+	for _, pi := range p {
+		if pi.Match(framework.NewResourceUid("w", "r", "redis", nil, nil)) {
+			redis = pi
+			break
+		}
+	}
+	require.NotNil(t, redis)
+
+	password := `p@ss "word"`
+	out, err := redis.Provision(context.Background(), &provisioners.Input{
+		ResourceGuid:   "0123456789abcdef",
+		ResourceUid:    "redis.default#w.r",
+		ResourceType:   "redis",
+		ResourceState:  map[string]interface{}{"password": password},
+		SourceWorkload: "w",
+	})
+	require.NoError(t, err)
+	secret, err := internal.NormalizeManifest(out.Manifests[0])
+	require.NoError(t, err)
+	assert.Equal(t, "Secret", secret["kind"])
+	// plaintext values must not be base64 encoded by the template since the api server encodes stringData itself
+	assert.NotContains(t, secret, "data")
+	assert.Equal(t, map[string]interface{}{
+		"password":   password,
+		"redis.conf": "requirepass " + password + "\nport 6379\nsave 60 1\nloglevel warning\n",
+	}, secret["stringData"])
+}
+
+func TestDefaultRouteProvisioner(t *testing.T) {
+	p, err := loader.LoadProvisioners([]byte(DefaultProvisioners))
+	require.NoError(t, err)
+	var route provisioners.Provisioner
+	for _, pi := range p {
+		if pi.Match(framework.NewResourceUid("w", "r", "route", nil, nil)) {
+			route = pi
+			break
+		}
+	}
+	require.NotNil(t, route)
+
+	input := func(params map[string]interface{}) *provisioners.Input {
+		return &provisioners.Input{
+			ResourceGuid:   "0123456789abcdef",
+			ResourceUid:    "route.default#w.r",
+			ResourceType:   "route",
+			ResourceParams: params,
+			SourceWorkload: "w",
+			WorkloadServices: map[string]provisioners.NetworkService{
+				"w": {ServiceName: "w", Ports: map[string]provisioners.ServicePort{"80": {Name: "web", Port: 80, TargetPort: 8080}}},
+			},
+		}
+	}
+
+	t.Run("no rewrite", func(t *testing.T) {
+		out, err := route.Provision(context.Background(), input(map[string]interface{}{"host": "example.com", "path": "/", "port": 80}))
+		require.NoError(t, err)
+		require.Len(t, out.Manifests, 1)
+		rules := out.Manifests[0]["spec"].(map[string]interface{})["rules"].([]interface{})
+		assert.NotContains(t, rules[0], "filters")
+	})
+
+	t.Run("rewrite target and annotations", func(t *testing.T) {
+		out, err := route.Provision(context.Background(), input(map[string]interface{}{
+			"host": "example.com", "path": "/api", "port": 80, "rewriteTarget": "/",
+			"annotations": map[string]interface{}{"example.com/timeout": "30s"},
+		}))
+		require.NoError(t, err)
+		require.Len(t, out.Manifests, 1)
+		assert.Equal(t, "30s", out.Manifests[0]["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})["example.com/timeout"])
+		rules := out.Manifests[0]["spec"].(map[string]interface{})["rules"].([]interface{})
+		assert.Equal(t, []interface{}{map[string]interface{}{
+			"type": "URLRewrite",
+			"urlRewrite": map[string]interface{}{
+				"path": map[string]interface{}{"type": "ReplacePrefixMatch", "replacePrefixMatch": "/"},
+			},
+		}}, rules[0].(map[string]interface{})["filters"])
+	})
+
+	t.Run("invalid rewrite target", func(t *testing.T) {
+		_, err := route.Provision(context.Background(), input(map[string]interface{}{"host": "example.com", "path": "/", "port": 80, "rewriteTarget": "api"}))
+		assert.ErrorContains(t, err, "params.rewriteTarget must start with a /")
+	})
+}
+
+func TestDefaultIngressRouteProvisioner(t *testing.T) {
+	p, err := loader.LoadProvisioners([]byte(DefaultProvisioners))
+	require.NoError(t, err)
+	var route provisioners.Provisioner
+	for _, pi := range p {
+		if pi.Match(framework.NewResourceUid("w", "r", "route", internal.Ref("ingress"), nil)) {
+			route = pi
+			break
+		}
+	}
+	require.NotNil(t, route)
+	assert.Equal(t, "template://default-provisioners/ingress-route", route.Uri())
+
+	input := func(params map[string]interface{}) *provisioners.Input {
+		return &provisioners.Input{
+			ResourceGuid:   "0123456789abcdef",
+			ResourceUid:    "route.ingress#w.r",
+			ResourceType:   "route",
+			ResourceClass:  "ingress",
+			ResourceParams: params,
+			SourceWorkload: "w",
+			WorkloadServices: map[string]provisioners.NetworkService{
+				"w": {ServiceName: "w", Ports: map[string]provisioners.ServicePort{"80": {Name: "web", Port: 80, TargetPort: 8080}}},
+			},
+		}
+	}
+
+	t.Run("rule only", func(t *testing.T) {
+		out, err := route.Provision(context.Background(), input(map[string]interface{}{"host": "example.com", "path": "/", "port": 80}))
+		require.NoError(t, err)
+		require.Len(t, out.Manifests, 1)
+		assert.Equal(t, "Ingress", out.Manifests[0]["kind"])
+		spec := out.Manifests[0]["spec"].(map[string]interface{})
+		assert.NotContains(t, spec, "defaultBackend")
+		assert.NotContains(t, spec, "tls")
+		assert.Empty(t, out.Messages)
+	})
+
+	t.Run("default backend and tls", func(t *testing.T) {
+		out, err := route.Provision(context.Background(), input(map[string]interface{}{
+			"host": "example.com", "path": "/api", "port": 80,
+			"defaultBackend": map[string]interface{}{"service": "fallback", "port": 8080},
+			"tls": []interface{}{
+				map[string]interface{}{"secretName": "example-tls", "hosts": []interface{}{"example.com"}},
+				map[string]interface{}{"secretName": "other-tls", "hosts": []interface{}{"example.com", "other.example.com"}},
+			},
+		}))
+		require.NoError(t, err)
+		require.Len(t, out.Manifests, 1)
+		spec := out.Manifests[0]["spec"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{
+			"service": map[string]interface{}{"name": "fallback", "port": map[string]interface{}{"number": 8080}},
+		}, spec["defaultBackend"])
+		assert.Equal(t, []interface{}{
+			map[string]interface{}{"secretName": "example-tls", "hosts": []interface{}{"example.com"}},
+			map[string]interface{}{"secretName": "other-tls", "hosts": []interface{}{"example.com", "other.example.com"}},
+		}, spec["tls"])
+		assert.Equal(t, []interface{}{map[string]interface{}{
+			"host": "example.com",
+			"http": map[string]interface{}{"paths": []interface{}{map[string]interface{}{
+				"path":     "/api",
+				"pathType": "Prefix",
+				"backend": map[string]interface{}{
+					"service": map[string]interface{}{"name": "w", "port": map[string]interface{}{"number": 80}},
+				},
+			}}},
+		}}, spec["rules"])
+		assert.Equal(t, []provisioners.ProvisionMessage{{
+			Level:   provisioners.MessageLevelWarn,
+			Message: "params.tls[1].hosts contains 'other.example.com' which does not match the rule host 'example.com'",
+		}}, out.Messages)
+	})
+
+	t.Run("default backend defaults to the workload service", func(t *testing.T) {
+		out, err := route.Provision(context.Background(), input(map[string]interface{}{
+			"host": "example.com", "path": "/", "port": 80, "defaultBackend": map[string]interface{}{"port": 80},
+		}))
+		require.NoError(t, err)
+		spec := out.Manifests[0]["spec"].(map[string]interface{})
+		assert.Equal(t, "w", spec["defaultBackend"].(map[string]interface{})["service"].(map[string]interface{})["name"])
+	})
+
+	t.Run("tls without a secret", func(t *testing.T) {
+		_, err := route.Provision(context.Background(), input(map[string]interface{}{
+			"host": "example.com", "path": "/", "port": 80,
+			"tls": []interface{}{map[string]interface{}{"hosts": []interface{}{"example.com"}}},
+		}))
+		assert.ErrorContains(t, err, "params.tls[0].secretName must be set")
+	})
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpprov
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/score-spec/score-go/framework"
+	"gopkg.in/yaml.v3"
+
+	"github.com/score-spec/score-k8s/internal/provisioners"
+)
+
+// Provisioner is the decoded http provisioner.
+// An http provisioner provisions a resource by POSTing the json encoded provisioner input to the uri and decoding the
+// json provision output from the response body.
+type Provisioner struct {
+	ProvisionerUri string  `yaml:"uri"`
+	ResType        string  `yaml:"type"`
+	ResClass       *string `yaml:"class,omitempty"`
+	ResId          *string `yaml:"id,omitempty"`
+	// Headers are additional headers to send with the request. Environment variables in the values are expanded so
+	// that tokens do not need to be stored in the provisioners file.
+	Headers map[string]string `yaml:"headers,omitempty"`
+	// ResParamsSchema is an optional JSON Schema that the resource params must match.
+	ResParamsSchema map[string]interface{} `yaml:"paramsSchema,omitempty"`
+	// ResNamespace is an optional namespace for the manifests of this provisioner, it overrides the resource namespace
+	// of the run.
+	ResNamespace string `yaml:"namespace,omitempty"`
+	// ResReadinessCheck is an optional check that the outputs must pass before they are accepted. The provisioner is
+	// invoked again until the check passes or times out.
+	ResReadinessCheck *provisioners.ReadinessCheck `yaml:"readinessCheck,omitempty"`
+}
+
+func (p *Provisioner) Uri() string {
+	return p.ProvisionerUri
+}
+
+func (p *Provisioner) ParamsSchema() map[string]interface{} {
+	return p.ResParamsSchema
+}
+
+func (p *Provisioner) Namespace() string {
+	return p.ResNamespace
+}
+
+func (p *Provisioner) ReadinessCheck() *provisioners.ReadinessCheck {
+	return p.ResReadinessCheck
+}
+
+func (p *Provisioner) Match(resUid framework.ResourceUid) bool {
+	if resUid.Type() != p.ResType {
+		return false
+	} else if p.ResClass != nil && resUid.Class() != *p.ResClass {
+		return false
+	} else if p.ResId != nil && resUid.Id() != *p.ResId {
+		return false
+	}
+	return true
+}
+
+func (p *Provisioner) Provision(ctx context.Context, input *provisioners.Input) (*provisioners.ProvisionOutput, error) {
+	rawInput, err := json.Marshal(input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode json input: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.ProvisionerUri, bytes.NewReader(rawInput))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	for k, v := range p.Headers {
+		req.Header.Set(k, os.ExpandEnv(v))
+	}
+
+	slog.Debug(fmt.Sprintf("Sending request to '%s' for http provisioner", p.ProvisionerUri))
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute http provisioner: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response from http provisioner: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		slog.Debug("Response from http provisioner:\n" + provisioners.RedactText(string(body)))
+		return nil, fmt.Errorf("http provisioner returned unexpected status %d", resp.StatusCode)
+	}
+
+	var output provisioners.ProvisionOutput
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&output); err != nil {
+		slog.Debug("Response from http provisioner:\n" + provisioners.RedactText(string(body)))
+		return nil, fmt.Errorf("failed to decode output from http provisioner: %w", err)
+	}
+	return &output, nil
+}
+
+func Parse(raw map[string]interface{}) (*Provisioner, error) {
+	p := new(Provisioner)
+	intermediate, _ := yaml.Marshal(raw)
+	dec := yaml.NewDecoder(bytes.NewReader(intermediate))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil {
+		return nil, err
+	}
+	if p.ProvisionerUri == "" {
+		return nil, fmt.Errorf("uri not set")
+	} else if p.ResType == "" {
+		return nil, fmt.Errorf("type not set")
+	}
+	if p.ResParamsSchema != nil {
+		if _, err := provisioners.CompileParamsSchema(p.ResParamsSchema); err != nil {
+			return nil, fmt.Errorf("paramsSchema: %w", err)
+		}
+	}
+	if p.ResNamespace != "" {
+		if err := provisioners.ValidateNamespace(p.ResNamespace); err != nil {
+			return nil, fmt.Errorf("namespace: %w", err)
+		}
+	}
+	if p.ResReadinessCheck != nil {
+		if err := p.ResReadinessCheck.Validate(); err != nil {
+			return nil, fmt.Errorf("readinessCheck: %w", err)
+		}
+	}
+
+	parts, err := url.Parse(p.ProvisionerUri)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	} else if parts.Scheme != "http" && parts.Scheme != "https" {
+		return nil, fmt.Errorf("http provisioner uri must use the http or https scheme")
+	} else if parts.Host == "" {
+		return nil, fmt.Errorf("http provisioner uri must contain a host")
+	} else if parts.User != nil {
+		return nil, fmt.Errorf("http provisioner uri cannot contain user info, use headers instead")
+	}
+
+	return p, nil
+}
+
+var _ provisioners.Provisioner = (*Provisioner)(nil)
+var _ provisioners.ParamsSchemaProvider = (*Provisioner)(nil)
+var _ provisioners.NamespaceProvider = (*Provisioner)(nil)
+var _ provisioners.ReadinessCheckProvider = (*Provisioner)(nil)
@@ -0,0 +1,74 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpprov
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/score-spec/score-k8s/internal/provisioners"
+)
+
+func TestParseUri_fail(t *testing.T) {
+	for k, v := range map[string]string{
+		"":                         "uri not set",
+		"http://":                  "http provisioner uri must contain a host",
+		"http://user:pw@host/path": "http provisioner uri cannot contain user info, use headers instead",
+		"ftp://host/path":          "http provisioner uri must use the http or https scheme",
+	} {
+		t.Run(k, func(t *testing.T) {
+			_, err := Parse(map[string]interface{}{"uri": k, "type": "foo"})
+			assert.EqualError(t, err, v)
+		})
+	}
+}
+
+func TestProvision(t *testing.T) {
+	t.Setenv("HTTP_PROV_TOKEN", "secret")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		raw, _ := io.ReadAll(r.Body)
+		var input provisioners.Input
+		require.NoError(t, json.Unmarshal(raw, &input))
+		if input.ResourceType == "broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"resource_outputs": {"uid": "` + input.ResourceUid + `"}}`))
+	}))
+	defer srv.Close()
+
+	p, err := Parse(map[string]interface{}{
+		"uri":     srv.URL + "/provision",
+		"type":    "thing",
+		"headers": map[string]interface{}{"Authorization": "Bearer ${HTTP_PROV_TOKEN}"},
+	})
+	require.NoError(t, err)
+
+	out, err := p.Provision(context.Background(), &provisioners.Input{ResourceUid: "thing.default#w.r", ResourceType: "thing"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"uid": "thing.default#w.r"}, out.ResourceOutputs)
+
+	_, err = p.Provision(context.Background(), &provisioners.Input{ResourceType: "broken"})
+	assert.EqualError(t, err, "http provisioner returned unexpected status 500")
+}
@@ -0,0 +1,104 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioners
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+
+	"github.com/score-spec/score-go/framework"
+	"gopkg.in/yaml.v3"
+
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+type incrementalKey struct{}
+
+// incrementalRun tracks the incremental provisioning of a single run.
+type incrementalRun struct {
+	force   bool
+	skipped int
+}
+
+// WithIncrementalProvisioning returns a context in which ProvisionResources skips the resources whose provisioner and
+// inputs are unchanged since they were last provisioned, reusing their outputs, manifests, sidecars, and spawned
+// resources from the state. When force is set, every resource is provisioned again but the cache is still recorded for
+// the next run.
+func WithIncrementalProvisioning(ctx context.Context, force bool) context.Context {
+	return context.WithValue(ctx, incrementalKey{}, &incrementalRun{force: force})
+}
+
+// provisionHash returns a hash of everything that determines the result of provisioning the resource: the provisioner
+// uri and definition and the provisioner input. The previous resource state and the shared state are excluded since
+// these are the results of earlier runs rather than inputs that the user changes.
+func provisionHash(provisioner Provisioner, input *Input) (string, error) {
+	rawProvisioner, err := yaml.Marshal(provisioner)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode provisioner: %w", err)
+	}
+	hashedInput := *input
+	hashedInput.ResourceState = nil
+	hashedInput.SharedState = nil
+	rawInput, err := json.Marshal(hashedInput)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode input: %w", err)
+	}
+	h := sha256.New()
+	for _, part := range [][]byte{[]byte(provisioner.Uri()), rawProvisioner, rawInput} {
+		_, _ = h.Write(part)
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// recordProvisionCache returns a copy of the state in which the hash and the unpersisted results of provisioning the
+// resource are stored in the resource state.
+func recordProvisionCache(state *project.State, resUid framework.ResourceUid, hash string, output *ProvisionOutput) *project.State {
+	out := *state
+	out.Resources = maps.Clone(state.Resources)
+	res := out.Resources[resUid]
+	res.Extras.ProvisionCache = &project.ProvisionCache{
+		Hash:           hash,
+		Manifests:      output.Manifests,
+		Sidecars:       output.Sidecars,
+		PodLabels:      output.PodLabels,
+		PodAnnotations: output.PodAnnotations,
+		Resources:      output.Resources,
+	}
+	out.Resources[resUid] = res
+	return &out
+}
+
+// applyProvisionCache returns a copy of the state in which the unpersisted results of the last provisioning of the
+// resource are restored from its cache. The outputs and state of the resource are already persisted.
+func applyProvisionCache(state *project.State, resUid framework.ResourceUid) *project.State {
+	out := *state
+	out.Resources = maps.Clone(state.Resources)
+	res := out.Resources[resUid]
+	cache := res.Extras.ProvisionCache
+	res.Extras.Manifests = cache.Manifests
+	if res.Extras.Manifests == nil {
+		res.Extras.Manifests = make([]map[string]interface{}, 0)
+	}
+	res.Extras.Sidecars = cache.Sidecars
+	res.Extras.PodLabels = cache.PodLabels
+	res.Extras.PodAnnotations = cache.PodAnnotations
+	out.Resources[resUid] = res
+	return &out
+}
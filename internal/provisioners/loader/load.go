@@ -32,6 +32,8 @@ import (
 
 	"github.com/score-spec/score-k8s/internal/provisioners"
 	"github.com/score-spec/score-k8s/internal/provisioners/cmdprov"
+	"github.com/score-spec/score-k8s/internal/provisioners/httpprov"
+	"github.com/score-spec/score-k8s/internal/provisioners/ociprov"
 	"github.com/score-spec/score-k8s/internal/provisioners/templateprov"
 )
 
@@ -67,6 +69,24 @@ func LoadProvisioners(raw []byte) ([]provisioners.Provisioner, error) {
 				slog.Debug(fmt.Sprintf("Loaded provisioner %s", p.Uri()))
 				out = append(out, p)
 			}
+		case "oci":
+			if p, err := ociprov.Parse(m); err != nil {
+				return nil, fmt.Errorf("%d: %s: failed to parse: %w", i, uri, err)
+			} else {
+				slog.Debug(fmt.Sprintf("Loaded provisioner %s", p.Uri()))
+				out = append(out, p)
+			}
+		case "http", "https":
+			if p, err := httpprov.Parse(m); err != nil {
+				return nil, fmt.Errorf("%d: %s: failed to parse: %w", i, uri, err)
+			} else {
+				slog.Debug(fmt.Sprintf("Loaded provisioner %s", p.Uri()))
+				out = append(out, p)
+			}
+		case "wasm":
+			// There is no embedded WASM runtime yet, so rather than falling back to running an executable, these are
+			// rejected until sandboxed provisioners can be supported.
+			return nil, fmt.Errorf("%d: %s: wasm provisioners are not supported yet, use a template, cmd, oci, or http provisioner instead", i, uri)
 		default:
 			return nil, fmt.Errorf("%d: unsupported provisioner type '%s'", i, u.Scheme)
 		}
@@ -63,6 +63,19 @@ func TestLoadProvisioners(t *testing.T) {
 		assert.True(t, p[0].Match(framework.NewResourceUid("w", "r", "thing", nil, internal.Ref("specific"))))
 	})
 
+	t.Run("nominal http", func(t *testing.T) {
+		p, err := LoadProvisioners([]byte(`
+- uri: https://provisioner.example.com/provision
+  type: thing
+  headers:
+    Authorization: Bearer ${TOKEN}
+`))
+		require.NoError(t, err)
+		assert.Len(t, p, 1)
+		assert.Equal(t, "https://provisioner.example.com/provision", p[0].Uri())
+		assert.True(t, p[0].Match(framework.NewResourceUid("w", "r", "thing", nil, nil)))
+	})
+
 	t.Run("unknown schema", func(t *testing.T) {
 		_, err := LoadProvisioners([]byte(`
 - uri: blah://example
@@ -71,6 +84,14 @@ func TestLoadProvisioners(t *testing.T) {
 		require.EqualError(t, err, "0: unsupported provisioner type 'blah'")
 	})
 
+	t.Run("wasm schema", func(t *testing.T) {
+		_, err := LoadProvisioners([]byte(`
+- uri: wasm://example/provisioner.wasm
+  type: thing
+`))
+		require.EqualError(t, err, "0: wasm://example/provisioner.wasm: wasm provisioners are not supported yet, use a template, cmd, oci, or http provisioner instead")
+	})
+
 	t.Run("missing uri", func(t *testing.T) {
 		_, err := LoadProvisioners([]byte(`
 - type: thing
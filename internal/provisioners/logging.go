@@ -0,0 +1,144 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioners
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+
+	"github.com/score-spec/score-go/framework"
+	"gopkg.in/yaml.v3"
+)
+
+type verboseLoggingKey struct{}
+
+// WithVerboseLogging returns a context in which the json input and output of each provisioner call is logged at info
+// level rather than debug level.
+func WithVerboseLogging(ctx context.Context) context.Context {
+	return context.WithValue(ctx, verboseLoggingKey{}, true)
+}
+
+// secretKeyPattern matches the keys of values that must not appear in the logs.
+var secretKeyPattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|api[-_]?key|private[-_]?key|credential)`)
+
+// secretLinePattern is the fallback for text that cannot be decoded, it matches "key: value" or "key=value" pairs
+// where the key looks like a secret.
+var secretLinePattern = regexp.MustCompile(`(?i)((?:password|passwd|secret|token|api[-_]?key|private[-_]?key|credential)[\w-]*["']?\s*[:=]\s*)("[^"]*"|'[^']*'|[^\s,}]+)`)
+
+const redactedValue = "<redacted>"
+
+// redactSecrets returns a json compatible copy of the value with any secret values replaced.
+func redactSecrets(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	return redactValue(out), nil
+}
+
+// redactValue replaces, in place, any scalar values under secret looking keys and the contents of any Kubernetes
+// Secret manifests since these hold the values that secret outputs refer to.
+func redactValue(v interface{}) interface{} {
+	switch typed := v.(type) {
+	case map[string]interface{}:
+		isSecret := typed["kind"] == "Secret"
+		for k, inner := range typed {
+			switch inner.(type) {
+			case nil:
+			case map[string]interface{}:
+				if isSecret && (k == "data" || k == "stringData") {
+					for ik := range inner.(map[string]interface{}) {
+						inner.(map[string]interface{})[ik] = redactedValue
+					}
+				} else {
+					redactValue(inner)
+				}
+			case []interface{}:
+				redactValue(inner)
+			default:
+				if secretKeyPattern.MatchString(k) {
+					typed[k] = redactedValue
+				}
+			}
+		}
+	case []interface{}:
+		for _, inner := range typed {
+			redactValue(inner)
+		}
+	}
+	return v
+}
+
+// RedactText redacts secrets from raw provisioner input or output before it is logged. Text that decodes as yaml or
+// json is redacted structurally and re-encoded as yaml, otherwise any secret looking "key: value" pairs are replaced.
+func RedactText(raw string) string {
+	var decoded interface{}
+	if err := yaml.Unmarshal([]byte(raw), &decoded); err == nil {
+		switch decoded.(type) {
+		case map[string]interface{}, []interface{}:
+			if out, err := yaml.Marshal(redactValue(decoded)); err == nil {
+				return string(out)
+			}
+		}
+	}
+	return secretLinePattern.ReplaceAllString(raw, "${1}"+redactedValue)
+}
+
+// logProvisionerIO logs the json encoded input or output of a provisioner call with any secrets redacted.
+func logProvisionerIO(ctx context.Context, resUid framework.ResourceUid, provisionerUri, kind string, v interface{}) {
+	level := slog.LevelDebug
+	if verbose, _ := ctx.Value(verboseLoggingKey{}).(bool); verbose {
+		level = slog.LevelInfo
+	}
+	if !slog.Default().Enabled(ctx, level) {
+		return
+	}
+	redacted, err := redactSecrets(v)
+	if err != nil {
+		slog.Log(ctx, level, fmt.Sprintf("Failed to encode provisioner %s for resource '%s': %v", kind, resUid, err))
+		return
+	}
+	raw := new(bytes.Buffer)
+	enc := json.NewEncoder(raw)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(redacted)
+	slog.Log(ctx, level, fmt.Sprintf("Provisioner '%s' %s for resource '%s':\n%s", provisionerUri, kind, resUid, bytes.TrimSpace(raw.Bytes())))
+}
+
+// logProvisionerMessages logs the messages returned by a provisioner at their level, with any secret looking values
+// redacted. An unknown level is an error so that provisioner authors notice it.
+func logProvisionerMessages(ctx context.Context, resUid framework.ResourceUid, provisionerUri string, messages []ProvisionMessage) error {
+	for i, m := range messages {
+		level := slog.LevelInfo
+		switch m.Level {
+		case "", MessageLevelInfo:
+		case MessageLevelWarn:
+			level = slog.LevelWarn
+		default:
+			return fmt.Errorf("messages.%d: unknown level '%s', expected %s or %s", i, m.Level, MessageLevelInfo, MessageLevelWarn)
+		}
+		slog.Log(ctx, level, fmt.Sprintf("Provisioner '%s' for resource '%s': %s", provisionerUri, resUid, secretLinePattern.ReplaceAllString(m.Message, "${1}"+redactedValue)))
+	}
+	return nil
+}
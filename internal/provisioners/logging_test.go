@@ -0,0 +1,102 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioners
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/score-spec/score-go/framework"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactSecrets(t *testing.T) {
+	out, err := redactSecrets(map[string]interface{}{
+		"host":     "localhost",
+		"password": "hunter2",
+		"nested": map[string]interface{}{
+			"api_key": "abc",
+			"list":    []interface{}{map[string]interface{}{"token": "t", "port": 5432}},
+		},
+		"client_secret": nil,
+		"env":           map[string]interface{}{"secretKeyRef": map[string]interface{}{"name": "s", "key": "k"}},
+		"manifests": []interface{}{
+			map[string]interface{}{"kind": "Secret", "data": map[string]interface{}{"host": "aG9zdA=="}, "stringData": map[string]interface{}{"user": "u"}},
+			map[string]interface{}{"kind": "ConfigMap", "data": map[string]interface{}{"host": "h"}},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"host":     "localhost",
+		"password": redactedValue,
+		"nested": map[string]interface{}{
+			"api_key": redactedValue,
+			"list":    []interface{}{map[string]interface{}{"token": redactedValue, "port": float64(5432)}},
+		},
+		"client_secret": nil,
+		"env":           map[string]interface{}{"secretKeyRef": map[string]interface{}{"name": "s", "key": "k"}},
+		"manifests": []interface{}{
+			map[string]interface{}{"kind": "Secret", "data": map[string]interface{}{"host": redactedValue}, "stringData": map[string]interface{}{"user": redactedValue}},
+			map[string]interface{}{"kind": "ConfigMap", "data": map[string]interface{}{"host": "h"}},
+		},
+	}, out)
+}
+
+func TestRedactText(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		raw    string
+		expect string
+	}{
+		{name: "json", raw: `{"resource_outputs": {"host": "h", "password": "p"}}`, expect: "resource_outputs:\n    host: h\n    password: <redacted>\n"},
+		{name: "yaml", raw: "resource_outputs:\n  api_key: abc\n", expect: "resource_outputs:\n    api_key: <redacted>\n"},
+		{name: "invalid", raw: `{"password": "p", "host": "h"`, expect: `{"password": <redacted>, "host": "h"`},
+		{name: "plain text", raw: "failed with token=abc123 for host", expect: "failed with token=<redacted> for host"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expect, RedactText(tc.raw))
+		})
+	}
+}
+
+func TestLogProvisionerIO(t *testing.T) {
+	buff := new(bytes.Buffer)
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewTextHandler(buff, &slog.HandlerOptions{Level: slog.LevelInfo})))
+	t.Cleanup(func() {
+		slog.SetDefault(prev)
+	})
+
+	resUid := framework.NewResourceUid("w", "r", "t", nil, nil)
+	output := &ProvisionOutput{ResourceOutputs: map[string]interface{}{"username": "u", "password": "p"}}
+
+	t.Run("debug by default", func(t *testing.T) {
+		buff.Reset()
+		logProvisionerIO(context.Background(), resUid, "cmd://example", "output", output)
+		assert.Empty(t, buff.String())
+	})
+
+	t.Run("info when verbose", func(t *testing.T) {
+		buff.Reset()
+		logProvisionerIO(WithVerboseLogging(context.Background()), resUid, "cmd://example", "output", output)
+		assert.Contains(t, buff.String(), "Provisioner 'cmd://example' output for resource 't.default#w.r'")
+		assert.Contains(t, buff.String(), `\"username\": \"u\"`)
+		assert.Contains(t, buff.String(), `\"password\": \"<redacted>\"`)
+		assert.NotContains(t, buff.String(), `\"p\"`)
+	})
+}
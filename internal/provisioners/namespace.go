@@ -0,0 +1,96 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioners
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/score-spec/score-go/framework"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+type namespacesKey struct{}
+
+// namespaces are the namespaces that the workload and resource manifests are emitted into.
+type namespaces struct {
+	workload string
+	resource string
+}
+
+// WithNamespaces returns a context in which the provisioners are told the namespace of the workloads and the namespace
+// that resource manifests are emitted into. An empty resource namespace means the workload namespace.
+func WithNamespaces(ctx context.Context, workloadNamespace, resourceNamespace string) context.Context {
+	return context.WithValue(ctx, namespacesKey{}, namespaces{workload: workloadNamespace, resource: resourceNamespace})
+}
+
+// NamespaceProvider is an optional interface implemented by provisioners that emit their manifests into a fixed
+// namespace rather than the resource namespace of the run.
+type NamespaceProvider interface {
+	Namespace() string
+}
+
+// ValidateNamespace checks that a provisioner namespace is a valid namespace name.
+func ValidateNamespace(namespace string) error {
+	if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+		return fmt.Errorf("'%s' is invalid: %s", namespace, strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+// resourceNamespaces returns the namespace that the manifests of the provisioner are emitted into and the namespace of
+// the workloads. The namespace of the provisioner wins over the resource namespace, which defaults to the workload
+// namespace.
+func resourceNamespaces(ctx context.Context, provisioner Provisioner) (string, string) {
+	ns, _ := ctx.Value(namespacesKey{}).(namespaces)
+	resource := ns.resource
+	if np, ok := provisioner.(NamespaceProvider); ok && np.Namespace() != "" {
+		resource = np.Namespace()
+	}
+	if resource == "" {
+		resource = ns.workload
+	}
+	return resource, ns.workload
+}
+
+// ServiceHost returns the hostname that a workload uses to reach the named service in the resource namespace. This is
+// the bare service name when the resource and the workloads share a namespace, and <service>.<namespace>.svc otherwise.
+func ServiceHost(service, namespace, workloadNamespace string) string {
+	if namespace == "" || namespace == workloadNamespace {
+		return service
+	}
+	return service + "." + namespace + ".svc"
+}
+
+// withResourceNamespace returns a copy of the state in which the namespace of the resource manifests is recorded if it
+// differs from the workload namespace.
+func withResourceNamespace(state *project.State, resUid framework.ResourceUid, namespace, workloadNamespace string) *project.State {
+	if namespace == workloadNamespace {
+		namespace = ""
+	}
+	if state.Resources[resUid].Extras.Namespace == namespace {
+		return state
+	}
+	out := *state
+	out.Resources = maps.Clone(state.Resources)
+	res := out.Resources[resUid]
+	res.Extras.Namespace = namespace
+	out.Resources[resUid] = res
+	return &out
+}
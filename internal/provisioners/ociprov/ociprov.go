@@ -0,0 +1,324 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ociprov implements provisioners that are distributed as OCI artifacts. The artifact is pulled from the
+// registry, verified against its digests, cached locally, and then executed in the same way as a cmd provisioner.
+package ociprov
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/score-spec/score-go/framework"
+	"gopkg.in/yaml.v3"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+	"oras.land/oras-go/v2/registry/remote/retry"
+
+	"github.com/score-spec/score-k8s/internal/provisioners"
+	"github.com/score-spec/score-k8s/internal/provisioners/cmdprov"
+)
+
+// ExecutableTitle is the title annotation of the layer holding the provisioner executable. A bundle may instead hold
+// one layer per platform titled ExecutableTitle-<os>-<arch>, which takes precedence for the current platform.
+const ExecutableTitle = "provisioner"
+
+type Provisioner struct {
+	ProvisionerUri string   `yaml:"uri"`
+	ResType        string   `yaml:"type"`
+	ResClass       *string  `yaml:"class,omitempty"`
+	ResId          *string  `yaml:"id,omitempty"`
+	Args           []string `yaml:"args,omitempty"`
+	// PlainHttp accesses the registry over http rather than https, this is intended for local registries only.
+	PlainHttp bool `yaml:"plainHttp,omitempty"`
+	// ResParamsSchema is an optional JSON Schema that the resource params must match.
+	ResParamsSchema map[string]interface{} `yaml:"paramsSchema,omitempty"`
+	// ResNamespace is an optional namespace for the manifests of this provisioner, it overrides the resource namespace
+	// of the run.
+	ResNamespace string `yaml:"namespace,omitempty"`
+	// ResReadinessCheck is an optional check that the outputs must pass before they are accepted.
+	ResReadinessCheck *provisioners.ReadinessCheck `yaml:"readinessCheck,omitempty"`
+
+	// cacheDir overrides the default cache directory.
+	cacheDir string
+	// newTarget overrides how the registry repository is accessed.
+	newTarget func(ref registry.Reference) (oras.ReadOnlyTarget, error)
+}
+
+func (p *Provisioner) Uri() string {
+	return p.ProvisionerUri
+}
+
+func (p *Provisioner) ParamsSchema() map[string]interface{} {
+	return p.ResParamsSchema
+}
+
+func (p *Provisioner) Namespace() string {
+	return p.ResNamespace
+}
+
+func (p *Provisioner) ReadinessCheck() *provisioners.ReadinessCheck {
+	return p.ResReadinessCheck
+}
+
+func (p *Provisioner) Match(resUid framework.ResourceUid) bool {
+	if resUid.Type() != p.ResType {
+		return false
+	} else if p.ResClass != nil && resUid.Class() != *p.ResClass {
+		return false
+	} else if p.ResId != nil && resUid.Id() != *p.ResId {
+		return false
+	}
+	return true
+}
+
+func (p *Provisioner) Provision(ctx context.Context, input *provisioners.Input) (*provisioners.ProvisionOutput, error) {
+	bin, err := p.executable(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return cmdprov.Execute(ctx, bin, p.Args, input, "provision")
+}
+
+// Plan executes the bundle with the <mode> arg set to "plan". Bundles without a <mode> arg do not support planning.
+func (p *Provisioner) Plan(ctx context.Context, input *provisioners.Input) (*provisioners.ProvisionOutput, error) {
+	if !slices.Contains(p.Args, cmdprov.ModeArg) {
+		return nil, provisioners.ErrPlanNotSupported
+	}
+	bin, err := p.executable(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return cmdprov.Execute(ctx, bin, p.Args, input, "plan")
+}
+
+// reference parses the registry reference from the uri, defaulting to the latest tag.
+func (p *Provisioner) reference() (registry.Reference, error) {
+	u, err := url.Parse(p.ProvisionerUri)
+	if err != nil {
+		return registry.Reference{}, fmt.Errorf("failed to parse url: %w", err)
+	}
+	ref, err := registry.ParseReference(u.Host + u.Path)
+	if err != nil {
+		return registry.Reference{}, fmt.Errorf("invalid artifact reference: %w", err)
+	}
+	if ref.Reference == "" {
+		ref.Reference = "latest"
+	}
+	return ref, nil
+}
+
+// target returns the repository to pull from, authenticating with the credentials in the docker config.
+func (p *Provisioner) target(ref registry.Reference) (oras.ReadOnlyTarget, error) {
+	if p.newTarget != nil {
+		return p.newTarget(ref)
+	}
+	repo, err := remote.NewRepository(ref.Registry + "/" + ref.Repository)
+	if err != nil {
+		return nil, fmt.Errorf("invalid repository: %w", err)
+	}
+	repo.PlainHTTP = p.PlainHttp
+	store, err := credentials.NewStoreFromDocker(credentials.StoreOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load docker credentials: %w", err)
+	}
+	repo.Client = &auth.Client{
+		Client:     retry.DefaultClient,
+		Cache:      auth.NewCache(),
+		Credential: credentials.Credential(store),
+	}
+	return repo, nil
+}
+
+// executable returns the path of the executable for the bundle. Within a provisioning run, the bundle is only pulled
+// once for each uri unless the executable no longer matches its digest.
+func (p *Provisioner) executable(ctx context.Context) (string, error) {
+	return provisioners.ResolveBinary(ctx, p.ProvisionerUri, func() (string, digest.Digest, error) {
+		return p.pull(ctx)
+	})
+}
+
+// pull returns the path and digest of the cached executable for the bundle, fetching it from the registry if needed.
+// A bundle that is pinned by digest is only fetched once, while a tag is resolved on each call. The manifest and
+// executable are verified against their digests both when fetched and when read from the cache.
+func (p *Provisioner) pull(ctx context.Context) (string, digest.Digest, error) {
+	ref, err := p.reference()
+	if err != nil {
+		return "", "", err
+	}
+	cacheDir := p.cacheDir
+	if cacheDir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to find the user cache directory: %w", err)
+		}
+		cacheDir = filepath.Join(userCacheDir, "score-k8s", "oci")
+	}
+
+	var target oras.ReadOnlyTarget
+	getTarget := func() (oras.ReadOnlyTarget, error) {
+		if target == nil {
+			if target, err = p.target(ref); err != nil {
+				return nil, err
+			}
+		}
+		return target, nil
+	}
+
+	var rawManifest []byte
+	pinned, pinnedErr := ref.Digest()
+	if pinnedErr == nil {
+		rawManifest, _ = readCached(cacheDir, pinned)
+	}
+	if rawManifest == nil {
+		t, err := getTarget()
+		if err != nil {
+			return "", "", err
+		}
+		desc, err := t.Resolve(ctx, ref.Reference)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to resolve '%s': %w", ref, err)
+		} else if pinnedErr == nil && desc.Digest != pinned {
+			return "", "", fmt.Errorf("manifest digest %s does not match the pinned digest %s", desc.Digest, pinned)
+		}
+		if rawManifest, err = content.FetchAll(ctx, t, desc); err != nil {
+			return "", "", fmt.Errorf("failed to fetch manifest: %w", err)
+		}
+		if err := writeCached(cacheDir, desc.Digest, rawManifest, 0644); err != nil {
+			return "", "", err
+		}
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(rawManifest, &manifest); err != nil {
+		return "", "", fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	layer, ok := selectExecutableLayer(manifest.Layers)
+	if !ok {
+		return "", "", fmt.Errorf("bundle has no layer titled '%s' or '%s-%s-%s'", ExecutableTitle, ExecutableTitle, runtime.GOOS, runtime.GOARCH)
+	}
+	if _, err := readCached(cacheDir, layer.Digest); err == nil {
+		return cachePath(cacheDir, layer.Digest), layer.Digest, nil
+	}
+	t, err := getTarget()
+	if err != nil {
+		return "", "", err
+	}
+	raw, err := content.FetchAll(ctx, t, layer)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to fetch executable: %w", err)
+	}
+	if err := writeCached(cacheDir, layer.Digest, raw, 0755); err != nil {
+		return "", "", err
+	}
+	slog.Info(fmt.Sprintf("Pulled provisioner %s (%s) into the cache", p.ProvisionerUri, layer.Digest))
+	return cachePath(cacheDir, layer.Digest), layer.Digest, nil
+}
+
+// selectExecutableLayer picks the layer for the current platform, falling back to the platform independent layer.
+func selectExecutableLayer(layers []ocispec.Descriptor) (ocispec.Descriptor, bool) {
+	for _, title := range []string{fmt.Sprintf("%s-%s-%s", ExecutableTitle, runtime.GOOS, runtime.GOARCH), ExecutableTitle} {
+		if i := slices.IndexFunc(layers, func(d ocispec.Descriptor) bool { return d.Annotations[ocispec.AnnotationTitle] == title }); i >= 0 {
+			return layers[i], true
+		}
+	}
+	return ocispec.Descriptor{}, false
+}
+
+func cachePath(cacheDir string, d digest.Digest) string {
+	return filepath.Join(cacheDir, d.Algorithm().String(), d.Encoded())
+}
+
+// readCached reads the content from the cache and verifies it against the digest.
+func readCached(cacheDir string, d digest.Digest) ([]byte, error) {
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	raw, err := os.ReadFile(cachePath(cacheDir, d))
+	if err != nil {
+		return nil, err
+	} else if d.Algorithm().FromBytes(raw) != d {
+		slog.Warn(fmt.Sprintf("Ignoring cached content for %s since it does not match the digest", d))
+		return nil, fmt.Errorf("cached content does not match the digest")
+	}
+	return raw, nil
+}
+
+// writeCached writes the verified content to the cache through a temporary file.
+func writeCached(cacheDir string, d digest.Digest, raw []byte, mode os.FileMode) error {
+	path := cachePath(cacheDir, d)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, raw, mode); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	} else if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to rename cache file: %w", err)
+	}
+	return nil
+}
+
+func Parse(raw map[string]interface{}) (*Provisioner, error) {
+	p := new(Provisioner)
+	intermediate, _ := yaml.Marshal(raw)
+	dec := yaml.NewDecoder(bytes.NewReader(intermediate))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil {
+		return nil, err
+	}
+	if p.ProvisionerUri == "" {
+		return nil, fmt.Errorf("uri not set")
+	} else if p.ResType == "" {
+		return nil, fmt.Errorf("type not set")
+	}
+	if p.ResParamsSchema != nil {
+		if _, err := provisioners.CompileParamsSchema(p.ResParamsSchema); err != nil {
+			return nil, fmt.Errorf("paramsSchema: %w", err)
+		}
+	}
+	if p.ResNamespace != "" {
+		if err := provisioners.ValidateNamespace(p.ResNamespace); err != nil {
+			return nil, fmt.Errorf("namespace: %w", err)
+		}
+	}
+	if p.ResReadinessCheck != nil {
+		if err := p.ResReadinessCheck.Validate(); err != nil {
+			return nil, fmt.Errorf("readinessCheck: %w", err)
+		}
+	}
+	if u, err := url.Parse(p.ProvisionerUri); err != nil {
+		return nil, fmt.Errorf("failed to parse url: %w", err)
+	} else if u.User != nil || len(u.Query()) != 0 || u.Fragment != "" {
+		return nil, fmt.Errorf("oci provisioner uri cannot contain user info, query params, or a fragment")
+	}
+	if _, err := p.reference(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
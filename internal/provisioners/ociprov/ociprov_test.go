@@ -0,0 +1,159 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ociprov
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/registry"
+
+	"github.com/score-spec/score-k8s/internal/provisioners"
+)
+
+// pushBundle pushes a bundle with the given executable layer into the store and tags it.
+func pushBundle(t *testing.T, store *memory.Store, title string, executable []byte, tag string) ocispec.Descriptor {
+	ctx := context.Background()
+	layer := content.NewDescriptorFromBytes("application/octet-stream", executable)
+	layer.Annotations = map[string]string{ocispec.AnnotationTitle: title}
+	require.NoError(t, store.Push(ctx, layer, bytes.NewReader(executable)))
+	desc, err := oras.PackManifest(ctx, store, oras.PackManifestVersion1_1, "application/vnd.score-k8s.provisioner.v1", oras.PackManifestOptions{
+		Layers: []ocispec.Descriptor{layer},
+	})
+	require.NoError(t, err)
+	require.NoError(t, store.Tag(ctx, desc, tag))
+	return desc
+}
+
+func TestParse(t *testing.T) {
+	_, err := Parse(map[string]interface{}{"uri": "oci://ghcr.io/example/provisioners/postgres:v1", "type": "postgres"})
+	assert.NoError(t, err)
+	_, err = Parse(map[string]interface{}{"uri": "oci://ghcr.io/example/provisioners/postgres#file.yaml", "type": "postgres"})
+	assert.EqualError(t, err, "oci provisioner uri cannot contain user info, query params, or a fragment")
+	_, err = Parse(map[string]interface{}{"uri": "oci://ghcr.io/Example", "type": "postgres"})
+	assert.ErrorContains(t, err, "invalid artifact reference")
+}
+
+func TestProvision(t *testing.T) {
+	store := memory.New()
+	desc := pushBundle(t, store, ExecutableTitle, []byte(`#!/bin/sh
+cat > /dev/null
+echo '{"resource_outputs":{"mode":"'$1'"}}'
+`), "v1")
+	fetches := 0
+	newTarget := func(ref registry.Reference) (oras.ReadOnlyTarget, error) {
+		fetches++
+		return store, nil
+	}
+	cacheDir := t.TempDir()
+
+	p, err := Parse(map[string]interface{}{"uri": "oci://example.com/provisioners/thing:v1", "type": "thing", "args": []interface{}{"<mode>"}})
+	require.NoError(t, err)
+	p.cacheDir, p.newTarget = cacheDir, newTarget
+	out, err := p.Provision(context.Background(), &provisioners.Input{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"mode": "provision"}, out.ResourceOutputs)
+	out, err = p.Plan(context.Background(), &provisioners.Input{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"mode": "plan"}, out.ResourceOutputs)
+	assert.Equal(t, 2, fetches, "a tag is resolved each time")
+
+	t.Run("a tag is resolved once per run", func(t *testing.T) {
+		fetches = 0
+		ctx := provisioners.WithBinaryCache(context.Background())
+		_, err = p.Provision(ctx, &provisioners.Input{})
+		require.NoError(t, err)
+		_, err = p.Plan(ctx, &provisioners.Input{})
+		require.NoError(t, err)
+		assert.Equal(t, 1, fetches)
+
+		var manifest ocispec.Manifest
+		require.NoError(t, decodeManifest(store, desc, &manifest))
+		require.NoError(t, os.WriteFile(cachePath(cacheDir, manifest.Layers[0].Digest), []byte("#!/bin/sh\nexit 1\n"), 0755))
+		out, err := p.Provision(ctx, &provisioners.Input{})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"mode": "provision"}, out.ResourceOutputs)
+		assert.Equal(t, 2, fetches, "a digest mismatch resolves the tag again")
+	})
+
+	t.Run("pinned digest is served from the cache", func(t *testing.T) {
+		fetches = 0
+		p, err := Parse(map[string]interface{}{"uri": "oci://example.com/provisioners/thing@" + desc.Digest.String(), "type": "thing"})
+		require.NoError(t, err)
+		p.cacheDir, p.newTarget = cacheDir, newTarget
+		_, err = p.Provision(context.Background(), &provisioners.Input{})
+		require.NoError(t, err)
+		assert.Equal(t, 0, fetches)
+	})
+
+	t.Run("corrupted cache is fetched again", func(t *testing.T) {
+		var manifest ocispec.Manifest
+		require.NoError(t, decodeManifest(store, desc, &manifest))
+		path := cachePath(cacheDir, manifest.Layers[0].Digest)
+		require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\nexit 1\n"), 0755))
+		_, err = p.Provision(context.Background(), &provisioners.Input{})
+		require.NoError(t, err)
+	})
+
+	t.Run("pinned digest mismatch", func(t *testing.T) {
+		other := pushBundle(t, store, ExecutableTitle, []byte("#!/bin/sh\n"), "v2")
+		p, err := Parse(map[string]interface{}{"uri": "oci://example.com/provisioners/thing@" + desc.Digest.String(), "type": "thing"})
+		require.NoError(t, err)
+		p.cacheDir = t.TempDir()
+		p.newTarget = func(ref registry.Reference) (oras.ReadOnlyTarget, error) {
+			return resolveTo{store, other}, nil
+		}
+		_, err = p.Provision(context.Background(), &provisioners.Input{})
+		assert.EqualError(t, err, fmt.Sprintf("manifest digest %s does not match the pinned digest %s", other.Digest, desc.Digest))
+	})
+
+	t.Run("missing executable", func(t *testing.T) {
+		pushBundle(t, store, "other", []byte("#!/bin/sh\nexit 0\n"), "v3")
+		p, err := Parse(map[string]interface{}{"uri": "oci://example.com/provisioners/thing:v3", "type": "thing"})
+		require.NoError(t, err)
+		p.cacheDir, p.newTarget = filepath.Join(t.TempDir(), "cache"), newTarget
+		_, err = p.Provision(context.Background(), &provisioners.Input{})
+		assert.ErrorContains(t, err, "bundle has no layer titled 'provisioner'")
+	})
+}
+
+// resolveTo is a target that resolves every reference to the same descriptor.
+type resolveTo struct {
+	*memory.Store
+	desc ocispec.Descriptor
+}
+
+func (r resolveTo) Resolve(ctx context.Context, reference string) (ocispec.Descriptor, error) {
+	return r.desc, nil
+}
+
+func decodeManifest(store *memory.Store, desc ocispec.Descriptor, out *ocispec.Manifest) error {
+	raw, err := content.FetchAll(context.Background(), store, desc)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioners
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// ParamsSchemaProvider is an optional interface implemented by provisioners that declare a JSON Schema for the params
+// of the resources they provision. The params are validated against the schema before the provisioner is invoked.
+type ParamsSchemaProvider interface {
+	ParamsSchema() map[string]interface{}
+}
+
+const paramsSchemaUrl = "params.schema.json"
+
+// CompileParamsSchema compiles the given JSON Schema so that it can be used to validate resource params.
+func CompileParamsSchema(schema map[string]interface{}) (*jsonschema.Schema, error) {
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(paramsSchemaUrl, bytes.NewReader(raw)); err != nil {
+		return nil, fmt.Errorf("failed to load schema: %w", err)
+	}
+	s, err := compiler.Compile(paramsSchemaUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile schema: %w", err)
+	}
+	return s, nil
+}
+
+// ValidateParams validates the resource params against the JSON Schema. The returned error lists each invalid path
+// along with the reason, sorted by path.
+func ValidateParams(schema map[string]interface{}, params map[string]interface{}) error {
+	s, err := CompileParamsSchema(schema)
+	if err != nil {
+		return err
+	}
+	if params == nil {
+		params = make(map[string]interface{})
+	}
+
+	// round trip the params through json so that the validator sees the same types it would for a json document
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to encode params: %w", err)
+	}
+	var intermediate interface{}
+	if err := json.Unmarshal(raw, &intermediate); err != nil {
+		return fmt.Errorf("failed to decode params: %w", err)
+	}
+
+	if err := s.Validate(intermediate); err != nil {
+		var ve *jsonschema.ValidationError
+		if !errors.As(err, &ve) {
+			return err
+		}
+		messages := make([]string, 0)
+		collectValidationMessages(ve, &messages)
+		slices.Sort(messages)
+		return errors.New(strings.Join(messages, "; "))
+	}
+	return nil
+}
+
+func collectValidationMessages(ve *jsonschema.ValidationError, messages *[]string) {
+	if len(ve.Causes) == 0 {
+		location := ve.InstanceLocation
+		if location == "" {
+			location = "/"
+		}
+		*messages = append(*messages, fmt.Sprintf("%s: %s", location, ve.Message))
+		return
+	}
+	for _, cause := range ve.Causes {
+		collectValidationMessages(cause, messages)
+	}
+}
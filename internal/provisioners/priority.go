@@ -0,0 +1,129 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioners
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/score-spec/score-go/framework"
+	score "github.com/score-spec/score-go/types"
+
+	util "github.com/score-spec/score-k8s/internal"
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+// resourcePriority returns the optional integer priority from the annotations of the resource metadata. Resources
+// without the annotation have a priority of 0.
+func resourcePriority(res framework.ScoreResourceState[project.ResourceExtras]) (int, error) {
+	raw, ok := util.FindAnnotation(res.Metadata, util.ResourcePriorityAnnotation)
+	if !ok {
+		return 0, nil
+	}
+	v, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("metadata: annotations: %s: '%s' is not an integer", util.ResourcePriorityAnnotation, raw)
+	}
+	return v, nil
+}
+
+// resourceDependencies returns the resources that each resource refers to through placeholders in its params. The
+// framework does not expose the edges of its topological sort, so rather than detecting placeholders again here, they
+// are read from the framework: sorting fails when a resource refers to a resource that is missing from its workload,
+// so each sibling that a resource refers to is found by sorting the workload without that sibling.
+func resourceDependencies(state *project.State) map[framework.ResourceUid]map[framework.ResourceUid]bool {
+	out := make(map[framework.ResourceUid]map[framework.ResourceUid]bool)
+	for workloadName, workload := range state.Workloads {
+		for resName, res := range workload.Spec.Resources {
+			if len(res.Params) == 0 {
+				continue
+			}
+			// only the params of this resource are kept so that a failure can only come from its placeholders
+			siblings := make(map[string]score.Resource, len(workload.Spec.Resources))
+			for name, sibling := range workload.Spec.Resources {
+				if name != resName {
+					sibling.Params = nil
+				}
+				siblings[name] = sibling
+			}
+			probe := &project.State{Workloads: map[string]framework.ScoreWorkloadState[project.WorkloadExtras]{
+				workloadName: {Spec: score.Workload{Metadata: workload.Spec.Metadata, Resources: siblings}},
+			}}
+			resUid := framework.NewResourceUid(workloadName, resName, res.Type, res.Class, res.Id)
+			for name, sibling := range workload.Spec.Resources {
+				if name == resName {
+					continue
+				}
+				delete(siblings, name)
+				if _, err := probe.GetSortedResourceUids(); err != nil {
+					if out[resUid] == nil {
+						out[resUid] = make(map[framework.ResourceUid]bool)
+					}
+					out[resUid][framework.NewResourceUid(workloadName, name, sibling.Type, sibling.Class, sibling.Id)] = true
+				}
+				sibling.Params = nil
+				siblings[name] = sibling
+			}
+		}
+	}
+	return out
+}
+
+// sortByPriority reorders the topologically sorted resource uids so that, of the resources whose dependencies have
+// all been placed, the one with the highest priority comes next. Dependencies always win over priority: a resource
+// is never placed before a resource it refers to, whatever their priorities. Equal priorities keep the input order.
+func sortByPriority(state *project.State, ordered []framework.ResourceUid) ([]framework.ResourceUid, error) {
+	priorities := make(map[framework.ResourceUid]int, len(ordered))
+	for _, resUid := range ordered {
+		p, err := resourcePriority(state.Resources[resUid])
+		if err != nil {
+			return nil, fmt.Errorf("resource '%s': %w", resUid, err)
+		}
+		priorities[resUid] = p
+	}
+	if !slices.ContainsFunc(slices.Collect(maps.Values(priorities)), func(p int) bool { return p != 0 }) {
+		return ordered, nil
+	}
+
+	deps := resourceDependencies(state)
+	placed := make(map[framework.ResourceUid]bool, len(ordered))
+	remaining := slices.Clone(ordered)
+	out := make([]framework.ResourceUid, 0, len(ordered))
+	for len(remaining) > 0 {
+		next := -1
+		for i, resUid := range remaining {
+			ready := true
+			for dep := range deps[resUid] {
+				if !placed[dep] && slices.Contains(remaining, dep) {
+					ready = false
+					break
+				}
+			}
+			if ready && (next < 0 || priorities[resUid] > priorities[remaining[next]]) {
+				next = i
+			}
+		}
+		if next < 0 {
+			// the input is already topologically sorted so this can only happen if the dependencies disagree with it
+			return ordered, nil
+		}
+		placed[remaining[next]] = true
+		out = append(out, remaining[next])
+		remaining = slices.Delete(remaining, next, next+1)
+	}
+	return out, nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioners
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/Masterminds/sprig/v3"
+)
+
+const (
+	DefaultReadinessCheckInterval = 5 * time.Second
+	DefaultReadinessCheckTimeout  = 5 * time.Minute
+)
+
+// ReadinessCheck is an optional check that the outputs of a provisioner must pass before they are accepted. While the
+// check fails, the provisioner is invoked again with the new resource state after the interval, until the timeout.
+// Exactly one of Template or Command must be set.
+type ReadinessCheck struct {
+	// Template is a Go text/template with access to .Outputs and .State that must evaluate to "true" when ready.
+	Template string `yaml:"template,omitempty"`
+	// Command is a binary and args that is executed with the json outputs on stdin and must exit 0 when ready.
+	Command []string `yaml:"command,omitempty"`
+	// Interval is the duration between attempts, defaults to DefaultReadinessCheckInterval.
+	Interval string `yaml:"interval,omitempty"`
+	// Timeout is the maximum duration to wait for the outputs to be ready, defaults to DefaultReadinessCheckTimeout.
+	Timeout string `yaml:"timeout,omitempty"`
+}
+
+// ReadinessCheckProvider is an optional interface implemented by provisioners that declare a readiness check.
+type ReadinessCheckProvider interface {
+	ReadinessCheck() *ReadinessCheck
+}
+
+// Validate checks that the readiness check is well-formed.
+func (rc *ReadinessCheck) Validate() error {
+	if (rc.Template == "") == (len(rc.Command) == 0) {
+		return fmt.Errorf("exactly one of template or command must be set")
+	}
+	if rc.Template != "" {
+		if _, err := rc.parseTemplate(); err != nil {
+			return fmt.Errorf("template: %w", err)
+		}
+	}
+	if _, _, err := rc.durations(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (rc *ReadinessCheck) parseTemplate() (*template.Template, error) {
+	return template.New("").Funcs(sprig.FuncMap()).Parse(rc.Template)
+}
+
+func (rc *ReadinessCheck) durations() (interval time.Duration, timeout time.Duration, err error) {
+	interval, timeout = DefaultReadinessCheckInterval, DefaultReadinessCheckTimeout
+	if rc.Interval != "" {
+		if interval, err = time.ParseDuration(rc.Interval); err != nil || interval <= 0 {
+			return 0, 0, fmt.Errorf("interval: '%s' is not a positive duration", rc.Interval)
+		}
+	}
+	if rc.Timeout != "" {
+		if timeout, err = time.ParseDuration(rc.Timeout); err != nil || timeout <= 0 {
+			return 0, 0, fmt.Errorf("timeout: '%s' is not a positive duration", rc.Timeout)
+		}
+	}
+	return interval, timeout, nil
+}
+
+// Ready runs the check against the provisioner output.
+func (rc *ReadinessCheck) Ready(ctx context.Context, output *ProvisionOutput) (bool, error) {
+	if rc.Template != "" {
+		tmpl, err := rc.parseTemplate()
+		if err != nil {
+			return false, fmt.Errorf("failed to parse template: %w", err)
+		}
+		buff := new(bytes.Buffer)
+		if err := tmpl.Execute(buff, map[string]interface{}{
+			"Outputs": output.ResourceOutputs,
+			"State":   output.ResourceState,
+		}); err != nil {
+			return false, fmt.Errorf("failed to execute template: %w", err)
+		}
+		return strings.TrimSpace(buff.String()) == "true", nil
+	}
+
+	rawOutputs, err := json.Marshal(output.ResourceOutputs)
+	if err != nil {
+		return false, fmt.Errorf("failed to encode outputs: %w", err)
+	}
+	cmd := exec.CommandContext(ctx, rc.Command[0], rc.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(rawOutputs)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		var ee *exec.ExitError
+		if errors.As(err, &ee) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to execute command: %w", err)
+	}
+	return true, nil
+}
+
+// provisionUntilReady invokes the provisioner and, if it declares a readiness check, invokes it again until the
+// outputs pass the check or the timeout expires.
+func provisionUntilReady(ctx context.Context, provisioner Provisioner, input *Input) (*ProvisionOutput, error) {
+	output, err := provisioner.Provision(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	rcp, ok := provisioner.(ReadinessCheckProvider)
+	if !ok || rcp.ReadinessCheck() == nil {
+		return output, nil
+	}
+	rc := rcp.ReadinessCheck()
+	interval, timeout, err := rc.durations()
+	if err != nil {
+		return nil, fmt.Errorf("readiness check: %w", err)
+	}
+	deadline := time.Now().Add(timeout)
+	for attempt := 1; ; attempt++ {
+		if ready, err := rc.Ready(ctx, output); err != nil {
+			return nil, fmt.Errorf("readiness check: %w", err)
+		} else if ready {
+			return output, nil
+		} else if time.Now().Add(interval).After(deadline) {
+			return nil, fmt.Errorf("readiness check: outputs were not ready after %s", timeout)
+		}
+		slog.Info(fmt.Sprintf("Resource '%s' is not ready yet (attempt %d), retrying in %s", input.ResourceUid, attempt, interval))
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+		retryInput := *input
+		if output.ResourceState != nil {
+			retryInput.ResourceState = output.ResourceState
+		}
+		if output, err = provisioner.Provision(ctx, &retryInput); err != nil {
+			return nil, err
+		}
+	}
+}
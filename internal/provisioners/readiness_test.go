@@ -0,0 +1,101 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package provisioners
+
+import (
+	"context"
+	"testing"
+
+	"github.com/score-spec/score-go/framework"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type readinessProvisioner struct {
+	Provisioner
+	check *ReadinessCheck
+}
+
+func (p *readinessProvisioner) ReadinessCheck() *ReadinessCheck {
+	return p.check
+}
+
+func TestReadinessCheckValidate(t *testing.T) {
+	for _, tc := range []struct {
+		check ReadinessCheck
+		err   string
+	}{
+		{check: ReadinessCheck{Template: `{{ ne .Outputs.host "" }}`, Interval: "1s", Timeout: "1m"}},
+		{check: ReadinessCheck{Command: []string{"true"}}},
+		{check: ReadinessCheck{}, err: "exactly one of template or command must be set"},
+		{check: ReadinessCheck{Template: "x", Command: []string{"true"}}, err: "exactly one of template or command must be set"},
+		{check: ReadinessCheck{Template: "{{ .Outputs"}, err: "template: template: :1: unclosed action"},
+		{check: ReadinessCheck{Template: "true", Interval: "0s"}, err: "interval: '0s' is not a positive duration"},
+		{check: ReadinessCheck{Template: "true", Timeout: "soon"}, err: "timeout: 'soon' is not a positive duration"},
+	} {
+		t.Run(tc.err, func(t *testing.T) {
+			err := tc.check.Validate()
+			if tc.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestProvisionUntilReady(t *testing.T) {
+	resUid := framework.NewResourceUid("w", "r", "t", nil, nil)
+	newProvisioner := func(check *ReadinessCheck, readyAfter int) (*readinessProvisioner, *int) {
+		var calls int
+		return &readinessProvisioner{
+			Provisioner: NewEphemeralProvisioner("blah://", resUid, func(ctx context.Context, input *Input) (*ProvisionOutput, error) {
+				calls++
+				attempt, _ := input.ResourceState["attempt"].(int)
+				host := ""
+				if attempt+1 >= readyAfter {
+					host = "db.internal"
+				}
+				return &ProvisionOutput{
+					ResourceState:   map[string]interface{}{"attempt": attempt + 1},
+					ResourceOutputs: map[string]interface{}{"host": host},
+				}, nil
+			}),
+			check: check,
+		}, &calls
+	}
+
+	t.Run("template", func(t *testing.T) {
+		p, calls := newProvisioner(&ReadinessCheck{Template: `{{ ne .Outputs.host "" }}`, Interval: "1ms"}, 3)
+		out, err := provisionUntilReady(context.Background(), p, &Input{ResourceUid: string(resUid)})
+		require.NoError(t, err)
+		assert.Equal(t, "db.internal", out.ResourceOutputs["host"])
+		assert.Equal(t, 3, *calls)
+	})
+
+	t.Run("command", func(t *testing.T) {
+		p, calls := newProvisioner(&ReadinessCheck{Command: []string{"sh", "-c", "grep -q db.internal"}, Interval: "1ms"}, 2)
+		out, err := provisionUntilReady(context.Background(), p, &Input{ResourceUid: string(resUid)})
+		require.NoError(t, err)
+		assert.Equal(t, "db.internal", out.ResourceOutputs["host"])
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		p, _ := newProvisioner(&ReadinessCheck{Template: `{{ ne .Outputs.host "" }}`, Interval: "10ms", Timeout: "25ms"}, 100)
+		_, err := provisionUntilReady(context.Background(), p, &Input{ResourceUid: string(resUid)})
+		assert.EqualError(t, err, "readiness check: outputs were not ready after 25ms")
+	})
+}
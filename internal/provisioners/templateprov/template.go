@@ -17,6 +17,7 @@ package templateprov
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
 	"strings"
@@ -26,7 +27,6 @@ import (
 	"github.com/mitchellh/mapstructure"
 	"github.com/score-spec/score-go/framework"
 	"gopkg.in/yaml.v3"
-	"k8s.io/apimachinery/pkg/runtime"
 
 	util "github.com/score-spec/score-k8s/internal"
 	"github.com/score-spec/score-k8s/internal/provisioners"
@@ -51,7 +51,26 @@ type Provisioner struct {
 	// OutputsTemplate generates the outputs of the resource, based on the init and current state.
 	OutputsTemplate string `yaml:"outputs,omitempty"`
 
+	// ManifestsTemplate generates a list of Kubernetes manifests to add to the output.
 	ManifestsTemplate string `yaml:"manifests,omitempty"`
+	// ResourcesTemplate generates a map of new resources to add to the source workload. These are provisioned after
+	// this resource.
+	ResourcesTemplate string `yaml:"resources,omitempty"`
+	// SidecarsTemplate generates a list of sidecars to inject into the workloads that reference this resource.
+	SidecarsTemplate string `yaml:"sidecars,omitempty"`
+	// PodLabelsTemplate and PodAnnotationsTemplate generate maps of labels and annotations to add to the pods of the
+	// workloads that reference this resource.
+	PodLabelsTemplate      string `yaml:"podLabels,omitempty"`
+	PodAnnotationsTemplate string `yaml:"podAnnotations,omitempty"`
+	// MessagesTemplate generates a list of messages for the user, each with a message and an optional info or warn
+	// level.
+	MessagesTemplate string `yaml:"messages,omitempty"`
+
+	// ResParamsSchema is an optional JSON Schema that the resource params must match before any templates are evaluated.
+	ResParamsSchema map[string]interface{} `yaml:"paramsSchema,omitempty"`
+	// ResNamespace is an optional namespace for the manifests of this provisioner, it overrides the resource namespace
+	// of the run.
+	ResNamespace string `yaml:"namespace,omitempty"`
 }
 
 func Parse(raw map[string]interface{}) (*Provisioner, error) {
@@ -67,6 +86,16 @@ func Parse(raw map[string]interface{}) (*Provisioner, error) {
 	} else if p.ResType == "" {
 		return nil, fmt.Errorf("type not set")
 	}
+	if p.ResParamsSchema != nil {
+		if _, err := provisioners.CompileParamsSchema(p.ResParamsSchema); err != nil {
+			return nil, fmt.Errorf("paramsSchema: %w", err)
+		}
+	}
+	if p.ResNamespace != "" {
+		if err := provisioners.ValidateNamespace(p.ResNamespace); err != nil {
+			return nil, fmt.Errorf("namespace: %w", err)
+		}
+	}
 	return p, nil
 }
 
@@ -74,6 +103,14 @@ func (p *Provisioner) Uri() string {
 	return p.ProvisionerUri
 }
 
+func (p *Provisioner) ParamsSchema() map[string]interface{} {
+	return p.ResParamsSchema
+}
+
+func (p *Provisioner) Namespace() string {
+	return p.ResNamespace
+}
+
 func (p *Provisioner) Match(resUid framework.ResourceUid) bool {
 	if resUid.Type() != p.ResType {
 		return false
@@ -92,7 +129,7 @@ func renderTemplateAndDecode(raw string, data interface{}, out interface{}) erro
 	}
 	prepared, err := template.New("").
 		Funcs(sprig.FuncMap()).
-		Funcs(template.FuncMap{"encodeSecretRef": util.EncodeSecretReference}).
+		Funcs(template.FuncMap{"encodeSecretRef": util.EncodeSecretReference, "encodeConfigMapRef": util.EncodeConfigMapReference}).
 		Parse(raw)
 	if err != nil {
 		return fmt.Errorf("failed to parse template: %w", err)
@@ -107,7 +144,7 @@ func renderTemplateAndDecode(raw string, data interface{}, out interface{}) erro
 	}
 	var intermediate interface{}
 	if err := yaml.Unmarshal([]byte(buffContents), &intermediate); err != nil {
-		slog.Debug(fmt.Sprintf("template output was '%s' from template '%s'", buffContents, raw))
+		slog.Debug(fmt.Sprintf("template output was '%s' from template '%s'", provisioners.RedactText(buffContents), raw))
 		return fmt.Errorf("failed to decode output: %w", err)
 	}
 	err = mapstructure.Decode(intermediate, &out)
@@ -140,6 +177,16 @@ type Data struct {
 
 	SourceWorkload   string
 	WorkloadServices map[string]provisioners.NetworkService
+
+	// Namespace is the namespace of the resource manifests and WorkloadNamespace is the namespace of the workloads.
+	Namespace         string
+	WorkloadNamespace string
+}
+
+// ServiceHost returns the hostname that the workloads use to reach the named service in the resource manifests. This
+// is qualified with the namespace when the resource and the workloads are in different namespaces.
+func (d *Data) ServiceHost(service string) string {
+	return provisioners.ServiceHost(service, d.Namespace, d.WorkloadNamespace)
 }
 
 func (p *Provisioner) Provision(ctx context.Context, input *provisioners.Input) (*provisioners.ProvisionOutput, error) {
@@ -147,17 +194,19 @@ func (p *Provisioner) Provision(ctx context.Context, input *provisioners.Input)
 
 	// The data payload that gets passed into each template
 	data := Data{
-		Guid:             input.ResourceGuid,
-		Uid:              input.ResourceUid,
-		Type:             input.ResourceType,
-		Class:            input.ResourceClass,
-		Id:               input.ResourceId,
-		Params:           input.ResourceParams,
-		Metadata:         input.ResourceMetadata,
-		State:            input.ResourceState,
-		Shared:           input.SharedState,
-		SourceWorkload:   input.SourceWorkload,
-		WorkloadServices: input.WorkloadServices,
+		Guid:              input.ResourceGuid,
+		Uid:               input.ResourceUid,
+		Type:              input.ResourceType,
+		Class:             input.ResourceClass,
+		Id:                input.ResourceId,
+		Params:            input.ResourceParams,
+		Metadata:          input.ResourceMetadata,
+		State:             input.ResourceState,
+		Shared:            input.SharedState,
+		SourceWorkload:    input.SourceWorkload,
+		WorkloadServices:  input.WorkloadServices,
+		Namespace:         input.Namespace,
+		WorkloadNamespace: input.WorkloadNamespace,
 	}
 
 	init := make(map[string]interface{})
@@ -188,15 +237,48 @@ func (p *Provisioner) Provision(ctx context.Context, input *provisioners.Input)
 		return nil, fmt.Errorf("manifests template failed: %w", err)
 	}
 
+	rawResources := make(map[string]interface{})
+	if err := renderTemplateAndDecode(p.ResourcesTemplate, &data, &rawResources); err != nil {
+		return nil, fmt.Errorf("resources template failed: %w", err)
+	}
+	if len(rawResources) > 0 {
+		raw, _ := json.Marshal(rawResources)
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&out.Resources); err != nil {
+			return nil, fmt.Errorf("resources template failed: failed to decode resources: %w", err)
+		}
+	}
+
+	if err := renderTemplateAndDecode(p.SidecarsTemplate, &data, &out.Sidecars); err != nil {
+		return nil, fmt.Errorf("sidecars template failed: %w", err)
+	}
+	if err := renderTemplateAndDecode(p.PodLabelsTemplate, &data, &out.PodLabels); err != nil {
+		return nil, fmt.Errorf("podLabels template failed: %w", err)
+	}
+	if err := renderTemplateAndDecode(p.PodAnnotationsTemplate, &data, &out.PodAnnotations); err != nil {
+		return nil, fmt.Errorf("podAnnotations template failed: %w", err)
+	}
+	if err := renderTemplateAndDecode(p.MessagesTemplate, &data, &out.Messages); err != nil {
+		return nil, fmt.Errorf("messages template failed: %w", err)
+	}
+
 	// validate the manifests
 	for i, manifest := range out.Manifests {
-		raw, _ := yaml.Marshal(manifest)
-		if _, _, err := util.YamlSerializerInfo.StrictSerializer.Decode(raw, nil, nil); err != nil && !runtime.IsNotRegisteredError(err) {
-			return nil, fmt.Errorf("manifests.%d: matched a known kind but was not valid: %w", i, err)
+		if _, err := util.NormalizeManifest(manifest); err != nil {
+			return nil, fmt.Errorf("manifests.%d: %w", i, err)
 		}
 	}
 
 	return out, nil
 }
 
+// Plan is the same as Provision since template provisioners have no side effects outside of their outputs.
+func (p *Provisioner) Plan(ctx context.Context, input *provisioners.Input) (*provisioners.ProvisionOutput, error) {
+	return p.Provision(ctx, input)
+}
+
 var _ provisioners.Provisioner = (*Provisioner)(nil)
+var _ provisioners.Planner = (*Provisioner)(nil)
+var _ provisioners.ParamsSchemaProvider = (*Provisioner)(nil)
+var _ provisioners.NamespaceProvider = (*Provisioner)(nil)
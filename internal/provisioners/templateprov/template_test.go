@@ -19,6 +19,7 @@ import (
 	"testing"
 
 	"github.com/score-spec/score-go/framework"
+	scoretypes "github.com/score-spec/score-go/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -75,3 +76,131 @@ c: {{ .Shared.c }}
 	assert.Equal(t, map[string]interface{}{"b": "STUFF", "c": 1}, out.ResourceOutputs)
 	assert.Len(t, out.Manifests, 1)
 }
+
+func TestParseParamsSchema(t *testing.T) {
+	p, err := Parse(map[string]interface{}{
+		"uri":          "template://example",
+		"type":         "thing",
+		"paramsSchema": map[string]interface{}{"type": "object", "required": []interface{}{"host"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"type": "object", "required": []interface{}{"host"}}, p.ParamsSchema())
+
+	_, err = Parse(map[string]interface{}{
+		"uri":          "template://example",
+		"type":         "thing",
+		"paramsSchema": map[string]interface{}{"type": "unknown"},
+	})
+	assert.ErrorContains(t, err, "paramsSchema: failed to compile schema: ")
+}
+
+func TestProvisionNamespace(t *testing.T) {
+	p, err := Parse(map[string]interface{}{
+		"uri":       "template://example",
+		"type":      "postgres",
+		"namespace": "data",
+		"outputs":   "host: {{ .ServiceHost \"pg\" }}\nnamespace: {{ .Namespace }}\nworkloadNamespace: {{ .WorkloadNamespace }}",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "data", p.Namespace())
+
+	out, err := p.Provision(context.Background(), &provisioners.Input{Namespace: "data", WorkloadNamespace: "apps"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"host": "pg.data.svc", "namespace": "data", "workloadNamespace": "apps"}, out.ResourceOutputs)
+
+	out, err = p.Provision(context.Background(), &provisioners.Input{Namespace: "apps", WorkloadNamespace: "apps"})
+	require.NoError(t, err)
+	assert.Equal(t, "pg", out.ResourceOutputs["host"])
+
+	_, err = Parse(map[string]interface{}{
+		"uri":       "template://example",
+		"type":      "postgres",
+		"namespace": "Data",
+	})
+	assert.ErrorContains(t, err, "namespace: 'Data' is invalid: a lowercase RFC 1123 label must consist of")
+}
+
+func TestProvisionSpawnedResources(t *testing.T) {
+	p, err := Parse(map[string]interface{}{
+		"uri":  "template://example",
+		"type": "thing",
+		"resources": `
+helper:
+  type: other
+  params:
+    source: {{ .Id }}
+`,
+	})
+	require.NoError(t, err)
+	out, err := p.Provision(context.Background(), &provisioners.Input{ResourceId: "w.r"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]scoretypes.Resource{
+		"helper": {Type: "other", Params: map[string]interface{}{"source": "w.r"}},
+	}, out.Resources)
+}
+
+func TestProvisionSidecars(t *testing.T) {
+	p, err := Parse(map[string]interface{}{
+		"uri":  "template://example",
+		"type": "tracing",
+		"sidecars": `
+- container:
+    name: otel
+    image: otel/opentelemetry-collector
+    env:
+    - name: SOURCE
+      value: {{ .Id }}
+`,
+	})
+	require.NoError(t, err)
+	out, err := p.Provision(context.Background(), &provisioners.Input{ResourceId: "w.r"})
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]interface{}{{
+		"container": map[string]interface{}{
+			"name":  "otel",
+			"image": "otel/opentelemetry-collector",
+			"env":   []interface{}{map[string]interface{}{"name": "SOURCE", "value": "w.r"}},
+		},
+	}}, out.Sidecars)
+}
+
+func TestProvisionPodMetadata(t *testing.T) {
+	p, err := Parse(map[string]interface{}{
+		"uri":  "template://example",
+		"type": "mesh",
+		"podLabels": `
+sidecar.istio.io/inject: "true"
+`,
+		"podAnnotations": `
+proxy.istio.io/config: '{"holdApplicationUntilProxyStarts": true}'
+mesh/source: {{ .Id }}
+`,
+	})
+	require.NoError(t, err)
+	out, err := p.Provision(context.Background(), &provisioners.Input{ResourceId: "w.r"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"sidecar.istio.io/inject": "true"}, out.PodLabels)
+	assert.Equal(t, map[string]string{
+		"proxy.istio.io/config": `{"holdApplicationUntilProxyStarts": true}`,
+		"mesh/source":           "w.r",
+	}, out.PodAnnotations)
+}
+
+func TestProvisionMessages(t *testing.T) {
+	p, err := Parse(map[string]interface{}{
+		"uri":  "template://example",
+		"type": "postgres",
+		"messages": `
+- message: using a dev-grade postgres for {{ .Id }}
+  level: warn
+- message: done
+`,
+	})
+	require.NoError(t, err)
+	out, err := p.Provision(context.Background(), &provisioners.Input{ResourceId: "w.r"})
+	require.NoError(t, err)
+	assert.Equal(t, []provisioners.ProvisionMessage{
+		{Level: "warn", Message: "using a dev-grade postgres for w.r"},
+		{Message: "done"},
+	}, out.Messages)
+}
@@ -20,11 +20,15 @@ import (
 	"strings"
 
 	"github.com/pkg/errors"
+	coreV1 "k8s.io/api/core/v1"
 )
 
 const (
 	magicPrefix = "🔐💬"
 	magicSuffix = "💬🔐"
+
+	// configMapRefKind is the optional third part of an encoded reference that marks it as a ConfigMap key reference.
+	configMapRefKind = "cm"
 )
 
 func EncodeSecretReference(secret, key string) string {
@@ -33,9 +37,33 @@ func EncodeSecretReference(secret, key string) string {
 		magicSuffix
 }
 
+// EncodeConfigMapReference is the same as EncodeSecretReference but refers to a key of a ConfigMap. Env vars that
+// contain the reference use a configMapKeyRef rather than a secretKeyRef.
+func EncodeConfigMapReference(configMap, key string) string {
+	return magicPrefix + base64.RawURLEncoding.EncodeToString([]byte(configMap)) +
+		"." + base64.RawURLEncoding.EncodeToString([]byte(key)) +
+		"." + configMapRefKind + magicSuffix
+}
+
 type SecretRef struct {
 	Name string
 	Key  string
+	// ConfigMap is true when the reference is to a key of a ConfigMap rather than a Secret.
+	ConfigMap bool
+}
+
+// EnvVarSource returns the secretKeyRef or configMapKeyRef source for an env var that reads the referenced key.
+func (r SecretRef) EnvVarSource() *coreV1.EnvVarSource {
+	if r.ConfigMap {
+		return &coreV1.EnvVarSource{ConfigMapKeyRef: &coreV1.ConfigMapKeySelector{
+			LocalObjectReference: coreV1.LocalObjectReference{Name: r.Name},
+			Key:                  r.Key,
+		}}
+	}
+	return &coreV1.EnvVarSource{SecretKeyRef: &coreV1.SecretKeySelector{
+		LocalObjectReference: coreV1.LocalObjectReference{Name: r.Name},
+		Key:                  r.Key,
+	}}
 }
 
 // DecodeSecretReferences resolves a string in a kubernetes manifest that may contain secret references into a
@@ -52,10 +80,12 @@ func DecodeSecretReferences(source string) ([]string, []SecretRef, error) {
 			r := part[:si]
 			parts[1+i] = part[si+len(magicSuffix):]
 			bits := strings.Split(r, ".")
-			if len(bits) != 2 {
+			out := SecretRef{}
+			if len(bits) == 3 && bits[2] == configMapRefKind {
+				out.ConfigMap = true
+			} else if len(bits) != 2 {
 				return nil, nil, errors.Errorf("invalid secret ref: more than 2 parts")
 			}
-			out := SecretRef{}
 			if r, err := base64.RawURLEncoding.DecodeString(bits[0]); err != nil {
 				return nil, nil, errors.Errorf("invalid secret ref: failed to decode parts.0")
 			} else {
@@ -34,3 +34,15 @@ func TestDecodeSecretReferences_nominal(t *testing.T) {
 		{Name: "s3", Key: "k3"},
 	}, refs)
 }
+
+func TestDecodeSecretReferences_configMap(t *testing.T) {
+	splits, refs, err := DecodeSecretReferences(
+		"a" + EncodeConfigMapReference("c1", "k1") + "b" + EncodeSecretReference("c1", "k1"),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", ""}, splits)
+	assert.Equal(t, []SecretRef{
+		{Name: "c1", Key: "k1", ConfigMap: true},
+		{Name: "c1", Key: "k1"},
+	}, refs)
+}
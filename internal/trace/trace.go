@@ -0,0 +1,111 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package trace records the timeline of the phases of a run, such as loading, provisioning each resource, converting
+// each workload, and writing the output, so that slow runs can be explained.
+package trace
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Span is a timed phase of a run.
+type Span struct {
+	Name string
+	// Depth is the number of spans that enclose this one.
+	Depth int
+	Start time.Time
+	// Duration is negative if the span never ended, for example because the run failed during it.
+	Duration time.Duration
+}
+
+// Recorder records the spans of a run. The zero value is ready to use.
+type Recorder struct {
+	// Now returns the current time, it defaults to time.Now.
+	Now func() time.Time
+
+	mu    sync.Mutex
+	spans []Span
+}
+
+type recorderKey struct{}
+
+type depthKey struct{}
+
+// WithRecorder returns a context in which the spans started by Start are recorded in the recorder.
+func WithRecorder(ctx context.Context, recorder *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, recorder)
+}
+
+// Start begins a span with the given name if the context has a Recorder. It returns a context for starting nested
+// spans and a function that ends the span. When there is no Recorder, these do nothing so that phases can be
+// instrumented unconditionally.
+func Start(ctx context.Context, name string) (context.Context, func()) {
+	r, ok := ctx.Value(recorderKey{}).(*Recorder)
+	if !ok {
+		return ctx, func() {}
+	}
+	depth, _ := ctx.Value(depthKey{}).(int)
+	r.mu.Lock()
+	index := len(r.spans)
+	r.spans = append(r.spans, Span{Name: name, Depth: depth, Start: r.now(), Duration: -1})
+	r.mu.Unlock()
+	var once sync.Once
+	return context.WithValue(ctx, depthKey{}, depth+1), func() {
+		once.Do(func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.spans[index].Duration = r.now().Sub(r.spans[index].Start)
+		})
+	}
+}
+
+func (r *Recorder) now() time.Time {
+	if r.Now != nil {
+		return r.Now()
+	}
+	return time.Now()
+}
+
+// Spans returns a copy of the recorded spans in the order they started.
+func (r *Recorder) Spans() []Span {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Span, len(r.spans))
+	copy(out, r.spans)
+	return out
+}
+
+// WriteTimeline writes one line per span in the order they started, with the offset from the start of the first span,
+// the duration, and the name indented by the depth. Spans that never ended have a duration of "-".
+func (r *Recorder) WriteTimeline(w io.Writer) error {
+	spans := r.Spans()
+	b := new(strings.Builder)
+	_, _ = fmt.Fprintf(b, "%12s %12s  %s\n", "START", "DURATION", "PHASE")
+	for _, s := range spans {
+		duration := "-"
+		if s.Duration >= 0 {
+			duration = s.Duration.Round(time.Microsecond).String()
+		}
+		offset := s.Start.Sub(spans[0].Start).Round(time.Microsecond)
+		_, _ = fmt.Fprintf(b, "%12s %12s  %s%s\n", offset, duration, strings.Repeat("  ", s.Depth), s.Name)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trace
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartWithoutRecorder(t *testing.T) {
+	ctx, end := Start(context.Background(), "nothing")
+	end()
+	assert.Equal(t, context.Background(), ctx)
+}
+
+func TestRecorder(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	recorder := &Recorder{Now: func() time.Time {
+		now = now.Add(1500 * time.Microsecond)
+		return now
+	}}
+	ctx := WithRecorder(context.Background(), recorder)
+
+	_, endLoad := Start(ctx, "load")
+	endLoad()
+	provisionCtx, endProvision := Start(ctx, "provision")
+	_, endResource := Start(provisionCtx, "provision postgres.default#w.db")
+	endResource()
+	endResource()
+	_, _ = Start(provisionCtx, "provision redis.default#w.cache")
+	endProvision()
+
+	spans := recorder.Spans()
+	require.Len(t, spans, 4)
+	assert.Equal(t, Span{Name: "load", Depth: 0, Start: spans[0].Start, Duration: 1500 * time.Microsecond}, spans[0])
+	assert.Equal(t, 1, spans[2].Depth)
+	assert.Equal(t, 1500*time.Microsecond, spans[2].Duration)
+	assert.Equal(t, time.Duration(-1), spans[3].Duration)
+
+	buff := new(bytes.Buffer)
+	require.NoError(t, recorder.WriteTimeline(buff))
+	assert.Equal(t, `       START     DURATION  PHASE
+          0s        1.5ms  load
+         3ms          6ms  provision
+       4.5ms        1.5ms    provision postgres.default#w.db
+       7.5ms            -    provision redis.default#w.cache
+`, buff.String())
+}
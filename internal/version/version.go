@@ -22,37 +22,62 @@ import (
 )
 
 var (
-	Version             string = "0.0.0"
+	Version string = "0.0.0"
+	// GitCommit and BuildDate are optionally injected at build time, otherwise they are read from the vcs build info.
+	GitCommit           string = ""
+	BuildDate           string = ""
 	semverPattern              = regexp.MustCompile(`^(?:v?)(\d+)(?:\.(\d+))?(?:\.(\d+))?$`)
 	constraintAndSemver        = regexp.MustCompile("^(>|>=|=)?" + semverPattern.String()[1:])
 )
 
-// BuildVersionString constructs a version string by looking at the build metadata injected at build time.
-// This is particularly useful when score-k8s is installed from the go module using go install.
-func BuildVersionString() string {
-	versionNumber, buildTime, gitSha, isDirtySuffix := Version, "local", "unknown", ""
+// BuildInfo is the version and build metadata of the current binary.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"gitCommit"`
+	BuildDate string `json:"buildDate"`
+	Dirty     bool   `json:"dirty"`
+}
+
+// GetBuildInfo returns the build metadata injected at build time, falling back to the metadata that go embeds in the
+// binary. This is particularly useful when score-k8s is installed from the go module using go install.
+func GetBuildInfo() BuildInfo {
+	out := BuildInfo{Version: Version, GitCommit: GitCommit, BuildDate: BuildDate}
 	if info, ok := debug.ReadBuildInfo(); ok {
 		if info.Main.Version != "" && info.Main.Version != "(devel)" {
-			versionNumber = info.Main.Version
+			out.Version = info.Main.Version
 		}
 		for _, setting := range info.Settings {
 			switch setting.Key {
 			case "vcs.time":
-				if setting.Value != "" {
-					buildTime = setting.Value
+				if out.BuildDate == "" {
+					out.BuildDate = setting.Value
 				}
 			case "vcs.revision":
-				if setting.Value != "" {
-					gitSha = setting.Value
+				if out.GitCommit == "" {
+					out.GitCommit = setting.Value
 				}
 			case "vcs.modified":
-				if setting.Value == "true" {
-					isDirtySuffix = "-dirty"
-				}
+				out.Dirty = setting.Value == "true"
 			}
 		}
 	}
-	return fmt.Sprintf("%s (build: %s, sha: %s%s)", versionNumber, buildTime, gitSha, isDirtySuffix)
+	if out.BuildDate == "" {
+		out.BuildDate = "local"
+	}
+	if out.GitCommit == "" {
+		out.GitCommit = "unknown"
+	}
+	return out
+}
+
+// BuildVersionString constructs a version string by looking at the build metadata injected at build time.
+func BuildVersionString() string {
+	info := GetBuildInfo()
+	isDirtySuffix := ""
+	if info.Dirty {
+		isDirtySuffix = "-dirty"
+	}
+	return fmt.Sprintf("%s (build: %s, sha: %s%s)", info.Version, info.BuildDate, info.GitCommit, isDirtySuffix)
 }
 
 func semverToI(x string) (int, error) {
@@ -0,0 +1,62 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+)
+
+// parseCapabilitiesFile reads the list of apiVersions available in the target cluster. The format matches the output
+// of "kubectl api-versions": one group/version per line. Blank lines and lines starting with # are ignored.
+func parseCapabilitiesFile(entry string, flagName string) ([]string, error) {
+	raw, err := os.ReadFile(entry)
+	if err != nil {
+		return nil, fmt.Errorf("--%s '%s' is invalid, failed to read file: %w", flagName, entry, err)
+	}
+	out := make([]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for line := 1; scanner.Scan(); line++ {
+		apiVersion := strings.TrimSpace(scanner.Text())
+		if apiVersion == "" || strings.HasPrefix(apiVersion, "#") {
+			continue
+		}
+		if strings.ContainsAny(apiVersion, " \t") || strings.Count(apiVersion, "/") > 1 || strings.HasPrefix(apiVersion, "/") || strings.HasSuffix(apiVersion, "/") {
+			return nil, fmt.Errorf("--%s '%s' is invalid, line %d: '%s' is not a valid apiVersion", flagName, entry, line, apiVersion)
+		}
+		out = append(out, apiVersion)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("--%s '%s' is invalid, no apiVersions listed", flagName, entry)
+	}
+	return out, nil
+}
+
+// filterByCapabilities removes the manifests whose apiVersion is not available in the target cluster and logs a
+// warning for each one, rather than emitting a manifest that would fail to apply.
+func filterByCapabilities(manifests []Manifest, capabilities []string) []Manifest {
+	return slices.DeleteFunc(manifests, func(m Manifest) bool {
+		if slices.Contains(capabilities, m.APIVersion) {
+			return false
+		}
+		slog.Warn(fmt.Sprintf("Skipping %s '%s': apiVersion '%s' is not listed in --%s", m.Kind, m.Name, m.APIVersion, capabilitiesFileFlag))
+		return true
+	})
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/score-spec/score-k8s/internal/project"
+	"github.com/score-spec/score-k8s/internal/provisioners"
+	_default "github.com/score-spec/score-k8s/internal/provisioners/default"
+	"github.com/score-spec/score-k8s/internal/provisioners/loader"
+)
+
+// ConvertOptions are the inputs to a stateless conversion. Each field corresponds to a flag of the "score-k8s convert"
+// command.
+type ConvertOptions struct {
+	// ScoreFile is the Score file to convert. It may contain multiple workload documents.
+	ScoreFile string
+	// Image is an optional container image to use for any container with image == '.'.
+	Image string
+	// SkipProvisioning leaves any resource placeholders unresolved instead of provisioning the resources with the
+	// default provisioners.
+	SkipProvisioning bool
+}
+
+// Convert converts a single Score file into a set of Kubernetes manifests without a state directory. The workloads are
+// added to an empty in-memory state and provisioned with the default provisioners only. Nothing is persisted, so any
+// generated values such as passwords will be different on each call.
+func Convert(ctx context.Context, opts ConvertOptions) ([]Manifest, error) {
+	state := newInMemoryState()
+
+	rawWorkloads, err := readScoreFile(opts.ScoreFile)
+	if err != nil {
+		return nil, err
+	} else if len(rawWorkloads) == 0 {
+		return nil, errors.Errorf("score file is empty: %s", opts.ScoreFile)
+	}
+	for _, rawWorkload := range rawWorkloads {
+		if state, err = addScoreWorkload(state, opts.ScoreFile, rawWorkload, Options{Image: opts.Image}); err != nil {
+			return nil, err
+		}
+	}
+
+	if state, err = state.WithPrimedResources(); err != nil {
+		return nil, errors.Wrap(err, "failed to prime resources")
+	}
+
+	var defaultProvisioners []provisioners.Provisioner
+	if opts.SkipProvisioning {
+		defaultProvisioners = buildPlaceholderProvisioners(state)
+	} else if defaultProvisioners, err = loader.LoadProvisioners([]byte(_default.DefaultProvisioners)); err != nil {
+		return nil, errors.Wrap(err, "failed to load default provisioners")
+	}
+
+	state, err = provisioners.ProvisionResources(ctx, state, defaultProvisioners)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to provision resources")
+	}
+
+	return buildManifests(ctx, state, Options{}, nil)
+}
+
+// buildPlaceholderProvisioners returns a provisioner for each resource in the state that returns its outputs as the
+// original ${resources.<name>.<key>} placeholder so that the workloads can be converted without provisioning.
+func buildPlaceholderProvisioners(state *project.State) []provisioners.Provisioner {
+	out := make([]provisioners.Provisioner, 0, len(state.Resources))
+	for resUid, res := range state.Resources {
+		resName := strings.TrimPrefix(resUid.Id(), res.SourceWorkload+".")
+		out = append(out, provisioners.NewEphemeralProvisioner("placeholder://"+resName, resUid, func(ctx context.Context, input *provisioners.Input) (*provisioners.ProvisionOutput, error) {
+			slog.Debug(fmt.Sprintf("Skipping provisioning of resource '%s'", resUid))
+			return &provisioners.ProvisionOutput{
+				OutputLookupFunc: func(keys ...string) (interface{}, error) {
+					return fmt.Sprintf("${resources.%s}", strings.Join(append([]string{resName}, keys...), ".")), nil
+				},
+			}, nil
+		}))
+	}
+	return out
+}
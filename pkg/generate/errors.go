@@ -0,0 +1,137 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/score-spec/score-k8s/internal/provisioners"
+)
+
+// ValidationError is returned when a Score file or workload is invalid, or when a workload cannot be converted into
+// manifests because of invalid configuration such as an annotation. The error message is unchanged from the
+// underlying error.
+type ValidationError struct {
+	// Workload is the name of the workload, this is empty if the workload name is not known yet.
+	Workload string
+	// Path is the Score file that contains the workload, this is empty if the workload was already in the state.
+	Path string
+	Err  error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ProvisionError is returned when a resource cannot be provisioned. It carries the uid of the resource and the uri of
+// the matching provisioner, if any.
+type ProvisionError = provisioners.ProvisionError
+
+// UnmatchedResourcesError is returned before provisioning when one or more resources are not matched by any
+// provisioner. It lists every unmatched resource as a *ProvisionError.
+type UnmatchedResourcesError = provisioners.UnmatchedResourcesError
+
+// UnresolvedRefError is returned when an output manifest still contains a secret reference that was not resolved by
+// the converter, for example because a secret output was used somewhere it is not supported.
+type UnresolvedRefError struct {
+	// Workload is the name of the workload that produced the manifest, if any.
+	Workload string
+	// Resource is the uid of the resource that produced the manifest, if any.
+	Resource string
+	// Path is the path of the unresolved reference within the manifest.
+	Path string
+}
+
+func (e *UnresolvedRefError) Error() string {
+	return fmt.Sprintf("unresolved secret ref in manifest: %s", e.Path)
+}
+
+// ConversionErrors is returned when Options.KeepGoing is set and one or more workloads could not be converted. Each
+// item is a *ValidationError for one workload, in workload name order.
+type ConversionErrors []error
+
+func (e ConversionErrors) Error() string {
+	lines := make([]string, 0, len(e)+1)
+	lines = append(lines, fmt.Sprintf("%d workloads failed to convert:", len(e)))
+	for _, err := range e {
+		lines = append(lines, "- "+err.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (e ConversionErrors) Unwrap() []error {
+	return e
+}
+
+// MissingProbesError is returned when Options.RequireProbes is set and one or more workloads have containers without
+// a readinessProbe. It maps each workload name to the names of those containers.
+type MissingProbesError map[string][]string
+
+func (e MissingProbesError) Error() string {
+	lines := make([]string, 0, len(e)+1)
+	lines = append(lines, fmt.Sprintf("--%s: %d workloads have containers without a readinessProbe:", requireProbesFlag, len(e)))
+	for _, workload := range slices.Sorted(maps.Keys(e)) {
+		containers := slices.Sorted(slices.Values(e[workload]))
+		lines = append(lines, fmt.Sprintf("- workload '%s' (containers: %s)", workload, strings.Join(containers, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// FloatingImagesError is returned when Options.StrictImages is set and one or more workloads have containers with an
+// image without a tag or with the 'latest' tag. It maps the workload name to the <container>=<image> entries.
+type FloatingImagesError map[string][]string
+
+func (e FloatingImagesError) Error() string {
+	lines := make([]string, 0, len(e)+1)
+	lines = append(lines, fmt.Sprintf("--%s: %d workloads have containers with a latest or missing image tag:", strictImagesFlag, len(e)))
+	for _, workload := range slices.Sorted(maps.Keys(e)) {
+		containers := slices.Sorted(slices.Values(e[workload]))
+		lines = append(lines, fmt.Sprintf("- workload '%s' (containers: %s)", workload, strings.Join(containers, ", ")))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ManifestSizeError is returned when Options.MaxManifestSize is set and an output manifest is larger than the limit.
+// This usually means that a large blob was inlined into a Secret or ConfigMap by mistake.
+type ManifestSizeError struct {
+	Kind string
+	Name string
+	// Workload is the name of the workload that produced the manifest, if any.
+	Workload string
+	// Resource and Provisioner are the uid of the resource that produced the manifest and the uri of its provisioner, if
+	// any.
+	Resource    string
+	Provisioner string
+	// Size and Limit are the yaml encoded size of the manifest and the maximum size in bytes.
+	Size  int64
+	Limit int64
+}
+
+func (e *ManifestSizeError) Error() string {
+	source := ""
+	if e.Resource != "" {
+		source = fmt.Sprintf(" from resource '%s' (provisioner '%s')", e.Resource, e.Provisioner)
+	} else if e.Workload != "" {
+		source = fmt.Sprintf(" from workload '%s'", e.Workload)
+	}
+	return fmt.Sprintf("%s '%s'%s is %d bytes which exceeds the --%s of %d bytes", e.Kind, e.Name, source, e.Size, maxManifestSizeFlag, e.Limit)
+}
@@ -0,0 +1,131 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// scoreKeyOrder is the order of the top level keys of a formatted Score file. Any other keys follow in alphabetical
+// order, as do the keys of every nested map.
+var scoreKeyOrder = []string{"apiVersion", "metadata", "service", "containers", "resources"}
+
+// FormatScoreFile validates the Score file and returns its content in canonical form: each workload document is
+// re-emitted in block style with a 2-space indent and stable key ordering, while comments are kept. Empty documents are dropped. The
+// formatted content always decodes to the same workloads as the original, otherwise an error is returned.
+func FormatScoreFile(path string) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read input score file: %s", path)
+	}
+	documents, err := decodeScoreDocuments(raw)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode input score file: %s", path)
+	} else if len(documents) == 0 {
+		return nil, errors.Errorf("score file is empty: %s", path)
+	}
+
+	out := new(bytes.Buffer)
+	enc := yaml.NewEncoder(out)
+	enc.SetIndent(2)
+	for _, document := range documents {
+		var rawWorkload map[string]interface{}
+		if err := document.Decode(&rawWorkload); err != nil {
+			return nil, errors.Wrapf(err, "failed to decode input score file: %s", path)
+		} else if _, err := validateScoreWorkload(path, rawWorkload, false); err != nil {
+			return nil, err
+		}
+		sortMappingKeys(document.Content[0], scoreKeyOrder)
+		if err := enc.Encode(document); err != nil {
+			return nil, errors.Wrapf(err, "failed to encode score file: %s", path)
+		}
+	}
+	_ = enc.Close()
+
+	// formatting must never change the meaning of the file
+	formatted, err := decodeScoreDocuments(out.Bytes())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode formatted score file: %s", path)
+	}
+	for i := range documents {
+		var before, after interface{}
+		_ = documents[i].Decode(&before)
+		_ = formatted[i].Decode(&after)
+		if !reflect.DeepEqual(before, after) {
+			return nil, errors.Errorf("cannot format score file without changing its content, document %d may use yaml anchors: %s", i, path)
+		}
+	}
+	return out.Bytes(), nil
+}
+
+// decodeScoreDocuments decodes each non-empty yaml document, keeping the comments.
+func decodeScoreDocuments(raw []byte) ([]*yaml.Node, error) {
+	out := make([]*yaml.Node, 0, 1)
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	for {
+		var document yaml.Node
+		if err := dec.Decode(&document); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		} else if len(document.Content) > 0 && !(document.Content[0].Kind == yaml.ScalarNode && document.Content[0].Tag == "!!null") {
+			out = append(out, &document)
+		}
+	}
+	return out, nil
+}
+
+// sortMappingKeys sorts the keys of the mapping node and any nested mapping nodes, and switches flow style maps and
+// lists to block style. The given order applies to the keys of this node only and any keys not in it follow in
+// alphabetical order.
+func sortMappingKeys(node *yaml.Node, order []string) {
+	if node.Kind == yaml.MappingNode || node.Kind == yaml.SequenceNode {
+		node.Style &^= yaml.FlowStyle
+	}
+	if node.Kind == yaml.MappingNode {
+		pairs := make([][2]*yaml.Node, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			pairs = append(pairs, [2]*yaml.Node{node.Content[i], node.Content[i+1]})
+		}
+		rank := func(key string) int {
+			if i := slices.Index(order, key); i >= 0 {
+				return i
+			}
+			return len(order)
+		}
+		slices.SortStableFunc(pairs, func(a, b [2]*yaml.Node) int {
+			if ra, rb := rank(a[0].Value), rank(b[0].Value); ra != rb {
+				return ra - rb
+			}
+			return strings.Compare(a[0].Value, b[0].Value)
+		})
+		node.Content = node.Content[:0]
+		for _, pair := range pairs {
+			node.Content = append(node.Content, pair[0], pair[1])
+		}
+	}
+	for _, child := range node.Content {
+		sortMappingKeys(child, nil)
+	}
+}
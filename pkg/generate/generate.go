@@ -0,0 +1,1115 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package generate exposes the score-k8s generate pipeline as a library. It loads the project state, adds or updates
+// the given Score files, provisions resources, and converts the workloads into a set of Kubernetes manifests.
+package generate
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/imdario/mergo"
+	"github.com/pkg/errors"
+	"github.com/score-spec/score-go/framework"
+	scoreloader "github.com/score-spec/score-go/loader"
+	scoreschema "github.com/score-spec/score-go/schema"
+	scoretypes "github.com/score-spec/score-go/types"
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/score-spec/score-k8s/internal"
+	"github.com/score-spec/score-k8s/internal/convert"
+	"github.com/score-spec/score-k8s/internal/project"
+	"github.com/score-spec/score-k8s/internal/provisioners"
+	"github.com/score-spec/score-k8s/internal/provisioners/loader"
+	"github.com/score-spec/score-k8s/internal/trace"
+	"github.com/score-spec/score-k8s/internal/version"
+)
+
+// The names of the generate command flags. These are used in error messages so that failures can be traced back to
+// the input that caused them.
+const (
+	overridesFileFlag       = "overrides-file"
+	overridePropertyFlag    = "override-property"
+	imageFlag               = "image"
+	patchManifestsFlag      = "patch-manifests"
+	outputFormatFlag        = "output-format"
+	classDefaultFlag        = "class-default"
+	annotationsFileFlag     = "annotations-file"
+	manifestFilterFlag      = "manifest-filter"
+	expandEnvFlag           = "expand-env"
+	noStateFlag             = "no-state"
+	overridesFromEnvFlag    = "overrides-from-env"
+	noManagedLabelsFlag     = "no-managed-labels"
+	provisionerTimeoutFlag  = "provisioner-timeout"
+	namespaceFlag           = "namespace"
+	emitNamespaceFlag       = "emit-namespace"
+	namespaceLabelFlag      = "namespace-label"
+	namespaceAnnotationFlag = "namespace-annotation"
+	resourceNamespaceFlag   = "resource-namespace"
+	capabilitiesFileFlag    = "capabilities-file"
+	annotateFromEnvFlag     = "annotate-from-env"
+	labelSchemeFlag         = "label-scheme"
+	strictSchemaFlag        = "strict-schema"
+	ownerRefFlag            = "owner-ref"
+	ownerRefFilterFlag      = "owner-ref-filter"
+	requireProbesFlag       = "require-probes"
+	maxManifestSizeFlag     = "max-manifest-size"
+	incrementalFlag         = "incremental"
+	forceFlag               = "force"
+	strictImagesFlag        = "strict-images"
+)
+
+// The supported values of Options.ExpandEnv.
+const (
+	// ExpandEnvEnabled expands ${VAR} references in override property values, undefined variables expand to "".
+	ExpandEnvEnabled = "true"
+	// ExpandEnvStrict expands ${VAR} references in override property values and fails on undefined variables.
+	ExpandEnvStrict = "strict"
+)
+
+// Options are the inputs to a generate run. Each field corresponds to a flag of the "score-k8s generate" command.
+type Options struct {
+	// Directory is the project directory that contains the .score-k8s state directory. Defaults to the current
+	// directory.
+	Directory string
+	// ScoreFiles is the list of Score files to add to or update in the project. When empty, the workloads already in
+	// the state are regenerated.
+	ScoreFiles []string
+	// OverridesFile is an optional file of Score overrides to merge into the single Score file.
+	OverridesFile string
+	// OverridesFromEnv is the optional name of an environment variable containing base64 encoded yaml Score overrides
+	// to merge into the single Score file after the OverridesFile.
+	OverridesFromEnv string
+	// OverrideProperties is an optional set of path=value overrides to apply to the single Score file. A bare path, or
+	// a path with an empty value, removes the property.
+	OverrideProperties []string
+	// Image is an optional container image to use for any container with image == '.'.
+	Image string
+	// PatchManifests is an optional set of <kind|*>/<name|*>/path=value patches to apply to the output manifests.
+	PatchManifests []string
+	// OutputFormat optionally changes the output. OutputFormatKubectlApplyOrder changes the order of the output
+	// manifests, while OutputFormatHelmValues leaves the manifests unchanged and signals that the caller should write
+	// the HelmValues of the manifests instead. When empty the manifests are returned in the default order.
+	OutputFormat string
+	// ClassDefault is an optional resource class to set on any resource in the Score files that does not declare a
+	// class. This allows the same Score file to select environment specific provisioners.
+	ClassDefault string
+	// AnnotationsFile is an optional yaml file containing a map of annotations to add to every output manifest.
+	// Annotations already set on a manifest take precedence.
+	AnnotationsFile string
+	// AnnotateFromEnv is an optional map of annotation keys to environment variable names. Each annotation is added
+	// to every output manifest with the value of the environment variable, such as a git sha or build url from CI.
+	// These take precedence over the AnnotationsFile, but not over annotations already set on a manifest. Unset
+	// variables are skipped unless ExpandEnv is ExpandEnvStrict.
+	AnnotateFromEnv map[string]string
+	// ManifestFilters optionally restricts the output manifests to those matching any of the kind=<kind>[,name=<name>]
+	// filters. All resources are still provisioned and the state is updated as normal.
+	ManifestFilters []string
+	// ExpandEnv optionally expands ${VAR} references to environment variables in the OverrideProperties values. This
+	// is either empty, ExpandEnvEnabled, or ExpandEnvStrict. Score placeholders like ${resources.db.host} are not
+	// affected since they are not valid environment variable names.
+	ExpandEnv string
+	// VerboseProvisioner logs the json input and output of each provisioner call at info level rather than debug
+	// level. Values under secret looking keys are redacted.
+	VerboseProvisioner bool
+	// NoState builds the state in memory from the ScoreFiles only. Any existing state file is ignored and the state is
+	// not persisted, while the provisioners are still loaded from the state directory.
+	NoState bool
+	// StampVersion adds an annotation with the score-k8s version to every output manifest.
+	StampVersion bool
+	// NoManagedLabels removes the app.kubernetes.io/name and app.kubernetes.io/managed-by labels from the converted
+	// workload manifests, keeping only the app.kubernetes.io/instance label and the selector labels of the LabelScheme.
+	NoManagedLabels bool
+	// ProvisionerTimeout is an optional limit on the total time spent provisioning resources. When it is exceeded,
+	// provisioning is aborted and the state is left untouched.
+	ProvisionerTimeout time.Duration
+	// Namespace is an optional namespace to set on every namespaced output manifest that does not already have one.
+	Namespace string
+	// EmitNamespace prepends a Namespace object for the Namespace to the output manifests. It is ignored when no
+	// Namespace is set.
+	EmitNamespace bool
+	// NamespaceLabels and NamespaceAnnotations are added to the emitted Namespace object.
+	NamespaceLabels      map[string]string
+	NamespaceAnnotations map[string]string
+	// ResourceNamespace is an optional namespace for the manifests of the provisioned resources, it requires a
+	// Namespace for the workloads. Provisioners that declare their own namespace take precedence, and the provisioners
+	// are told both namespaces so that the hostnames they output can reach across them.
+	ResourceNamespace string
+	// CapabilitiesFile is an optional file listing the apiVersions available in the target cluster, in the format of
+	// "kubectl api-versions". Output manifests with any other apiVersion are skipped with a warning. When empty, every
+	// apiVersion is assumed to be available.
+	CapabilitiesFile string
+	// KeepGoing continues converting the remaining workloads when one fails to convert and then returns all of the
+	// conversion failures together as ConversionErrors. Other failures, such as provisioning errors or unresolved
+	// secret references, still stop the run immediately.
+	KeepGoing bool
+	// LabelScheme optionally selects the labels that the workload Services, NetworkPolicies, PodMonitors, and
+	// Deployments or StatefulSets use to select their pods. This is one of convert.LabelSchemes, and defaults to
+	// convert.LabelSchemeRecommended which selects on the app.kubernetes.io/instance label. Changing the label scheme
+	// of an existing Deployment or StatefulSet requires it to be recreated since selectors are immutable.
+	LabelScheme string
+	// StrictSchema validates each Score file against the Score schema exactly as it was written, before any backwards
+	// compatible upgrades are applied or unsupported container resources are moved into an annotation. This rejects
+	// deprecated fields and unknown container resource names that are otherwise accepted. Unknown workload annotations
+	// with the k8s.score.dev/ prefix are also an error instead of a warning.
+	StrictSchema bool
+	// OwnerRef is an optional parent object in the form kind/name/uid/apiVersion that is added to the ownerReferences of
+	// the namespaced output manifests, so that they are garbage collected when the parent is deleted. The parent must
+	// be in the same namespace as the manifests.
+	OwnerRef string
+	// OwnerRefFilters optionally limits the OwnerRef to the output manifests matching any of the filters, in the same
+	// kind=<kind>[,name=<name>] form as ManifestFilters.
+	OwnerRefFilters []string
+	// RequireProbes fails the run with a MissingProbesError when any container of a workload Deployment or StatefulSet
+	// has no readinessProbe after conversion and manifest patches.
+	RequireProbes bool
+	// MaxManifestSize optionally limits the yaml encoded size in bytes of each output manifest. A larger manifest fails
+	// the run with a ManifestSizeError naming the resource and provisioner that produced it. Zero disables the limit.
+	MaxManifestSize int64
+	// Incremental skips provisioning the resources whose provisioner definition, params, metadata, and other inputs are
+	// unchanged since they were last provisioned with Incremental set, reusing the outputs, manifests, and sidecars
+	// recorded in the state. Provisioners must be deterministic for this to be safe, since changes outside of the
+	// inputs, such as to the executable of a cmd provisioner, are not detected.
+	Incremental bool
+	// Force provisions every resource when Incremental is set, while still recording the state for the next run.
+	Force bool
+	// StrictImages fails the run with a FloatingImagesError when any container of a workload has an image without a
+	// tag or with the 'latest' tag, after the image override and manifest patches. Images pinned to a digest pass.
+	StrictImages bool
+	// ConsolidateSecrets copies the values of the secret env vars of each workload that refer to the Secrets generated
+	// by provisioners into a single <workload>-env Secret, and rewrites the env vars to refer to it. The Secrets from the
+	// provisioners are still output since other objects may refer to them.
+	ConsolidateSecrets bool
+	// Env is the environment, such as prod, that the k8s.score.dev/when annotation of each workload is evaluated
+	// against to decide which of its annotations and containers apply.
+	Env string
+}
+
+// Manifest is a single output manifest along with the identity of the object and where it came from.
+type Manifest struct {
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+	// Workload is the name of the workload the manifest was converted from. This is empty for resource manifests.
+	Workload string
+	// Resource is the uid of the resource whose provisioner returned the manifest. This is empty for workload manifests.
+	Resource string
+	// Object is the manifest content as it should be written to the output.
+	Object map[string]interface{}
+}
+
+// Signature returns the unique apiVersion/kind/namespace/name signature of the manifest.
+func (m *Manifest) Signature() string {
+	return fmt.Sprintf("%s/%s/%s/%s", m.APIVersion, m.Kind, m.Namespace, m.Name)
+}
+
+// RegisterScheme registers additional types with the scheme used to validate and serialize the output manifests. This
+// allows library users to register the Go types of their custom resources, such as those generated by controller-gen,
+// so that provisioner manifests of these kinds are strictly validated. It must be called before Run or Convert.
+func RegisterScheme(funcs ...func(*runtime.Scheme) error) error {
+	return internal.AddToScheme(funcs...)
+}
+
+// newManifest builds a Manifest from the raw object, reading the identity from the object itself.
+func newManifest(object map[string]interface{}, workload, resource string) Manifest {
+	apiVersion, _ := object["apiVersion"].(string)
+	kind, _ := object["kind"].(string)
+	metadata, _ := object["metadata"].(map[string]interface{})
+	namespace, _ := metadata["namespace"].(string)
+	name, _ := metadata["name"].(string)
+	return Manifest{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Namespace:  namespace,
+		Name:       name,
+		Workload:   workload,
+		Resource:   resource,
+		Object:     object,
+	}
+}
+
+// Run executes the generate pipeline and returns the combined set of output manifests. The state directory is
+// updated with the new state, but the manifests are not written anywhere; that is left to the caller. If the context
+// is cancelled while provisioning, Run returns an error and the state directory is left untouched.
+func Run(ctx context.Context, opts Options) ([]Manifest, error) {
+	manifests, _, err := run(ctx, opts)
+	return manifests, err
+}
+
+// run is the implementation of Run which also returns the final state for reporting.
+func run(ctx context.Context, opts Options) ([]Manifest, *project.State, error) {
+	if opts.OutputFormat != "" && opts.OutputFormat != OutputFormatKubectlApplyOrder && opts.OutputFormat != OutputFormatHelmValues {
+		return nil, nil, errors.Errorf("--%s '%s' is not supported, expected '%s' or '%s'", outputFormatFlag, opts.OutputFormat, OutputFormatKubectlApplyOrder, OutputFormatHelmValues)
+	}
+
+	if opts.LabelScheme != "" && !slices.Contains(convert.LabelSchemes, opts.LabelScheme) {
+		return nil, nil, errors.Errorf("--%s '%s' is not supported, expected one of %s", labelSchemeFlag, opts.LabelScheme, strings.Join(convert.LabelSchemes, ", "))
+	}
+
+	if err := validateNamespaceOptions(opts); err != nil {
+		return nil, nil, err
+	}
+
+	var annotations map[string]string
+	if opts.AnnotationsFile != "" {
+		var err error
+		if annotations, err = parseAnnotationsFile(opts.AnnotationsFile, annotationsFileFlag); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(opts.AnnotateFromEnv) > 0 {
+		fromEnv, err := annotationsFromEnv(opts.AnnotateFromEnv, annotateFromEnvFlag, opts.ExpandEnv == ExpandEnvStrict)
+		if err != nil {
+			return nil, nil, err
+		}
+		if annotations == nil {
+			annotations = make(map[string]string, len(fromEnv))
+		}
+		maps.Copy(annotations, fromEnv)
+	}
+	if opts.StampVersion {
+		if annotations == nil {
+			annotations = make(map[string]string, 1)
+		}
+		annotations[internal.GeneratorVersionAnnotation] = version.GetBuildInfo().Version
+	}
+
+	var capabilities []string
+	if opts.CapabilitiesFile != "" {
+		var err error
+		if capabilities, err = parseCapabilitiesFile(opts.CapabilitiesFile, capabilitiesFileFlag); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	filters := make([]manifestFilter, 0, len(opts.ManifestFilters))
+	for _, entry := range opts.ManifestFilters {
+		f, err := parseManifestFilter(entry, manifestFilterFlag)
+		if err != nil {
+			return nil, nil, err
+		}
+		filters = append(filters, f)
+	}
+
+	var owner *ownerRef
+	if opts.OwnerRef != "" {
+		o, err := parseOwnerRef(opts.OwnerRef, ownerRefFlag)
+		if err != nil {
+			return nil, nil, err
+		}
+		owner = &o
+	}
+	ownerFilters := make([]manifestFilter, 0, len(opts.OwnerRefFilters))
+	for _, entry := range opts.OwnerRefFilters {
+		f, err := parseManifestFilter(entry, ownerRefFilterFlag)
+		if err != nil {
+			return nil, nil, err
+		}
+		ownerFilters = append(ownerFilters, f)
+	}
+	if owner == nil && len(ownerFilters) > 0 {
+		return nil, nil, errors.Errorf("--%s requires --%s", ownerRefFilterFlag, ownerRefFlag)
+	}
+
+	if opts.Force && !opts.Incremental {
+		return nil, nil, errors.Errorf("--%s requires --%s", forceFlag, incrementalFlag)
+	}
+
+	sd, state, localProvisioners, err := prepare(ctx, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if opts.NoManagedLabels {
+		slog.Warn(fmt.Sprintf("--%s removes the %s label, tools that find the objects managed by score-k8s through this label will not work", noManagedLabelsFlag, convert.SelectorLabelManagedBy))
+	}
+
+	if opts.VerboseProvisioner {
+		ctx = provisioners.WithVerboseLogging(ctx)
+	}
+	if opts.Incremental {
+		ctx = provisioners.WithIncrementalProvisioning(ctx, opts.Force)
+	}
+	ctx = provisioners.WithNamespaces(ctx, opts.Namespace, opts.ResourceNamespace)
+	provisionCtx, endSpan := trace.Start(ctx, "provision")
+	state, err = provisionWithTimeout(provisionCtx, opts.ProvisionerTimeout, func(ctx context.Context) (*project.State, error) {
+		return provisioners.ProvisionResources(ctx, state, localProvisioners)
+	})
+	endSpan()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to provision resources")
+	}
+
+	if !opts.NoState {
+		_, endSpan := trace.Start(ctx, "persist state")
+		sd.State = *state
+		if err := sd.Persist(); err != nil {
+			return nil, nil, errors.Wrap(err, "failed to persist state file")
+		}
+		endSpan()
+		slog.Info("Persisted state file")
+	}
+
+	convertCtx, endSpan := trace.Start(ctx, "convert")
+	outputManifests, err := buildManifests(convertCtx, state, opts, annotations)
+	if err != nil {
+		return nil, nil, err
+	}
+	endSpan()
+	if owner != nil {
+		applyOwnerRef(outputManifests, *owner, ownerFilters)
+	}
+	if opts.RequireProbes {
+		if missing := findMissingProbes(outputManifests); len(missing) > 0 {
+			return nil, nil, missing
+		}
+	}
+	if opts.StrictImages {
+		if floating := findFloatingImages(outputManifests); len(floating) > 0 {
+			return nil, nil, floating
+		}
+	}
+	if capabilities != nil {
+		outputManifests = filterByCapabilities(outputManifests, capabilities)
+	}
+	if len(filters) > 0 {
+		outputManifests = slices.DeleteFunc(outputManifests, func(m Manifest) bool {
+			return !slices.ContainsFunc(filters, func(f manifestFilter) bool {
+				return f.Match(m)
+			})
+		})
+	}
+	if err := checkManifestSizes(outputManifests, opts.MaxManifestSize, state); err != nil {
+		return nil, nil, err
+	}
+	return outputManifests, state, nil
+}
+
+// provisionWithTimeout runs the provisioning function with a deadline if the timeout is set. When the deadline is hit,
+// the error still names the resource that was being provisioned.
+func provisionWithTimeout[T any](ctx context.Context, timeout time.Duration, f func(ctx context.Context) (T, error)) (T, error) {
+	if timeout <= 0 {
+		return f(ctx)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	out, err := f(ctx)
+	if err != nil && errors.Is(err, context.DeadlineExceeded) {
+		return out, errors.Wrapf(err, "--%s of %s exceeded", provisionerTimeoutFlag, timeout)
+	}
+	return out, err
+}
+
+// buildManifests collects the resource manifests and converts the workloads in the provisioned state into the output
+// manifests, applying any extra annotations, manifest patches, and output format.
+func buildManifests(ctx context.Context, state *project.State, opts Options, annotations map[string]string) ([]Manifest, error) {
+	var err error
+
+	// the workloads are converted first so that the resource manifests they refer to can be kept next to them
+	workloadManifests := make([]Manifest, 0)
+	conversionErrors := make(ConversionErrors, 0)
+	for _, workloadName := range slices.Sorted(maps.Keys(state.Workloads)) {
+		_, endSpan := trace.Start(ctx, "convert workload "+workloadName)
+		manifests, err := convert.ConvertWorkloadWithOptions(state, workloadName, convert.Options{
+			LabelScheme:        opts.LabelScheme,
+			StrictAnnotations:  opts.StrictSchema,
+			ConsolidateSecrets: opts.ConsolidateSecrets,
+			Env:                opts.Env,
+		})
+		endSpan()
+		if err != nil {
+			err = &ValidationError{Workload: workloadName, Err: errors.Wrapf(err, "workload: %s: failed to convert", workloadName)}
+			if !opts.KeepGoing {
+				return nil, err
+			}
+			slog.Error(err.Error())
+			conversionErrors = append(conversionErrors, err)
+			continue
+		}
+		for _, m := range manifests {
+			subOut := new(bytes.Buffer)
+			if err = internal.YamlSerializerInfo.Serializer.Encode(m.(runtime.Object), subOut); err != nil {
+				return nil, errors.Wrapf(err, "workload: %s: failed to serialise manifest %s", workloadName, m.GetName())
+			}
+			var intermediate map[string]interface{}
+			_ = yaml.Unmarshal(subOut.Bytes(), &intermediate)
+			if p, ok := internal.FindFirstUnresolvedSecretRef("", intermediate); ok {
+				return nil, &UnresolvedRefError{Workload: workloadName, Path: p}
+			}
+			workloadManifests = append(workloadManifests, newManifest(intermediate, workloadName, ""))
+		}
+		slog.Info(fmt.Sprintf("Wrote %d manifests to manifests buffer for workload '%s'", len(manifests), workloadName))
+	}
+
+	outputManifests := make([]Manifest, 0)
+	references := podReferences(workloadManifests)
+	resIds, _ := state.GetSortedResourceUids()
+	for _, id := range resIds {
+		res := state.Resources[id]
+		if len(res.Extras.Manifests) > 0 {
+			for i, manifest := range res.Extras.Manifests {
+				if p, ok := internal.FindFirstUnresolvedSecretRef("", manifest); ok {
+					return nil, &UnresolvedRefError{Resource: string(id), Path: p}
+				}
+				normalized, err := internal.NormalizeManifest(manifest)
+				if err != nil {
+					return nil, errors.Wrapf(err, "resource: %s: manifests.%d", id, i)
+				}
+				if res.Extras.Namespace != "" {
+					placed, err := placeResourceManifest(normalized, res.Extras.Namespace, references)
+					if err != nil {
+						return nil, errors.Wrapf(err, "resource: %s: manifests.%d", id, i)
+					}
+					for _, m := range placed {
+						outputManifests = appendManifest(outputManifests, newManifest(m, "", string(id)))
+					}
+					continue
+				}
+				outputManifests = appendManifest(outputManifests, newManifest(normalized, "", string(id)))
+			}
+			slog.Info(fmt.Sprintf("Wrote %d resource manifests to manifests buffer for resource '%s'", len(res.Extras.Manifests), id))
+		}
+	}
+	for _, m := range workloadManifests {
+		outputManifests = appendManifest(outputManifests, m)
+	}
+
+	if len(conversionErrors) > 0 {
+		return nil, conversionErrors
+	}
+
+	outputManifests = applyNamespace(outputManifests, opts)
+
+	if opts.NoManagedLabels {
+		for _, m := range outputManifests {
+			if m.Workload != "" {
+				removeManagedLabels(m.Object)
+			}
+		}
+	}
+
+	if len(annotations) > 0 {
+		for _, m := range outputManifests {
+			applyAnnotations(m.Object, annotations)
+		}
+	}
+
+	// patch manifests here
+	if len(opts.PatchManifests) > 0 {
+		objects := make([]map[string]interface{}, len(outputManifests))
+		for i, m := range outputManifests {
+			objects[i] = m.Object
+		}
+		for _, entry := range opts.PatchManifests {
+			if objects, err = parseAndApplyManifestPatches(entry, patchManifestsFlag, objects); err != nil {
+				return nil, err
+			}
+		}
+		// patches may have modified the identity of the manifests, so we rebuild them
+		for i, m := range outputManifests {
+			outputManifests[i] = newManifest(objects[i], m.Workload, m.Resource)
+		}
+	}
+
+	if opts.OutputFormat == OutputFormatKubectlApplyOrder {
+		sortForApply(outputManifests)
+	}
+
+	return outputManifests, nil
+}
+
+// prepare loads the state directory, adds the Score files to the state, primes the resources, and loads the
+// provisioners. Nothing is persisted.
+func prepare(ctx context.Context, opts Options) (*project.StateDirectory, *project.State, []provisioners.Provisioner, error) {
+	_, endSpan := trace.Start(ctx, "load")
+	directory := opts.Directory
+	if directory == "" {
+		directory = "."
+	}
+
+	var sd *project.StateDirectory
+	var err error
+	if opts.NoState {
+		if len(opts.ScoreFiles) == 0 {
+			return nil, nil, nil, errors.Errorf("--%s requires at least one score file", noStateFlag)
+		}
+		sd = &project.StateDirectory{Path: filepath.Join(directory, project.DefaultRelativeStateDirectory), State: *newInMemoryState()}
+		if st, err := os.Stat(sd.Path); err != nil || !st.IsDir() {
+			return nil, nil, nil, fmt.Errorf("state directory does not exist, please run \"score-k8s init\" first")
+		}
+		slog.Info("Ignoring any existing state file")
+	} else {
+		var ok bool
+		sd, ok, err = project.LoadStateDirectory(directory)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to load existing state directory: %w", err)
+		} else if !ok {
+			return nil, nil, nil, fmt.Errorf("state directory does not exist, please run \"score-k8s init\" first")
+		}
+	}
+	state := &sd.State
+
+	if opts.ExpandEnv != "" && opts.ExpandEnv != ExpandEnvEnabled && opts.ExpandEnv != ExpandEnvStrict {
+		return nil, nil, nil, errors.Errorf("--%s '%s' is not supported, expected '%s' or '%s'", expandEnvFlag, opts.ExpandEnv, ExpandEnvEnabled, ExpandEnvStrict)
+	}
+
+	if len(opts.ScoreFiles) != 1 && (opts.OverridesFile != "" || len(opts.OverrideProperties) > 0 || opts.Image != "") {
+		return nil, nil, nil, errors.Errorf("cannot use --%s, --%s, or --%s when 0 or more than 1 score files are provided", overridePropertyFlag, overridesFileFlag, imageFlag)
+	}
+	if len(opts.ScoreFiles) != 1 && opts.OverridesFromEnv != "" {
+		return nil, nil, nil, errors.Errorf("cannot use --%s when 0 or more than 1 score files are provided", overridesFromEnvFlag)
+	}
+
+	scoreFiles := slices.Clone(opts.ScoreFiles)
+	slices.Sort(scoreFiles)
+	for _, arg := range scoreFiles {
+		rawWorkloads, err := readScoreFile(arg)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if len(rawWorkloads) > 1 && (opts.OverridesFile != "" || len(opts.OverrideProperties) > 0 || opts.Image != "") {
+			return nil, nil, nil, errors.Errorf("cannot use --%s, --%s, or --%s when the score file contains more than 1 document: %s", overridePropertyFlag, overridesFileFlag, imageFlag, arg)
+		}
+		if len(rawWorkloads) > 1 && opts.OverridesFromEnv != "" {
+			return nil, nil, nil, errors.Errorf("cannot use --%s when the score file contains more than 1 document: %s", overridesFromEnvFlag, arg)
+		}
+		for _, rawWorkload := range rawWorkloads {
+			if state, err = addScoreWorkload(state, arg, rawWorkload, opts); err != nil {
+				return nil, nil, nil, err
+			}
+		}
+	}
+
+	if len(state.Workloads) == 0 {
+		return nil, nil, nil, errors.New("Project is empty, please add a score file")
+	}
+	endSpan()
+
+	_, endSpan = trace.Start(ctx, "prime")
+	if state, err = state.WithPrimedResources(); err != nil {
+		return nil, nil, nil, errors.Wrap(err, "failed to prime resources")
+	}
+	endSpan()
+
+	slog.Info("Primed resources", "#workloads", len(state.Workloads), "#resources", len(state.Resources))
+
+	_, endSpan = trace.Start(ctx, "load provisioners")
+	localProvisioners, err := loader.LoadProvisionersFromDirectory(sd.Path, loader.DefaultSuffix)
+	if err != nil {
+		return nil, nil, nil, errors.Wrapf(err, "failed to load provisioners")
+	}
+	endSpan()
+	slog.Info("Loaded provisioners", "#provisioners", len(localProvisioners))
+
+	return sd, state, localProvisioners, nil
+}
+
+// newInMemoryState returns an empty state that is not backed by a state file.
+func newInMemoryState() *project.State {
+	return &project.State{
+		Workloads:   map[string]framework.ScoreWorkloadState[project.WorkloadExtras]{},
+		Resources:   map[framework.ResourceUid]framework.ScoreResourceState[project.ResourceExtras]{},
+		SharedState: map[string]interface{}{},
+	}
+}
+
+// readScoreFile reads all the yaml documents in the score file. Empty documents are ignored.
+func readScoreFile(path string) ([]map[string]interface{}, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read input score file: %s", path)
+	}
+	out := make([]map[string]interface{}, 0, 1)
+	dec := yaml.NewDecoder(bytes.NewReader(raw))
+	for {
+		var rawWorkload map[string]interface{}
+		if err := dec.Decode(&rawWorkload); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, errors.Wrapf(err, "failed to decode input score file: %s", path)
+		} else if rawWorkload != nil {
+			out = append(out, rawWorkload)
+		}
+	}
+	return out, nil
+}
+
+// validateScoreWorkload upgrades and validates the raw workload and decodes it into a Score workload. The raw workload
+// is modified in place. When strict is set, the workload must also be valid before any upgrades are applied.
+func validateScoreWorkload(arg string, rawWorkload map[string]interface{}, strict bool) (*scoretypes.Workload, error) {
+	// In strict mode the workload must be valid as written, without relying on the lenient upgrades below
+	if strict {
+		if err := scoreschema.Validate(rawWorkload); err != nil {
+			return nil, &ValidationError{Path: arg, Err: errors.Wrapf(err, "invalid score file: %s (--%s)", arg, strictSchemaFlag)}
+		}
+	}
+
+	// Ensure transforms are applied (be a good citizen)
+	if changes, err := scoreschema.ApplyCommonUpgradeTransforms(rawWorkload); err != nil {
+		return nil, fmt.Errorf("failed to upgrade spec: %w", err)
+	} else if len(changes) > 0 {
+		for _, change := range changes {
+			slog.Info(fmt.Sprintf("Applying backwards compatible upgrade %s", change))
+		}
+	}
+
+	// Move any container resources the Score schema does not support into an annotation for the converter
+	if err := convert.ExtractContainerResources(rawWorkload); err != nil {
+		return nil, &ValidationError{Path: arg, Err: errors.Wrapf(err, "invalid score file: %s", arg)}
+	}
+
+	var workload scoretypes.Workload
+	if err := scoreschema.Validate(rawWorkload); err != nil {
+		return nil, &ValidationError{Path: arg, Err: errors.Wrapf(err, "invalid score file: %s", arg)}
+	} else if err := scoreloader.MapSpec(&workload, rawWorkload); err != nil {
+		return nil, &ValidationError{Path: arg, Err: errors.Wrapf(err, "failed to decode input score file: %s", arg)}
+	}
+	return &workload, nil
+}
+
+// addScoreWorkload applies the overrides to the raw workload, validates it, and adds it to the state.
+func addScoreWorkload(state *project.State, arg string, rawWorkload map[string]interface{}, opts Options) (*project.State, error) {
+	var err error
+
+	// apply overrides
+
+	if opts.OverridesFile != "" {
+		if err := parseAndApplyOverrideFile(opts.OverridesFile, overridesFileFlag, rawWorkload); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.OverridesFromEnv != "" {
+		if err := parseAndApplyOverrideEnv(opts.OverridesFromEnv, overridesFromEnvFlag, rawWorkload); err != nil {
+			return nil, err
+		}
+	}
+
+	// Now read, parse, and apply any override properties to the score files
+	for _, overridePropertyEntry := range opts.OverrideProperties {
+		if rawWorkload, err = parseAndApplyOverrideProperty(overridePropertyEntry, overridePropertyFlag, rawWorkload, opts.ExpandEnv); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := resolveWorkloadName(arg, rawWorkload); err != nil {
+		return nil, err
+	}
+
+	workload, err := validateScoreWorkload(arg, rawWorkload, opts.StrictSchema)
+	if err != nil {
+		return nil, err
+	}
+	workloadName := workload.Metadata["name"].(string)
+
+	// Apply the default class to resources without one
+	if opts.ClassDefault != "" {
+		for resName, res := range workload.Resources {
+			if res.Class == nil {
+				res.Class = internal.Ref(opts.ClassDefault)
+				slog.Info(fmt.Sprintf("Set class of resource '%s' to '%s' from --%s", resName, opts.ClassDefault, classDefaultFlag))
+				workload.Resources[resName] = res
+			}
+		}
+	}
+
+	// Apply image override
+	for containerName, container := range workload.Containers {
+		if container.Image == "." {
+			if opts.Image != "" {
+				container.Image = opts.Image
+				slog.Info(fmt.Sprintf("Set container image for container '%s' to %s from --%s", containerName, opts.Image, imageFlag))
+				workload.Containers[containerName] = container
+			} else {
+				return nil, &ValidationError{Workload: workloadName, Path: arg, Err: errors.Errorf("failed to convert '%s' because container '%s' has no image and --image was not provided", arg, containerName)}
+			}
+		}
+	}
+
+	var extras project.WorkloadExtras
+	if existing, ok := state.Workloads[workloadName]; ok && existing.Extras.InstanceSuffix != "" {
+		extras = existing.Extras
+	} else {
+		extrasBytes := make([]byte, 5)
+		_, _ = rand.Read(extrasBytes)
+		extras.InstanceSuffix = fmt.Sprintf("-%x", extrasBytes)
+	}
+
+	if state, err = state.WithWorkload(workload, &arg, extras); err != nil {
+		return nil, errors.Wrapf(err, "failed to add score file to project: %s", arg)
+	}
+	slog.Info("Added score file to project", "file", arg)
+	return state, nil
+}
+
+// PlannedResource is the planned outcome of provisioning a single resource.
+type PlannedResource struct {
+	ResourceUid    string
+	ProvisionerUri string
+	// Skipped is true when the provisioner does not support plan mode.
+	Skipped bool
+	// Outputs is the sorted list of output keys that the resource would have.
+	Outputs []string
+	// Manifests is the set of manifests the provisioner would return.
+	Manifests []Manifest
+}
+
+// Plan runs the same steps as Run up to provisioning, but invokes the provisioners in plan mode and returns a summary
+// of what they would do. Provisioners that do not support plan mode are skipped. The state directory is not updated
+// and no workloads are converted.
+func Plan(ctx context.Context, opts Options) ([]PlannedResource, error) {
+	_, state, localProvisioners, err := prepare(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.VerboseProvisioner {
+		ctx = provisioners.WithVerboseLogging(ctx)
+	}
+	ctx = provisioners.WithNamespaces(ctx, opts.Namespace, opts.ResourceNamespace)
+	results, err := provisionWithTimeout(ctx, opts.ProvisionerTimeout, func(ctx context.Context) ([]provisioners.PlanResult, error) {
+		return provisioners.PlanResources(ctx, state, localProvisioners)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to plan resources")
+	}
+
+	out := make([]PlannedResource, 0, len(results))
+	for _, result := range results {
+		pr := PlannedResource{
+			ResourceUid:    string(result.ResourceUid),
+			ProvisionerUri: result.ProvisionerUri,
+			Skipped:        result.Skipped,
+		}
+		if result.Output != nil {
+			pr.Outputs = slices.Sorted(maps.Keys(result.Output.ResourceOutputs))
+			for _, m := range result.Output.Manifests {
+				pr.Manifests = append(pr.Manifests, newManifest(m, "", pr.ResourceUid))
+			}
+		}
+		out = append(out, pr)
+	}
+	return out, nil
+}
+
+// appendManifest adds the manifest to the output list, replacing any previous manifest with the same signature.
+func appendManifest(manifests []Manifest, manifest Manifest) []Manifest {
+	mSig := manifest.Signature()
+	manifests = slices.DeleteFunc(manifests, func(other Manifest) bool {
+		if other.Signature() == mSig {
+			slog.Info(fmt.Sprintf("Overriding duplicate resource manifest %s", mSig))
+			return true
+		}
+		return false
+	})
+	return append(manifests, manifest)
+}
+
+func parseAndApplyOverrideFile(entry string, flagName string, spec map[string]interface{}) error {
+	if raw, err := os.ReadFile(entry); err != nil {
+		return fmt.Errorf("--%s '%s' is invalid, failed to read file: %w", flagName, entry, err)
+	} else {
+		slog.Info(fmt.Sprintf("Applying overrides from %s to workload", entry))
+		var out map[string]interface{}
+		if err := yaml.Unmarshal(raw, &out); err != nil {
+			return fmt.Errorf("--%s '%s' is invalid: failed to decode yaml: %w", flagName, entry, err)
+		} else if err := mergo.Merge(&spec, out, mergo.WithOverride); err != nil {
+			return fmt.Errorf("--%s '%s' failed to apply: %w", flagName, entry, err)
+		}
+	}
+	return nil
+}
+
+// parseAndApplyOverrideEnv merges the yaml overrides in the named environment variable into the spec. The value must be
+// base64 encoded yaml so that it can be passed through systems that do not preserve newlines.
+func parseAndApplyOverrideEnv(entry string, flagName string, spec map[string]interface{}) error {
+	raw := strings.TrimSpace(os.Getenv(entry))
+	if raw == "" {
+		return fmt.Errorf("--%s '%s' is invalid, the environment variable is not set or empty", flagName, entry)
+	}
+	content, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return fmt.Errorf("--%s '%s' is invalid, the environment variable is not base64 encoded: %w", flagName, entry, err)
+	}
+	slog.Info(fmt.Sprintf("Applying overrides from environment variable %s to workload", entry))
+	var out map[string]interface{}
+	if err := yaml.Unmarshal(content, &out); err != nil {
+		return fmt.Errorf("--%s '%s' is invalid: failed to decode yaml: %w", flagName, entry, err)
+	} else if err := mergo.Merge(&spec, out, mergo.WithOverride); err != nil {
+		return fmt.Errorf("--%s '%s' failed to apply: %w", flagName, entry, err)
+	}
+	return nil
+}
+
+// parseAndApplyOverrideProperty applies a single override property to the spec. The entry is either a bare path which
+// removes the property, a path= which also removes the property, or a path=value which sets the yaml value. An empty
+// string is set with path="".
+func parseAndApplyOverrideProperty(entry string, flagName string, spec map[string]interface{}, expandEnv string) (map[string]interface{}, error) {
+	parts := strings.SplitN(entry, "=", 2)
+	if parts[0] == "" {
+		return nil, fmt.Errorf("--%s '%s' is invalid, expected a =-separated path and value", flagName, entry)
+	} else if len(parts) == 1 {
+		slog.Info(fmt.Sprintf("Overriding '%s' in workload", parts[0]))
+		after, err := framework.OverridePathInMap(spec, framework.ParseDotPathParts(parts[0]), true, nil)
+		if err != nil {
+			return nil, fmt.Errorf("--%s '%s' could not be applied: %w", flagName, entry, err)
+		}
+		return after, nil
+	}
+	if expandEnv != "" {
+		var err error
+		if parts[1], err = expandEnvVars(parts[1], expandEnv == ExpandEnvStrict); err != nil {
+			return nil, fmt.Errorf("--%s '%s' is invalid: %w", flagName, entry, err)
+		}
+	}
+	if parts[1] == "" {
+		slog.Info(fmt.Sprintf("Overriding '%s' in workload", parts[0]))
+		after, err := framework.OverridePathInMap(spec, framework.ParseDotPathParts(parts[0]), true, nil)
+		if err != nil {
+			return nil, fmt.Errorf("--%s '%s' could not be applied: %w", flagName, entry, err)
+		}
+		return after, nil
+	} else {
+		var value interface{}
+		if err := yaml.Unmarshal([]byte(parts[1]), &value); err != nil {
+			return nil, fmt.Errorf("--%s '%s' is invalid, failed to unmarshal value as json: %w", flagName, entry, err)
+		}
+		slog.Info(fmt.Sprintf("Overriding '%s' in workload", parts[0]))
+		after, err := framework.OverridePathInMap(spec, framework.ParseDotPathParts(parts[0]), false, value)
+		if err != nil {
+			return nil, fmt.Errorf("--%s '%s' could not be applied: %w", flagName, entry, err)
+		}
+		return after, nil
+	}
+}
+
+func parseAndApplyManifestPatches(entry string, flagName string, manifests []map[string]interface{}) ([]map[string]interface{}, error) {
+	parts := strings.SplitN(entry, "=", 2)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("--%s '%s' is invalid, expected a =-separated path and value", flagName, entry)
+	}
+	filter := strings.SplitN(parts[0], "/", 3)
+	if len(filter) != 3 {
+		return nil, fmt.Errorf("--%s '%s' is invalid, expected the patch path to have an initial <kind>/<name>/... prefix", flagName, entry)
+	}
+	kindFilter, nameFilter, path := filter[0], filter[1], filter[2]
+	outManifests := slices.Clone(manifests)
+
+	for i, manifest := range manifests {
+		kind, kOk := manifest["kind"].(string)
+		meta, _ := manifest["metadata"].(map[string]interface{})
+		name, nOk := meta["name"].(string)
+		if (kindFilter == "*" || (kOk && kind == kindFilter)) && (nameFilter == "*" || (nOk && name == nameFilter)) {
+			if parts[1] == "" {
+				slog.Info(fmt.Sprintf("Overriding '%s' in manifest %s/%s", path, kind, name))
+				after, err := framework.OverridePathInMap(manifest, framework.ParseDotPathParts(path), true, nil)
+				if err != nil {
+					return nil, fmt.Errorf("--%s '%s' could not be applied to %s/%s: %w", flagName, entry, kind, name, err)
+				}
+				manifest = after
+			} else {
+				var value interface{}
+				if err := yaml.Unmarshal([]byte(parts[1]), &value); err != nil {
+					return nil, fmt.Errorf("--%s '%s' is invalid, failed to unmarshal value as yaml: %w", flagName, entry, err)
+				}
+				slog.Info(fmt.Sprintf("Overriding '%s' in manifest %s/%s", path, kind, name))
+				after, err := framework.OverridePathInMap(manifest, framework.ParseDotPathParts(path), false, value)
+				if err != nil {
+					return nil, fmt.Errorf("--%s '%s' could not be applied to %s/%s: %w", flagName, entry, kind, name, err)
+				}
+				manifest = after
+			}
+		}
+		outManifests[i] = manifest
+	}
+	return outManifests, nil
+}
+
+// parseAnnotationsFile reads a yaml map of annotation keys to string values from the file.
+func parseAnnotationsFile(entry string, flagName string) (map[string]string, error) {
+	raw, err := os.ReadFile(entry)
+	if err != nil {
+		return nil, fmt.Errorf("--%s '%s' is invalid, failed to read file: %w", flagName, entry, err)
+	}
+	var out map[string]string
+	if err := yaml.Unmarshal(raw, &out); err != nil {
+		return nil, fmt.Errorf("--%s '%s' is invalid, failed to decode map of strings: %w", flagName, entry, err)
+	}
+	for _, k := range slices.Sorted(maps.Keys(out)) {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return nil, fmt.Errorf("--%s '%s' is invalid, '%s' is not a valid annotation key: %s", flagName, entry, k, strings.Join(errs, ", "))
+		}
+	}
+	return out, nil
+}
+
+// annotationsFromEnv reads the value of each annotation from the named environment variable. Unset variables are
+// skipped, or are an error when strict is set.
+func annotationsFromEnv(entries map[string]string, flagName string, strict bool) (map[string]string, error) {
+	out := make(map[string]string, len(entries))
+	for _, k := range slices.Sorted(maps.Keys(entries)) {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return nil, fmt.Errorf("--%s '%s=%s' is invalid, '%s' is not a valid annotation key: %s", flagName, k, entries[k], k, strings.Join(errs, ", "))
+		}
+		v, ok := os.LookupEnv(entries[k])
+		if !ok {
+			if strict {
+				return nil, fmt.Errorf("--%s '%s=%s' is invalid, environment variable %s is not set", flagName, k, entries[k], entries[k])
+			}
+			slog.Info(fmt.Sprintf("Skipping annotation '%s' since environment variable %s is not set", k, entries[k]))
+			continue
+		}
+		out[k] = v
+	}
+	return out, nil
+}
+
+// applyAnnotations adds the annotations to the metadata of the manifest object without replacing any existing values.
+func applyAnnotations(object map[string]interface{}, annotations map[string]string) {
+	metadata, _ := object["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+		object["metadata"] = metadata
+	}
+	existing, _ := metadata["annotations"].(map[string]interface{})
+	if existing == nil {
+		existing = make(map[string]interface{}, len(annotations))
+		metadata["annotations"] = existing
+	}
+	for k, v := range annotations {
+		if _, ok := existing[k]; !ok {
+			existing[k] = v
+		}
+	}
+}
+
+// managedLabels are the labels added to the workload manifests that are not needed by any selector.
+var managedLabels = []string{convert.SelectorLabelName, convert.SelectorLabelManagedBy}
+
+// removeManagedLabels removes the managedLabels from the metadata of the object and any nested templates such as the
+// pod template. Selectors are not affected.
+func removeManagedLabels(object map[string]interface{}) {
+	for k, v := range object {
+		switch typed := v.(type) {
+		case map[string]interface{}:
+			if k == "metadata" {
+				if labels, ok := typed["labels"].(map[string]interface{}); ok {
+					for _, label := range managedLabels {
+						delete(labels, label)
+					}
+				}
+			}
+			removeManagedLabels(typed)
+		case []interface{}:
+			for _, item := range typed {
+				if m, ok := item.(map[string]interface{}); ok {
+					removeManagedLabels(m)
+				}
+			}
+		}
+	}
+}
+
+// manifestFilter selects output manifests by kind and optionally name.
+type manifestFilter struct {
+	Kind string
+	Name string
+}
+
+// Match returns true if the manifest has the kind and, if set, the name of the filter.
+func (f manifestFilter) Match(m Manifest) bool {
+	return m.Kind == f.Kind && (f.Name == "" || m.Name == f.Name)
+}
+
+func parseManifestFilter(entry string, flagName string) (manifestFilter, error) {
+	var out manifestFilter
+	for _, part := range strings.Split(entry, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok || v == "" {
+			return out, fmt.Errorf("--%s '%s' is invalid, expected kind=<kind>[,name=<name>]", flagName, entry)
+		}
+		switch k {
+		case "kind":
+			out.Kind = v
+		case "name":
+			out.Name = v
+		default:
+			return out, fmt.Errorf("--%s '%s' is invalid, unknown key '%s', expected kind or name", flagName, entry, k)
+		}
+	}
+	if out.Kind == "" {
+		return out, fmt.Errorf("--%s '%s' is invalid, expected kind=<kind>[,name=<name>]", flagName, entry)
+	}
+	return out, nil
+}
+
+var envVarReference = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// resolveWorkloadName expands the ${VAR} environment variable references in the metadata.name of the raw workload, so
+// that the same Score file can be used for several instances of a workload. This happens before the workload is
+// validated and added to the state since the name is the identity of the workload and its objects. Other placeholders,
+// like ${resources.db.name}, cannot be supported since the resources are themselves identified by the workload name.
+func resolveWorkloadName(arg string, rawWorkload map[string]interface{}) error {
+	metadata, _ := rawWorkload["metadata"].(map[string]interface{})
+	raw, ok := metadata["name"].(string)
+	if !ok || !strings.Contains(raw, "${") {
+		return nil
+	}
+	name, err := expandEnvVars(raw, true)
+	if err != nil {
+		return &ValidationError{Path: arg, Err: errors.Wrapf(err, "metadata.name: '%s'", raw)}
+	} else if strings.Contains(name, "${") {
+		return &ValidationError{Path: arg, Err: errors.Errorf("metadata.name: '%s': only ${VAR} environment variable placeholders are supported", raw)}
+	} else if errs := validation.IsDNS1123Label(name); len(errs) > 0 {
+		return &ValidationError{Path: arg, Err: errors.Errorf("metadata.name: '%s' resolved to '%s' which is not a valid name: %s", raw, name, strings.Join(errs, "; "))}
+	}
+	slog.Info(fmt.Sprintf("Resolved workload name '%s' to '%s'", raw, name))
+	metadata["name"] = name
+	return nil
+}
+
+// expandEnvVars replaces ${VAR} references with the value of the environment variable. In strict mode, any undefined
+// variable is an error, otherwise it expands to an empty string.
+func expandEnvVars(value string, strict bool) (string, error) {
+	var missing []string
+	out := envVarReference.ReplaceAllStringFunc(value, func(ref string) string {
+		name := envVarReference.FindStringSubmatch(ref)[1]
+		v, ok := os.LookupEnv(name)
+		if !ok && !slices.Contains(missing, name) {
+			missing = append(missing, name)
+		}
+		return v
+	})
+	if strict && len(missing) > 0 {
+		return "", fmt.Errorf("undefined environment variables: %s", strings.Join(missing, ", "))
+	}
+	return out, nil
+}
@@ -0,0 +1,1346 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"context"
+	"encoding/base64"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/score-spec/score-go/framework"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/score-spec/score-k8s/internal/project"
+	_default "github.com/score-spec/score-k8s/internal/provisioners/default"
+)
+
+// initProject writes an empty state directory and the default provisioners into the given directory, like
+// "score-k8s init" does.
+func initProject(t *testing.T, dir string) {
+	t.Helper()
+	sd := &project.StateDirectory{
+		Path: filepath.Join(dir, project.DefaultRelativeStateDirectory),
+		State: project.State{
+			Workloads:   map[string]framework.ScoreWorkloadState[project.WorkloadExtras]{},
+			Resources:   map[framework.ResourceUid]framework.ScoreResourceState[project.ResourceExtras]{},
+			SharedState: map[string]interface{}{},
+		},
+	}
+	require.NoError(t, sd.Persist())
+	require.NoError(t, os.WriteFile(filepath.Join(sd.Path, "zz-default.provisioners.yaml"), []byte(_default.DefaultProvisioners), 0644))
+}
+
+func manifestKinds(manifests []Manifest) []string {
+	out := make([]string, 0, len(manifests))
+	for _, m := range manifests {
+		out = append(out, m.Kind)
+	}
+	return out
+}
+
+func TestRunWithoutInit(t *testing.T) {
+	td := t.TempDir()
+	_, err := Run(context.Background(), Options{Directory: td})
+	assert.EqualError(t, err, "state directory does not exist, please run \"score-k8s init\" first")
+}
+
+func TestRunWithoutScoreFiles(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	_, err := Run(context.Background(), Options{Directory: td})
+	assert.EqualError(t, err, "Project is empty, please add a score file")
+}
+
+func TestRunOverridesWithMultipleFiles(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	_, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{"a.yaml", "b.yaml"}, Image: "nginx"})
+	assert.EqualError(t, err, "cannot use --override-property, --overrides-file, or --image when 0 or more than 1 score files are provided")
+}
+
+func TestRunUnsupportedOutputFormat(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	_, err := Run(context.Background(), Options{Directory: td, OutputFormat: "helm"})
+	assert.EqualError(t, err, "--output-format 'helm' is not supported, expected 'kubectl-apply-order' or 'helm-values'")
+}
+
+func TestRunUnsupportedLabelScheme(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	_, err := Run(context.Background(), Options{Directory: td, LabelScheme: "helm"})
+	assert.EqualError(t, err, "--label-scheme 'helm' is not supported, expected one of recommended, legacy")
+}
+
+func TestRunNominal(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: .
+    variables:
+      THING: ${resources.foo.plaintext}
+service:
+  ports:
+    web:
+      port: 8080
+resources:
+  foo:
+    type: example-provisioner-resource
+`), 0644))
+
+	manifests, err := Run(context.Background(), Options{
+		Directory:          td,
+		ScoreFiles:         []string{scoreFile},
+		Image:              "nginx:latest",
+		OverrideProperties: []string{"metadata.annotations.key=value"},
+		PatchManifests:     []string{"Deployment/example/spec.replicas=3"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ConfigMap", "Service", "Deployment"}, manifestKinds(manifests))
+	assert.Equal(t, 3, manifests[2].Object["spec"].(map[string]interface{})["replicas"])
+
+	// each manifest carries its identity and source
+	assert.Equal(t, "v1", manifests[0].APIVersion)
+	assert.Equal(t, "", manifests[0].Workload)
+	assert.Equal(t, "example-provisioner-resource.default#example.foo", manifests[0].Resource)
+	assert.Equal(t, "apps/v1/Deployment//example", manifests[2].Signature())
+	assert.Equal(t, "example", manifests[2].Workload)
+	assert.Equal(t, "", manifests[2].Resource)
+
+	// the state should have been persisted
+	sd, ok, err := project.LoadStateDirectory(td)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Len(t, sd.State.Workloads, 1)
+	assert.Len(t, sd.State.Resources, 1)
+
+	// and a second run without score files regenerates the same workloads
+	manifests2, err := Run(context.Background(), Options{Directory: td})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ConfigMap", "Service", "Deployment"}, manifestKinds(manifests2))
+}
+
+func TestRunMultiDocumentScoreFile(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example-a
+containers:
+  main:
+    image: nginx
+---
+---
+apiVersion: score.dev/v1b1
+metadata:
+  name: example-b
+containers:
+  main:
+    image: .
+`), 0644))
+
+	t.Run("with overrides", func(t *testing.T) {
+		_, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, Image: "busybox"})
+		assert.EqualError(t, err, "cannot use --override-property, --overrides-file, or --image when the score file contains more than 1 document: "+scoreFile)
+	})
+
+	t.Run("without overrides", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example-a
+containers:
+  main:
+    image: nginx
+---
+apiVersion: score.dev/v1b1
+metadata:
+  name: example-b
+containers:
+  main:
+    image: busybox
+`), 0644))
+		_, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}})
+		require.NoError(t, err)
+		sd, _, err := project.LoadStateDirectory(td)
+		require.NoError(t, err)
+		assert.Len(t, sd.State.Workloads, 2)
+		assert.Equal(t, scoreFile, *sd.State.Workloads["example-a"].File)
+		assert.Equal(t, scoreFile, *sd.State.Workloads["example-b"].File)
+	})
+}
+
+func TestRunClassDefault(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+resources:
+  res-a:
+    type: thing
+  res-b:
+    type: thing
+    class: explicit
+`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(td, ".score-k8s", "00.provisioners.yaml"), []byte(`
+- uri: template://thing
+  type: thing
+`), 0644))
+
+	_, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, ClassDefault: "prod"})
+	require.NoError(t, err)
+	sd, _, err := project.LoadStateDirectory(td)
+	require.NoError(t, err)
+	assert.Equal(t, []framework.ResourceUid{"thing.explicit#example.res-b", "thing.prod#example.res-a"}, slices.Sorted(maps.Keys(sd.State.Resources)))
+}
+
+func TestRunExtraContainerResources(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+    resources:
+      limits:
+        memory: 128Mi
+        ephemeral-storage: 1Gi
+        nvidia.com/gpu: 1
+      requests:
+        ephemeral-storage: 1Gi
+`), 0644))
+
+	manifests, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}})
+	require.NoError(t, err)
+	var resources map[string]interface{}
+	for _, m := range manifests {
+		if m.Kind == "Deployment" {
+			spec := m.Object["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})
+			resources = spec["containers"].([]interface{})[0].(map[string]interface{})["resources"].(map[string]interface{})
+		}
+	}
+	assert.Equal(t, map[string]interface{}{
+		"limits":   map[string]interface{}{"memory": "128Mi", "ephemeral-storage": "1Gi", "nvidia.com/gpu": "1"},
+		"requests": map[string]interface{}{"ephemeral-storage": "1Gi"},
+	}, resources)
+}
+
+func TestRunAnnotationsFile(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+service:
+  ports:
+    web:
+      port: 80
+`), 0644))
+	annotationsFile := filepath.Join(td, "annotations.yaml")
+	require.NoError(t, os.WriteFile(annotationsFile, []byte(`
+example.com/team: platform
+k8s.score.dev/workload-name: ignored
+`), 0644))
+
+	manifests, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, AnnotationsFile: annotationsFile})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Service", "Deployment"}, manifestKinds(manifests))
+	for _, m := range manifests {
+		assert.Equal(t, map[string]interface{}{
+			"example.com/team":            "platform",
+			"k8s.score.dev/workload-name": "example",
+		}, m.Object["metadata"].(map[string]interface{})["annotations"])
+	}
+
+	require.NoError(t, os.WriteFile(annotationsFile, []byte(`"not a key!": value`), 0644))
+	_, err = Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, AnnotationsFile: annotationsFile})
+	assert.ErrorContains(t, err, "--annotations-file '"+annotationsFile+"' is invalid, 'not a key!' is not a valid annotation key: ")
+}
+
+func TestRunAnnotateFromEnv(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+`), 0644))
+	annotationsFile := filepath.Join(td, "annotations.yaml")
+	require.NoError(t, os.WriteFile(annotationsFile, []byte(`example.com/git-sha: from-file`), 0644))
+	t.Setenv("TEST_GIT_SHA", "abc123")
+	t.Setenv("TEST_BUILD_URL", "https://ci.example.com/builds/1")
+
+	fromEnv := map[string]string{
+		"example.com/git-sha":   "TEST_GIT_SHA",
+		"example.com/build-url": "TEST_BUILD_URL",
+		"example.com/missing":   "TEST_UNSET_VARIABLE",
+	}
+	manifests, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, AnnotationsFile: annotationsFile, AnnotateFromEnv: fromEnv})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"example.com/git-sha":         "abc123",
+		"example.com/build-url":       "https://ci.example.com/builds/1",
+		"k8s.score.dev/workload-name": "example",
+	}, manifests[0].Object["metadata"].(map[string]interface{})["annotations"])
+
+	_, err = Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, AnnotateFromEnv: fromEnv, ExpandEnv: ExpandEnvStrict})
+	assert.EqualError(t, err, "--annotate-from-env 'example.com/missing=TEST_UNSET_VARIABLE' is invalid, environment variable TEST_UNSET_VARIABLE is not set")
+
+	_, err = Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, AnnotateFromEnv: map[string]string{"not a key!": "TEST_GIT_SHA"}})
+	assert.ErrorContains(t, err, "--annotate-from-env 'not a key!=TEST_GIT_SHA' is invalid, 'not a key!' is not a valid annotation key: ")
+}
+
+func TestRunManifestFilter(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+service:
+  ports:
+    web:
+      port: 80
+resources:
+  db:
+    type: postgres
+`), 0644))
+
+	manifests, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, ManifestFilters: []string{
+		"kind=Deployment", "kind=Service,name=example",
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Service", "Deployment"}, manifestKinds(manifests))
+
+	// everything is still provisioned
+	sd, _, err := project.LoadStateDirectory(td)
+	require.NoError(t, err)
+	assert.Len(t, sd.State.Resources, 1)
+
+	_, err = Run(context.Background(), Options{Directory: td, ManifestFilters: []string{"name=example"}})
+	assert.EqualError(t, err, "--manifest-filter 'name=example' is invalid, expected kind=<kind>[,name=<name>]")
+	_, err = Run(context.Background(), Options{Directory: td, ManifestFilters: []string{"kind=Service,namespace=x"}})
+	assert.EqualError(t, err, "--manifest-filter 'kind=Service,namespace=x' is invalid, unknown key 'namespace', expected kind or name")
+}
+
+func TestParseAndApplyOverrideProperty(t *testing.T) {
+	newSpec := func() map[string]interface{} {
+		return map[string]interface{}{"metadata": map[string]interface{}{"name": "example", "key": "value"}}
+	}
+
+	for _, tc := range []struct {
+		name     string
+		entry    string
+		expected map[string]interface{}
+		err      string
+	}{
+		{name: "set value", entry: "metadata.key=other", expected: map[string]interface{}{"name": "example", "key": "other"}},
+		{name: "set empty string", entry: `metadata.key=""`, expected: map[string]interface{}{"name": "example", "key": ""}},
+		{name: "delete with equals", entry: "metadata.key=", expected: map[string]interface{}{"name": "example"}},
+		{name: "delete bare path", entry: "metadata.key", expected: map[string]interface{}{"name": "example"}},
+		{name: "delete missing bare path", entry: "metadata.missing", expected: map[string]interface{}{"name": "example", "key": "value"}},
+		{name: "value containing equals", entry: "metadata.key=a=b", expected: map[string]interface{}{"name": "example", "key": "a=b"}},
+		{name: "empty", entry: "", err: "--override-property '' is invalid, expected a =-separated path and value"},
+		{name: "empty path", entry: "=value", err: "--override-property '=value' is invalid, expected a =-separated path and value"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := parseAndApplyOverrideProperty(tc.entry, overridePropertyFlag, newSpec(), "")
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, out["metadata"])
+		})
+	}
+}
+
+func TestRunExpandEnv(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+`), 0644))
+	t.Setenv("TEST_CI_IMAGE", "registry.example.com/app:1.2.3")
+	overrides := []string{
+		"containers.main.image=${TEST_CI_IMAGE}",
+		"containers.main.variables.HOST=${metadata.name}",
+	}
+
+	manifests, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, OverrideProperties: overrides, ExpandEnv: ExpandEnvStrict})
+	require.NoError(t, err)
+	container := manifests[0].Object["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "registry.example.com/app:1.2.3", container["image"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "HOST", "value": "example"}}, container["env"])
+
+	_, err = Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, OverrideProperties: []string{
+		"containers.main.image=${TEST_UNDEFINED_IMAGE}",
+	}, ExpandEnv: ExpandEnvStrict})
+	assert.EqualError(t, err, "--override-property 'containers.main.image=${TEST_UNDEFINED_IMAGE}' is invalid: undefined environment variables: TEST_UNDEFINED_IMAGE")
+
+	_, err = Run(context.Background(), Options{Directory: td, ExpandEnv: "sometimes"})
+	assert.EqualError(t, err, "--expand-env 'sometimes' is not supported, expected 'true' or 'strict'")
+}
+
+func TestRunNoState(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+resources:
+  db:
+    type: postgres
+`), 0644))
+
+	// the state file is not read, so an existing workload is not included
+	other := filepath.Join(td, "other.yaml")
+	require.NoError(t, os.WriteFile(other, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: other
+containers:
+  main:
+    image: nginx
+`), 0644))
+	_, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{other}})
+	require.NoError(t, err)
+	before, err := os.ReadFile(filepath.Join(td, project.DefaultRelativeStateDirectory, project.StateFileName))
+	require.NoError(t, err)
+
+	manifests, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, NoState: true})
+	require.NoError(t, err)
+	workloads := make([]string, 0)
+	for _, m := range manifests {
+		if m.Workload != "" {
+			workloads = append(workloads, m.Workload)
+		}
+	}
+	assert.Equal(t, []string{"example"}, workloads)
+	assert.Contains(t, manifestKinds(manifests), "StatefulSet")
+
+	// the state file is not written
+	after, err := os.ReadFile(filepath.Join(td, project.DefaultRelativeStateDirectory, project.StateFileName))
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after))
+
+	_, err = Run(context.Background(), Options{Directory: td, NoState: true})
+	assert.EqualError(t, err, "--no-state requires at least one score file")
+	_, err = Run(context.Background(), Options{Directory: t.TempDir(), ScoreFiles: []string{scoreFile}, NoState: true})
+	assert.EqualError(t, err, "state directory does not exist, please run \"score-k8s init\" first")
+}
+
+func TestRunOverridesFromEnv(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+`), 0644))
+
+	deploymentImage := func(manifests []Manifest) interface{} {
+		for _, m := range manifests {
+			if m.Kind == "Deployment" {
+				return m.Object["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"].([]interface{})[0].(map[string]interface{})["image"]
+			}
+		}
+		return nil
+	}
+
+	t.Setenv("TEST_SCORE_OVERRIDES", base64.StdEncoding.EncodeToString([]byte("containers:\n  main:\n    image: nginx:encoded\n")))
+	manifests, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, OverridesFromEnv: "TEST_SCORE_OVERRIDES"})
+	require.NoError(t, err)
+	assert.Equal(t, "nginx:encoded", deploymentImage(manifests))
+
+	t.Setenv("TEST_SCORE_OVERRIDES", "")
+	_, err = Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, OverridesFromEnv: "TEST_SCORE_OVERRIDES"})
+	assert.EqualError(t, err, "--overrides-from-env 'TEST_SCORE_OVERRIDES' is invalid, the environment variable is not set or empty")
+
+	// plain yaml is rejected rather than guessed at, even when it happens to be valid base64
+	t.Setenv("TEST_SCORE_OVERRIDES", "containers:\n  main:\n    image: nginx:plain\n")
+	_, err = Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, OverridesFromEnv: "TEST_SCORE_OVERRIDES"})
+	assert.EqualError(t, err, "--overrides-from-env 'TEST_SCORE_OVERRIDES' is invalid, the environment variable is not base64 encoded: illegal base64 data at input byte 10")
+	t.Setenv("TEST_SCORE_OVERRIDES", "abcd")
+	_, err = Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, OverridesFromEnv: "TEST_SCORE_OVERRIDES"})
+	assert.ErrorContains(t, err, "--overrides-from-env 'TEST_SCORE_OVERRIDES' is invalid: failed to decode yaml: ")
+
+	t.Setenv("TEST_SCORE_OVERRIDES", base64.StdEncoding.EncodeToString([]byte("- a list")))
+	_, err = Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, OverridesFromEnv: "TEST_SCORE_OVERRIDES"})
+	assert.EqualError(t, err, "--overrides-from-env 'TEST_SCORE_OVERRIDES' is invalid: failed to decode yaml: yaml: unmarshal errors:\n  line 1: cannot unmarshal !!seq into map[string]interface {}")
+
+	_, err = Run(context.Background(), Options{Directory: td, OverridesFromEnv: "TEST_SCORE_OVERRIDES"})
+	assert.EqualError(t, err, "cannot use --overrides-from-env when 0 or more than 1 score files are provided")
+}
+
+func TestRunStampVersion(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+service:
+  ports:
+    web:
+      port: 80
+`), 0644))
+
+	manifests, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, StampVersion: true})
+	require.NoError(t, err)
+	require.Len(t, manifests, 2)
+	for _, m := range manifests {
+		assert.Equal(t, "0.0.0", m.Object["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})["k8s.score.dev/generator-version"])
+	}
+
+	manifests, err = Run(context.Background(), Options{Directory: td})
+	require.NoError(t, err)
+	for _, m := range manifests {
+		assert.NotContains(t, m.Object["metadata"].(map[string]interface{})["annotations"], "k8s.score.dev/generator-version")
+	}
+}
+
+func TestRunNoManagedLabels(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+service:
+  ports:
+    web:
+      port: 80
+`), 0644))
+
+	manifests, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, NoManagedLabels: true})
+	require.NoError(t, err)
+	require.Len(t, manifests, 2)
+	var template map[string]interface{}
+	for _, m := range manifests {
+		labels := m.Object["metadata"].(map[string]interface{})["labels"].(map[string]interface{})
+		assert.NotContains(t, labels, "app.kubernetes.io/name")
+		assert.NotContains(t, labels, "app.kubernetes.io/managed-by")
+		assert.Contains(t, labels, "app.kubernetes.io/instance")
+		if m.Object["kind"] == "Deployment" {
+			template = m.Object["spec"].(map[string]interface{})["template"].(map[string]interface{})
+		}
+	}
+	require.NotNil(t, template)
+	assert.NotContains(t, template["metadata"].(map[string]interface{})["labels"], "app.kubernetes.io/managed-by")
+	assert.Contains(t, template["metadata"].(map[string]interface{})["labels"], "app.kubernetes.io/instance")
+
+	manifests, err = Run(context.Background(), Options{Directory: td})
+	require.NoError(t, err)
+	for _, m := range manifests {
+		assert.Contains(t, m.Object["metadata"].(map[string]interface{})["labels"], "app.kubernetes.io/managed-by")
+	}
+}
+
+func TestRunTypedErrors(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	require.NoError(t, os.WriteFile(filepath.Join(td, ".score-k8s", "00.provisioners.yaml"), []byte(`
+- uri: template://secret-thing
+  type: secret-thing
+  manifests: |
+    - apiVersion: v1
+      kind: ConfigMap
+      metadata:
+        name: leaked
+      data:
+        value: {{ encodeSecretRef "my-secret" "password" | quote }}
+`), 0644))
+	write := func(name, content string) string {
+		p := filepath.Join(td, name)
+		require.NoError(t, os.WriteFile(p, []byte(content), 0644))
+		return p
+	}
+
+	t.Run("validation", func(t *testing.T) {
+		scoreFile := write("invalid.yaml", "apiVersion: score.dev/v1b1\nmetadata:\n  name: example\n")
+		_, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, NoState: true})
+		var target *ValidationError
+		require.ErrorAs(t, err, &target)
+		assert.Equal(t, scoreFile, target.Path)
+		assert.Contains(t, err.Error(), "invalid score file: "+scoreFile)
+	})
+
+	t.Run("provision", func(t *testing.T) {
+		scoreFile := write("unknown.yaml", "apiVersion: score.dev/v1b1\nmetadata:\n  name: example\ncontainers:\n  main:\n    image: nginx\nresources:\n  thing:\n    type: unknown-thing\n")
+		_, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, NoState: true})
+		var target *ProvisionError
+		require.ErrorAs(t, err, &target)
+		assert.Equal(t, framework.ResourceUid("unknown-thing.default#example.thing"), target.ResourceUid)
+		assert.Equal(t, "", target.ProvisionerUri)
+	})
+
+	t.Run("unresolved ref", func(t *testing.T) {
+		scoreFile := write("secret.yaml", "apiVersion: score.dev/v1b1\nmetadata:\n  name: example\ncontainers:\n  main:\n    image: nginx\nresources:\n  thing:\n    type: secret-thing\n")
+		_, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, NoState: true})
+		var target *UnresolvedRefError
+		require.ErrorAs(t, err, &target)
+		assert.Equal(t, "secret-thing.default#example.thing", target.Resource)
+		assert.Equal(t, ".data.value", target.Path)
+		assert.EqualError(t, err, "unresolved secret ref in manifest: .data.value")
+	})
+}
+
+func TestRunProvisionerTimeout(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	require.NoError(t, os.WriteFile(filepath.Join(td, ".score-k8s", "00.provisioners.yaml"), []byte(`
+- uri: cmd://sleep
+  type: slow
+  args: ["5"]
+`), 0644))
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+resources:
+  thing:
+    type: slow
+`), 0644))
+
+	start := time.Now()
+	_, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, ProvisionerTimeout: 100 * time.Millisecond})
+	assert.Less(t, time.Since(start), 4*time.Second)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "--provisioner-timeout of 100ms exceeded: resource 'slow.default#example.thing'")
+
+	// the state is left untouched
+	sd, _, err := project.LoadStateDirectory(td)
+	require.NoError(t, err)
+	assert.Len(t, sd.State.Workloads, 0)
+}
+
+func TestRunNamespace(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	require.NoError(t, os.WriteFile(filepath.Join(td, ".score-k8s", "00.provisioners.yaml"), []byte(`
+- uri: template://thing
+  type: thing
+  manifests: |
+    - apiVersion: rbac.authorization.k8s.io/v1
+      kind: ClusterRole
+      metadata:
+        name: thing-reader
+    - apiVersion: v1
+      kind: ConfigMap
+      metadata:
+        name: thing
+        namespace: other
+`), 0644))
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+resources:
+  thing:
+    type: thing
+`), 0644))
+
+	manifests, err := Run(context.Background(), Options{
+		Directory: td, ScoreFiles: []string{scoreFile},
+		Namespace: "apps", EmitNamespace: true, NamespaceLabels: map[string]string{"team": "payments"},
+		OutputFormat: OutputFormatKubectlApplyOrder,
+	})
+	require.NoError(t, err)
+	signatures := make([]string, 0, len(manifests))
+	for _, m := range manifests {
+		signatures = append(signatures, m.Signature())
+	}
+	assert.Equal(t, []string{
+		"v1/Namespace//apps",
+		"rbac.authorization.k8s.io/v1/ClusterRole//thing-reader",
+		"v1/ConfigMap/other/thing",
+		"apps/v1/Deployment/apps/example",
+	}, signatures)
+	assert.Equal(t, map[string]interface{}{"name": "apps", "labels": map[string]interface{}{"team": "payments"}}, manifests[0].Object["metadata"])
+
+	// no Namespace object without a namespace
+	manifests, err = Run(context.Background(), Options{Directory: td, EmitNamespace: true})
+	require.NoError(t, err)
+	assert.Len(t, manifests, 3)
+	assert.Equal(t, "", manifests[2].Namespace)
+
+	_, err = Run(context.Background(), Options{Directory: td, Namespace: "Not_Valid"})
+	assert.ErrorContains(t, err, "--namespace 'Not_Valid' is invalid: a lowercase RFC 1123 label must consist of")
+}
+
+func TestRunResourceNamespace(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	require.NoError(t, os.WriteFile(filepath.Join(td, ".score-k8s", "00.provisioners.yaml"), []byte(`
+- uri: template://db
+  type: db
+  outputs: |
+    host: {{ .ServiceHost "db" }}
+  manifests: |
+    - apiVersion: v1
+      kind: Service
+      metadata:
+        name: db
+      spec:
+        ports:
+        - port: 5432
+    - apiVersion: rbac.authorization.k8s.io/v1
+      kind: ClusterRole
+      metadata:
+        name: db-reader
+- uri: template://cache
+  type: cache
+  namespace: cache
+  outputs: |
+    host: {{ .ServiceHost "cache" }}
+  manifests: |
+    - apiVersion: v1
+      kind: ConfigMap
+      metadata:
+        name: cache
+`), 0644))
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+    variables:
+      DB_HOST: ${resources.db.host}
+      CACHE_HOST: ${resources.cache.host}
+resources:
+  db:
+    type: db
+  cache:
+    type: cache
+`), 0644))
+
+	manifests, err := Run(context.Background(), Options{
+		Directory: td, ScoreFiles: []string{scoreFile}, Namespace: "apps", ResourceNamespace: "data",
+	})
+	require.NoError(t, err)
+	signatures := make([]string, 0, len(manifests))
+	for _, m := range manifests {
+		signatures = append(signatures, m.Signature())
+	}
+	assert.ElementsMatch(t, []string{
+		"v1/Service/data/db",
+		"rbac.authorization.k8s.io/v1/ClusterRole//db-reader",
+		"v1/ConfigMap/cache/cache",
+		"apps/v1/Deployment/apps/example",
+	}, signatures)
+	containerEnv := func(manifests []Manifest) interface{} {
+		deployment := manifests[slices.IndexFunc(manifests, func(m Manifest) bool { return m.Kind == "Deployment" })]
+		podSpec := deployment.Object["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})
+		return podSpec["containers"].([]interface{})[0].(map[string]interface{})["env"]
+	}
+	assert.ElementsMatch(t, []interface{}{
+		map[string]interface{}{"name": "CACHE_HOST", "value": "cache.cache.svc"},
+		map[string]interface{}{"name": "DB_HOST", "value": "db.data.svc"},
+	}, containerEnv(manifests))
+
+	// the resources follow the workloads when the namespaces match
+	manifests, err = Run(context.Background(), Options{Directory: td, Namespace: "data", ResourceNamespace: "data"})
+	require.NoError(t, err)
+	assert.Contains(t, containerEnv(manifests), map[string]interface{}{"name": "DB_HOST", "value": "db"})
+
+	_, err = Run(context.Background(), Options{Directory: td, ResourceNamespace: "data"})
+	assert.EqualError(t, err, "--resource-namespace requires --namespace")
+	_, err = Run(context.Background(), Options{Directory: td, Namespace: "apps", ResourceNamespace: "Not_Valid"})
+	assert.ErrorContains(t, err, "--resource-namespace 'Not_Valid' is invalid: a lowercase RFC 1123 label must consist of")
+}
+
+// A pod can only refer to Secrets and volume claims in its own namespace, so those that the workload refers to must
+// still be output into the workload namespace when the resources are moved.
+func TestRunResourceNamespaceWorkloadReferences(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	require.NoError(t, os.WriteFile(filepath.Join(td, ".score-k8s", "00.provisioners.yaml"), []byte(`
+- uri: template://pvc-volume
+  type: volume
+  outputs: |
+    source:
+      persistentVolumeClaim:
+        claimName: data-{{ .SourceWorkload }}
+  manifests: |
+    - apiVersion: v1
+      kind: PersistentVolumeClaim
+      metadata:
+        name: data-{{ .SourceWorkload }}
+      spec:
+        accessModes: [ReadWriteOnce]
+        resources:
+          requests:
+            storage: 1Gi
+`), 0644))
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+    variables:
+      DB_HOST: ${resources.db.host}
+      DB_PASSWORD: ${resources.db.password}
+    volumes:
+    - target: /data
+      source: ${resources.data}
+resources:
+  db:
+    type: postgres
+  data:
+    type: volume
+`), 0644))
+
+	manifests, err := Run(context.Background(), Options{
+		Directory: td, ScoreFiles: []string{scoreFile}, Namespace: "apps", ResourceNamespace: "data",
+	})
+	require.NoError(t, err)
+	namespaces := make(map[string][]string)
+	for _, m := range manifests {
+		namespaces[m.Kind+"/"+m.Name] = append(namespaces[m.Kind+"/"+m.Name], m.Namespace)
+	}
+	deployment := manifests[slices.IndexFunc(manifests, func(m Manifest) bool { return m.Kind == "Deployment" })]
+	podSpec := deployment.Object["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})
+	env := podSpec["containers"].([]interface{})[0].(map[string]interface{})["env"].([]interface{})
+	secretName := ""
+	for _, e := range env {
+		if ref, ok := e.(map[string]interface{})["valueFrom"]; ok {
+			secretName = ref.(map[string]interface{})["secretKeyRef"].(map[string]interface{})["name"].(string)
+		}
+	}
+	require.NotEmpty(t, secretName)
+
+	assert.Equal(t, []string{"apps"}, namespaces["Deployment/example"])
+	// the database and its Secret are in the resource namespace, and the Secret is copied for the workload
+	assert.Equal(t, []string{"data"}, namespaces["StatefulSet/"+secretName])
+	assert.Equal(t, []string{"data"}, namespaces["Service/"+secretName])
+	assert.ElementsMatch(t, []string{"data", "apps"}, namespaces["Secret/"+secretName])
+	assert.Contains(t, env, map[string]interface{}{"name": "DB_HOST", "value": secretName + ".data.svc"})
+	// the claim is only used by the workload so it is not moved
+	assert.Equal(t, []string{"apps"}, namespaces["PersistentVolumeClaim/data-example"])
+}
+
+func TestRunCapabilitiesFile(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+  annotations:
+    k8s.score.dev/pod-monitor: "9090"
+containers:
+  main:
+    image: nginx
+`), 0644))
+	capabilitiesFile := filepath.Join(td, "api-versions.txt")
+	require.NoError(t, os.WriteFile(capabilitiesFile, []byte("# kubectl api-versions\napps/v1\n\nv1\n"), 0644))
+
+	manifests, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}})
+	require.NoError(t, err)
+	require.Len(t, manifests, 2)
+	assert.ElementsMatch(t, []string{"Deployment", "PodMonitor"}, []string{manifests[0].Kind, manifests[1].Kind})
+
+	manifests, err = Run(context.Background(), Options{Directory: td, CapabilitiesFile: capabilitiesFile})
+	require.NoError(t, err)
+	require.Len(t, manifests, 1)
+	assert.Equal(t, "Deployment", manifests[0].Kind)
+
+	require.NoError(t, os.WriteFile(capabilitiesFile, []byte("apps/v1\nnot a version\n"), 0644))
+	_, err = Run(context.Background(), Options{Directory: td, CapabilitiesFile: capabilitiesFile})
+	assert.EqualError(t, err, "--capabilities-file '"+capabilitiesFile+"' is invalid, line 2: 'not a version' is not a valid apiVersion")
+}
+
+func TestRunKeepGoing(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	scoreFiles := make([]string, 0)
+	for _, name := range []string{"wl-a", "wl-b", "wl-c"} {
+		scoreFile := filepath.Join(td, name+".yaml")
+		runtimeClass := "gvisor"
+		if name != "wl-b" {
+			runtimeClass = "Not_Valid"
+		}
+		require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: `+name+`
+  annotations:
+    k8s.score.dev/runtime-class: `+runtimeClass+`
+containers:
+  main:
+    image: nginx
+`), 0644))
+		scoreFiles = append(scoreFiles, scoreFile)
+	}
+
+	_, err := Run(context.Background(), Options{Directory: td, ScoreFiles: scoreFiles})
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "wl-a", validationErr.Workload)
+
+	_, err = Run(context.Background(), Options{Directory: td, KeepGoing: true})
+	var conversionErrs ConversionErrors
+	require.ErrorAs(t, err, &conversionErrs)
+	require.Len(t, conversionErrs, 2)
+	assert.Contains(t, err.Error(), "2 workloads failed to convert:\n- workload: wl-a: failed to convert: ")
+	assert.Contains(t, err.Error(), "\n- workload: wl-c: failed to convert: ")
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, "wl-a", validationErr.Workload)
+}
+
+func TestRunStrictSchema(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+    resources:
+      limits:
+        ephemeral-storage: 1Gi
+`), 0644))
+
+	manifests, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Deployment"}, manifestKinds(manifests))
+
+	_, err = Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, StrictSchema: true})
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Contains(t, err.Error(), "invalid score file: "+scoreFile+" (--strict-schema): ")
+	assert.Contains(t, err.Error(), "ephemeral-storage")
+}
+
+func TestRunStrictSchemaUnknownAnnotation(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+  annotations:
+    k8s.score.dev/comand-shell: "true"
+containers:
+  main:
+    image: nginx
+`), 0644))
+
+	manifests, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Deployment"}, manifestKinds(manifests))
+
+	_, err = Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, StrictSchema: true})
+	assert.ErrorContains(t, err, "metadata: annotations: k8s.score.dev/comand-shell: unknown annotation, did you mean 'k8s.score.dev/command-shell'?")
+}
+
+func TestRunOwnerRef(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+service:
+  ports:
+    web:
+      port: 80
+`), 0644))
+	owner := "Application/my-app/7f2a8c4e-0b1d-4e6f-9a3b-5c8d2e1f4a6b/example.com/v1"
+
+	manifests, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, OwnerRef: owner, Namespace: "apps", EmitNamespace: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Namespace", "Service", "Deployment"}, manifestKinds(manifests))
+	assert.NotContains(t, manifests[0].Object["metadata"], "ownerReferences")
+	for _, m := range manifests[1:] {
+		assert.Equal(t, []interface{}{map[string]interface{}{
+			"apiVersion": "example.com/v1",
+			"kind":       "Application",
+			"name":       "my-app",
+			"uid":        "7f2a8c4e-0b1d-4e6f-9a3b-5c8d2e1f4a6b",
+		}}, m.Object["metadata"].(map[string]interface{})["ownerReferences"])
+	}
+
+	manifests, err = Run(context.Background(), Options{Directory: td, OwnerRef: owner, OwnerRefFilters: []string{"kind=Service"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Service", "Deployment"}, manifestKinds(manifests))
+	assert.Contains(t, manifests[0].Object["metadata"], "ownerReferences")
+	assert.NotContains(t, manifests[1].Object["metadata"], "ownerReferences")
+
+	for _, tc := range []struct {
+		ownerRef string
+		filters  []string
+		expected string
+	}{
+		{"Application/my-app/example.com/v1", nil, "--owner-ref 'Application/my-app/example.com/v1' is invalid, 'example.com' is not a valid uid"},
+		{"Application/my-app", nil, "--owner-ref 'Application/my-app' is invalid, expected kind/name/uid/apiVersion"},
+		{"application/my-app/7f2a8c4e-0b1d-4e6f-9a3b-5c8d2e1f4a6b/v1", nil, "--owner-ref 'application/my-app/7f2a8c4e-0b1d-4e6f-9a3b-5c8d2e1f4a6b/v1' is invalid, 'application' is not a valid kind"},
+		{"Application/My_App/7f2a8c4e-0b1d-4e6f-9a3b-5c8d2e1f4a6b/v1", nil, "--owner-ref 'Application/My_App/7f2a8c4e-0b1d-4e6f-9a3b-5c8d2e1f4a6b/v1' is invalid, 'My_App' is not a valid name: "},
+		{"Application/my-app/7f2a8c4e-0b1d-4e6f-9a3b-5c8d2e1f4a6b/a/b/c", nil, "--owner-ref 'Application/my-app/7f2a8c4e-0b1d-4e6f-9a3b-5c8d2e1f4a6b/a/b/c' is invalid, 'a/b/c' is not a valid apiVersion: "},
+		{"", []string{"kind=Service"}, "--owner-ref-filter requires --owner-ref"},
+	} {
+		t.Run(tc.ownerRef, func(t *testing.T) {
+			_, err := Run(context.Background(), Options{Directory: td, OwnerRef: tc.ownerRef, OwnerRefFilters: tc.filters})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.expected)
+		})
+	}
+}
+
+func TestRunRequireProbes(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: wl-a
+containers:
+  main:
+    image: nginx
+    readinessProbe:
+      httpGet:
+        path: /healthz
+        port: 8080
+---
+apiVersion: score.dev/v1b1
+metadata:
+  name: wl-b
+containers:
+  main:
+    image: nginx
+  sidecar:
+    image: busybox
+`), 0644))
+
+	_, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}})
+	require.NoError(t, err)
+
+	_, err = Run(context.Background(), Options{Directory: td, RequireProbes: true})
+	var missingErr MissingProbesError
+	require.ErrorAs(t, err, &missingErr)
+	assert.EqualError(t, err, "--require-probes: 1 workloads have containers without a readinessProbe:\n- workload 'wl-b' (containers: main, sidecar)")
+}
+
+func TestRunStrictImages(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: wl-a
+containers:
+  main:
+    image: nginx:1.27
+---
+apiVersion: score.dev/v1b1
+metadata:
+  name: wl-b
+containers:
+  main:
+    image: nginx
+  sidecar:
+    image: busybox:latest
+  pinned:
+    image: busybox@sha256:0123456789abcdef
+`), 0644))
+
+	_, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}})
+	require.NoError(t, err)
+
+	_, err = Run(context.Background(), Options{Directory: td, StrictImages: true})
+	var floatingErr FloatingImagesError
+	require.ErrorAs(t, err, &floatingErr)
+	assert.EqualError(t, err, "--strict-images: 1 workloads have containers with a latest or missing image tag:\n- workload 'wl-b' (containers: main=nginx, sidecar=busybox:latest)")
+
+	_, err = Run(context.Background(), Options{
+		Directory: td, ScoreFiles: []string{scoreFile}, StrictImages: true,
+		PatchManifests: []string{"Deployment/wl-b/spec.template.spec.containers.0.image=nginx:1.27", "Deployment/wl-b/spec.template.spec.containers.2.image=busybox:1.36"},
+	})
+	assert.NoError(t, err)
+}
+
+func TestRunMaxManifestSize(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	require.NoError(t, os.WriteFile(filepath.Join(td, ".score-k8s", "00.provisioners.yaml"), []byte(`
+- uri: template://blob
+  type: blob
+  manifests: |
+    - apiVersion: v1
+      kind: ConfigMap
+      metadata:
+        name: blob
+      data:
+        blob: {{ repeat 2000 "x" | quote }}
+`), 0644))
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+resources:
+  thing:
+    type: blob
+`), 0644))
+
+	manifests, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, MaxManifestSize: 4096})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ConfigMap", "Deployment"}, manifestKinds(manifests))
+
+	_, err = Run(context.Background(), Options{Directory: td, MaxManifestSize: 1024})
+	var sizeErr *ManifestSizeError
+	require.ErrorAs(t, err, &sizeErr)
+	assert.Equal(t, "template://blob", sizeErr.Provisioner)
+	assert.Regexp(t, `^ConfigMap 'blob' from resource 'blob.default#example.thing' \(provisioner 'template://blob'\) is \d+ bytes which exceeds the --max-manifest-size of 1024 bytes$`, err.Error())
+}
+
+func TestRunIncremental(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	require.NoError(t, os.WriteFile(filepath.Join(td, ".score-k8s", "00.provisioners.yaml"), []byte(`
+- uri: template://token
+  type: token
+  manifests: |
+    - apiVersion: v1
+      kind: ConfigMap
+      metadata:
+        name: token
+      data:
+        token: {{ randAlphaNum 16 | quote }}
+`), 0644))
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+resources:
+  thing:
+    type: token
+`), 0644))
+	token := func(manifests []Manifest) interface{} {
+		require.Equal(t, []string{"ConfigMap", "Deployment"}, manifestKinds(manifests))
+		return manifests[0].Object["data"].(map[string]interface{})["token"]
+	}
+
+	manifests, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}, Incremental: true})
+	require.NoError(t, err)
+	first := token(manifests)
+
+	manifests, err = Run(context.Background(), Options{Directory: td, Incremental: true})
+	require.NoError(t, err)
+	assert.Equal(t, first, token(manifests))
+
+	manifests, err = Run(context.Background(), Options{Directory: td, Incremental: true, Force: true})
+	require.NoError(t, err)
+	second := token(manifests)
+	assert.NotEqual(t, first, second)
+
+	manifests, err = Run(context.Background(), Options{Directory: td})
+	require.NoError(t, err)
+	assert.NotEqual(t, second, token(manifests))
+
+	_, err = Run(context.Background(), Options{Directory: td, Force: true})
+	assert.EqualError(t, err, "--force requires --incremental")
+}
+
+func TestRunConsolidateSecrets(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	require.NoError(t, os.WriteFile(filepath.Join(td, ".score-k8s", "00.provisioners.yaml"), []byte(`
+- uri: template://db
+  type: db
+  outputs: |
+    host: db
+    password: {{ encodeSecretRef "db-secret" "password" }}
+  manifests: |
+    - apiVersion: v1
+      kind: Secret
+      metadata:
+        name: db-secret
+      stringData:
+        password: hunter2
+`), 0644))
+	scoreFile := filepath.Join(td, "score.yaml")
+	require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: example
+containers:
+  main:
+    image: nginx
+    variables:
+      DB_HOST: ${resources.db.host}
+      DB_PASSWORD: ${resources.db.password}
+resources:
+  db:
+    type: db
+`), 0644))
+
+	manifests, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{scoreFile}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Secret", "Deployment"}, manifestKinds(manifests))
+
+	manifests, err = Run(context.Background(), Options{Directory: td, ConsolidateSecrets: true})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Secret", "Secret", "Deployment"}, manifestKinds(manifests))
+	assert.Equal(t, "example-env", manifests[1].Name)
+	assert.Equal(t, "example", manifests[1].Workload)
+	assert.Equal(t, map[string]interface{}{"db-secret.password": "aHVudGVyMg=="}, manifests[1].Object["data"])
+	podSpec := manifests[2].Object["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "DB_HOST", "value": "db"},
+		map[string]interface{}{"name": "DB_PASSWORD", "valueFrom": map[string]interface{}{
+			"secretKeyRef": map[string]interface{}{"name": "example-env", "key": "db-secret.password"},
+		}},
+	}, podSpec["containers"].([]interface{})[0].(map[string]interface{})["env"])
+}
+
+func TestRunWorkloadNameFromEnv(t *testing.T) {
+	td := t.TempDir()
+	initProject(t, td)
+	writeScore := func(name string) string {
+		scoreFile := filepath.Join(td, "score.yaml")
+		require.NoError(t, os.WriteFile(scoreFile, []byte(`
+apiVersion: score.dev/v1b1
+metadata:
+  name: `+name+`
+containers:
+  main:
+    image: nginx
+    variables:
+      NAME: ${metadata.name}
+`), 0644))
+		return scoreFile
+	}
+
+	t.Setenv("SK8S_INSTANCE", "blue")
+	manifests, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{writeScore("app-${SK8S_INSTANCE}")}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Deployment"}, manifestKinds(manifests))
+	assert.Equal(t, "app-blue", manifests[0].Name)
+	assert.Equal(t, "app-blue", manifests[0].Workload)
+	podSpec := manifests[0].Object["spec"].(map[string]interface{})["template"].(map[string]interface{})["spec"].(map[string]interface{})
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "NAME", "value": "app-blue"}}, podSpec["containers"].([]interface{})[0].(map[string]interface{})["env"])
+
+	for _, tc := range []struct {
+		name  string
+		value string
+		err   string
+	}{
+		{name: "undefined", value: "app-${SK8S_UNDEFINED}", err: "metadata.name: 'app-${SK8S_UNDEFINED}': undefined environment variables: SK8S_UNDEFINED"},
+		{name: "invalid", value: "app-${SK8S_INSTANCE}_", err: "metadata.name: 'app-${SK8S_INSTANCE}_' resolved to 'app-blue_' which is not a valid name: "},
+		{name: "resource", value: "app-${resources.db.name}", err: "metadata.name: 'app-${resources.db.name}': only ${VAR} environment variable placeholders are supported"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Run(context.Background(), Options{Directory: td, ScoreFiles: []string{writeScore(tc.value)}})
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+}
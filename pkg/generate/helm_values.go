@@ -0,0 +1,62 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+// OutputFormatHelmValues replaces the output manifests with a Helm values.yaml holding the tunable fields of each
+// workload, for charts that are maintained separately from the Score files.
+const OutputFormatHelmValues = "helm-values"
+
+// HelmValues returns the tunable fields of the workload Deployments, StatefulSets, and Rollouts in the manifests: the
+// replicas and the image and resources of each container and init container. The values are keyed by workload and
+// container name under a top level "workloads" key, so the result does not depend on the order of the manifests.
+// Replicas default to 1 and resources to an empty object so that every tunable field has a key.
+func HelmValues(manifests []Manifest) map[string]interface{} {
+	workloads := make(map[string]interface{})
+	for _, m := range manifests {
+		if m.Workload == "" || (m.Kind != "Deployment" && m.Kind != "StatefulSet" && m.Kind != "Rollout") {
+			continue
+		}
+		spec, _ := m.Object["spec"].(map[string]interface{})
+		template, _ := spec["template"].(map[string]interface{})
+		podSpec, _ := template["spec"].(map[string]interface{})
+
+		values := map[string]interface{}{"replicas": 1}
+		if replicas, ok := spec["replicas"]; ok {
+			values["replicas"] = replicas
+		}
+		for _, key := range []string{"containers", "initContainers"} {
+			containers, _ := podSpec[key].([]interface{})
+			if len(containers) == 0 {
+				continue
+			}
+			containerValues := make(map[string]interface{}, len(containers))
+			for _, raw := range containers {
+				c, _ := raw.(map[string]interface{})
+				name, _ := c["name"].(string)
+				resources, _ := c["resources"].(map[string]interface{})
+				if resources == nil {
+					resources = map[string]interface{}{}
+				}
+				containerValues[name] = map[string]interface{}{
+					"image":     c["image"],
+					"resources": resources,
+				}
+			}
+			values[key] = containerValues
+		}
+		workloads[m.Workload] = values
+	}
+	return map[string]interface{}{"workloads": workloads}
+}
@@ -0,0 +1,52 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHelmValues(t *testing.T) {
+	podTemplate := func(containers ...interface{}) map[string]interface{} {
+		return map[string]interface{}{"spec": map[string]interface{}{"containers": containers}}
+	}
+	manifests := []Manifest{
+		{Kind: "ConfigMap", Resource: "db.default#example.db", Object: map[string]interface{}{"kind": "ConfigMap"}},
+		{Kind: "Service", Workload: "web", Object: map[string]interface{}{"kind": "Service"}},
+		{Kind: "Deployment", Workload: "web", Object: map[string]interface{}{"spec": map[string]interface{}{
+			"template": podTemplate(map[string]interface{}{"name": "main", "image": "nginx"}),
+		}}},
+		{Kind: "StatefulSet", Workload: "db", Object: map[string]interface{}{"spec": map[string]interface{}{
+			"replicas": 3,
+			"template": podTemplate(map[string]interface{}{
+				"name": "main", "image": "postgres", "resources": map[string]interface{}{"requests": map[string]interface{}{"memory": "1Gi"}},
+			}),
+		}}},
+	}
+	assert.Equal(t, map[string]interface{}{"workloads": map[string]interface{}{
+		"web": map[string]interface{}{
+			"replicas":   1,
+			"containers": map[string]interface{}{"main": map[string]interface{}{"image": "nginx", "resources": map[string]interface{}{}}},
+		},
+		"db": map[string]interface{}{
+			"replicas": 3,
+			"containers": map[string]interface{}{"main": map[string]interface{}{
+				"image": "postgres", "resources": map[string]interface{}{"requests": map[string]interface{}{"memory": "1Gi"}},
+			}},
+		},
+	}}, HelmValues(manifests))
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"strings"
+
+	"github.com/score-spec/score-k8s/internal/convert"
+)
+
+// isFloatingImage returns true when the image has no tag, or the 'latest' tag, and is not pinned to a digest.
+func isFloatingImage(image string) bool {
+	if strings.Contains(image, "@") {
+		return false
+	}
+	// a ':' before the last '/' separates the port of the registry rather than the tag
+	i := strings.LastIndex(image, ":")
+	if i < 0 || i < strings.LastIndex(image, "/") {
+		return true
+	}
+	return image[i+1:] == "latest"
+}
+
+// findFloatingImages returns the <container>=<image> entries of the containers and init containers with a floating
+// image in each Deployment, StatefulSet, or Rollout converted from a workload, keyed by workload name.
+func findFloatingImages(manifests []Manifest) FloatingImagesError {
+	out := make(FloatingImagesError)
+	for _, m := range manifests {
+		if m.Workload == "" || (m.Kind != convert.WorkloadKindDeployment && m.Kind != convert.WorkloadKindStatefulSet && m.Kind != convert.WorkloadKindRollout) {
+			continue
+		}
+		spec, _ := m.Object["spec"].(map[string]interface{})
+		template, _ := spec["template"].(map[string]interface{})
+		podSpec, _ := template["spec"].(map[string]interface{})
+		for _, key := range []string{"initContainers", "containers"} {
+			containers, _ := podSpec[key].([]interface{})
+			for _, rawContainer := range containers {
+				container, _ := rawContainer.(map[string]interface{})
+				name, _ := container["name"].(string)
+				image, _ := container["image"].(string)
+				if isFloatingImage(image) {
+					out[m.Workload] = append(out[m.Workload], name+"="+image)
+				}
+			}
+		}
+	}
+	return out
+}
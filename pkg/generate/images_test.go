@@ -0,0 +1,38 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_isFloatingImage(t *testing.T) {
+	for image, expected := range map[string]bool{
+		"nginx":                                true,
+		"nginx:latest":                         true,
+		"nginx:1.27":                           false,
+		"registry.example.com:5000/app":        true,
+		"registry.example.com:5000/app:v1":     false,
+		"registry.example.com:5000/app:latest": true,
+		"nginx@sha256:0123456789abcdef":        false,
+		"nginx:latest@sha256:0123456789abcdef": false,
+	} {
+		t.Run(image, func(t *testing.T) {
+			assert.Equal(t, expected, isFloatingImage(image))
+		})
+	}
+}
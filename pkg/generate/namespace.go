@@ -0,0 +1,189 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/score-spec/score-k8s/internal"
+)
+
+// clusterScopedKinds are the built-in kinds that do not belong to a namespace. Any other kind, including custom
+// resources, is assumed to be namespaced.
+var clusterScopedKinds = []string{
+	"Namespace", "CustomResourceDefinition", "ClusterRole", "ClusterRoleBinding", "PersistentVolume", "StorageClass",
+	"PriorityClass", "IngressClass", "RuntimeClass", "ValidatingWebhookConfiguration", "MutatingWebhookConfiguration",
+	"APIService", "GatewayClass",
+}
+
+// validateNamespaceOptions checks the namespace and the labels and annotations of the emitted Namespace object.
+func validateNamespaceOptions(opts Options) error {
+	if opts.Namespace != "" {
+		if errs := validation.IsDNS1123Label(opts.Namespace); len(errs) > 0 {
+			return errors.Errorf("--%s '%s' is invalid: %s", namespaceFlag, opts.Namespace, strings.Join(errs, ", "))
+		}
+	}
+	if opts.ResourceNamespace != "" {
+		if errs := validation.IsDNS1123Label(opts.ResourceNamespace); len(errs) > 0 {
+			return errors.Errorf("--%s '%s' is invalid: %s", resourceNamespaceFlag, opts.ResourceNamespace, strings.Join(errs, ", "))
+		} else if opts.Namespace == "" {
+			return errors.Errorf("--%s requires --%s", resourceNamespaceFlag, namespaceFlag)
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(opts.NamespaceLabels)) {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return errors.Errorf("--%s '%s' is invalid: %s", namespaceLabelFlag, k, strings.Join(errs, ", "))
+		} else if errs := validation.IsValidLabelValue(opts.NamespaceLabels[k]); len(errs) > 0 {
+			return errors.Errorf("--%s '%s' is invalid: %s", namespaceLabelFlag, k, strings.Join(errs, ", "))
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(opts.NamespaceAnnotations)) {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return errors.Errorf("--%s '%s' is invalid: %s", namespaceAnnotationFlag, k, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// applyNamespace sets the namespace on every namespaced manifest that does not already have one, and prepends a
+// Namespace object if requested.
+func applyNamespace(manifests []Manifest, opts Options) []Manifest {
+	if opts.Namespace == "" {
+		if opts.EmitNamespace {
+			slog.Warn(fmt.Sprintf("Skipping --%s since no --%s is set", emitNamespaceFlag, namespaceFlag))
+		}
+		return manifests
+	}
+	for i, m := range manifests {
+		if setManifestNamespace(m.Object, opts.Namespace) {
+			manifests[i] = newManifest(m.Object, m.Workload, m.Resource)
+		}
+	}
+	if opts.EmitNamespace {
+		metadata := map[string]interface{}{"name": opts.Namespace}
+		if len(opts.NamespaceLabels) > 0 {
+			labels := make(map[string]interface{}, len(opts.NamespaceLabels))
+			for k, v := range opts.NamespaceLabels {
+				labels[k] = v
+			}
+			metadata["labels"] = labels
+		}
+		if len(opts.NamespaceAnnotations) > 0 {
+			annotations := make(map[string]interface{}, len(opts.NamespaceAnnotations))
+			for k, v := range opts.NamespaceAnnotations {
+				annotations[k] = v
+			}
+			metadata["annotations"] = annotations
+		}
+		namespace := newManifest(map[string]interface{}{"apiVersion": "v1", "kind": "Namespace", "metadata": metadata}, "", "")
+		manifests = slices.Insert(manifests, 0, namespace)
+	}
+	return manifests
+}
+
+// setManifestNamespace sets the namespace of the object if it is namespaced and does not already have one. It returns
+// whether the object was changed.
+func setManifestNamespace(object map[string]interface{}, namespace string) bool {
+	metadata, _ := object["metadata"].(map[string]interface{})
+	if ns, _ := metadata["namespace"].(string); ns != "" {
+		return false
+	} else if kind, _ := object["kind"].(string); slices.Contains(clusterScopedKinds, kind) {
+		return false
+	}
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+		object["metadata"] = metadata
+	}
+	metadata["namespace"] = namespace
+	return true
+}
+
+// podReferences returns the Secrets, ConfigMaps, and PersistentVolumeClaims that the pods of the workload manifests
+// refer to by name, from env vars, envFrom, volumes, and image pull secrets, as <kind>/<name> keys.
+func podReferences(manifests []Manifest) map[string]bool {
+	out := make(map[string]bool)
+	add := func(kind string, name interface{}) {
+		if n, _ := name.(string); n != "" {
+			out[kind+"/"+n] = true
+		}
+	}
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			for k, child := range v {
+				ref, _ := child.(map[string]interface{})
+				switch k {
+				case "secretKeyRef", "secretRef":
+					add("Secret", ref["name"])
+				case "secret":
+					add("Secret", ref["secretName"])
+					add("Secret", ref["name"])
+				case "configMapKeyRef", "configMapRef", "configMap":
+					add("ConfigMap", ref["name"])
+				case "persistentVolumeClaim":
+					add("PersistentVolumeClaim", ref["claimName"])
+				case "imagePullSecrets":
+					items, _ := child.([]interface{})
+					for _, item := range items {
+						ref, _ := item.(map[string]interface{})
+						add("Secret", ref["name"])
+					}
+				}
+				walk(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	for _, m := range manifests {
+		walk(m.Object)
+	}
+	return out
+}
+
+// placeResourceManifest moves a resource manifest into its resource namespace and returns the manifests to output. A
+// pod can only refer to Secrets, ConfigMaps, and PersistentVolumeClaims in its own namespace, so those that the
+// workloads refer to are kept in the workload namespace too: a Secret or ConfigMap is copied so that the pods of the
+// resource can still use it, while a PersistentVolumeClaim is not moved at all since a copy would be another volume.
+func placeResourceManifest(object map[string]interface{}, namespace string, references map[string]bool) ([]map[string]interface{}, error) {
+	metadata, _ := object["metadata"].(map[string]interface{})
+	kind, _ := object["kind"].(string)
+	name, _ := metadata["name"].(string)
+	if ns, _ := metadata["namespace"].(string); ns != "" || object["apiVersion"] != "v1" || !references[kind+"/"+name] {
+		setManifestNamespace(object, namespace)
+		return []map[string]interface{}{object}, nil
+	}
+	if kind == "PersistentVolumeClaim" {
+		slog.Info(fmt.Sprintf("Keeping %s '%s' in the workload namespace instead of '%s' since a workload refers to it", kind, name, namespace))
+		return []map[string]interface{}{object}, nil
+	}
+	workloadCopy, err := internal.NormalizeManifest(object)
+	if err != nil {
+		return nil, err
+	}
+	slog.Info(fmt.Sprintf("Copying %s '%s' into the workload namespace since a workload refers to it", kind, name))
+	setManifestNamespace(object, namespace)
+	return []map[string]interface{}{object, workloadCopy}, nil
+}
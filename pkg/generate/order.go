@@ -0,0 +1,74 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"slices"
+	"strings"
+)
+
+// OutputFormatKubectlApplyOrder sorts the output manifests so that they can be applied in a single kubectl apply
+// without failing on missing namespaces, definitions, or permissions.
+const OutputFormatKubectlApplyOrder = "kubectl-apply-order"
+
+const (
+	applyOrderNamespace = iota
+	applyOrderDefinition
+	applyOrderRbac
+	applyOrderOther
+	applyOrderCustom
+)
+
+var rbacKinds = []string{"ServiceAccount", "Role", "ClusterRole", "RoleBinding", "ClusterRoleBinding"}
+
+// sortForApply stable sorts the manifests into Namespaces, CustomResourceDefinitions, RBAC, then everything else,
+// with custom resources last.
+func sortForApply(manifests []Manifest) {
+	// any kinds defined by definitions in the output are custom resources, even if they use a built-in looking group
+	defined := make(map[string]bool)
+	for _, m := range manifests {
+		if m.Kind == "CustomResourceDefinition" {
+			spec, _ := m.Object["spec"].(map[string]interface{})
+			group, _ := spec["group"].(string)
+			names, _ := spec["names"].(map[string]interface{})
+			kind, _ := names["kind"].(string)
+			defined[group+"/"+kind] = true
+		}
+	}
+	slices.SortStableFunc(manifests, func(a, b Manifest) int {
+		return applyOrder(a, defined) - applyOrder(b, defined)
+	})
+}
+
+func applyOrder(m Manifest, defined map[string]bool) int {
+	group, _, ok := strings.Cut(m.APIVersion, "/")
+	if !ok {
+		group = ""
+	}
+	switch {
+	case group == "" && m.Kind == "Namespace":
+		return applyOrderNamespace
+	case group == "apiextensions.k8s.io" && m.Kind == "CustomResourceDefinition":
+		return applyOrderDefinition
+	case (group == "" || group == "rbac.authorization.k8s.io") && slices.Contains(rbacKinds, m.Kind):
+		return applyOrderRbac
+	case defined[group+"/"+m.Kind]:
+		return applyOrderCustom
+	case group == "" || !strings.Contains(group, ".") || strings.HasSuffix(group, ".k8s.io"):
+		return applyOrderOther
+	default:
+		return applyOrderCustom
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortForApply(t *testing.T) {
+	manifests := []Manifest{
+		{APIVersion: "example.com/v1", Kind: "Widget", Name: "w"},
+		{APIVersion: "apps/v1", Kind: "Deployment", Name: "d"},
+		{APIVersion: "v1", Kind: "Service", Name: "s"},
+		{APIVersion: "gateway.networking.k8s.io/v1", Kind: "HTTPRoute", Name: "r"},
+		{APIVersion: "widgets.k8s.io/v1", Kind: "Gadget", Name: "g"},
+		{APIVersion: "rbac.authorization.k8s.io/v1", Kind: "RoleBinding", Name: "rb"},
+		{APIVersion: "v1", Kind: "ServiceAccount", Name: "sa"},
+		{APIVersion: "apiextensions.k8s.io/v1", Kind: "CustomResourceDefinition", Name: "gadgets.widgets.k8s.io", Object: map[string]interface{}{
+			"spec": map[string]interface{}{"group": "widgets.k8s.io", "names": map[string]interface{}{"kind": "Gadget"}},
+		}},
+		{APIVersion: "v1", Kind: "Namespace", Name: "ns"},
+	}
+	sortForApply(manifests)
+	names := make([]string, len(manifests))
+	for i, m := range manifests {
+		names[i] = m.Kind + "/" + m.Name
+	}
+	assert.Equal(t, []string{
+		"Namespace/ns",
+		"CustomResourceDefinition/gadgets.widgets.k8s.io",
+		"RoleBinding/rb",
+		"ServiceAccount/sa",
+		"Deployment/d",
+		"Service/s",
+		"HTTPRoute/r",
+		"Widget/w",
+		"Gadget/g",
+	}, names)
+}
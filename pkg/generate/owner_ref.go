@@ -0,0 +1,109 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// ownerRefKind and ownerRefUID match the kind and uid of an owner reference. Kubernetes uids are RFC 4122 UUIDs.
+var (
+	ownerRefKind = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	ownerRefUID  = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// ownerRef is the parent object that is added to the ownerReferences of the output manifests.
+type ownerRef struct {
+	Kind       string
+	Name       string
+	UID        string
+	APIVersion string
+}
+
+// parseOwnerRef parses an owner reference in the form kind/name/uid/apiVersion. The apiVersion is last since it may
+// contain a / itself, for example apps/v1.
+func parseOwnerRef(entry string, flagName string) (ownerRef, error) {
+	parts := strings.SplitN(entry, "/", 4)
+	if len(parts) != 4 {
+		return ownerRef{}, fmt.Errorf("--%s '%s' is invalid, expected kind/name/uid/apiVersion", flagName, entry)
+	}
+	out := ownerRef{Kind: parts[0], Name: parts[1], UID: parts[2], APIVersion: parts[3]}
+	if !ownerRefKind.MatchString(out.Kind) {
+		return ownerRef{}, fmt.Errorf("--%s '%s' is invalid, '%s' is not a valid kind", flagName, entry, out.Kind)
+	}
+	if errs := validation.IsDNS1123Subdomain(out.Name); len(errs) > 0 {
+		return ownerRef{}, fmt.Errorf("--%s '%s' is invalid, '%s' is not a valid name: %s", flagName, entry, out.Name, strings.Join(errs, ", "))
+	}
+	if !ownerRefUID.MatchString(out.UID) {
+		return ownerRef{}, fmt.Errorf("--%s '%s' is invalid, '%s' is not a valid uid", flagName, entry, out.UID)
+	}
+	gv, err := schema.ParseGroupVersion(out.APIVersion)
+	if err == nil && gv.Version == "" {
+		err = fmt.Errorf("missing version")
+	} else if err == nil && gv.Group != "" {
+		if errs := validation.IsDNS1123Subdomain(gv.Group); len(errs) > 0 {
+			err = fmt.Errorf("invalid group: %s", strings.Join(errs, ", "))
+		}
+	}
+	if err != nil {
+		return ownerRef{}, fmt.Errorf("--%s '%s' is invalid, '%s' is not a valid apiVersion: %w", flagName, entry, out.APIVersion, err)
+	}
+	return out, nil
+}
+
+// applyOwnerRef adds the owner reference to the manifests that match any of the filters, or to all the manifests when
+// there are no filters. Cluster scoped manifests are skipped since they cannot be owned by a namespaced object, and
+// the owner is never added to itself.
+func applyOwnerRef(manifests []Manifest, owner ownerRef, filters []manifestFilter) {
+	for _, m := range manifests {
+		if len(filters) > 0 && !slices.ContainsFunc(filters, func(f manifestFilter) bool {
+			return f.Match(m)
+		}) {
+			continue
+		}
+		if slices.Contains(clusterScopedKinds, m.Kind) {
+			slog.Info(fmt.Sprintf("Skipping --%s for cluster scoped %s '%s'", ownerRefFlag, m.Kind, m.Name))
+			continue
+		}
+		if m.Kind == owner.Kind && m.Name == owner.Name && m.APIVersion == owner.APIVersion {
+			continue
+		}
+		metadata, _ := m.Object["metadata"].(map[string]interface{})
+		if metadata == nil {
+			metadata = make(map[string]interface{})
+			m.Object["metadata"] = metadata
+		}
+		existing, _ := metadata["ownerReferences"].([]interface{})
+		if slices.ContainsFunc(existing, func(item interface{}) bool {
+			ref, _ := item.(map[string]interface{})
+			return ref["uid"] == owner.UID
+		}) {
+			continue
+		}
+		metadata["ownerReferences"] = append(existing, map[string]interface{}{
+			"apiVersion": owner.APIVersion,
+			"kind":       owner.Kind,
+			"name":       owner.Name,
+			"uid":        owner.UID,
+		})
+	}
+}
@@ -0,0 +1,40 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import "github.com/score-spec/score-k8s/internal/convert"
+
+// findMissingProbes returns the names of the containers without a readinessProbe in each Deployment, StatefulSet, or
+// Rollout converted from a workload, keyed by workload name.
+func findMissingProbes(manifests []Manifest) MissingProbesError {
+	out := make(MissingProbesError)
+	for _, m := range manifests {
+		if m.Workload == "" || (m.Kind != convert.WorkloadKindDeployment && m.Kind != convert.WorkloadKindStatefulSet && m.Kind != convert.WorkloadKindRollout) {
+			continue
+		}
+		spec, _ := m.Object["spec"].(map[string]interface{})
+		template, _ := spec["template"].(map[string]interface{})
+		podSpec, _ := template["spec"].(map[string]interface{})
+		containers, _ := podSpec["containers"].([]interface{})
+		for _, rawContainer := range containers {
+			container, _ := rawContainer.(map[string]interface{})
+			if _, ok := container["readinessProbe"]; !ok {
+				name, _ := container["name"].(string)
+				out[m.Workload] = append(out[m.Workload], name)
+			}
+		}
+	}
+	return out
+}
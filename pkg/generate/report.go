@@ -0,0 +1,101 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+// Report is a machine-readable summary of a generate run, intended for CI dashboards.
+type Report struct {
+	// Manifests is the total number of output manifests.
+	Manifests int `json:"manifests"`
+	// Workloads is the number of output manifests for each workload.
+	Workloads map[string]ReportWorkload `json:"workloads"`
+	// Resources lists the resources in the state along with the provisioner used and their number of output manifests.
+	Resources []ReportResource `json:"resources"`
+	// Provisioners is the sorted list of unique provisioner uris used by the resources.
+	Provisioners []string `json:"provisioners"`
+	// Warnings are any warning messages logged during the run. These are collected by the caller.
+	Warnings []string `json:"warnings"`
+}
+
+// ReportWorkload is the summary of a single workload in the Report.
+type ReportWorkload struct {
+	Manifests int `json:"manifests"`
+}
+
+// ReportResource is the summary of a single resource in the Report.
+type ReportResource struct {
+	Uid         string `json:"uid"`
+	Type        string `json:"type"`
+	Class       string `json:"class"`
+	Provisioner string `json:"provisioner"`
+	Manifests   int    `json:"manifests"`
+}
+
+// RunWithReport is the same as Run but also returns a Report summarizing the output manifests and resources. The
+// Warnings of the report are left empty for the caller to fill in from its logging.
+func RunWithReport(ctx context.Context, opts Options) ([]Manifest, *Report, error) {
+	manifests, state, err := run(ctx, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return manifests, buildReport(manifests, state), nil
+}
+
+// buildReport counts the output manifests by workload and resource.
+func buildReport(manifests []Manifest, state *project.State) *Report {
+	report := &Report{
+		Manifests:    len(manifests),
+		Workloads:    make(map[string]ReportWorkload, len(state.Workloads)),
+		Resources:    make([]ReportResource, 0, len(state.Resources)),
+		Provisioners: make([]string, 0),
+		Warnings:     make([]string, 0),
+	}
+	for workloadName := range state.Workloads {
+		report.Workloads[workloadName] = ReportWorkload{}
+	}
+	resourceManifests := make(map[string]int)
+	for _, m := range manifests {
+		if m.Workload != "" {
+			w := report.Workloads[m.Workload]
+			w.Manifests++
+			report.Workloads[m.Workload] = w
+		} else if m.Resource != "" {
+			resourceManifests[m.Resource]++
+		}
+	}
+	provisionerUris := make(map[string]bool)
+	for _, uid := range slices.Sorted(maps.Keys(state.Resources)) {
+		res := state.Resources[uid]
+		report.Resources = append(report.Resources, ReportResource{
+			Uid:         string(uid),
+			Type:        res.Type,
+			Class:       res.Class,
+			Provisioner: res.ProvisionerUri,
+			Manifests:   resourceManifests[string(uid)],
+		})
+		if res.ProvisionerUri != "" {
+			provisionerUris[res.ProvisionerUri] = true
+		}
+	}
+	report.Provisioners = append(report.Provisioners, slices.Sorted(maps.Keys(provisionerUris))...)
+	return report
+}
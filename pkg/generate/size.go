@@ -0,0 +1,44 @@
+// Copyright 2024 Humanitec
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package generate
+
+import (
+	"github.com/score-spec/score-go/framework"
+	"gopkg.in/yaml.v3"
+
+	"github.com/score-spec/score-k8s/internal/project"
+)
+
+// checkManifestSizes returns a ManifestSizeError for the first manifest whose yaml encoding is larger than the limit in
+// bytes. A limit of zero or less disables the check.
+func checkManifestSizes(manifests []Manifest, limit int64, state *project.State) error {
+	if limit <= 0 {
+		return nil
+	}
+	for _, m := range manifests {
+		raw, err := yaml.Marshal(m.Object)
+		if err != nil {
+			return err
+		}
+		if size := int64(len(raw)); size > limit {
+			out := &ManifestSizeError{Kind: m.Kind, Name: m.Name, Workload: m.Workload, Resource: m.Resource, Size: size, Limit: limit}
+			if res, ok := state.Resources[framework.ResourceUid(m.Resource)]; ok {
+				out.Provisioner = res.ProvisionerUri
+			}
+			return out
+		}
+	}
+	return nil
+}